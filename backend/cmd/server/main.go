@@ -1,11 +1,15 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
+	"log/slog"
 	"os"
 	"synapse/internal/db"
+	"synapse/internal/events"
 	"synapse/internal/handlers"
+	"synapse/internal/logging"
+	"synapse/internal/metrics"
 	"synapse/internal/repository"
 	"synapse/internal/services"
 
@@ -20,6 +24,10 @@ func main() {
 		log.Println("No .env file found, using environment variables")
 	}
 
+	// Structured logs; the standard log package is routed through it too
+	logger := logging.New()
+	slog.SetDefault(logger)
+
 	// Initialize databases
 	if err := db.InitPostgres(); err != nil {
 		log.Fatalf("Failed to initialize PostgreSQL: %v", err)
@@ -30,7 +38,14 @@ func main() {
 		log.Fatalf("Failed to create schema: %v", err)
 	}
 
-	if err := db.InitChroma(); err != nil {
+	// Prometheus metrics are opt-in; without them every service reports to a no-op
+	var appMetrics metrics.Metrics = metrics.Noop{}
+	metricsEnabled := os.Getenv("METRICS_ENABLED") == "true"
+	if metricsEnabled {
+		appMetrics = metrics.NewPrometheus(nil)
+	}
+
+	if err := db.InitChroma(appMetrics); err != nil {
 		log.Printf("Warning: Failed to initialize ChromaDB: %v", err)
 		log.Println("ChromaDB might not be running. Start it with: chroma run --path ./chroma_db")
 	}
@@ -57,19 +72,51 @@ func main() {
 		} else {
 			log.Println("Using Google Gemini (free) for AI features")
 		}
+	} else if aiProvider == "ollama" {
+		ollamaHost := os.Getenv("OLLAMA_HOST")
+		if ollamaHost == "" {
+			ollamaHost = "http://localhost:11434"
+		}
+		log.Printf("Using local Ollama at %s for AI features (offline mode)", ollamaHost)
 	}
 
 	// Initialize services
-	aiService := services.NewAIService()
+	aiService := services.NewAIService(appMetrics, logger)
 	itemRepo := repository.NewItemRepository(db.Pool)
 	relationRepo := repository.NewRelationRepository(db.Pool)
-	itemService := services.NewItemService(itemRepo, aiService)
-	searchService := services.NewSearchService(aiService, itemRepo)
+	collectionRepo := repository.NewCollectionRepository(db.Pool)
+	// One guard so every service agrees on the collection's embedding dimension
+	embeddingGuard := services.NewEmbeddingGuard(repository.NewEmbeddingConfigRepository(db.Pool), aiService, logger)
+	itemService := services.NewItemService(itemRepo, aiService, embeddingGuard, logger)
+	searchService := services.NewSearchService(aiService, itemRepo, collectionRepo, embeddingGuard, appMetrics, logger)
+	searchService.SetTrigramAvailable(db.TrigramAvailable(context.Background()))
+	// Saves and edits must show up in the next search, not after the cache TTL
+	itemService.OnItemsChanged(searchService.InvalidateCache)
+	// Item lifecycle events go to WEBHOOK_URL when it's set
+	if webhookURL := os.Getenv("WEBHOOK_URL"); webhookURL != "" {
+		itemService.SetEventEmitter(events.NewWebhook(webhookURL, os.Getenv("WEBHOOK_SECRET"), logger))
+	}
 	relationService := services.NewRelationService(itemRepo, relationRepo, aiService)
+	collectionService := services.NewCollectionService(collectionRepo, itemRepo)
+	// Searches scoped to a collection change when its items do
+	collectionService.OnItemsChanged(searchService.InvalidateCache)
+	healthService := services.NewHealthService(db.Pool, aiService)
+
+	// Semantic queries filter on vector metadata, so vectors stored before a field
+	// was added get it before serving; on failure it's retried at the next start
+	if err := itemService.BackfillVectorMetadata(context.Background(), repository.NewVectorMigrationRepository(db.Pool)); err != nil {
+		logger.Warn("failed to backfill vector metadata; older items may be missing from semantic search", "error", err)
+	}
+
+	// Keep link previews fresh and flag dead links in the background
+	go itemService.RunMetadataRefresher(context.Background())
 
 	// Initialize handlers
 	itemHandler := handlers.NewItemHandler(itemService, relationService)
 	searchHandler := handlers.NewSearchHandler(searchService)
+	adminHandler := handlers.NewAdminHandler(itemService)
+	collectionHandler := handlers.NewCollectionHandler(collectionService)
+	healthHandler := handlers.NewHealthHandler(healthService)
 
 	// Setup router
 	r := gin.Default()
@@ -78,28 +125,69 @@ func main() {
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", handlers.UserIDHeader, handlers.RequestIDHeader}
+	config.ExposeHeaders = []string{handlers.RequestIDHeader}
 	r.Use(cors.New(config))
 
-	// Health check
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	// Correlation ID for every log line a request produces
+	r.Use(handlers.RequestIDMiddleware())
+
+	// Health checks: /health is liveness, /ready probes dependencies
+	r.GET("/health", healthHandler.Health)
+	r.GET("/ready", healthHandler.Ready)
+	if metricsEnabled {
+		r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
 
 	// API routes
-	api := r.Group("/api")
+	// Every API request acts for the user named by its X-User-ID header
+	api := r.Group("/api", handlers.UserMiddleware())
 	{
 		// Items
 		api.POST("/items", itemHandler.CreateItem)
+		api.POST("/items/preview", itemHandler.PreviewItem)
+		api.POST("/items/bulk", itemHandler.BulkCreateItems)
 		api.GET("/items", itemHandler.GetAllItems)
 		api.GET("/items/:id", itemHandler.GetItem)
+		api.PUT("/items/:id", itemHandler.UpdateItem)
 		api.DELETE("/items/:id", itemHandler.DeleteItem)
+		api.POST("/items/:id/restore", itemHandler.RestoreItem)
 		api.GET("/items/:id/related", itemHandler.GetRelatedItems)
+		api.GET("/items/:id/similar", searchHandler.RelatedItems)
 		api.POST("/items/:id/refresh-image", itemHandler.RefreshImage)
 		api.POST("/items/:id/refresh-summary", itemHandler.RefreshSummary)
+		api.POST("/items/:id/regenerate", itemHandler.RegenerateEnrichment)
+		api.POST("/items/:id/refresh-metadata", itemHandler.RefreshMetadata)
+		api.GET("/items/:id/summary/stream", itemHandler.StreamSummary)
+		api.GET("/stats", itemHandler.GetStats)
+		api.GET("/tags", itemHandler.GetTags)
+		api.GET("/autocomplete", itemHandler.Autocomplete)
+		api.GET("/links/broken", itemHandler.GetBrokenLinks)
+		api.GET("/timeline", itemHandler.GetTimeline)
+		api.GET("/on-this-day", itemHandler.GetOnThisDay)
+		api.GET("/export", itemHandler.Export)
+		api.POST("/import", itemHandler.Import)
+		api.POST("/import/bookmarks", itemHandler.ImportBookmarks)
+		api.GET("/import/jobs/:id", itemHandler.GetImportJob)
+
+		// Collections
+		api.POST("/collections", collectionHandler.CreateCollection)
+		api.GET("/collections", collectionHandler.GetCollections)
+		api.GET("/collections/:id", collectionHandler.GetCollection)
+		api.PUT("/collections/:id", collectionHandler.UpdateCollection)
+		api.DELETE("/collections/:id", collectionHandler.DeleteCollection)
+		api.GET("/collections/:id/items", collectionHandler.GetCollectionItems)
+		api.POST("/collections/:id/items", collectionHandler.AddItem)
+		api.DELETE("/collections/:id/items/:itemId", collectionHandler.RemoveItem)
 
 		// Search
 		api.GET("/search", searchHandler.Search)
+		api.GET("/search/suggest", searchHandler.Suggest)
+		api.GET("/search/explain", searchHandler.Explain)
+		api.GET("/recommendations", searchHandler.Recommend)
+
+		// Admin
+		api.POST("/admin/reindex", adminHandler.Reindex)
 	}
 
 	port := os.Getenv("PORT")
@@ -107,7 +195,7 @@ func main() {
 		port = "8080"
 	}
 
-	fmt.Printf("Server starting on port %s\n", port)
+	logger.Info("server starting", "port", port)
 	if err := r.Run(":" + port); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
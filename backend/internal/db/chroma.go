@@ -2,21 +2,70 @@ package db
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"synapse/internal/metrics"
+	"sync"
+	"time"
 )
 
+// ErrChromaUnavailable is returned instead of querying while ChromaDB is known to be down
+var ErrChromaUnavailable = errors.New("ChromaDB is unavailable")
+
+// chromaRecheckInterval is how often Available probes ChromaDB while it's down
+const chromaRecheckInterval = 30 * time.Second
+
 type ChromaClient struct {
 	BaseURL string
 	Client  *http.Client
+	metrics metrics.Metrics
+
+	// mu guards downSince, set while queries can't reach ChromaDB, and
+	// lastProbe, when Available last pinged it
+	mu        sync.Mutex
+	downSince time.Time
+	lastProbe time.Time
 }
 
 var Chroma *ChromaClient
 
-func InitChroma() error {
+// defaultCollectionName is the ChromaDB collection used when CHROMA_COLLECTION is unset
+const defaultCollectionName = "synapse_items"
+
+// distanceSpace is the metric collections are created with. Query distances are
+// cosine distances, 1 - cosine similarity, which Similarity converts back.
+// Without it ChromaDB defaults to squared L2, whose distances that conversion
+// doesn't fit.
+const distanceSpace = "cosine"
+
+// Similarity converts a Query distance to cosine similarity (1 is identical)
+func Similarity(distance float64) float64 {
+	return 1.0 - distance
+}
+
+// Distance converts a cosine similarity back to a Query distance
+func Distance(similarity float64) float64 {
+	return 1.0 - similarity
+}
+
+// CollectionName returns the ChromaDB collection items are stored in. Setting
+// CHROMA_COLLECTION (e.g. "synapse_items_staging") lets several environments
+// share one ChromaDB. Every service reads it from here so they can't drift.
+func CollectionName() string {
+	if name := os.Getenv("CHROMA_COLLECTION"); name != "" {
+		return name
+	}
+	return defaultCollectionName
+}
+
+// InitChroma connects to ChromaDB; query latency is reported to m (nil for none)
+func InitChroma(m metrics.Metrics) error {
 	baseURL := os.Getenv("CHROMA_URL")
 	if baseURL == "" {
 		baseURL = "http://localhost:8000"
@@ -25,24 +74,110 @@ func InitChroma() error {
 	Chroma = &ChromaClient{
 		BaseURL: baseURL,
 		Client:  &http.Client{},
+		metrics: metrics.OrNoop(m),
 	}
 
 	// Create collection if it doesn't exist
-	collectionName := "synapse_items"
+	collectionName := CollectionName()
 	if err := Chroma.CreateCollection(collectionName); err != nil {
 		// Collection might already exist, that's okay
-		fmt.Printf("Note: Collection creation: %v\n", err)
+		slog.Info("ChromaDB collection creation failed; it will be created on first add", "collection", collectionName, "error", err)
+	}
+
+	// A collection made before cosine distance was set keeps ChromaDB's L2
+	// default, which similarity scores don't fit
+	if space, err := Chroma.CollectionSpace(collectionName); err != nil {
+		slog.Info("could not read the ChromaDB collection's distance metric", "collection", collectionName, "error", err)
+	} else if space != distanceSpace {
+		slog.Warn("ChromaDB collection doesn't use cosine distance, so similarity scores and SEARCH_MIN_SIMILARITY are meaningless; set CHROMA_COLLECTION to a new collection and reindex",
+			"collection", collectionName, "distance", space)
 	}
 
 	return nil
 }
 
+// Ping checks that ChromaDB is up via its heartbeat endpoint, falling back to
+// the v2 API on servers that have dropped v1
+func (c *ChromaClient) Ping(ctx context.Context) error {
+	var lastErr error
+	for _, version := range []string{"v1", "v2"} {
+		url := fmt.Sprintf("%s/api/%s/heartbeat", c.BaseURL, version)
+		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+
+		resp, err := c.Client.Do(req)
+		if err != nil {
+			return err
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode == 200 {
+			return nil
+		}
+		lastErr = fmt.Errorf("ChromaDB heartbeat returned %d: %s", resp.StatusCode, string(body))
+		if resp.StatusCode != 404 && resp.StatusCode != 410 {
+			break
+		}
+	}
+	return lastErr
+}
+
+// Available reports whether ChromaDB is believed reachable, so callers can skip
+// work whose only use is a query, like generating a query embedding. It's
+// cached: once a query fails to connect, ChromaDB counts as down until a ping,
+// made at most every chromaRecheckInterval, succeeds.
+func (c *ChromaClient) Available(ctx context.Context) bool {
+	c.mu.Lock()
+	if c.downSince.IsZero() {
+		c.mu.Unlock()
+		return true
+	}
+	if time.Since(c.lastProbe) < chromaRecheckInterval {
+		c.mu.Unlock()
+		return false
+	}
+	c.lastProbe = time.Now()
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := c.Ping(ctx); err != nil {
+		return false
+	}
+	c.recordReachable(nil)
+	return true
+}
+
+// recordReachable updates the availability flag after a request: err is the
+// error from connecting, nil if ChromaDB answered. Changes are logged once,
+// not per request.
+func (c *ChromaClient) recordReachable(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		if !c.downSince.IsZero() {
+			slog.Info("ChromaDB is reachable again, semantic search resumed", "down_for", time.Since(c.downSince).Round(time.Second))
+			c.downSince = time.Time{}
+		}
+		return
+	}
+	if c.downSince.IsZero() {
+		slog.Warn("ChromaDB is unreachable, searching by text only until it recovers", "error", err)
+		c.downSince = time.Now()
+		c.lastProbe = time.Now()
+	}
+}
+
+// CreateCollection creates a collection using cosine distance. The metric is
+// fixed when a collection is created, so one created without it (ChromaDB's L2
+// default) must be replaced with a new collection and reindexed.
 func (c *ChromaClient) CreateCollection(name string) error {
 	// Try v1 API first (for older ChromaDB versions)
 	url := fmt.Sprintf("%s/api/v1/collections", c.BaseURL)
 	
 	payload := map[string]interface{}{
-		"name": name,
+		"name":     name,
+		"metadata": map[string]interface{}{"hnsw:space": distanceSpace},
 	}
 	
 	jsonData, _ := json.Marshal(payload)
@@ -64,6 +199,33 @@ func (c *ChromaClient) CreateCollection(name string) error {
 	return nil
 }
 
+// CollectionSpace returns the distance metric a collection was created with,
+// "l2" when none was set
+func (c *ChromaClient) CollectionSpace(name string) (string, error) {
+	url := fmt.Sprintf("%s/api/v1/collections/%s", c.BaseURL, name)
+	resp, err := c.Client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to get collection: %s", string(body))
+	}
+
+	var result struct {
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if space, ok := result.Metadata["hnsw:space"].(string); ok && space != "" {
+		return space, nil
+	}
+	return "l2", nil
+}
+
 func (c *ChromaClient) AddEmbedding(collectionName, id string, embedding []float32, metadata map[string]interface{}) error {
 	// Try v1 API
 	url := fmt.Sprintf("%s/api/v1/collections/%s/add", c.BaseURL, collectionName)
@@ -99,9 +261,171 @@ func (c *ChromaClient) AddEmbedding(collectionName, id string, embedding []float
 	return nil
 }
 
-func (c *ChromaClient) Query(collectionName string, queryEmbedding []float32, nResults int) ([]string, []float64, error) {
+// UpsertEmbedding replaces the vector and metadata stored under id, adding it if missing
+func (c *ChromaClient) UpsertEmbedding(collectionName, id string, embedding []float32, metadata map[string]interface{}) error {
+	url := fmt.Sprintf("%s/api/v1/collections/%s/upsert", c.BaseURL, collectionName)
+
+	payload := map[string]interface{}{
+		"ids":        []string{id},
+		"embeddings": [][]float32{embedding},
+		"metadatas":  []map[string]interface{}{metadata},
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 || resp.StatusCode == 501 {
+		return fmt.Errorf("ChromaDB v1 API deprecated - semantic search disabled")
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to upsert embedding: %s", string(body))
+	}
+
+	return nil
+}
+
+// UpdateMetadata replaces the metadata stored with each of ids, keeping their
+// vectors. metadatas lines up with ids.
+func (c *ChromaClient) UpdateMetadata(collectionName string, ids []string, metadatas []map[string]interface{}) error {
+	url := fmt.Sprintf("%s/api/v1/collections/%s/update", c.BaseURL, collectionName)
+
+	payload := map[string]interface{}{
+		"ids":       ids,
+		"metadatas": metadatas,
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 || resp.StatusCode == 501 {
+		return fmt.Errorf("ChromaDB v1 API deprecated - semantic search disabled")
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to update metadata: %s", string(body))
+	}
+
+	return nil
+}
+
+// DeleteEmbedding removes the vector stored under id. Deleting an ID that was
+// never added is not an error.
+func (c *ChromaClient) DeleteEmbedding(collectionName, id string) error {
+	url := fmt.Sprintf("%s/api/v1/collections/%s/delete", c.BaseURL, collectionName)
+
+	payload := map[string]interface{}{
+		"ids": []string{id},
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 || resp.StatusCode == 501 {
+		return fmt.Errorf("ChromaDB v1 API deprecated - semantic search disabled")
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete embedding: %s", string(body))
+	}
+
+	return nil
+}
+
+// GetEmbedding returns the vector stored under id, or nil if there is none
+func (c *ChromaClient) GetEmbedding(collectionName, id string) ([]float32, error) {
+	embeddings, err := c.GetEmbeddings(collectionName, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[id], nil
+}
+
+// GetEmbeddings returns the vectors stored under ids in one request, keyed by
+// ID. IDs without a vector are left out.
+func (c *ChromaClient) GetEmbeddings(collectionName string, ids []string) (map[string][]float32, error) {
+	url := fmt.Sprintf("%s/api/v1/collections/%s/get", c.BaseURL, collectionName)
+
+	payload := map[string]interface{}{
+		"ids":     ids,
+		"include": []string{"embeddings"},
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 || resp.StatusCode == 501 {
+		return nil, fmt.Errorf("ChromaDB v1 API deprecated - semantic search disabled")
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get embedding: %s", string(body))
+	}
+
+	var result struct {
+		IDs        []string    `json:"ids"`
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	// ChromaDB doesn't promise to keep the order of ids
+	embeddings := make(map[string][]float32, len(result.IDs))
+	for i, id := range result.IDs {
+		if i < len(result.Embeddings) && len(result.Embeddings[i]) > 0 {
+			embeddings[id] = result.Embeddings[i]
+		}
+	}
+	return embeddings, nil
+}
+
+// Query returns the IDs of the nResults nearest embeddings whose metadata
+// matches where, with their cosine distances (see Similarity) and stored
+// metadata, nearest first. where is a ChromaDB where filter such as
+// {"user_id": "..."}, or {"$and": [...]} for several fields; nil matches everything.
+func (c *ChromaClient) Query(collectionName string, queryEmbedding []float32, nResults int, where map[string]interface{}) ([]string, []float64, []map[string]interface{}, error) {
+	start := time.Now()
+	ids, distances, metadatas, err := c.query(collectionName, queryEmbedding, nResults, where)
+	metrics.OrNoop(c.metrics).ObserveChromaQuery(time.Since(start), err)
+	return ids, distances, metadatas, err
+}
+
+func (c *ChromaClient) query(collectionName string, queryEmbedding []float32, nResults int, where map[string]interface{}) ([]string, []float64, []map[string]interface{}, error) {
 	if queryEmbedding == nil || len(queryEmbedding) == 0 {
-		return []string{}, []float64{}, fmt.Errorf("query embedding cannot be empty")
+		return []string{}, []float64{}, nil, fmt.Errorf("query embedding cannot be empty")
 	}
 
 	url := fmt.Sprintf("%s/api/v1/collections/%s/query", c.BaseURL, collectionName)
@@ -109,6 +433,11 @@ func (c *ChromaClient) Query(collectionName string, queryEmbedding []float32, nR
 	payload := map[string]interface{}{
 		"query_embeddings": [][]float32{queryEmbedding},
 		"n_results":        nResults,
+		// Documents aren't stored, so there's no need to ask for them
+		"include": []string{"metadatas", "distances"},
+	}
+	if len(where) > 0 {
+		payload["where"] = where
 	}
 	
 	jsonData, _ := json.Marshal(payload)
@@ -116,33 +445,40 @@ func (c *ChromaClient) Query(collectionName string, queryEmbedding []float32, nR
 	req.Header.Set("Content-Type", "application/json")
 	
 	resp, err := c.Client.Do(req)
+	c.recordReachable(err)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	defer resp.Body.Close()
 	
 	// If v1 API is deprecated, return empty results
 	if resp.StatusCode == 404 || resp.StatusCode == 501 {
-		return []string{}, []float64{}, fmt.Errorf("ChromaDB v1 API deprecated - semantic search disabled")
+		return []string{}, []float64{}, nil, fmt.Errorf("ChromaDB v1 API deprecated - semantic search disabled")
 	}
 	
 	if resp.StatusCode != 200 {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, nil, fmt.Errorf("failed to query: %s", string(body))
+		return nil, nil, nil, fmt.Errorf("failed to query: %s", string(body))
 	}
 	
 	var result struct {
-		Ids       [][]string   `json:"ids"`
-		Distances [][]float64  `json:"distances"`
+		Ids       [][]string                 `json:"ids"`
+		Distances [][]float64                `json:"distances"`
+		Metadatas [][]map[string]interface{} `json:"metadatas"`
 	}
 	
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	
 	if len(result.Ids) == 0 || len(result.Ids[0]) == 0 {
-		return []string{}, []float64{}, nil
+		return []string{}, []float64{}, nil, nil
 	}
 	
-	return result.Ids[0], result.Distances[0], nil
+	// Metadata lines up with the IDs; entries are nil for vectors stored without any
+	metadatas := make([]map[string]interface{}, len(result.Ids[0]))
+	if len(result.Metadatas) > 0 {
+		copy(metadatas, result.Metadatas[0])
+	}
+	return result.Ids[0], result.Distances[0], metadatas, nil
 }
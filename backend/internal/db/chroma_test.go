@@ -0,0 +1,349 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"synapse/internal/metrics"
+)
+
+func TestDeleteEmbedding(t *testing.T) {
+	var gotPath string
+	var gotIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		var payload struct {
+			IDs []string `json:"ids"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		gotIDs = payload.IDs
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := &ChromaClient{BaseURL: server.URL, Client: server.Client()}
+	if err := client.DeleteEmbedding("synapse_items", "item-1"); err != nil {
+		t.Fatalf("DeleteEmbedding: %v", err)
+	}
+	if gotPath != "/api/v1/collections/synapse_items/delete" {
+		t.Errorf("request path = %q", gotPath)
+	}
+	if want := []string{"item-1"}; !reflect.DeepEqual(gotIDs, want) {
+		t.Errorf("deleted ids = %v, want %v", gotIDs, want)
+	}
+}
+
+func TestDeleteEmbeddingReportsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := &ChromaClient{BaseURL: server.URL, Client: server.Client()}
+	if err := client.DeleteEmbedding("synapse_items", "item-1"); err == nil {
+		t.Error("DeleteEmbedding succeeded though ChromaDB failed")
+	}
+}
+
+func TestPing(t *testing.T) {
+	tests := []struct {
+		name    string
+		v1, v2  int // heartbeat status codes
+		wantErr bool
+	}{
+		{"v1 API", http.StatusOK, http.StatusOK, false},
+		{"v2-only server", http.StatusGone, http.StatusOK, false},
+		{"no heartbeat", http.StatusNotFound, http.StatusNotFound, true},
+		{"server error", http.StatusInternalServerError, http.StatusOK, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v1/heartbeat":
+					w.WriteHeader(tt.v1)
+				case "/api/v2/heartbeat":
+					w.WriteHeader(tt.v2)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			client := &ChromaClient{BaseURL: server.URL, Client: server.Client()}
+			if err := client.Ping(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Ping error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// queryMetrics records the errors passed to ObserveChromaQuery
+type queryMetrics struct {
+	metrics.Noop
+	errs []error
+}
+
+func (m *queryMetrics) ObserveChromaQuery(duration time.Duration, err error) {
+	m.errs = append(m.errs, err)
+}
+
+func TestQueryRecordsMetrics(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			http.Error(w, "boom", status)
+			return
+		}
+		w.Write([]byte(`{"ids": [["item-1"]], "distances": [[0.1]], "metadatas": [[{}]]}`))
+	}))
+	defer server.Close()
+
+	// A client built without InitChroma has no metrics and must still query
+	bare := &ChromaClient{BaseURL: server.URL, Client: server.Client()}
+	if _, _, _, err := bare.Query("synapse_items", []float32{1, 0}, 1, nil); err != nil {
+		t.Fatalf("Query without metrics: %v", err)
+	}
+
+	m := &queryMetrics{}
+	client := &ChromaClient{BaseURL: server.URL, Client: server.Client(), metrics: m}
+	ids, _, _, err := client.Query("synapse_items", []float32{1, 0}, 1, nil)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if want := []string{"item-1"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+
+	status = http.StatusInternalServerError
+	if _, _, _, err := client.Query("synapse_items", []float32{1, 0}, 1, nil); err == nil {
+		t.Fatal("Query against a failing server returned no error")
+	}
+
+	if len(m.errs) != 2 {
+		t.Fatalf("recorded %d queries, want 2", len(m.errs))
+	}
+	if m.errs[0] != nil {
+		t.Errorf("first query recorded error %v, want nil", m.errs[0])
+	}
+	if m.errs[1] == nil {
+		t.Error("failed query recorded no error")
+	}
+}
+
+func TestCreateCollectionUsesCosine(t *testing.T) {
+	var gotPath string
+	var payload struct {
+		Name     string                 `json:"name"`
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := &ChromaClient{BaseURL: server.URL, Client: server.Client()}
+	if err := client.CreateCollection("synapse_items"); err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	if gotPath != "/api/v1/collections" || payload.Name != "synapse_items" {
+		t.Errorf("created %q at %q", payload.Name, gotPath)
+	}
+	if want := map[string]interface{}{"hnsw:space": "cosine"}; !reflect.DeepEqual(payload.Metadata, want) {
+		t.Errorf("metadata = %v, want %v", payload.Metadata, want)
+	}
+}
+
+func TestCollectionSpace(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		want     string
+		wantErr  bool
+	}{
+		{"cosine", http.StatusOK, `{"name": "synapse_items", "metadata": {"hnsw:space": "cosine"}}`, "cosine", false},
+		{"inner product", http.StatusOK, `{"name": "synapse_items", "metadata": {"hnsw:space": "ip"}}`, "ip", false},
+		{"no metadata is L2", http.StatusOK, `{"name": "synapse_items", "metadata": null}`, "l2", false},
+		{"other metadata is L2", http.StatusOK, `{"name": "synapse_items", "metadata": {"owner": "synapse"}}`, "l2", false},
+		{"missing collection", http.StatusNotFound, `{"error": "not found"}`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/collections/synapse_items" {
+					http.NotFound(w, r)
+					return
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client := &ChromaClient{BaseURL: server.URL, Client: server.Client()}
+			got, err := client.CollectionSpace("synapse_items")
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("CollectionSpace = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitChromaWarnsAboutL2Collections(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+		wantWarn bool
+	}{
+		{"cosine", `{"hnsw:space": "cosine"}`, false},
+		{"created without a metric", `null`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					w.Write([]byte(`{"name": "synapse_items", "metadata": ` + tt.metadata + `}`))
+					return
+				}
+				w.WriteHeader(http.StatusConflict)
+			}))
+			defer server.Close()
+
+			var logs bytes.Buffer
+			previousLogger, previousChroma := slog.Default(), Chroma
+			slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+			t.Cleanup(func() {
+				slog.SetDefault(previousLogger)
+				Chroma = previousChroma
+			})
+			t.Setenv("CHROMA_URL", server.URL)
+			t.Setenv("CHROMA_COLLECTION", "synapse_items")
+
+			if err := InitChroma(nil); err != nil {
+				t.Fatalf("InitChroma: %v", err)
+			}
+			if warned := strings.Contains(logs.String(), "level=WARN"); warned != tt.wantWarn {
+				t.Errorf("warned = %v, want %v; logs:\n%s", warned, tt.wantWarn, logs.String())
+			}
+		})
+	}
+}
+
+// flakyTransport fails every request while down is set, counting heartbeats
+type flakyTransport struct {
+	down       atomic.Bool
+	heartbeats atomic.Int32
+}
+
+func (f *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Path, "/heartbeat") {
+		f.heartbeats.Add(1)
+	}
+	if f.down.Load() {
+		return nil, errors.New("connection refused")
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestAvailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ids": [[]], "distances": [[]]}`))
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	transport := &flakyTransport{}
+	client := &ChromaClient{BaseURL: server.URL, Client: &http.Client{Transport: transport}}
+	ctx := context.Background()
+	// expireProbe makes the next Available due for a ping
+	expireProbe := func() {
+		client.mu.Lock()
+		client.lastProbe = time.Now().Add(-chromaRecheckInterval)
+		client.mu.Unlock()
+	}
+
+	if !client.Available(ctx) || transport.heartbeats.Load() != 0 {
+		t.Fatal("a client that hasn't failed should be available without a ping")
+	}
+
+	// Every failed query counts, but the outage is logged once
+	transport.down.Store(true)
+	for i := 0; i < 3; i++ {
+		if _, _, _, err := client.Query("synapse_items", []float32{1, 0}, 1, nil); err == nil {
+			t.Fatal("Query succeeded while ChromaDB was down")
+		}
+	}
+	if n := strings.Count(logs.String(), "ChromaDB is unreachable"); n != 1 {
+		t.Errorf("outage logged %d times, want once:\n%s", n, logs.String())
+	}
+
+	// Within chromaRecheckInterval of the failure, it's down without a ping
+	if client.Available(ctx) || client.Available(ctx) {
+		t.Error("Available = true right after a failed query")
+	}
+	if n := transport.heartbeats.Load(); n != 0 {
+		t.Errorf("pinged %d times within the recheck interval, want 0", n)
+	}
+
+	// Once the interval is up, one call pings; a failed ping restarts the wait
+	expireProbe()
+	if client.Available(ctx) || client.Available(ctx) {
+		t.Error("Available = true while the ping fails")
+	}
+	if n := transport.heartbeats.Load(); n != 1 {
+		t.Errorf("pinged %d times after the interval, want 1", n)
+	}
+
+	// A successful ping brings it back, logged once, and no more pings are needed
+	transport.down.Store(false)
+	expireProbe()
+	if !client.Available(ctx) || !client.Available(ctx) {
+		t.Error("Available = false after a successful ping")
+	}
+	if n := transport.heartbeats.Load(); n != 2 {
+		t.Errorf("pinged %d times in total, want 2", n)
+	}
+	if n := strings.Count(logs.String(), "ChromaDB is reachable again"); n != 1 {
+		t.Errorf("recovery logged %d times, want once:\n%s", n, logs.String())
+	}
+}
+
+func TestRecordReachableRecoversOnSuccessfulQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ids": [[]], "distances": [[]]}`))
+	}))
+	defer server.Close()
+
+	transport := &flakyTransport{}
+	client := &ChromaClient{BaseURL: server.URL, Client: &http.Client{Transport: transport}}
+	transport.down.Store(true)
+	client.Query("synapse_items", []float32{1, 0}, 1, nil)
+
+	// A query that gets through brings it back without waiting for a ping
+	transport.down.Store(false)
+	if _, _, _, err := client.Query("synapse_items", []float32{1, 0}, 1, nil); err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if !client.Available(context.Background()) || transport.heartbeats.Load() != 0 {
+		t.Error("a successful query didn't mark ChromaDB available")
+	}
+}
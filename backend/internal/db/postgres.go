@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -97,6 +98,126 @@ func CreateSchema() error {
 	`
 
 	_, err = Pool.Exec(context.Background(), migration2)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// Newer columns use ADD COLUMN IF NOT EXISTS (PostgreSQL 9.6+)
+	migrations := []string{
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS language TEXT`,
+		// Keyset pagination orders by (created_at, id)
+		`CREATE INDEX IF NOT EXISTS idx_items_created_at_id ON items(created_at DESC, id DESC)`,
+		// Full-text search; titles weigh more than summaries, which weigh more than body text
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(summary, '')), 'B') ||
+			setweight(to_tsvector('english', coalesce(content, '')), 'C') ||
+			setweight(to_tsvector('english', coalesce(ocr_text, '')), 'D')
+		) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_items_search_vector ON items USING GIN(search_vector)`,
+		// Canonical source URL for duplicate detection. Not UNIQUE, since
+		// DUPLICATE_POLICY=allow deliberately saves the same link twice.
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS normalized_url TEXT`,
+		`CREATE INDEX IF NOT EXISTS idx_items_normalized_url ON items(normalized_url)`,
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS reading_time_minutes INTEGER`,
+		// Structured product price for shopping items, NULL when unknown
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS price NUMERIC`,
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS currency TEXT`,
+		// Site icon shown next to saved links
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS favicon_url TEXT`,
+		// Vision-model description of image items, used as alt text and embedded with them
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS image_description TEXT`,
+		// Link health, updated by the metadata refresher; http_status is 0 for unreachable links
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS http_status INTEGER`,
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS link_broken BOOLEAN NOT NULL DEFAULT false`,
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS last_checked_at TIMESTAMP`,
+		// Tag autocomplete matches tags joined into one string, which the
+		// trigram index below covers; array_to_string itself isn't IMMUTABLE
+		`CREATE OR REPLACE FUNCTION items_tags_text(tags TEXT[]) RETURNS TEXT
+			LANGUAGE sql IMMUTABLE AS $$ SELECT array_to_string(tags, ' ') $$`,
+		// Archived (soft-deleted) items have deleted_at set
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`,
+		// Stale links for the metadata refresher; needs deleted_at, added above
+		`CREATE INDEX IF NOT EXISTS idx_items_link_checked ON items((COALESCE(last_checked_at, created_at))) WHERE deleted_at IS NULL AND type IN ('url', 'blog', 'video')`,
+		// Owner of each item. Items saved before multi-tenancy belong to the
+		// default (nil UUID) user, which is also who unauthenticated requests act as.
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS user_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000'`,
+		`CREATE INDEX IF NOT EXISTS idx_items_user_created_at ON items(user_id, created_at DESC, id DESC)`,
+		// Price range filters; needs price and user_id, both added above
+		`CREATE INDEX IF NOT EXISTS idx_items_user_price ON items(user_id, price) WHERE price IS NOT NULL`,
+		// Collections (folders); an item can be in several, so membership is a join table
+		`CREATE TABLE IF NOT EXISTS collections (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+			name TEXT NOT NULL,
+			description TEXT,
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_collections_user_name ON collections(user_id, LOWER(name))`,
+		`CREATE TABLE IF NOT EXISTS collection_items (
+			collection_id UUID REFERENCES collections(id) ON DELETE CASCADE,
+			item_id UUID REFERENCES items(id) ON DELETE CASCADE,
+			added_at TIMESTAMP DEFAULT NOW(),
+			PRIMARY KEY (collection_id, item_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_collection_items_item ON collection_items(item_id)`,
+		// Type and category browse pages; category is matched case-insensitively
+		`CREATE INDEX IF NOT EXISTS idx_items_user_type_created_at ON items(user_id, type, created_at DESC, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_items_user_category_created_at ON items(user_id, LOWER(category), created_at DESC, id DESC)`,
+		// SHA-256 of the text an item's vector was generated from, so edits that
+		// don't change it skip re-embedding; NULL for vectors stored before it existed
+		`ALTER TABLE items ADD COLUMN IF NOT EXISTS embedding_source_hash TEXT`,
+		// Embedding model and dimension each ChromaDB collection was filled with
+		`CREATE TABLE IF NOT EXISTS embedding_config (
+			collection_name TEXT PRIMARY KEY,
+			model TEXT NOT NULL,
+			dimension INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+		// One-time ChromaDB migrations that have finished, e.g. metadata backfills
+		`CREATE TABLE IF NOT EXISTS vector_migrations (
+			name TEXT PRIMARY KEY,
+			completed_at TIMESTAMP DEFAULT NOW()
+		)`,
+	}
+	for _, migration := range migrations {
+		if _, err := Pool.Exec(context.Background(), migration); err != nil {
+			return err
+		}
+	}
+
+	createTrigramIndexes()
+	return nil
 }
 
+// trigramMigrations need the pg_trgm extension
+var trigramMigrations = []string{
+	// Typo-tolerant title search and title autocomplete
+	`CREATE INDEX IF NOT EXISTS idx_items_title_trgm ON items USING GIN(title gin_trgm_ops)`,
+	// Tag autocomplete; a trigram index can't cover an array, so it covers the tags joined
+	`CREATE INDEX IF NOT EXISTS idx_items_tags_trgm ON items USING GIN(items_tags_text(tags) gin_trgm_ops)`,
+}
+
+// createTrigramIndexes enables pg_trgm and its indexes. pg_trgm ships with
+// PostgreSQL's contrib package, which some hosts leave out or only let a
+// superuser enable, so failing here is logged rather than fatal.
+func createTrigramIndexes() {
+	if _, err := Pool.Exec(context.Background(), `CREATE EXTENSION IF NOT EXISTS pg_trgm`); err != nil {
+		slog.Warn("pg_trgm extension unavailable; typo-tolerant search is off and autocomplete is unindexed. Install PostgreSQL's contrib package or run CREATE EXTENSION pg_trgm as a superuser, then restart", "error", err)
+		return
+	}
+	for _, migration := range trigramMigrations {
+		if _, err := Pool.Exec(context.Background(), migration); err != nil {
+			slog.Warn("failed to create trigram index; autocomplete is unindexed", "error", err)
+		}
+	}
+}
+
+// TrigramAvailable reports whether the pg_trgm extension is enabled, which
+// CreateSchema tries to do. Without it, typo-tolerant search must be off and
+// autocomplete runs unindexed.
+func TrigramAvailable(ctx context.Context) bool {
+	var enabled bool
+	err := Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm')`).Scan(&enabled)
+	return err == nil && enabled
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// baselineSchema is the items table as the first release created it, before
+// any of the migrations in CreateSchema
+const baselineSchema = `
+	CREATE TABLE items (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		title TEXT NOT NULL,
+		content TEXT NOT NULL,
+		summary TEXT,
+		source_url TEXT,
+		type TEXT NOT NULL,
+		tags TEXT[] DEFAULT '{}',
+		embedding_id TEXT,
+		image_url TEXT,
+		embed_html TEXT,
+		created_at TIMESTAMP DEFAULT NOW()
+	)`
+
+// emptySchema points Pool at a new, empty PostgreSQL schema in the database in
+// TEST_DATABASE_URL, so CreateSchema runs as it would on a fresh database. It
+// skips the test when TEST_DATABASE_URL isn't set, and drops the schema afterwards.
+func emptySchema(t *testing.T) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+
+	admin, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(admin.Close)
+	name := "schema_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := admin.Exec(ctx, `CREATE SCHEMA `+name); err != nil {
+		t.Fatalf("CREATE SCHEMA: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec(context.Background(), `DROP SCHEMA `+name+` CASCADE`) })
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse TEST_DATABASE_URL: %v", err)
+	}
+	// Extensions such as pg_trgm may already be installed in public
+	config.ConnConfig.RuntimeParams["search_path"] = fmt.Sprintf("%s, public", name)
+	Pool, err = pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("connect to %s: %v", name, err)
+	}
+	t.Cleanup(Pool.Close)
+}
+
+func TestCreateSchemaOnEmptyDatabase(t *testing.T) {
+	emptySchema(t)
+
+	if err := CreateSchema(); err != nil {
+		t.Fatalf("CreateSchema: %v", err)
+	}
+	// Every server start runs it again
+	if err := CreateSchema(); err != nil {
+		t.Fatalf("CreateSchema again: %v", err)
+	}
+}
+
+func TestCreateSchemaUpgradesBaseline(t *testing.T) {
+	emptySchema(t)
+	ctx := context.Background()
+	if _, err := Pool.Exec(ctx, baselineSchema); err != nil {
+		t.Fatalf("create baseline schema: %v", err)
+	}
+	if _, err := Pool.Exec(ctx, `INSERT INTO items (title, content, type) VALUES ('Saved before upgrading', 'Old note', 'text')`); err != nil {
+		t.Fatalf("insert baseline item: %v", err)
+	}
+
+	if err := CreateSchema(); err != nil {
+		t.Fatalf("CreateSchema: %v", err)
+	}
+
+	// Existing items belong to the default user and aren't archived
+	var userID uuid.UUID
+	var archived bool
+	err := Pool.QueryRow(ctx, `SELECT user_id, deleted_at IS NOT NULL FROM items`).Scan(&userID, &archived)
+	if err != nil {
+		t.Fatalf("read upgraded item: %v", err)
+	}
+	if userID != uuid.Nil || archived {
+		t.Errorf("upgraded item user_id = %v, archived = %v, want default user, not archived", userID, archived)
+	}
+}
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"context"
+	"synapse/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Type names an item lifecycle event
+type Type string
+
+const (
+	ItemCreated Type = "item.created"
+	ItemUpdated Type = "item.updated"
+	// ItemDeleted is sent when an item is archived, or with Permanent set when it's deleted for good
+	ItemDeleted Type = "item.deleted"
+)
+
+// Event is emitted after an item change has been written to the database
+type Event struct {
+	ID         uuid.UUID   `json:"id"`
+	Type       Type        `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	UserID     uuid.UUID   `json:"user_id"`
+	Item       models.Item `json:"item"`
+	Permanent  bool        `json:"permanent,omitempty"`
+}
+
+// New returns an event of type t about item, stamped now. The item is copied,
+// so later changes to it don't reach subscribers.
+func New(t Type, item *models.Item) Event {
+	return Event{
+		ID:         uuid.New(),
+		Type:       t,
+		OccurredAt: time.Now().UTC(),
+		UserID:     item.UserID,
+		Item:       *item,
+	}
+}
+
+// Emitter delivers item events to subscribers. Webhook implements it; pass
+// Noop to turn events off. Emit must not block the caller: deliveries run in
+// the background and outlive ctx's cancellation.
+type Emitter interface {
+	Emit(ctx context.Context, event Event)
+}
+
+// Noop discards every event
+type Noop struct{}
+
+func (Noop) Emit(ctx context.Context, event Event) {}
+
+// OrNoop returns e, or Noop if e is nil, so callers can accept a nil Emitter
+func OrNoop(e Emitter) Emitter {
+	if e == nil {
+		return Noop{}
+	}
+	return e
+}
@@ -0,0 +1,109 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"synapse/internal/logging"
+	"time"
+)
+
+const (
+	// webhookAttempts is how many times a delivery is tried before it's dropped
+	webhookAttempts = 5
+	// webhookBackoff is the wait before the first retry; it doubles after each failure
+	webhookBackoff = 2 * time.Second
+)
+
+// Webhook POSTs each event as JSON to a URL, retrying failed deliveries with
+// exponential backoff. When a secret is set, the body's HMAC-SHA256 is sent in
+// the X-Synapse-Signature header as "sha256=<hex>" so receivers can verify it.
+type Webhook struct {
+	url    string
+	secret string
+	client *http.Client
+	// backoff is the wait before the first retry
+	backoff time.Duration
+	logger  *slog.Logger
+}
+
+func NewWebhook(url, secret string, logger *slog.Logger) *Webhook {
+	return &Webhook{
+		url:     url,
+		secret:  secret,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		backoff: webhookBackoff,
+		logger:  logging.OrDefault(logger),
+	}
+}
+
+// Emit delivers event in a background goroutine. Its context keeps ctx's
+// values (such as the request ID for logs) but not its cancellation, so a
+// finished request doesn't abort the delivery.
+func (w *Webhook) Emit(ctx context.Context, event Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		w.logger.ErrorContext(ctx, "failed to encode webhook event", "operation", "webhook", "event_id", event.ID, "event", event.Type, "error", err)
+		return
+	}
+	go w.deliver(context.WithoutCancel(ctx), event, body)
+}
+
+// deliver posts body until it's accepted, webhookAttempts tries fail, or ctx is done
+func (w *Webhook) deliver(ctx context.Context, event Event, body []byte) {
+	backoff := w.backoff
+	for attempt := 1; ; attempt++ {
+		err := w.post(ctx, event, body)
+		if err == nil {
+			return
+		}
+		if attempt == webhookAttempts {
+			w.logger.ErrorContext(ctx, "webhook delivery failed, giving up", "operation", "webhook", "event_id", event.ID, "event", event.Type, "attempts", attempt, "error", err)
+			return
+		}
+		w.logger.WarnContext(ctx, "webhook delivery failed, retrying", "operation", "webhook", "event_id", event.ID, "event", event.Type, "attempt", attempt, "retry_in", backoff, "error", err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			w.logger.WarnContext(ctx, "webhook delivery cancelled", "operation", "webhook", "event_id", event.ID, "event", event.Type, "attempts", attempt, "error", ctx.Err())
+			return
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// post makes one delivery attempt. Any non-2xx response counts as a failure.
+func (w *Webhook) post(ctx context.Context, event Event, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Synapse-Webhook/1.0")
+	req.Header.Set("X-Synapse-Event", string(event.Type))
+	req.Header.Set("X-Synapse-Delivery", event.ID.String())
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		req.Header.Set("X-Synapse-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
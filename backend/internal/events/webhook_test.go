@@ -0,0 +1,155 @@
+package events
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// delivery is one request received by a test webhook endpoint
+type delivery struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver serves a webhook endpoint that fails the first failures requests
+// with a 503 and records every request on the returned channel
+func newReceiver(t *testing.T, failures int32) (*httptest.Server, <-chan delivery) {
+	received := make(chan delivery, 10)
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- delivery{header: r.Header.Clone(), body: body}
+		if calls.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func testWebhook(url, secret string) *Webhook {
+	w := NewWebhook(url, secret, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	w.backoff = time.Millisecond
+	return w
+}
+
+// next returns the next delivery, failing the test if none arrives in time
+func next(t *testing.T, received <-chan delivery) delivery {
+	t.Helper()
+	select {
+	case d := <-received:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery received")
+		return delivery{}
+	}
+}
+
+func TestWebhookDeliversSignedEvent(t *testing.T) {
+	server, received := newReceiver(t, 0)
+	w := testWebhook(server.URL, "s3cret")
+
+	item := &models.Item{ID: uuid.New(), Title: "Tomato soup", UserID: uuid.New()}
+	event := New(ItemCreated, item)
+	w.Emit(context.Background(), event)
+
+	d := next(t, received)
+	var got Event
+	if err := json.Unmarshal(d.body, &got); err != nil {
+		t.Fatalf("body isn't an event: %v", err)
+	}
+	if got.ID != event.ID || got.Type != ItemCreated || got.Item.ID != item.ID || got.UserID != item.UserID {
+		t.Errorf("delivered event = %+v, want %+v", got, event)
+	}
+	if d.header.Get("X-Synapse-Event") != "item.created" || d.header.Get("X-Synapse-Delivery") != event.ID.String() {
+		t.Errorf("event headers = %q, %q", d.header.Get("X-Synapse-Event"), d.header.Get("X-Synapse-Delivery"))
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(d.body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); d.header.Get("X-Synapse-Signature") != want {
+		t.Errorf("signature = %q, want %q", d.header.Get("X-Synapse-Signature"), want)
+	}
+}
+
+func TestWebhookWithoutSecretIsUnsigned(t *testing.T) {
+	server, received := newReceiver(t, 0)
+	testWebhook(server.URL, "").Emit(context.Background(), New(ItemUpdated, &models.Item{ID: uuid.New()}))
+
+	if d := next(t, received); d.header.Get("X-Synapse-Signature") != "" {
+		t.Errorf("signature = %q, want none without a secret", d.header.Get("X-Synapse-Signature"))
+	}
+}
+
+func TestWebhookRetriesFailedDeliveries(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int32
+		wantCalls int
+	}{
+		{"succeeds after retries", 2, 3},
+		{"gives up after the last attempt", webhookAttempts + 1, webhookAttempts},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, received := newReceiver(t, tt.failures)
+			w := testWebhook(server.URL, "")
+			event := New(ItemDeleted, &models.Item{ID: uuid.New()})
+			body, _ := json.Marshal(event)
+
+			// deliver runs in the caller here, so it has returned by the time it's checked
+			w.deliver(context.Background(), event, body)
+			if got := len(received); got != tt.wantCalls {
+				t.Errorf("webhook called %d times, want %d", got, tt.wantCalls)
+			}
+			for i := 0; i < tt.wantCalls; i++ {
+				if d := <-received; d.header.Get("X-Synapse-Delivery") != event.ID.String() {
+					t.Errorf("retry %d has delivery ID %q, want the same event", i, d.header.Get("X-Synapse-Delivery"))
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookRetryStopsWhenCancelled(t *testing.T) {
+	server, received := newReceiver(t, webhookAttempts)
+	w := testWebhook(server.URL, "")
+	w.backoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	event := New(ItemCreated, &models.Item{ID: uuid.New()})
+	body, _ := json.Marshal(event)
+	done := make(chan struct{})
+	go func() {
+		w.deliver(ctx, event, body)
+		close(done)
+	}()
+
+	next(t, received)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deliver kept waiting to retry after its context was cancelled")
+	}
+	if len(received) != 0 {
+		t.Error("delivery was retried after its context was cancelled")
+	}
+}
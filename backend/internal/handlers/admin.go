@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"synapse/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	itemService *services.ItemService
+}
+
+func NewAdminHandler(itemService *services.ItemService) *AdminHandler {
+	return &AdminHandler{itemService: itemService}
+}
+
+// Reindex regenerates the caller's embeddings and stores them in ChromaDB. An interrupted
+// run reports a cursor that can be passed back as ?cursor= to resume.
+func (h *AdminHandler) Reindex(c *gin.Context) {
+	report, err := h.itemService.ReindexAll(c.Request.Context(), currentUserID(c), c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  err.Error(),
+			"report": report,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"synapse/internal/models"
+	"synapse/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type CollectionHandler struct {
+	collectionService *services.CollectionService
+}
+
+func NewCollectionHandler(collectionService *services.CollectionService) *CollectionHandler {
+	return &CollectionHandler{collectionService: collectionService}
+}
+
+func (h *CollectionHandler) CreateCollection(c *gin.Context) {
+	var req models.CreateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection, err := h.collectionService.CreateCollection(c.Request.Context(), currentUserID(c), &req)
+	if err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+func (h *CollectionHandler) GetCollections(c *gin.Context) {
+	collections, err := h.collectionService.GetCollections(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, collections)
+}
+
+func (h *CollectionHandler) GetCollection(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	collection, err := h.collectionService.GetCollection(c.Request.Context(), currentUserID(c), id)
+	if err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+func (h *CollectionHandler) UpdateCollection(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.UpdateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection, err := h.collectionService.UpdateCollection(c.Request.Context(), currentUserID(c), id, &req)
+	if err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+func (h *CollectionHandler) DeleteCollection(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.collectionService.DeleteCollection(c.Request.Context(), currentUserID(c), id); err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "collection deleted"})
+}
+
+func (h *CollectionHandler) GetCollectionItems(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	items, err := h.collectionService.ListItemsInCollection(c.Request.Context(), currentUserID(c), id)
+	if err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *CollectionHandler) AddItem(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.AddCollectionItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.ItemID == uuid.Nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "item_id is required"})
+		return
+	}
+
+	if err := h.collectionService.AddItemToCollection(c.Request.Context(), currentUserID(c), id, req.ItemID); err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "item added to collection"})
+}
+
+func (h *CollectionHandler) RemoveItem(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	itemID, err := uuid.Parse(c.Param("itemId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		return
+	}
+
+	if err := h.collectionService.RemoveItemFromCollection(c.Request.Context(), currentUserID(c), id, itemID); err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "item removed from collection"})
+}
+
+// respondCollectionError maps collection service errors to HTTP statuses
+func respondCollectionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, services.ErrCollectionNameRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCollectionNameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
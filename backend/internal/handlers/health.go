@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	"synapse/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	healthService *services.HealthService
+}
+
+func NewHealthHandler(healthService *services.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+// Health is a liveness check: the process is up and serving requests
+func (h *HealthHandler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready is a readiness check: it probes Postgres, ChromaDB and the AI provider
+// and returns 503 if any is down, so traffic isn't routed here until they're live
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.healthService.Check(c.Request.Context())
+	if !report.Ready() {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -1,12 +1,20 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"synapse/internal/models"
 	"synapse/internal/services"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type ItemHandler struct {
@@ -28,24 +36,100 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 		return
 	}
 
-	// Validate required fields
-	if req.Title == "" && req.Content == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "title or content is required"})
+	// Validate required fields (a bare URL is enough - title is fetched from the page)
+	if req.Title == "" && req.Content == "" && req.SourceURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title, content, or source_url is required"})
 		return
 	}
 
-	// Set default type
-	if req.Type == "" {
-		req.Type = "text"
+	// An empty type is inferred from the URL and content by the service
+	item, err := h.itemService.CreateItem(c.Request.Context(), currentUserID(c), &req)
+	if err != nil {
+		respondCreateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// PreviewItem returns the item CreateItem would save, with its generated
+// summary, tags, and category, without saving it
+func (h *ItemHandler) PreviewItem(c *gin.Context) {
+	var req models.CreateItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Title == "" && req.Content == "" && req.SourceURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title, content, or source_url is required"})
+		return
 	}
 
-	item, err := h.itemService.CreateItem(c.Request.Context(), &req)
+	item, err := h.itemService.PreviewItem(c.Request.Context(), currentUserID(c), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondCreateError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, item)
+	c.JSON(http.StatusOK, item)
+}
+
+// respondCreateError reports a failed create or preview, with the existing item for duplicates
+func respondCreateError(c *gin.Context, err error) {
+	var dupErr *services.DuplicateItemError
+	if errors.As(err, &dupErr) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        err.Error(),
+			"duplicate_of": dupErr.Existing.ID,
+			"reason":       dupErr.Reason,
+			"item":         dupErr.Existing,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// maxBulkItems bounds a single bulk import request
+const maxBulkItems = 5000
+
+// BulkCreateItems starts importing many items and returns the job to poll for
+// each item's success or failure
+func (h *ItemHandler) BulkCreateItems(c *gin.Context) {
+	var req models.BulkCreateItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "items is required"})
+		return
+	}
+	if len(req.Items) > maxBulkItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d items can be imported at once", maxBulkItems)})
+		return
+	}
+
+	// Saving thousands of items outlives the request; the caller polls the job
+	job := h.itemService.StartBulkCreate(c.Request.Context(), currentUserID(c), req.Items)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetImportJob reports the progress of a bulk or bookmark import
+func (h *ItemHandler) GetImportJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	job, ok := h.itemService.GetImportJob(currentUserID(c), id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
 }
 
 func (h *ItemHandler) GetItem(c *gin.Context) {
@@ -56,7 +140,7 @@ func (h *ItemHandler) GetItem(c *gin.Context) {
 		return
 	}
 
-	item, err := h.itemService.GetItem(c.Request.Context(), id)
+	item, err := h.itemService.GetItem(c.Request.Context(), currentUserID(c), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
 		return
@@ -66,7 +150,21 @@ func (h *ItemHandler) GetItem(c *gin.Context) {
 }
 
 func (h *ItemHandler) GetAllItems(c *gin.Context) {
-	items, err := h.itemService.GetAllItems(c.Request.Context())
+	// ?sort= is newest (default), oldest, or title; relevance lists newest first
+	if !models.IsValidSortBy(c.DefaultQuery("sort", models.SortNewest)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort, expected newest, oldest, or title"})
+		return
+	}
+
+	// Paginate only when asked, so existing clients still get a plain array
+	if c.Query("page") != "" || c.Query("limit") != "" || c.Query("cursor") != "" ||
+		c.Query("type") != "" || c.Query("category") != "" || c.Query("tag") != "" {
+		h.getItemsPage(c)
+		return
+	}
+
+	includeArchived := c.Query("include_archived") == "true"
+	items, err := h.itemService.GetAllItems(c.Request.Context(), currentUserID(c), includeArchived, c.Query("sort"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -80,6 +178,256 @@ func (h *ItemHandler) GetAllItems(c *gin.Context) {
 	c.JSON(http.StatusOK, items)
 }
 
+// getItemsPage serves GET /api/items?page=&limit=&cursor=&sort= as an ItemPage. ?type=,
+// ?category= (case-insensitive) or ?tag= browse one type, category or tag; those page by offset only,
+// as does any order other than newest.
+func (h *ItemHandler) getItemsPage(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	ctx, userID, offset, sortBy := c.Request.Context(), currentUserID(c), (page-1)*limit, c.Query("sort")
+	var itemsPage *models.ItemPage
+	switch {
+	case c.Query("type") != "":
+		itemsPage, err = h.itemService.GetItemsByType(ctx, userID, c.Query("type"), sortBy, limit, offset)
+	case c.Query("category") != "":
+		itemsPage, err = h.itemService.GetItemsByCategory(ctx, userID, c.Query("category"), sortBy, limit, offset)
+	case c.Query("tag") != "":
+		itemsPage, err = h.itemService.GetItemsByTag(ctx, userID, c.Query("tag"), sortBy, limit, offset)
+	default:
+		itemsPage, err = h.itemService.GetItemsPage(ctx, userID, sortBy, limit, offset, c.Query("cursor"))
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, itemsPage)
+}
+
+func (h *ItemHandler) UpdateItem(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.UpdateItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.itemService.UpdateItem(c.Request.Context(), currentUserID(c), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+		case errors.Is(err, services.ErrItemArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *ItemHandler) GetStats(c *gin.Context) {
+	stats, err := h.itemService.Stats(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetTags lists the caller's tags with usage counts, most used first
+func (h *ItemHandler) GetTags(c *gin.Context) {
+	tags, err := h.itemService.ListTags(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// Autocomplete serves GET /api/autocomplete?q=: tags and item titles starting
+// with q, for suggestions as the user types in the search box
+func (h *ItemHandler) Autocomplete(c *gin.Context) {
+	suggestions, err := h.itemService.Autocomplete(c.Request.Context(), currentUserID(c), c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// GetTimeline serves GET /api/timeline?bucket=day|week|month&from=&to=&limit=:
+// recent items grouped by when they were saved. Dates are YYYY-MM-DD or RFC 3339.
+func (h *ItemHandler) GetTimeline(c *gin.Context) {
+	bucket := c.DefaultQuery("bucket", models.BucketMonth)
+	if !models.IsValidDateBucket(bucket) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bucket, expected day, week, or month"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	var from, to *time.Time
+	if value := c.Query("from"); value != "" {
+		t, err := parseDateParam(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date"})
+			return
+		}
+		from = &t
+	}
+	if value := c.Query("to"); value != "" {
+		t, err := parseDateParam(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date"})
+			return
+		}
+		if len(value) == len("2006-01-02") {
+			// A bare date means through the end of that day
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		to = &t
+	}
+
+	buckets, err := h.itemService.Timeline(c.Request.Context(), currentUserID(c), bucket, from, to, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, buckets)
+}
+
+// GetOnThisDay serves GET /api/on-this-day?date=YYYY-MM-DD&limit=: items saved
+// on the same month and day in earlier years, grouped by year. date defaults
+// to today.
+func (h *ItemHandler) GetOnThisDay(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	// Saved times are in UTC, so "today" is too
+	date := time.Now().UTC()
+	if value := c.Query("date"); value != "" {
+		parsed, err := parseDateParam(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
+			return
+		}
+		date = parsed
+	}
+
+	buckets, err := h.itemService.OnThisDay(c.Request.Context(), currentUserID(c), date, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, buckets)
+}
+
+// Export serves GET /api/export?format=json|csv: the caller's whole library as
+// a download. The body is streamed, so a failure partway through can only be
+// logged and the download cut short.
+func (h *ItemHandler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	var export func(context.Context, uuid.UUID, io.Writer) error
+	switch format {
+	case "json":
+		export = h.itemService.ExportJSON
+		c.Header("Content-Type", "application/json")
+	case "csv":
+		export = h.itemService.ExportCSV
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format, expected json or csv"})
+		return
+	}
+
+	filename := fmt.Sprintf("synapse-export-%s.%s", time.Now().Format("2006-01-02"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	ctx := c.Request.Context()
+	if err := export(ctx, currentUserID(c), c.Writer); err != nil {
+		slog.ErrorContext(ctx, "export failed", "operation", "export", "format", format, "error", err)
+	}
+}
+
+// Import serves POST /api/import?on_conflict=skip|update: recreates items from
+// a GET /api/export?format=json body and reports created/skipped/failed counts.
+// Items whose ID already exists are skipped unless on_conflict=update.
+func (h *ItemHandler) Import(c *gin.Context) {
+	onConflict := c.DefaultQuery("on_conflict", "skip")
+	if onConflict != "skip" && onConflict != "update" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid on_conflict, expected skip or update"})
+		return
+	}
+
+	report, err := h.itemService.ImportJSON(c.Request.Context(), currentUserID(c), c.Request.Body, onConflict == "update")
+	if err != nil {
+		// Items before a malformed one were already imported, so report them too
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "report": report})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// maxBookmarkFileBytes bounds an uploaded bookmark export
+const maxBookmarkFileBytes = 10 << 20
+
+// ImportBookmarks starts importing a browser's bookmark export (Netscape
+// bookmark HTML) and returns the job to poll for its report
+func (h *ItemHandler) ImportBookmarks(c *gin.Context) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxBookmarkFileBytes)
+	job, err := h.itemService.StartBookmarkImport(c.Request.Context(), currentUserID(c), body, maxBulkItems)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("bookmark file is larger than %d MB", maxBookmarkFileBytes>>20)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// parseDateParam parses a YYYY-MM-DD or RFC 3339 query parameter
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
 func (h *ItemHandler) DeleteItem(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -88,7 +436,17 @@ func (h *ItemHandler) DeleteItem(c *gin.Context) {
 		return
 	}
 
-	if err := h.itemService.DeleteItem(c.Request.Context(), id); err != nil {
+	// ?permanent=true deletes for good; otherwise the item is archived and can be restored
+	deleteItem := h.itemService.DeleteItem
+	if c.Query("permanent") == "true" {
+		deleteItem = h.itemService.HardDeleteItem
+	}
+
+	if err := deleteItem(c.Request.Context(), currentUserID(c), id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -96,6 +454,27 @@ func (h *ItemHandler) DeleteItem(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "item deleted"})
 }
 
+func (h *ItemHandler) RestoreItem(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	item, err := h.itemService.RestoreItem(c.Request.Context(), currentUserID(c), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
 func (h *ItemHandler) GetRelatedItems(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -105,8 +484,12 @@ func (h *ItemHandler) GetRelatedItems(c *gin.Context) {
 	}
 
 	limit := 5
-	related, err := h.relationService.FindRelatedItems(c.Request.Context(), id, limit)
+	related, err := h.relationService.FindRelatedItems(c.Request.Context(), currentUserID(c), id, limit)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -122,13 +505,13 @@ func (h *ItemHandler) RefreshImage(c *gin.Context) {
 		return
 	}
 
-	if err := h.itemService.RefreshImageForItem(c.Request.Context(), id); err != nil {
+	if err := h.itemService.RefreshImageForItem(c.Request.Context(), currentUserID(c), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Return updated item
-	item, err := h.itemService.GetItem(c.Request.Context(), id)
+	item, err := h.itemService.GetItem(c.Request.Context(), currentUserID(c), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -137,6 +520,42 @@ func (h *ItemHandler) RefreshImage(c *gin.Context) {
 	c.JSON(http.StatusOK, item)
 }
 
+// GetBrokenLinks reports the caller's saved links that no longer work;
+// ?recheck=true re-checks links even if they were checked recently
+func (h *ItemHandler) GetBrokenLinks(c *gin.Context) {
+	report, err := h.itemService.FindBrokenLinks(c.Request.Context(), currentUserID(c), c.Query("recheck") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RefreshMetadata re-fetches a link's preview and checks whether the link still works
+func (h *ItemHandler) RefreshMetadata(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	item, err := h.itemService.RefreshMetadata(c.Request.Context(), currentUserID(c), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+		case errors.Is(err, services.ErrNoLinkMetadata):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
 func (h *ItemHandler) RefreshSummary(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -145,13 +564,13 @@ func (h *ItemHandler) RefreshSummary(c *gin.Context) {
 		return
 	}
 
-	if err := h.itemService.RefreshSummaryForItem(c.Request.Context(), id); err != nil {
+	if err := h.itemService.RefreshSummaryForItem(c.Request.Context(), currentUserID(c), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Return current item (summary will be updated asynchronously)
-	item, err := h.itemService.GetItem(c.Request.Context(), id)
+	item, err := h.itemService.GetItem(c.Request.Context(), currentUserID(c), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -163,3 +582,62 @@ func (h *ItemHandler) RefreshSummary(c *gin.Context) {
 	})
 }
 
+// RegenerateEnrichment redoes an item's AI summary, tags, and optionally category
+// synchronously. The body picks the fields and may be empty, meaning summary and tags.
+func (h *ItemHandler) RegenerateEnrichment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var opts models.RegenerateOptions
+	if err := c.ShouldBindJSON(&opts); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.itemService.RegenerateEnrichment(c.Request.Context(), currentUserID(c), id, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+		case errors.Is(err, services.ErrItemArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// StreamSummary streams a newly generated summary over Server-Sent Events
+func (h *ItemHandler) StreamSummary(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	chunks, errs, err := h.itemService.StreamSummary(c.Request.Context(), currentUserID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+		return
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		chunk, ok := <-chunks
+		if !ok {
+			if err := <-errs; err != nil {
+				c.SSEvent("error", err.Error())
+			}
+			c.SSEvent("done", "")
+			return false
+		}
+		c.SSEvent("message", chunk)
+		return true
+	})
+}
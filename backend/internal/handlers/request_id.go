@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"synapse/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the correlation ID of a request. A caller-supplied ID
+// is kept so logs can be joined across services; otherwise one is generated.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds caller-supplied IDs, which end up in every log line
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware puts the request's correlation ID in its context, for
+// logging, and echoes it in the response header
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = uuid.NewString()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"synapse/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		header   string
+		wantKept bool
+	}{
+		{"generated when missing", "", false},
+		{"caller's ID is kept", "trace-123", true},
+		{"overlong ID is replaced", strings.Repeat("x", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			r := gin.New()
+			r.GET("/api/items", RequestIDMiddleware(), func(c *gin.Context) {
+				got = logging.RequestID(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			r.ServeHTTP(w, req)
+
+			if echoed := w.Header().Get(RequestIDHeader); echoed != got {
+				t.Errorf("response header = %q, context ID = %q", echoed, got)
+			}
+			if tt.wantKept {
+				if got != tt.header {
+					t.Errorf("request ID = %q, want %q", got, tt.header)
+				}
+				return
+			}
+			if _, err := uuid.Parse(got); err != nil {
+				t.Errorf("request ID = %q, want a generated UUID", got)
+			}
+		})
+	}
+}
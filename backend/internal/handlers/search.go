@@ -1,13 +1,22 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"synapse/internal/db"
+	"synapse/internal/models"
 	"synapse/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
+// maxSearchPage bounds how many results a search has to fuse and re-rank
+const maxSearchPage = 10
+
 type SearchHandler struct {
 	searchService *services.SearchService
 }
@@ -17,6 +26,17 @@ func NewSearchHandler(searchService *services.SearchService) *SearchHandler {
 }
 
 func (h *SearchHandler) Search(c *gin.Context) {
+	h.search(c, false)
+}
+
+// Explain serves GET /api/search/explain, a debug search taking the same
+// parameters as /api/search (except preview and cursor) whose results also
+// say how they were scored
+func (h *SearchHandler) Explain(c *gin.Context) {
+	h.search(c, true)
+}
+
+func (h *SearchHandler) search(c *gin.Context, explain bool) {
 	query := c.Query("q")
 	if query == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "query parameter 'q' is required"})
@@ -29,8 +49,128 @@ func (h *SearchHandler) Search(c *gin.Context) {
 		limit = 10
 	}
 
-	results, err := h.searchService.Search(c.Request.Context(), query, limit)
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	// Rather than quietly serving page 1, refuse pages past the ones a search can reach
+	if page > maxSearchPage {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("page must be at most %d", maxSearchPage)})
+		return
+	}
+
+	// ?collection_id= searches within a single collection
+	var collectionID *uuid.UUID
+	if idStr := c.Query("collection_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection_id"})
+			return
+		}
+		collectionID = &id
+	}
+
+	// ?sort= is relevance (default), newest, oldest, or title
+	sortBy := c.DefaultQuery("sort", models.SortRelevance)
+	if !models.IsValidSortBy(sortBy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort, expected relevance, newest, oldest, or title"})
+		return
+	}
+
+	if explain {
+		results, err := h.searchService.SearchExplain(c.Request.Context(), currentUserID(c), query, collectionID, sortBy, limit, (page-1)*limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, results)
+		return
+	}
+
+	// ?preview=true is a quick semantic-only search returning card fields only
+	if c.Query("preview") == "true" {
+		results, err := h.searchService.PreviewSearch(c.Request.Context(), currentUserID(c), query, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, results)
+		return
+	}
+
+	// ?cursor= (empty for the first page) pages with cursors for infinite
+	// scroll and returns a SearchPage; otherwise results are a plain array
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		searchPage, err := h.searchService.SearchPage(c.Request.Context(), currentUserID(c), query, collectionID, sortBy, limit, cursor)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidCursor) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, searchPage)
+		return
+	}
+
+	// ?facets=true also counts the matches by type and category
+	if c.Query("facets") == "true" {
+		faceted, err := h.searchService.SearchWithFacets(c.Request.Context(), currentUserID(c), query, collectionID, sortBy, limit, (page-1)*limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, faceted)
+		return
+	}
+
+	results, err := h.searchService.Search(c.Request.Context(), currentUserID(c), query, collectionID, sortBy, limit, (page-1)*limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// Suggest serves GET /api/search/suggest?q=: a "Did you mean" query for a
+// search that found little, or "" when the query is fine
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parameter 'q' is required"})
+		return
+	}
+
+	suggestion, err := h.searchService.Suggest(c.Request.Context(), currentUserID(c), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"suggestion": suggestion})
+}
+
+// RelatedItems serves GET /api/items/:id/similar: saved items most like the given one
+func (h *SearchHandler) RelatedItems(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	results, err := h.searchService.RelatedItems(c.Request.Context(), currentUserID(c), id, limit)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -38,3 +178,23 @@ func (h *SearchHandler) Search(c *gin.Context) {
 	c.JSON(http.StatusOK, results)
 }
 
+// Recommend serves GET /api/recommendations: older saved items like the ones
+// saved most recently
+func (h *SearchHandler) Recommend(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	results, err := h.searchService.Recommend(c.Request.Context(), currentUserID(c), limit)
+	if err != nil {
+		if errors.Is(err, db.ErrChromaUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UserIDHeader names the user a request acts for. This API doesn't
+// authenticate users itself: a multi-user install must sit behind a proxy
+// that authenticates them and sets this header, overwriting any the client sent.
+const UserIDHeader = "X-User-ID"
+
+const userIDKey = "userID"
+
+// UserMiddleware puts the user ID from UserIDHeader in the context. Requests
+// without the header act for the default (nil UUID) user, so a single-user
+// install works unchanged; a header that isn't a UUID gets a 400.
+func UserMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := uuid.Nil
+		if header := c.GetHeader(UserIDHeader); header != "" {
+			var err error
+			userID, err = uuid.Parse(header)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid " + UserIDHeader + " header"})
+				return
+			}
+		}
+		c.Set(userIDKey, userID)
+		c.Next()
+	}
+}
+
+// currentUserID returns the user ID set by UserMiddleware
+func currentUserID(c *gin.Context) uuid.UUID {
+	if userID, ok := c.Get(userIDKey); ok {
+		return userID.(uuid.UUID)
+	}
+	return uuid.Nil
+}
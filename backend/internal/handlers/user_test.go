@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestUserMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	alice := uuid.New()
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		want       uuid.UUID
+	}{
+		{"no header is the default user", "", http.StatusOK, uuid.Nil},
+		{"user from header", alice.String(), http.StatusOK, alice},
+		{"not a UUID", "alice", http.StatusBadRequest, uuid.Nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got uuid.UUID
+			r := gin.New()
+			r.GET("/api/items", UserMiddleware(), func(c *gin.Context) {
+				got = currentUserID(c)
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+			if tt.header != "" {
+				req.Header.Set(UserIDHeader, tt.header)
+			}
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got != tt.want {
+				t.Errorf("user = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestContextHandlerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(contextHandler{slog.NewTextHandler(&buf, nil)})
+
+	logger.InfoContext(WithRequestID(context.Background(), "req-1"), "with id")
+	logger.InfoContext(context.Background(), "without id")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want 2", len(lines))
+	}
+	if !strings.Contains(lines[0], "request_id=req-1") {
+		t.Errorf("line %q has no request_id", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Errorf("line %q has a request_id without one in its context", lines[1])
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import "time"
+
+// Metrics receives measurements from the services. Prometheus implements it;
+// pass Noop to turn metrics off.
+type Metrics interface {
+	// ObserveAICall records one provider request; operation is what it was for, e.g. "summary" or "embedding"
+	ObserveAICall(provider, operation string, duration time.Duration, err error)
+	// ObserveEmbeddingCache records one embedding cache lookup; cache is "content"
+	// for item embeddings or "query" for search query embeddings
+	ObserveEmbeddingCache(cache string, hit bool)
+	// ObserveChromaQuery records one ChromaDB nearest-neighbour query
+	ObserveChromaQuery(duration time.Duration, err error)
+	// ObserveSearch records one search backend run; source is "semantic" or "text"
+	ObserveSearch(source string, results int, duration time.Duration, err error)
+}
+
+// Noop discards every measurement
+type Noop struct{}
+
+func (Noop) ObserveAICall(provider, operation string, duration time.Duration, err error) {}
+func (Noop) ObserveEmbeddingCache(cache string, hit bool)                                {}
+func (Noop) ObserveChromaQuery(duration time.Duration, err error)                        {}
+func (Noop) ObserveSearch(source string, results int, duration time.Duration, err error) {}
+
+// OrNoop returns m, or Noop if m is nil, so constructors can accept a nil Metrics
+func OrNoop(m Metrics) Metrics {
+	if m == nil {
+		return Noop{}
+	}
+	return m
+}
+
+// status labels an outcome for counters
+func status(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus exports measurements as Prometheus metrics prefixed "synapse_"
+type Prometheus struct {
+	aiDuration    *prometheus.HistogramVec
+	aiRequests    *prometheus.CounterVec
+	cacheLookups  *prometheus.CounterVec
+	chromaLatency *prometheus.HistogramVec
+	searchLatency *prometheus.HistogramVec
+	searchResults *prometheus.HistogramVec
+}
+
+// NewPrometheus registers the metrics with reg, or the default registry if reg is nil
+func NewPrometheus(reg prometheus.Registerer) *Prometheus {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	p := &Prometheus{
+		aiDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "synapse_ai_request_duration_seconds",
+			Help:    "Duration of AI provider requests.",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 20, 30, 60},
+		}, []string{"provider", "operation"}),
+		aiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "synapse_ai_requests_total",
+			Help: "AI provider requests by outcome.",
+		}, []string{"provider", "operation", "status"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "synapse_embedding_cache_lookups_total",
+			Help: "Embedding cache lookups by cache (content or query) and result (hit or miss).",
+		}, []string{"cache", "result"}),
+		chromaLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "synapse_chroma_query_duration_seconds",
+			Help:    "Duration of ChromaDB queries.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"status"}),
+		searchLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "synapse_search_duration_seconds",
+			Help:    "Duration of each search backend.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"source", "status"}),
+		searchResults: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "synapse_search_results",
+			Help:    "Number of results each search backend returned.",
+			Buckets: []float64{0, 1, 5, 10, 20, 50, 100, 200},
+		}, []string{"source"}),
+	}
+
+	reg.MustRegister(p.aiDuration, p.aiRequests, p.cacheLookups, p.chromaLatency, p.searchLatency, p.searchResults)
+	return p
+}
+
+func (p *Prometheus) ObserveAICall(provider, operation string, duration time.Duration, err error) {
+	p.aiDuration.WithLabelValues(provider, operation).Observe(duration.Seconds())
+	p.aiRequests.WithLabelValues(provider, operation, status(err)).Inc()
+}
+
+func (p *Prometheus) ObserveEmbeddingCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	p.cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+func (p *Prometheus) ObserveChromaQuery(duration time.Duration, err error) {
+	p.chromaLatency.WithLabelValues(status(err)).Observe(duration.Seconds())
+}
+
+func (p *Prometheus) ObserveSearch(source string, results int, duration time.Duration, err error) {
+	p.searchLatency.WithLabelValues(source, status(err)).Observe(duration.Seconds())
+	if err == nil {
+		p.searchResults.WithLabelValues(source).Observe(float64(results))
+	}
+}
+
+// Handler serves the default registry in the Prometheus text format, for mounting at /metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
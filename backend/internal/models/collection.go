@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Collection is a named, user-owned folder of items. An item can be in any
+// number of collections.
+type Collection struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	ItemCount   int       `json:"item_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CreateCollectionRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// UpdateCollectionRequest edits a collection. Nil fields are left unchanged.
+type UpdateCollectionRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+type AddCollectionItemRequest struct {
+	ItemID uuid.UUID `json:"item_id"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// EmbeddingConfig records which embedding model filled a ChromaDB collection.
+// Vectors from different models (or dimensions) can't be compared.
+type EmbeddingConfig struct {
+	CollectionName string    `json:"collection_name"`
+	Model          string    `json:"model"`
+	Dimension      int       `json:"dimension"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -6,32 +6,85 @@ import (
 	"github.com/google/uuid"
 )
 
+// Search result orders for QueryFilters.SortBy
+const (
+	SortRelevance = "relevance" // Best match first (the default)
+	SortNewest    = "newest"
+	SortOldest    = "oldest"
+	SortTitle     = "title" // Title A-Z, case-insensitive
+)
+
+// IsValidSortBy reports whether sortBy is one of the Sort* orders
+func IsValidSortBy(sortBy string) bool {
+	switch sortBy {
+	case SortRelevance, SortNewest, SortOldest, SortTitle:
+		return true
+	}
+	return false
+}
+
 type QueryFilters struct {
-	SearchTerms   string
-	Type          string
-	DateFrom      *time.Time
-	DateTo        *time.Time
-	Tags          []string
-	PriceMax      *float64
-	PriceMin      *float64
-	Author        string
-	Source        string
+	SearchTerms     string
+	Type            string
+	DateFrom        *time.Time
+	DateTo          *time.Time
+	Tags            []string
+	PriceMax        *float64
+	PriceMin        *float64
+	Author          string
+	Source          string
+	Category        string     // Canonical category name, matched case-insensitively
+	IncludeArchived bool       // Also match archived (soft-deleted) items
+	CollectionID    *uuid.UUID // Only match items in this collection
+	// FuzzyThreshold is the pg_trgm word similarity at which a misspelled term
+	// still matches a title ("kubernets" -> "Kubernetes"); 0 disables fuzzy matching
+	FuzzyThreshold float64
+	// SortBy is one of the Sort* orders; "" means SortRelevance
+	SortBy string
+	// ExcludeTerms drops items whose title, summary, content, or OCR text
+	// mentions any of them, ignoring case ("kubernetes -helm")
+	ExcludeTerms []string
+	// Phrases must each appear verbatim, ignoring case, in the title, summary,
+	// content, or OCR text ("\"machine learning\"")
+	Phrases []string
 }
 
 type Item struct {
-	ID          uuid.UUID `json:"id"`
-	Title       string    `json:"title"`
-	Content     string    `json:"content"`
-	Summary     string    `json:"summary"`
-	SourceURL   string    `json:"source_url"`
-	Type        string    `json:"type"` // "text", "url", "image", "book", "recipe"
-	Category    string    `json:"category"` // AI-categorized section: "Technology", "Food & Recipes", "Books", "Videos", "Shopping", "Articles", "Notes", etc.
-	Tags        []string  `json:"tags"`
-	EmbeddingID string    `json:"embedding_id"`
-	ImageURL    string    `json:"image_url"`    // For book covers, recipe images, or page previews
-	EmbedHTML   string    `json:"embed_html"`   // For URL embeds/previews
-	OcrText     string    `json:"ocr_text"`     // Extracted text from images/screenshots via OCR
-	CreatedAt   time.Time `json:"created_at"`
+	ID            uuid.UUID `json:"id"`
+	Title         string    `json:"title"`
+	Content       string    `json:"content"`
+	Summary       string    `json:"summary"`
+	SourceURL     string    `json:"source_url"`
+	Type          string    `json:"type"`     // "text", "url", "image", "book", "recipe"
+	Category      string    `json:"category"` // AI-categorized section: "Technology", "Food & Recipes", "Books", "Videos", "Shopping", "Articles", "Notes", etc.
+	Tags          []string  `json:"tags"`
+	EmbeddingID   string    `json:"embedding_id"`
+	ImageURL      string    `json:"image_url"`  // For book covers, recipe images, or page previews
+	EmbedHTML     string    `json:"embed_html"` // For URL embeds/previews
+	OcrText       string    `json:"ocr_text"`   // Extracted text from images/screenshots via OCR
+	Language      string    `json:"language"`   // Detected ISO 639-1 language code of the original content
+	NormalizedURL string    `json:"-"`          // Canonical SourceURL used to detect duplicate saves
+	// ReadingTimeMinutes is the estimated reading time, or the video length for videos; 0 if unknown
+	ReadingTimeMinutes int `json:"reading_time_minutes"`
+	// Price and Currency (ISO 4217) are the product price of shopping items; Price is nil if unknown
+	Price    *float64 `json:"price,omitempty"`
+	Currency string   `json:"currency,omitempty"`
+	// FaviconURL is the absolute URL of the linked site's icon, for url, blog, and amazon items
+	FaviconURL string `json:"favicon_url,omitempty"`
+	// ImageDescription describes what an image item shows, written by a vision model for alt text and search
+	ImageDescription string `json:"image_description,omitempty"`
+	// HTTPStatus is the link's response status when last checked, at LastCheckedAt (0 if it
+	// couldn't be reached). LinkBroken flags links that answered 4xx/5xx or were unreachable.
+	HTTPStatus    int        `json:"http_status,omitempty"`
+	LinkBroken    bool       `json:"link_broken,omitempty"`
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	// DeletedAt is set when the item is archived; archived items are hidden but can be restored
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// UserID owns the item; uuid.Nil is the default user of a single-user install
+	UserID uuid.UUID `json:"user_id"`
+	// EmbeddingSourceHash identifies the text the item's vector was generated from; "" if unknown
+	EmbeddingSourceHash string `json:"-"`
 }
 
 type CreateItemRequest struct {
@@ -41,6 +94,107 @@ type CreateItemRequest struct {
 	Type      string            `json:"type"` // "text", "url", "image", "amazon", "blog", "video"
 	ImageURL  string            `json:"image_url"` // For pre-extracted images
 	Metadata  map[string]string `json:"metadata"` // Additional metadata (price, rating, etc.)
+	// TranslateToEnglish translates non-English content before summarizing and embedding
+	// (the original content is still stored). Also enabled globally by TRANSLATE_TO_ENGLISH=true.
+	TranslateToEnglish bool `json:"translate_to_english"`
+	// AllowDuplicate saves the item even if the same link or content was already saved
+	AllowDuplicate bool `json:"allow_duplicate"`
+	// Tags are set by importers (e.g. bookmark folders) and kept ahead of AI-generated tags
+	Tags []string `json:"-"`
+	// CreatedAt backdates an imported item to when it was originally saved; zero means now
+	CreatedAt time.Time `json:"-"`
+}
+
+// BulkCreateItemsRequest imports many items at once, e.g. exported browser bookmarks
+type BulkCreateItemsRequest struct {
+	Items []CreateItemRequest `json:"items"`
+}
+
+// BulkCreateItemResult is the outcome for one item of a bulk import, in request order
+type BulkCreateItemResult struct {
+	Item        *Item      `json:"item,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	DuplicateOf *uuid.UUID `json:"duplicate_of,omitempty"`
+}
+
+type BulkCreateItemsResponse struct {
+	Created int                    `json:"created"`
+	Failed  int                    `json:"failed"`
+	Results []BulkCreateItemResult `json:"results"`
+}
+
+// UpdateItemRequest edits a saved item. Nil fields are left unchanged.
+type UpdateItemRequest struct {
+	Title     *string   `json:"title"`
+	Content   *string   `json:"content"`
+	Summary   *string   `json:"summary"`
+	Category  *string   `json:"category"`
+	Tags      *[]string `json:"tags"`
+	ImageURL  *string   `json:"image_url"`
+	EmbedHTML *string   `json:"embed_html"`
+	// Regenerate re-embeds and re-summarizes the item when its content changes
+	Regenerate bool `json:"regenerate"`
+}
+
+// RegenerateOptions picks which AI-generated fields RegenerateEnrichment redoes.
+// With none set, the summary and tags are regenerated.
+type RegenerateOptions struct {
+	Summary  bool `json:"summary"`
+	Tags     bool `json:"tags"`
+	Category bool `json:"category"`
+}
+
+// TagCount is how many items carry a tag, for sizing a tag cloud
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// Autocomplete suggestion kinds for Suggestion.Type
+const (
+	SuggestionTag   = "tag"
+	SuggestionTitle = "title"
+)
+
+// Suggestion is one autocomplete match for a partly typed query: a tag with how
+// many items carry it, or an item title with that item's ID
+type Suggestion struct {
+	Type   string     `json:"type"`
+	Text   string     `json:"text"`
+	ItemID *uuid.UUID `json:"item_id,omitempty"`
+	Count  int        `json:"count,omitempty"`
+}
+
+// Timeline groupings for ItemRepository.GetByDateBucket
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week" // ISO weeks, starting Monday
+	BucketMonth = "month"
+)
+
+// IsValidDateBucket reports whether bucket is one of the Bucket* groupings
+func IsValidDateBucket(bucket string) bool {
+	switch bucket {
+	case BucketDay, BucketWeek, BucketMonth:
+		return true
+	}
+	return false
+}
+
+// DateBucket is a period and the items saved in it, newest first. Label is
+// "2006-01-02" for days, "2006-W01" for weeks, "2006-01" for months, and the
+// year for "on this day" results.
+type DateBucket struct {
+	Label string    `json:"label"`
+	Start time.Time `json:"start"`
+	Items []Item    `json:"items"`
+}
+
+// ItemStats are item counts for the dashboard
+type ItemStats struct {
+	Total      int            `json:"total"`
+	ByType     map[string]int `json:"by_type"`
+	ByCategory map[string]int `json:"by_category"`
 }
 
 type RelatedItem struct {
@@ -51,5 +205,51 @@ type RelatedItem struct {
 type SearchResult struct {
 	Item           Item    `json:"item"`
 	SimilarityScore float64 `json:"similarity_score"`
+	// SemanticScore and TextScore are the pre-fusion scores (cosine similarity
+	// and ts_rank), kept for debugging rankings; zero if absent from that list
+	SemanticScore float64 `json:"semantic_score,omitempty"`
+	TextScore     float64 `json:"text_score,omitempty"`
+	// Snippet is an HTML-escaped excerpt around the matched terms, with matches in <mark> tags
+	Snippet string `json:"snippet,omitempty"`
+	// Explain breaks down the score; only set by SearchService.SearchExplain
+	Explain *SearchExplanation `json:"explain,omitempty"`
+}
+
+// FacetCount is how many search matches have one type or category
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Facets counts a search's matches by type and category, most common first,
+// for filter chips like "video (12)"
+type Facets struct {
+	Types      []FacetCount `json:"types"`
+	Categories []FacetCount `json:"categories"`
+}
+
+// FacetedSearch is one page of search results with facet counts over the matches
+type FacetedSearch struct {
+	Results []SearchResult `json:"results"`
+	Facets  Facets         `json:"facets"`
+}
+
+// SearchExplanation shows how a hybrid search result was ranked, for tuning relevance
+type SearchExplanation struct {
+	// SemanticDistance is the ChromaDB cosine distance to the query embedding,
+	// nil if the result wasn't in the semantic list
+	SemanticDistance *float64 `json:"semantic_distance"`
+	// SemanticRank and TextRank are 1-based positions in each list before
+	// fusion, 0 if the result wasn't in that list
+	SemanticRank int  `json:"semantic_rank"`
+	TextRank     int  `json:"text_rank"`
+	InBoth       bool `json:"in_both"`
+	// FusedScore is the normalized Reciprocal Rank Fusion score, before boosts
+	FusedScore float64 `json:"fused_score"`
+	// ExactMatchBoost was added to FusedScore for containing the search terms verbatim
+	ExactMatchBoost float64 `json:"exact_match_boost"`
+	// FusedRank is the 1-based position after fusion and boosting, before AI
+	// re-ranking or a non-relevance sort reordered the results
+	FusedRank int `json:"fused_rank"`
 }
 
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor can't be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ItemCursor is a keyset position in the (created_at DESC, id DESC) item ordering
+type ItemCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Encode returns an opaque, URL-safe form of the cursor
+func (c ItemCursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeItemCursor parses a cursor produced by ItemCursor.Encode
+func DecodeItemCursor(s string) (*ItemCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &ItemCursor{CreatedAt: time.Unix(0, n).UTC(), ID: parsedID}, nil
+}
+
+// SearchCursor is a position in a search's ranked results: the last result
+// returned and how many results came before the next page
+type SearchCursor struct {
+	Offset int
+	ID     uuid.UUID
+}
+
+// Encode returns an opaque, URL-safe form of the cursor
+func (c SearchCursor) Encode() string {
+	raw := strconv.Itoa(c.Offset) + ":" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeSearchCursor parses a cursor produced by SearchCursor.Encode
+func DecodeSearchCursor(s string) (*SearchCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	offset, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	n, err := strconv.Atoi(offset)
+	if err != nil || n < 0 {
+		return nil, ErrInvalidCursor
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &SearchCursor{Offset: n, ID: parsedID}, nil
+}
+
+// SearchPage is one page of search results plus the cursor for the next
+type SearchPage struct {
+	Results []SearchResult `json:"results"`
+	// NextCursor continues the search after these results; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ItemPage is one page of items plus what the client needs to fetch the next
+type ItemPage struct {
+	Items  []Item `json:"items"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	// NextCursor fetches the following page without an OFFSET scan; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
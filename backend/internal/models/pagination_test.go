@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSearchCursorRoundTrip(t *testing.T) {
+	for _, cursor := range []SearchCursor{
+		{Offset: 0, ID: uuid.New()},
+		{Offset: 20, ID: uuid.New()},
+		{Offset: 499, ID: uuid.Nil},
+	} {
+		got, err := DecodeSearchCursor(cursor.Encode())
+		if err != nil || *got != cursor {
+			t.Errorf("DecodeSearchCursor(Encode(%+v)) = %+v, %v", cursor, got, err)
+		}
+	}
+}
+
+func TestDecodeSearchCursorRejectsMalformed(t *testing.T) {
+	id := uuid.NewString()
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "not a cursor!"},
+		{"no separator", encode("10" + id)},
+		{"negative offset", encode("-1:" + id)},
+		{"non-numeric offset", encode("ten:" + id)},
+		{"bad ID", encode("10:not-a-uuid")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := DecodeSearchCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeSearchCursor(%q) = %+v, %v; want ErrInvalidCursor", tt.cursor, got, err)
+			}
+		})
+	}
+}
+
+func TestItemCursorRoundTrip(t *testing.T) {
+	cursor := ItemCursor{CreatedAt: time.Date(2026, 3, 4, 5, 6, 7, 89, time.UTC), ID: uuid.New()}
+	got, err := DecodeItemCursor(cursor.Encode())
+	if err != nil || !got.CreatedAt.Equal(cursor.CreatedAt) || got.ID != cursor.ID {
+		t.Errorf("DecodeItemCursor(Encode(%+v)) = %+v, %v", cursor, got, err)
+	}
+	if _, err := DecodeItemCursor("not a cursor!"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("DecodeItemCursor of garbage: err = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CollectionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCollectionRepository(pool *pgxpool.Pool) *CollectionRepository {
+	return &CollectionRepository{pool: pool}
+}
+
+// collectionColumns is the column list scanCollection expects, in order. The
+// item count leaves out archived items, matching what ListItems returns.
+const collectionColumns = `c.id, c.user_id, c.name, c.description, c.created_at,
+	(SELECT COUNT(*) FROM collection_items ci JOIN items i ON i.id = ci.item_id
+		WHERE ci.collection_id = c.id AND i.deleted_at IS NULL)`
+
+func scanCollection(row pgx.Row) (*models.Collection, error) {
+	var collection models.Collection
+	var description sql.NullString
+
+	err := row.Scan(&collection.ID, &collection.UserID, &collection.Name, &description, &collection.CreatedAt, &collection.ItemCount)
+	if err != nil {
+		return nil, err
+	}
+	if description.Valid {
+		collection.Description = description.String
+	}
+	return &collection, nil
+}
+
+func (r *CollectionRepository) Create(ctx context.Context, collection *models.Collection) error {
+	query := `
+		INSERT INTO collections (id, user_id, name, description, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := r.pool.Exec(ctx, query,
+		collection.ID, collection.UserID, collection.Name, collection.Description, collection.CreatedAt,
+	)
+	return err
+}
+
+// GetByID returns one of userID's collections, or pgx.ErrNoRows
+func (r *CollectionRepository) GetByID(ctx context.Context, userID, id uuid.UUID) (*models.Collection, error) {
+	query := `
+		SELECT ` + collectionColumns + `
+		FROM collections c
+		WHERE c.id = $1 AND c.user_id = $2
+	`
+	return scanCollection(r.pool.QueryRow(ctx, query, id, userID))
+}
+
+// GetAll returns userID's collections in name order
+func (r *CollectionRepository) GetAll(ctx context.Context, userID uuid.UUID) ([]models.Collection, error) {
+	query := `
+		SELECT ` + collectionColumns + `
+		FROM collections c
+		WHERE c.user_id = $1
+		ORDER BY LOWER(c.name)
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []models.Collection{}
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, *collection)
+	}
+	return collections, rows.Err()
+}
+
+// Update saves a collection's name and description, returning pgx.ErrNoRows
+// if it doesn't exist or doesn't belong to collection.UserID
+func (r *CollectionRepository) Update(ctx context.Context, collection *models.Collection) error {
+	query := `UPDATE collections SET name = $1, description = $2 WHERE id = $3 AND user_id = $4`
+	tag, err := r.pool.Exec(ctx, query, collection.Name, collection.Description, collection.ID, collection.UserID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Delete removes a collection; its items are kept
+func (r *CollectionRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	query := `DELETE FROM collections WHERE id = $1 AND user_id = $2`
+	tag, err := r.pool.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// AddItem puts an item in a collection; adding it twice is a no-op
+func (r *CollectionRepository) AddItem(ctx context.Context, collectionID, itemID uuid.UUID) error {
+	query := `
+		INSERT INTO collection_items (collection_id, item_id)
+		VALUES ($1, $2)
+		ON CONFLICT (collection_id, item_id) DO NOTHING
+	`
+	_, err := r.pool.Exec(ctx, query, collectionID, itemID)
+	return err
+}
+
+// RemoveItem takes an item out of a collection, returning pgx.ErrNoRows if it wasn't in it
+func (r *CollectionRepository) RemoveItem(ctx context.Context, collectionID, itemID uuid.UUID) error {
+	query := `DELETE FROM collection_items WHERE collection_id = $1 AND item_id = $2`
+	tag, err := r.pool.Exec(ctx, query, collectionID, itemID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ItemIDs returns the IDs of every item in a collection
+func (r *CollectionRepository) ItemIDs(ctx context.Context, collectionID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, `SELECT item_id FROM collection_items WHERE collection_id = $1`, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
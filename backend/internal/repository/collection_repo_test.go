@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"synapse/internal/db"
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// createTestCollection saves a collection owned by userID and removes it when the test ends
+func createTestCollection(t *testing.T, repo *CollectionRepository, userID uuid.UUID, name string) *models.Collection {
+	t.Helper()
+	collection := &models.Collection{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if err := repo.Create(context.Background(), collection); err != nil {
+		t.Fatalf("Create(%q): %v", name, err)
+	}
+	t.Cleanup(func() { repo.Delete(context.Background(), userID, collection.ID) })
+	return collection
+}
+
+func TestCollectionItems(t *testing.T) {
+	items := testItemRepo(t)
+	repo := NewCollectionRepository(db.Pool)
+	ctx := context.Background()
+	userID := uuid.New()
+	word := uniqueWord()
+	papers := createTestCollection(t, repo, userID, "ML Papers")
+	attention := createTestItem(t, items, userID, "Attention is all you need "+word, nil)
+	resnet := createTestItem(t, items, userID, "Deep residual learning "+word, nil)
+	outside := createTestItem(t, items, userID, "Learning to cook "+word, nil)
+
+	for _, item := range []*models.Item{attention, resnet} {
+		if err := repo.AddItem(ctx, papers.ID, item.ID); err != nil {
+			t.Fatalf("AddItem: %v", err)
+		}
+	}
+	// Adding an item twice is a no-op
+	if err := repo.AddItem(ctx, papers.ID, attention.ID); err != nil {
+		t.Errorf("second AddItem: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, userID, papers.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Name != "ML Papers" || got.ItemCount != 2 {
+		t.Errorf("collection = %+v, want ML Papers with 2 items", got)
+	}
+	if ids, err := repo.ItemIDs(ctx, papers.ID); err != nil || !sameIDs(ids, []uuid.UUID{attention.ID, resnet.ID}) {
+		t.Errorf("ItemIDs = %v, %v; want both papers", ids, err)
+	}
+
+	// Searching within the collection leaves out matching items outside it
+	results, err := items.SearchItems(ctx, userID, &models.QueryFilters{SearchTerms: word, CollectionID: &papers.ID}, 10, 0)
+	if err != nil {
+		t.Fatalf("SearchItems: %v", err)
+	}
+	if got := resultIDs(results); !sameIDs(got, []uuid.UUID{attention.ID, resnet.ID}) {
+		t.Errorf("SearchItems in collection = %v, want only the papers, not %v", got, outside.ID)
+	}
+
+	// Archived items are hidden from the listing and the count
+	if err := items.Delete(ctx, userID, resnet.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	listed, err := items.GetByCollection(ctx, userID, papers.ID)
+	if err != nil {
+		t.Fatalf("GetByCollection: %v", err)
+	}
+	if len(listed) != 1 || listed[0].ID != attention.ID {
+		t.Errorf("GetByCollection = %d items, want only %v", len(listed), attention.ID)
+	}
+	if got, _ := repo.GetByID(ctx, userID, papers.ID); got.ItemCount != 1 {
+		t.Errorf("ItemCount after archiving = %d, want 1", got.ItemCount)
+	}
+
+	if err := repo.RemoveItem(ctx, papers.ID, attention.ID); err != nil {
+		t.Fatalf("RemoveItem: %v", err)
+	}
+	if err := repo.RemoveItem(ctx, papers.ID, attention.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("second RemoveItem error = %v, want pgx.ErrNoRows", err)
+	}
+
+	// Deleting the collection keeps its items
+	if err := repo.Delete(ctx, userID, papers.ID); err != nil {
+		t.Fatalf("Delete collection: %v", err)
+	}
+	if _, err := items.GetByID(ctx, userID, attention.ID); err != nil {
+		t.Errorf("item after deleting its collection: %v", err)
+	}
+}
+
+func TestCollectionRepositoryIsolatesUsers(t *testing.T) {
+	testItemRepo(t)
+	repo := NewCollectionRepository(db.Pool)
+	ctx := context.Background()
+	owner, other := uuid.New(), uuid.New()
+	collection := createTestCollection(t, repo, owner, "Vacation Planning")
+	createTestCollection(t, repo, owner, "ML Papers")
+
+	if _, err := repo.GetByID(ctx, other, collection.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("GetByID as another user: err = %v, want pgx.ErrNoRows", err)
+	}
+	if list, err := repo.GetAll(ctx, other); err != nil || len(list) != 0 {
+		t.Errorf("GetAll as another user = %v, %v; want nothing", list, err)
+	}
+	renamed := *collection
+	renamed.UserID = other
+	renamed.Name = "Mine now"
+	if err := repo.Update(ctx, &renamed); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("Update as another user: err = %v, want pgx.ErrNoRows", err)
+	}
+	if err := repo.Delete(ctx, other, collection.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("Delete as another user: err = %v, want pgx.ErrNoRows", err)
+	}
+
+	// The owner's collections are listed by name
+	list, err := repo.GetAll(ctx, owner)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "ML Papers" || list[1].Name != "Vacation Planning" {
+		t.Errorf("GetAll = %+v, want ML Papers then Vacation Planning", list)
+	}
+}
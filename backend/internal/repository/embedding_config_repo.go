@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"synapse/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EmbeddingConfigRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewEmbeddingConfigRepository(pool *pgxpool.Pool) *EmbeddingConfigRepository {
+	return &EmbeddingConfigRepository{pool: pool}
+}
+
+// Get returns the embedding config recorded for a collection, or pgx.ErrNoRows
+func (r *EmbeddingConfigRepository) Get(ctx context.Context, collectionName string) (*models.EmbeddingConfig, error) {
+	query := `
+		SELECT collection_name, model, dimension, created_at
+		FROM embedding_config
+		WHERE collection_name = $1
+	`
+
+	var config models.EmbeddingConfig
+	err := r.pool.QueryRow(ctx, query, collectionName).Scan(
+		&config.CollectionName, &config.Model, &config.Dimension, &config.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Create records a collection's embedding config. If one is already recorded
+// it is kept, so the first writer wins; re-read it with Get.
+func (r *EmbeddingConfigRepository) Create(ctx context.Context, config *models.EmbeddingConfig) error {
+	query := `
+		INSERT INTO embedding_config (collection_name, model, dimension)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (collection_name) DO NOTHING
+	`
+	_, err := r.pool.Exec(ctx, query, config.CollectionName, config.Model, config.Dimension)
+	return err
+}
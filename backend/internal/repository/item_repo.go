@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
 	"synapse/internal/models"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -20,43 +23,26 @@ func NewItemRepository(pool *pgxpool.Pool) *ItemRepository {
 	return &ItemRepository{pool: pool}
 }
 
-func (r *ItemRepository) Create(ctx context.Context, item *models.Item) error {
-	query := `
-		INSERT INTO items (id, title, content, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, ocr_text, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-	`
-	
-	tagsArray := pgtype.Array[string]{
-		Elements: item.Tags,
-		Valid:    true,
-	}
-	
-	_, err := r.pool.Exec(ctx, query,
-		item.ID, item.Title, item.Content, item.Summary, item.SourceURL,
-		item.Type, item.Category, tagsArray, item.EmbeddingID, item.ImageURL, item.EmbedHTML, item.OcrText, item.CreatedAt,
-	)
-	return err
-}
+// itemColumns is the column list scanItem expects, in order
+const itemColumns = `id, title, content, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, ocr_text, language, normalized_url, reading_time_minutes, price, currency, favicon_url, image_description, http_status, link_broken, last_checked_at, user_id, created_at, deleted_at, embedding_source_hash`
 
-func (r *ItemRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Item, error) {
-	query := `
-		SELECT id, title, content, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, ocr_text, created_at
-		FROM items
-		WHERE id = $1
-	`
-	
+// scanItem scans a row selected with itemColumns, mapping NULLs to empty strings
+func scanItem(row pgx.Row) (*models.Item, error) {
 	var item models.Item
 	var tagsArray pgtype.Array[string]
-	var imageURL, embedHTML, category, ocrText sql.NullString
-	
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	var imageURL, embedHTML, category, ocrText, language, normalizedURL, currency, faviconURL, imageDescription, embeddingSourceHash sql.NullString
+	var readingTime, httpStatus sql.NullInt32
+	// NUMERIC scans into pgtype.Float8; database/sql's NullFloat64 would get it as text
+	var price pgtype.Float8
+
+	err := row.Scan(
 		&item.ID, &item.Title, &item.Content, &item.Summary, &item.SourceURL,
-		&item.Type, &category, &tagsArray, &item.EmbeddingID, &imageURL, &embedHTML, &ocrText, &item.CreatedAt,
+		&item.Type, &category, &tagsArray, &item.EmbeddingID, &imageURL, &embedHTML, &ocrText, &language, &normalizedURL, &readingTime, &price, &currency, &faviconURL, &imageDescription, &httpStatus, &item.LinkBroken, &item.LastCheckedAt, &item.UserID, &item.CreatedAt, &item.DeletedAt, &embeddingSourceHash,
 	)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	item.Tags = tagsArray.Elements
 	if category.Valid {
 		item.Category = category.String
@@ -70,17 +56,96 @@ func (r *ItemRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Ite
 	if ocrText.Valid {
 		item.OcrText = ocrText.String
 	}
+	if language.Valid {
+		item.Language = language.String
+	}
+	if normalizedURL.Valid {
+		item.NormalizedURL = normalizedURL.String
+	}
+	if readingTime.Valid {
+		item.ReadingTimeMinutes = int(readingTime.Int32)
+	}
+	if price.Valid {
+		item.Price = &price.Float64
+	}
+	if currency.Valid {
+		item.Currency = currency.String
+	}
+	if faviconURL.Valid {
+		item.FaviconURL = faviconURL.String
+	}
+	if imageDescription.Valid {
+		item.ImageDescription = imageDescription.String
+	}
+	if httpStatus.Valid {
+		item.HTTPStatus = int(httpStatus.Int32)
+	}
+	if embeddingSourceHash.Valid {
+		item.EmbeddingSourceHash = embeddingSourceHash.String
+	}
 	return &item, nil
 }
 
-func (r *ItemRepository) GetAll(ctx context.Context) ([]models.Item, error) {
+func (r *ItemRepository) Create(ctx context.Context, item *models.Item) error {
+	query := `
+		INSERT INTO items (id, title, content, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, ocr_text, language, normalized_url, reading_time_minutes, price, currency, favicon_url, image_description, user_id, created_at, embedding_source_hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+	`
+	
+	tagsArray := pgtype.Array[string]{
+		Elements: item.Tags,
+		Valid:    true,
+	}
+	
+	_, err := r.pool.Exec(ctx, query,
+		item.ID, item.Title, item.Content, item.Summary, item.SourceURL,
+		item.Type, item.Category, tagsArray, item.EmbeddingID, item.ImageURL, item.EmbedHTML, item.OcrText, item.Language, item.NormalizedURL, item.ReadingTimeMinutes, item.Price, item.Currency, item.FaviconURL, item.ImageDescription, item.UserID, item.CreatedAt, item.EmbeddingSourceHash,
+	)
+	return err
+}
+
+// GetByID returns one of userID's items, or pgx.ErrNoRows if it doesn't exist or belongs to someone else
+func (r *ItemRepository) GetByID(ctx context.Context, userID, id uuid.UUID) (*models.Item, error) {
 	query := `
-		SELECT id, title, content, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, ocr_text, created_at
+		SELECT ` + itemColumns + `
 		FROM items
-		ORDER BY created_at DESC
+		WHERE id = $1 AND user_id = $2
+	`
+	
+	return scanItem(r.pool.QueryRow(ctx, query, id, userID))
+}
+
+// GetBySourceURL returns userID's oldest item whose normalized URL is
+// normalizedURL, or pgx.ErrNoRows. Items saved before normalized_url existed are matched on
+// source_url instead.
+func (r *ItemRepository) GetBySourceURL(ctx context.Context, userID uuid.UUID, normalizedURL string) (*models.Item, error) {
+	query := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE (normalized_url = $1 OR (normalized_url IS NULL AND source_url = ANY($2)))
+			AND user_id = $3 AND deleted_at IS NULL
+		ORDER BY created_at ASC
+		LIMIT 1
+	`
+
+	legacy := []string{normalizedURL, normalizedURL + "/"}
+	return scanItem(r.pool.QueryRow(ctx, query, normalizedURL, legacy, userID))
+}
+
+// GetAll returns every item belonging to userID in sortBy order (newest first by default). Archived items are left out unless includeArchived is set.
+func (r *ItemRepository) GetAll(ctx context.Context, userID uuid.UUID, includeArchived bool, sortBy string) ([]models.Item, error) {
+	orderBy, err := listOrderBy(sortBy)
+	if err != nil {
+		return []models.Item{}, err
+	}
+	query := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE user_id = $1 AND ($2 OR deleted_at IS NULL)
+		ORDER BY ` + orderBy + `
 	`
 	
-	rows, err := r.pool.Query(ctx, query)
+	rows, err := r.pool.Query(ctx, query, userID, includeArchived)
 	if err != nil {
 		return []models.Item{}, err
 	}
@@ -88,49 +153,384 @@ func (r *ItemRepository) GetAll(ctx context.Context) ([]models.Item, error) {
 	
 	items := []models.Item{}
 	for rows.Next() {
-		var item models.Item
-		var tagsArray pgtype.Array[string]
-		var imageURL, embedHTML, category, ocrText sql.NullString
-		
-		err := rows.Scan(
-			&item.ID, &item.Title, &item.Content, &item.Summary, &item.SourceURL,
-			&item.Type, &category, &tagsArray, &item.EmbeddingID, &imageURL, &embedHTML, &ocrText, &item.CreatedAt,
-		)
+		item, err := scanItem(rows)
 		if err != nil {
 			return []models.Item{}, err
 		}
-		
-		item.Tags = tagsArray.Elements
-		if category.Valid {
-			item.Category = category.String
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return []models.Item{}, err
+	}
+	
+	return items, nil
+}
+
+// GetAllPaginated returns one page of userID's items in sortBy order (newest first by default) and their total count
+func (r *ItemRepository) GetAllPaginated(ctx context.Context, userID uuid.UUID, sortBy string, limit, offset int) ([]models.Item, int, error) {
+	orderBy, err := listOrderBy(sortBy)
+	if err != nil {
+		return []models.Item{}, 0, err
+	}
+	query := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE user_id = $1 AND deleted_at IS NULL
+		ORDER BY ` + orderBy + `
+		LIMIT $2 OFFSET $3
+	`
+
+	items, err := r.queryItems(ctx, query, userID, limit, offset)
+	if err != nil {
+		return []models.Item{}, 0, err
+	}
+
+	total, err := r.Count(ctx, userID)
+	if err != nil {
+		return []models.Item{}, 0, err
+	}
+	return items, total, nil
+}
+
+// GetByType returns one page of userID's items of itemType in sortBy order, and how many there are in total
+func (r *ItemRepository) GetByType(ctx context.Context, userID uuid.UUID, itemType, sortBy string, limit, offset int) ([]models.Item, int, error) {
+	return r.getPageWhere(ctx, userID, `type = $2`, itemType, sortBy, limit, offset)
+}
+
+// GetByCategory returns one page of userID's items in category in sortBy order, and how many there are
+// in total. Matching is case-insensitive so older free-form categories still match.
+func (r *ItemRepository) GetByCategory(ctx context.Context, userID uuid.UUID, category, sortBy string, limit, offset int) ([]models.Item, int, error) {
+	return r.getPageWhere(ctx, userID, `LOWER(category) = LOWER($2)`, category, sortBy, limit, offset)
+}
+
+// GetByTag returns one page of userID's items tagged tag in sortBy order, and how many there are
+// in total. Matching is case-insensitive, since tags saved before they were lowercased keep their case.
+func (r *ItemRepository) GetByTag(ctx context.Context, userID uuid.UUID, tag, sortBy string, limit, offset int) ([]models.Item, int, error) {
+	return r.getPageWhere(ctx, userID, `EXISTS (SELECT 1 FROM unnest(tags) AS tag WHERE LOWER(tag) = LOWER($2))`, tag, sortBy, limit, offset)
+}
+
+// ListTags returns every distinct tag on userID's items with how many items carry it, most used first
+func (r *ItemRepository) ListTags(ctx context.Context, userID uuid.UUID) ([]models.TagCount, error) {
+	query := `
+		SELECT tag, COUNT(*)
+		FROM items, unnest(tags) AS tag
+		WHERE user_id = $1 AND deleted_at IS NULL
+		GROUP BY tag
+		ORDER BY COUNT(*) DESC, tag
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.TagCount{}
+	for rows.Next() {
+		var tc models.TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, err
 		}
-		if imageURL.Valid {
-			item.ImageURL = imageURL.String
+		tags = append(tags, tc)
+	}
+	return tags, rows.Err()
+}
+
+// AutocompleteTags returns up to limit of userID's tags starting with prefix
+// (case-insensitively), most used first
+func (r *ItemRepository) AutocompleteTags(ctx context.Context, userID uuid.UUID, prefix string, limit int) ([]models.Suggestion, error) {
+	// The first ILIKE narrows to items with a matching tag through the trigram
+	// index on items_tags_text; the second keeps only the matching tags
+	query := `
+		SELECT tag, COUNT(*)
+		FROM items, unnest(tags) AS tag
+		WHERE user_id = $1 AND deleted_at IS NULL
+		  AND items_tags_text(tags) ILIKE $2 AND tag ILIKE $3
+		GROUP BY tag
+		ORDER BY COUNT(*) DESC, tag
+		LIMIT $4
+	`
+
+	escaped := escapeLike(prefix)
+	rows, err := r.pool.Query(ctx, query, userID, "%"+escaped+"%", escaped+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []models.Suggestion{}
+	for rows.Next() {
+		s := models.Suggestion{Type: models.SuggestionTag}
+		if err := rows.Scan(&s.Text, &s.Count); err != nil {
+			return nil, err
 		}
-		if embedHTML.Valid {
-			item.EmbedHTML = embedHTML.String
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
+
+// AutocompleteTitles returns up to limit of userID's items whose title starts with
+// prefix (case-insensitively). Shorter titles, the closest matches, come first.
+func (r *ItemRepository) AutocompleteTitles(ctx context.Context, userID uuid.UUID, prefix string, limit int) ([]models.Suggestion, error) {
+	// An anchored ILIKE is served by the trigram index on title
+	query := `
+		SELECT id, title
+		FROM items
+		WHERE user_id = $1 AND deleted_at IS NULL AND title ILIKE $2
+		ORDER BY LENGTH(title), created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID, escapeLike(prefix)+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []models.Suggestion{}
+	for rows.Next() {
+		var id uuid.UUID
+		s := models.Suggestion{Type: models.SuggestionTitle}
+		if err := rows.Scan(&id, &s.Text); err != nil {
+			return nil, err
 		}
-		if ocrText.Valid {
-			item.OcrText = ocrText.String
+		s.ItemID = &id
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
+
+// listOrderBy returns the ORDER BY clause for listing items in sortBy order.
+// Only whitelisted orders reach the SQL. A listing has no query to be
+// relevant to, so relevance (and "") lists newest first.
+func listOrderBy(sortBy string) (string, error) {
+	switch sortBy {
+	case "", models.SortRelevance, models.SortNewest:
+		return "created_at DESC, id DESC", nil
+	case models.SortOldest:
+		return "created_at ASC, id ASC", nil
+	case models.SortTitle:
+		return "LOWER(title) ASC, id ASC", nil
+	}
+	return "", fmt.Errorf("invalid sort order %q", sortBy)
+}
+
+// escapeLike escapes LIKE's wildcards in s so it matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// getPageWhere pages through userID's items matching cond, a condition on $2 (never user input)
+func (r *ItemRepository) getPageWhere(ctx context.Context, userID uuid.UUID, cond string, arg interface{}, sortBy string, limit, offset int) ([]models.Item, int, error) {
+	orderBy, err := listOrderBy(sortBy)
+	if err != nil {
+		return []models.Item{}, 0, err
+	}
+	query := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE user_id = $1 AND ` + cond + ` AND deleted_at IS NULL
+		ORDER BY ` + orderBy + `
+		LIMIT $3 OFFSET $4
+	`
+
+	items, err := r.queryItems(ctx, query, userID, arg, limit, offset)
+	if err != nil {
+		return []models.Item{}, 0, err
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM items WHERE user_id = $1 AND ` + cond + ` AND deleted_at IS NULL`
+	if err := r.pool.QueryRow(ctx, countQuery, userID, arg).Scan(&total); err != nil {
+		return []models.Item{}, 0, err
+	}
+	return items, total, nil
+}
+
+// GetAllAfter returns up to limit of userID's items that sort after cursor, newest first.
+// Unlike GetAllPaginated it seeks via the (created_at, id) index instead of
+// scanning past skipped rows.
+func (r *ItemRepository) GetAllAfter(ctx context.Context, userID uuid.UUID, cursor *models.ItemCursor, limit int) ([]models.Item, int, error) {
+	query := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE user_id = $1 AND (created_at, id) < ($2, $3) AND deleted_at IS NULL
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`
+
+	items, err := r.queryItems(ctx, query, userID, cursor.CreatedAt, cursor.ID, limit)
+	if err != nil {
+		return []models.Item{}, 0, err
+	}
+
+	total, err := r.Count(ctx, userID)
+	if err != nil {
+		return []models.Item{}, 0, err
+	}
+	return items, total, nil
+}
+
+// Count returns the total number of userID's items, not counting archived ones
+func (r *ItemRepository) Count(ctx context.Context, userID uuid.UUID) (int, error) {
+	var total int
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM items WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&total)
+	return total, err
+}
+
+// CountByType returns the number of userID's items of each type
+func (r *ItemRepository) CountByType(ctx context.Context, userID uuid.UUID) (map[string]int, error) {
+	return r.countGroupedBy(ctx, userID, `type`)
+}
+
+// CountByCategory returns the number of userID's items in each category; uncategorized items count under ""
+func (r *ItemRepository) CountByCategory(ctx context.Context, userID uuid.UUID) (map[string]int, error) {
+	return r.countGroupedBy(ctx, userID, `COALESCE(category, '')`)
+}
+
+// countGroupedBy counts userID's items grouped by a column expression (never user input)
+func (r *ItemRepository) countGroupedBy(ctx context.Context, userID uuid.UUID, expr string) (map[string]int, error) {
+	query := `SELECT ` + expr + `, COUNT(*) FROM items WHERE user_id = $1 AND deleted_at IS NULL GROUP BY 1`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var key string
+		var count int
+		if err := rows.Scan(&key, &count); err != nil {
+			return nil, err
 		}
-		items = append(items, item)
+		counts[key] = count
 	}
-	
-	return items, nil
+	return counts, rows.Err()
+}
+
+// GetByDateBucket returns userID's most recent limit items, optionally within
+// [from, to], grouped into day, week, or month buckets (models.Bucket*), newest first
+func (r *ItemRepository) GetByDateBucket(ctx context.Context, userID uuid.UUID, bucket string, from, to *time.Time, limit int) ([]models.DateBucket, error) {
+	if !models.IsValidDateBucket(bucket) {
+		return nil, fmt.Errorf("invalid date bucket %q", bucket)
+	}
+
+	query := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE user_id = $1 AND deleted_at IS NULL
+			AND ($2::timestamp IS NULL OR created_at >= $2)
+			AND ($3::timestamp IS NULL OR created_at <= $3)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`
+	items, err := r.queryItems(ctx, query, userID, inUTC(from), inUTC(to), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupByDate(items, func(t time.Time) (time.Time, string) {
+		switch bucket {
+		case models.BucketDay:
+			start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+			return start, start.Format("2006-01-02")
+		case models.BucketWeek:
+			// Weeks start on Monday, matching ISO week numbers
+			daysSinceMonday := (int(t.Weekday()) + 6) % 7
+			start := time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, t.Location())
+			year, week := t.ISOWeek()
+			return start, fmt.Sprintf("%d-W%02d", year, week)
+		default:
+			start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+			return start, start.Format("2006-01")
+		}
+	}), nil
+}
+
+// GetOnThisDay returns up to limit of userID's items saved on month/day in
+// years before beforeYear, grouped by year, most recent year first
+func (r *ItemRepository) GetOnThisDay(ctx context.Context, userID uuid.UUID, month time.Month, day, beforeYear, limit int) ([]models.DateBucket, error) {
+	query := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE user_id = $1 AND deleted_at IS NULL
+			AND EXTRACT(MONTH FROM created_at) = $2
+			AND EXTRACT(DAY FROM created_at) = $3
+			AND EXTRACT(YEAR FROM created_at) < $4
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5
+	`
+	items, err := r.queryItems(ctx, query, userID, int(month), day, beforeYear, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupByDate(items, func(t time.Time) (time.Time, string) {
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		return start, fmt.Sprint(t.Year())
+	}), nil
+}
+
+// inUTC converts a time bound to UTC, or returns nil for no bound. created_at
+// is a TIMESTAMP holding UTC, and pgx drops a bound's zone rather than
+// converting it, so "00:00+05:00" would otherwise compare as 00:00 UTC.
+func inUTC(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// groupByDate splits items (sorted newest first) into consecutive buckets
+// keyed by bucketOf, which returns a bucket's start and label
+func groupByDate(items []models.Item, bucketOf func(time.Time) (time.Time, string)) []models.DateBucket {
+	buckets := []models.DateBucket{}
+	for _, item := range items {
+		start, label := bucketOf(item.CreatedAt)
+		if n := len(buckets); n == 0 || buckets[n-1].Label != label {
+			buckets = append(buckets, models.DateBucket{Label: label, Start: start})
+		}
+		last := &buckets[len(buckets)-1]
+		last.Items = append(last.Items, item)
+	}
+	return buckets
+}
+
+// queryItems runs a query selecting itemColumns and scans every row
+func (r *ItemRepository) queryItems(ctx context.Context, query string, args ...interface{}) ([]models.Item, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
 }
 
-func (r *ItemRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Item, error) {
+// GetByIDs returns the items in ids that belong to userID; others are silently left out
+func (r *ItemRepository) GetByIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]models.Item, error) {
 	if len(ids) == 0 {
 		return []models.Item{}, nil
 	}
 	
 	query := `
-		SELECT id, title, content, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, ocr_text, created_at
+		SELECT ` + itemColumns + `
 		FROM items
-		WHERE id = ANY($1)
+		WHERE id = ANY($1) AND user_id = $2 AND deleted_at IS NULL
 	`
 	
-	rows, err := r.pool.Query(ctx, query, ids)
+	rows, err := r.pool.Query(ctx, query, ids, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -138,43 +538,188 @@ func (r *ItemRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]model
 	
 	var items []models.Item
 	for rows.Next() {
-		var item models.Item
-		var tagsArray pgtype.Array[string]
-		var imageURL, embedHTML, category, ocrText sql.NullString
-		
-		err := rows.Scan(
-			&item.ID, &item.Title, &item.Content, &item.Summary, &item.SourceURL,
-			&item.Type, &category, &tagsArray, &item.EmbeddingID, &imageURL, &embedHTML, &ocrText, &item.CreatedAt,
-		)
+		item, err := scanItem(rows)
 		if err != nil {
 			return nil, err
 		}
-		
-		item.Tags = tagsArray.Elements
-		if category.Valid {
-			item.Category = category.String
-		}
-		if imageURL.Valid {
-			item.ImageURL = imageURL.String
-		}
-		if embedHTML.Valid {
-			item.EmbedHTML = embedHTML.String
-		}
-		if ocrText.Valid {
-			item.OcrText = ocrText.String
-		}
-		items = append(items, item)
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 	
 	return items, nil
 }
 
-func (r *ItemRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM items WHERE id = $1`
-	_, err := r.pool.Exec(ctx, query, id)
+// GetByCollection returns userID's items in a collection, most recently added first
+func (r *ItemRepository) GetByCollection(ctx context.Context, userID, collectionID uuid.UUID) ([]models.Item, error) {
+	query := `
+		SELECT ` + itemColumns + `
+		FROM items
+		JOIN collection_items ci ON ci.item_id = items.id
+		WHERE ci.collection_id = $1 AND user_id = $2 AND deleted_at IS NULL
+		ORDER BY ci.added_at DESC
+	`
+	return r.queryItems(ctx, query, collectionID, userID)
+}
+
+// Delete archives an item: it's hidden from listings and search but can be
+// restored. Returns pgx.ErrNoRows if userID has no such unarchived item.
+func (r *ItemRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	query := `UPDATE items SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	tag, err := r.pool.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// HardDelete permanently removes an item
+func (r *ItemRepository) HardDelete(ctx context.Context, userID, id uuid.UUID) error {
+	query := `DELETE FROM items WHERE id = $1 AND user_id = $2`
+	_, err := r.pool.Exec(ctx, query, id, userID)
 	return err
 }
 
+// Restore un-archives an item, returning pgx.ErrNoRows if userID has no such item
+func (r *ItemRepository) Restore(ctx context.Context, userID, id uuid.UUID) error {
+	query := `UPDATE items SET deleted_at = NULL WHERE id = $1 AND user_id = $2`
+	tag, err := r.pool.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Update saves the editable fields of an item by ID, returning pgx.ErrNoRows if
+// it doesn't exist or doesn't belong to item.UserID
+func (r *ItemRepository) Update(ctx context.Context, item *models.Item) error {
+	query := `
+		UPDATE items
+		SET title = $1, content = $2, summary = $3, category = $4, tags = $5, image_url = $6, embed_html = $7, embedding_id = $8, embedding_source_hash = $9
+		WHERE id = $10 AND user_id = $11
+	`
+
+	tagsArray := pgtype.Array[string]{
+		Elements: item.Tags,
+		Valid:    true,
+	}
+
+	tag, err := r.pool.Exec(ctx, query,
+		item.Title, item.Content, item.Summary, item.Category, tagsArray, item.ImageURL, item.EmbedHTML, item.EmbeddingID, item.EmbeddingSourceHash, item.ID, item.UserID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateEnrichment saves an item's regenerated summary, category, and tags and
+// its embedding ID and source hash, returning pgx.ErrNoRows if the item doesn't exist
+func (r *ItemRepository) UpdateEnrichment(ctx context.Context, item *models.Item) error {
+	query := `
+		UPDATE items
+		SET summary = $1, category = $2, tags = $3, embedding_id = $4, embedding_source_hash = $5
+		WHERE id = $6 AND user_id = $7
+	`
+
+	tagsArray := pgtype.Array[string]{
+		Elements: item.Tags,
+		Valid:    true,
+	}
+
+	tag, err := r.pool.Exec(ctx, query,
+		item.Summary, item.Category, tagsArray, item.EmbeddingID, item.EmbeddingSourceHash, item.ID, item.UserID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateLinkMetadata saves a re-fetched link preview (title, image, embed) and
+// the link's check result, returning pgx.ErrNoRows if the item doesn't exist
+func (r *ItemRepository) UpdateLinkMetadata(ctx context.Context, item *models.Item) error {
+	query := `
+		UPDATE items
+		SET title = $1, image_url = $2, embed_html = $3, http_status = $4, link_broken = $5, last_checked_at = $6
+		WHERE id = $7 AND user_id = $8
+	`
+
+	tag, err := r.pool.Exec(ctx, query,
+		item.Title, item.ImageURL, item.EmbedHTML, item.HTTPStatus, item.LinkBroken, item.LastCheckedAt, item.ID, item.UserID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateLinkStatus saves the result of checking an item's link
+func (r *ItemRepository) UpdateLinkStatus(ctx context.Context, item *models.Item) error {
+	query := `UPDATE items SET http_status = $1, link_broken = $2, last_checked_at = $3 WHERE id = $4 AND user_id = $5`
+	_, err := r.pool.Exec(ctx, query, item.HTTPStatus, item.LinkBroken, item.LastCheckedAt, item.ID, item.UserID)
+	return err
+}
+
+// GetLinks returns userID's url, blog, and video items that have a source URL,
+// newest first. Archived items are left out.
+func (r *ItemRepository) GetLinks(ctx context.Context, userID uuid.UUID) ([]models.Item, error) {
+	query := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE user_id = $1 AND type IN ('url', 'blog', 'video') AND source_url <> '' AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	return r.queryItems(ctx, query, userID)
+}
+
+// GetStaleLinks returns up to limit url, blog, and video items of every user
+// whose link was last checked (or, if never, saved) before checkedBefore,
+// least recently checked first. Archived items are left out.
+func (r *ItemRepository) GetStaleLinks(ctx context.Context, checkedBefore time.Time, limit int) ([]models.Item, error) {
+	query := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE type IN ('url', 'blog', 'video') AND source_url <> '' AND deleted_at IS NULL
+			AND COALESCE(last_checked_at, created_at) < $1
+		ORDER BY COALESCE(last_checked_at, created_at) ASC
+		LIMIT $2
+	`
+
+	return r.queryItems(ctx, query, checkedBefore, limit)
+}
+
+// GetEmbeddedAfter returns up to limit unarchived items of every user that have
+// a vector, in ID order starting after afterID, for walking the whole index
+func (r *ItemRepository) GetEmbeddedAfter(ctx context.Context, afterID uuid.UUID, limit int) ([]models.Item, error) {
+	query := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE COALESCE(embedding_id, '') <> '' AND deleted_at IS NULL AND id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	return r.queryItems(ctx, query, afterID, limit)
+}
+
 // UpdateSummary updates the summary field of an item (for async summarization)
 func (r *ItemRepository) UpdateSummary(ctx context.Context, id uuid.UUID, summary string) error {
 	query := `UPDATE items SET summary = $1 WHERE id = $2`
@@ -189,6 +734,20 @@ func (r *ItemRepository) UpdateImageURL(ctx context.Context, id uuid.UUID, image
 	return err
 }
 
+// UpdateEmbeddingID updates an item's embedding_id and the hash of the text its vector was generated from
+func (r *ItemRepository) UpdateEmbeddingID(ctx context.Context, id uuid.UUID, embeddingID, sourceHash string) error {
+	query := `UPDATE items SET embedding_id = $1, embedding_source_hash = $2 WHERE id = $3`
+	_, err := r.pool.Exec(ctx, query, embeddingID, sourceHash, id)
+	return err
+}
+
+// UpdateImageDescription updates the image_description field of an item
+func (r *ItemRepository) UpdateImageDescription(ctx context.Context, id uuid.UUID, description string) error {
+	query := `UPDATE items SET image_description = $1 WHERE id = $2`
+	_, err := r.pool.Exec(ctx, query, description, id)
+	return err
+}
+
 // UpdateOCRText updates the ocr_text field of an item
 func (r *ItemRepository) UpdateOCRText(ctx context.Context, id uuid.UUID, ocrText string) error {
 	query := `UPDATE items SET ocr_text = $1 WHERE id = $2`
@@ -196,66 +755,78 @@ func (r *ItemRepository) UpdateOCRText(ctx context.Context, id uuid.UUID, ocrTex
 	return err
 }
 
-// SearchItems performs text search with filters (includes OCR text)
-func (r *ItemRepository) SearchItems(ctx context.Context, filters *models.QueryFilters, limit int) ([]models.Item, error) {
-	query := `
-		SELECT id, title, content, summary, source_url, type, category, tags, embedding_id, image_url, embed_html, ocr_text, created_at
-		FROM items
-		WHERE 1=1
-	`
-	args := []interface{}{}
-	argIndex := 1
+// SearchItems performs full-text search with filters over userID's items (includes OCR text).
+// Each result's SimilarityScore is its ts_rank relevance in [0, 1), or 0
+// when there are no search terms.
+func (r *ItemRepository) SearchItems(ctx context.Context, userID uuid.UUID, filters *models.QueryFilters, limit, offset int) ([]models.SearchResult, error) {
+	args := []interface{}{userID}
+	argIndex := 2
+	rank := "0::float8"
+	orderBy := "created_at DESC, id DESC"
+	where := ""
+	var fuzzyTerms []string
 
-	// Text search (includes OCR text for images/screenshots)
-	// Enhanced to handle multiple terms from Claude query expansion
+	// Text search against the weighted search_vector (title, summary, content, OCR text).
+	// Claude query expansion yields several alternative terms, so any of them may match.
 	if filters.SearchTerms != "" {
-		// Split enhanced query into individual terms for better matching
-		terms := strings.Fields(filters.SearchTerms)
-		
-		// Build OR conditions for each term - matches if ANY term is found
-		// This allows finding content even when exact phrase doesn't match
-		var conditions []string
-		for _, term := range terms {
+		var terms []string
+		for _, term := range strings.Fields(filters.SearchTerms) {
 			if len(term) < 2 { // Skip very short terms
 				continue
 			}
-			termPattern := "%" + term + "%"
-			conditions = append(conditions, fmt.Sprintf(`(
-				title ILIKE $%d OR 
-				content ILIKE $%d OR 
-				summary ILIKE $%d OR
-				ocr_text ILIKE $%d
-			)`, argIndex, argIndex, argIndex, argIndex))
-			args = append(args, termPattern)
-			argIndex++
+			terms = append(terms, term)
 		}
-		
-		if len(conditions) > 0 {
-			// Also try exact phrase match for better relevance
-			exactPattern := "%" + filters.SearchTerms + "%"
-			conditions = append(conditions, fmt.Sprintf(`(
-				title ILIKE $%d OR 
-				content ILIKE $%d OR 
-				summary ILIKE $%d OR
-				ocr_text ILIKE $%d
-			)`, argIndex, argIndex, argIndex, argIndex))
-			args = append(args, exactPattern)
+
+		if len(terms) > 0 {
+			// websearch_to_tsquery never fails on user input, unlike to_tsquery
+			tsQuery := fmt.Sprintf(`websearch_to_tsquery('english', $%d)`, argIndex)
+			args = append(args, strings.Join(terms, " OR "))
 			argIndex++
-			
-			query += " AND (" + strings.Join(conditions, " OR ") + ")"
+
+			// Normalization 32 maps rank into [0, 1) so it can be fused with similarity
+			rank = fmt.Sprintf(`ts_rank(search_vector, %s, 32)`, tsQuery)
+			match := fmt.Sprintf(`search_vector @@ %s`, tsQuery)
+
+			// Full-text search needs correctly spelled words, so titles are also
+			// matched by trigram word similarity to catch typos
+			if filters.FuzzyThreshold > 0 {
+				for _, term := range terms {
+					if len(term) >= 3 && !strings.EqualFold(term, "or") && !strings.EqualFold(term, "and") {
+						fuzzyTerms = append(fuzzyTerms, term)
+					}
+				}
+			}
+			if len(fuzzyTerms) > 0 {
+				var fuzzyMatches, similarities []string
+				for _, term := range fuzzyTerms {
+					// <% uses the GIN trigram index and the threshold set below
+					fuzzyMatches = append(fuzzyMatches, fmt.Sprintf(`$%d <%% title`, argIndex))
+					similarities = append(similarities, fmt.Sprintf(`word_similarity($%d, title)`, argIndex))
+					args = append(args, term)
+					argIndex++
+				}
+				match = fmt.Sprintf(`(%s OR %s)`, match, strings.Join(fuzzyMatches, " OR "))
+				rank = fmt.Sprintf(`GREATEST(%s, %s)`, rank, strings.Join(similarities, ", "))
+			}
+
+			where += ` AND ` + match
+			orderBy = rank + " DESC, " + orderBy
 		}
 	}
 
-	// Type filter (only apply if search terms exist, or if type was explicitly set)
-	// This allows searching for "video" to find items containing "video" even if type doesn't match
-	if filters.Type != "" && filters.SearchTerms != "" {
-		// If we have search terms, type filter is optional - search in all types but prefer the specified type
-		// We'll handle this in post-processing or make it optional
-		// For now, if type is set and search terms exist, we'll search in that type OR in content
-		// This is a bit complex, so let's make type filter optional when search terms exist
+	query := `
+		SELECT ` + itemColumns + `, ` + rank + `
+		FROM items
+		WHERE user_id = $1` + where
+
+	// Archived items are excluded unless asked for
+	if !filters.IncludeArchived {
+		query += ` AND deleted_at IS NULL`
 	}
-	if filters.Type != "" && filters.SearchTerms == "" {
-		// Only apply type filter if no search terms (pure type filter)
+
+	// Type filter, ANDed with the text predicate when both are present
+	// ("video machine learning" means videos about machine learning)
+	if filters.Type != "" {
 		query += fmt.Sprintf(` AND type = $%d`, argIndex)
 		args = append(args, filters.Type)
 		argIndex++
@@ -264,12 +835,12 @@ func (r *ItemRepository) SearchItems(ctx context.Context, filters *models.QueryF
 	// Date range filter
 	if filters.DateFrom != nil {
 		query += fmt.Sprintf(` AND created_at >= $%d`, argIndex)
-		args = append(args, *filters.DateFrom)
+		args = append(args, inUTC(filters.DateFrom))
 		argIndex++
 	}
 	if filters.DateTo != nil {
 		query += fmt.Sprintf(` AND created_at <= $%d`, argIndex)
-		args = append(args, *filters.DateTo)
+		args = append(args, inUTC(filters.DateTo))
 		argIndex++
 	}
 
@@ -288,51 +859,107 @@ func (r *ItemRepository) SearchItems(ctx context.Context, filters *models.QueryF
 		argIndex++
 	}
 
-	// Category filter (using Source field from QueryFilters for category)
-	if filters.Source != "" {
-		query += fmt.Sprintf(` AND category = $%d`, argIndex)
-		args = append(args, filters.Source)
+	// Category filter, case-insensitive so older free-form categories still match
+	if filters.Category != "" {
+		query += fmt.Sprintf(` AND LOWER(category) = LOWER($%d)`, argIndex)
+		args = append(args, filters.Category)
 		argIndex++
 	}
 
-	query += ` ORDER BY created_at DESC LIMIT $` + fmt.Sprintf("%d", argIndex)
-	args = append(args, limit)
+	// Price range filter. Items without a stored price are kept, as most items
+	// aren't products; the search service still checks their content for one.
+	if filters.PriceMin != nil {
+		query += fmt.Sprintf(` AND (price IS NULL OR price >= $%d)`, argIndex)
+		args = append(args, *filters.PriceMin)
+		argIndex++
+	}
+	if filters.PriceMax != nil {
+		query += fmt.Sprintf(` AND (price IS NULL OR price <= $%d)`, argIndex)
+		args = append(args, *filters.PriceMax)
+		argIndex++
+	}
 
-	rows, err := r.pool.Query(ctx, query, args...)
-	if err != nil {
-		return []models.Item{}, err
+	// Exact phrases and excluded terms, matched as substrings like the semantic results are
+	for _, phrase := range filters.Phrases {
+		query += ` AND ` + mentionsArg(argIndex)
+		args = append(args, "%"+escapeLike(phrase)+"%")
+		argIndex++
+	}
+	for _, term := range filters.ExcludeTerms {
+		query += ` AND NOT ` + mentionsArg(argIndex)
+		args = append(args, "%"+escapeLike(term)+"%")
+		argIndex++
 	}
-	defer rows.Close()
 
-	items := []models.Item{}
-	for rows.Next() {
-		var item models.Item
-		var tagsArray pgtype.Array[string]
-		var imageURL, embedHTML, category, ocrText sql.NullString
-
-		err := rows.Scan(
-			&item.ID, &item.Title, &item.Content, &item.Summary, &item.SourceURL,
-			&item.Type, &category, &tagsArray, &item.EmbeddingID, &imageURL, &embedHTML, &ocrText, &item.CreatedAt,
-		)
-		if err != nil {
-			return []models.Item{}, err
-		}
+	// Collection filter, for searching within one collection
+	if filters.CollectionID != nil {
+		query += fmt.Sprintf(` AND id IN (SELECT item_id FROM collection_items WHERE collection_id = $%d)`, argIndex)
+		args = append(args, *filters.CollectionID)
+		argIndex++
+	}
 
-		item.Tags = tagsArray.Elements
-		if category.Valid {
-			item.Category = category.String
+	// Relevance keeps the rank order built above
+	if filters.SortBy != "" && filters.SortBy != models.SortRelevance {
+		var err error
+		if orderBy, err = listOrderBy(filters.SortBy); err != nil {
+			return []models.SearchResult{}, err
 		}
-		if imageURL.Valid {
-			item.ImageURL = imageURL.String
+	}
+
+	query += fmt.Sprintf(` ORDER BY %s LIMIT $%d OFFSET $%d`, orderBy, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var rows pgx.Rows
+	var err error
+	if len(fuzzyTerms) > 0 {
+		// The <% threshold is a setting, so scope it to a transaction
+		tx, beginErr := r.pool.Begin(ctx)
+		if beginErr != nil {
+			return []models.SearchResult{}, beginErr
 		}
-		if embedHTML.Valid {
-			item.EmbedHTML = embedHTML.String
+		// Read-only, so there's nothing to commit
+		defer tx.Rollback(ctx)
+
+		threshold := strconv.FormatFloat(filters.FuzzyThreshold, 'f', -1, 64)
+		if _, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)`, threshold); err != nil {
+			return []models.SearchResult{}, err
 		}
-		if ocrText.Valid {
-			item.OcrText = ocrText.String
+		rows, err = tx.Query(ctx, query, args...)
+	} else {
+		rows, err = r.pool.Query(ctx, query, args...)
+	}
+	if err != nil {
+		return []models.SearchResult{}, err
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var score float64
+		// Scan through the same itemColumns/scanItem pair as GetAll so the
+		// destinations (including category) can't drift from the SELECT list
+		item, err := scanItem(rankedRow{row: rows, rank: &score})
+		if err != nil {
+			return []models.SearchResult{}, err
 		}
-		items = append(items, item)
+		results = append(results, models.SearchResult{Item: *item, SimilarityScore: score})
 	}
 
-	return items, nil
+	return results, rows.Err()
+}
+
+// mentionsArg is a condition that an item's title, content, summary, or OCR
+// text matches the ILIKE pattern in argument n
+func mentionsArg(n int) string {
+	return fmt.Sprintf(`(title ILIKE $%d OR content ILIKE $%d OR COALESCE(summary, '') ILIKE $%d OR COALESCE(ocr_text, '') ILIKE $%d)`, n, n, n, n)
+}
+
+// rankedRow scans a row selected with itemColumns plus one trailing rank column
+type rankedRow struct {
+	row  pgx.Row
+	rank *float64
+}
+
+func (r rankedRow) Scan(dest ...interface{}) error {
+	return r.row.Scan(append(dest, r.rank)...)
 }
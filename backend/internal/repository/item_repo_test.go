@@ -0,0 +1,813 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"synapse/internal/db"
+	"synapse/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// testItemRepo connects to the PostgreSQL database in TEST_DATABASE_URL and
+// creates the schema, skipping the test when it isn't set
+func testItemRepo(t *testing.T) *ItemRepository {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	t.Setenv("DATABASE_URL", url)
+	if err := db.InitPostgres(); err != nil {
+		t.Fatalf("InitPostgres: %v", err)
+	}
+	t.Cleanup(db.Pool.Close)
+	if err := db.CreateSchema(); err != nil {
+		t.Fatalf("CreateSchema: %v", err)
+	}
+	return NewItemRepository(db.Pool)
+}
+
+// uniqueWord returns a word no other test's items contain, so a test's
+// searches only find its own rows
+func uniqueWord() string {
+	return "w" + strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
+// createTestItem saves a text item owned by userID, after applying edit to it,
+// and removes it when the test ends
+func createTestItem(t *testing.T, repo *ItemRepository, userID uuid.UUID, title string, edit func(*models.Item)) *models.Item {
+	t.Helper()
+	item := &models.Item{
+		ID:        uuid.New(),
+		Title:     title,
+		Content:   title,
+		Type:      "text",
+		Tags:      []string{},
+		UserID:    userID,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if edit != nil {
+		edit(item)
+	}
+	ctx := context.Background()
+	if err := repo.Create(ctx, item); err != nil {
+		t.Fatalf("Create(%q): %v", title, err)
+	}
+	t.Cleanup(func() { repo.HardDelete(context.Background(), userID, item.ID) })
+	return item
+}
+
+func TestSearchItemsScansAllColumns(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	word := uniqueWord()
+	want := createTestItem(t, repo, userID, "Kubernetes in Action "+word, func(item *models.Item) {
+		item.Type = "book"
+		item.Category = "Books"
+		item.Tags = []string{"kubernetes", "devops"}
+		item.ImageURL = "https://example.com/cover.jpg"
+		item.Language = "en"
+	})
+
+	results, err := repo.SearchItems(ctx, userID, &models.QueryFilters{SearchTerms: word}, 10, 0)
+	if err != nil {
+		t.Fatalf("SearchItems: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("SearchItems returned %d results, want 1", len(results))
+	}
+
+	// A search result must carry the same fields as the item loaded by ID
+	stored, err := repo.GetByID(ctx, userID, want.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	got := results[0].Item
+	if !reflect.DeepEqual(got, *stored) {
+		t.Errorf("SearchItems item = %+v\nGetByID item = %+v", got, *stored)
+	}
+	if got.Category != "Books" || got.Language != "en" {
+		t.Errorf("SearchItems item lost category or language: %+v", got)
+	}
+	if results[0].SimilarityScore <= 0 || results[0].SimilarityScore >= 1 {
+		t.Errorf("SimilarityScore = %v, want in (0, 1)", results[0].SimilarityScore)
+	}
+}
+
+// resultIDs returns the IDs of results' items, in order
+func resultIDs(results []models.SearchResult) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, result := range results {
+		ids = append(ids, result.Item.ID)
+	}
+	return ids
+}
+
+// sameIDs reports whether got and want hold the same IDs, in any order
+func sameIDs(got, want []uuid.UUID) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	seen := make(map[uuid.UUID]int)
+	for _, id := range got {
+		seen[id]++
+	}
+	for _, id := range want {
+		seen[id]--
+	}
+	for _, n := range seen {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSearchItemsTypeAndTerms(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	word := uniqueWord()
+	mlVideo := createTestItem(t, repo, userID, "Machine learning crash course "+word, func(item *models.Item) { item.Type = "video" })
+	mlNote := createTestItem(t, repo, userID, "Machine learning reading notes "+word, nil)
+	cookingVideo := createTestItem(t, repo, userID, "Cooking fresh pasta "+word, func(item *models.Item) { item.Type = "video" })
+
+	tests := []struct {
+		name    string
+		filters models.QueryFilters
+		want    []uuid.UUID
+	}{
+		{"type and terms", models.QueryFilters{Type: "video", SearchTerms: "machine learning " + word}, []uuid.UUID{mlVideo.ID}},
+		{"type with the shared word", models.QueryFilters{Type: "video", SearchTerms: word}, []uuid.UUID{mlVideo.ID, cookingVideo.ID}},
+		{"terms only", models.QueryFilters{SearchTerms: "machine learning " + word}, []uuid.UUID{mlVideo.ID, mlNote.ID}},
+		{"type without matching terms", models.QueryFilters{Type: "text", SearchTerms: "pasta " + word}, []uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := repo.SearchItems(ctx, userID, &tt.filters, 10, 0)
+			if err != nil {
+				t.Fatalf("SearchItems: %v", err)
+			}
+			if got := resultIDs(results); !sameIDs(got, tt.want) {
+				t.Errorf("SearchItems(%+v) = %v, want %v", tt.filters, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchItemsCategory(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	inCategory := func(category string) func(*models.Item) {
+		return func(item *models.Item) { item.Category = category }
+	}
+	recipe := createTestItem(t, repo, userID, "Weeknight pasta", inCategory("Food & Recipes"))
+	// Saved before categories were a fixed set, in another case
+	legacy := createTestItem(t, repo, userID, "Pasta from scratch", inCategory("food & recipes"))
+	article := createTestItem(t, repo, userID, "The history of pasta", inCategory("Articles & News"))
+
+	tests := []struct {
+		name    string
+		filters models.QueryFilters
+		want    []uuid.UUID
+	}{
+		{"category", models.QueryFilters{Category: "Food & Recipes"}, []uuid.UUID{recipe.ID, legacy.ID}},
+		{"any case", models.QueryFilters{Category: "FOOD & RECIPES"}, []uuid.UUID{recipe.ID, legacy.ID}},
+		{"category and terms", models.QueryFilters{Category: "Food & Recipes", SearchTerms: "scratch"}, []uuid.UUID{legacy.ID}},
+		{"no category", models.QueryFilters{SearchTerms: "pasta"}, []uuid.UUID{recipe.ID, legacy.ID, article.ID}},
+		{"unknown category", models.QueryFilters{Category: "Travel"}, []uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := repo.SearchItems(ctx, userID, &tt.filters, 10, 0)
+			if err != nil {
+				t.Fatalf("SearchItems: %v", err)
+			}
+			if got := resultIDs(results); !sameIDs(got, tt.want) {
+				t.Errorf("SearchItems(%+v) = %v, want %v", tt.filters, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchivedItemsAreHidden(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	kept := createTestItem(t, repo, userID, "Sourdough starter", nil)
+	archived := createTestItem(t, repo, userID, "Sourdough loaf", nil)
+
+	if err := repo.Delete(ctx, userID, archived.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.Delete(ctx, userID, archived.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("second Delete error = %v, want pgx.ErrNoRows", err)
+	}
+
+	// listIDs returns the IDs GetAll lists
+	listIDs := func(includeArchived bool) []uuid.UUID {
+		t.Helper()
+		items, err := repo.GetAll(ctx, userID, includeArchived, "")
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		ids := []uuid.UUID{}
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+	// searchIDs returns the IDs a search for "sourdough" finds
+	searchIDs := func(includeArchived bool) []uuid.UUID {
+		t.Helper()
+		results, err := repo.SearchItems(ctx, userID, &models.QueryFilters{SearchTerms: "sourdough", IncludeArchived: includeArchived}, 10, 0)
+		if err != nil {
+			t.Fatalf("SearchItems: %v", err)
+		}
+		return resultIDs(results)
+	}
+
+	if got := listIDs(false); !sameIDs(got, []uuid.UUID{kept.ID}) {
+		t.Errorf("GetAll = %v, want only %v", got, kept.ID)
+	}
+	if got := listIDs(true); !sameIDs(got, []uuid.UUID{kept.ID, archived.ID}) {
+		t.Errorf("GetAll including archived = %v, want both", got)
+	}
+	if got := searchIDs(false); !sameIDs(got, []uuid.UUID{kept.ID}) {
+		t.Errorf("SearchItems = %v, want only %v", got, kept.ID)
+	}
+	if got := searchIDs(true); !sameIDs(got, []uuid.UUID{kept.ID, archived.ID}) {
+		t.Errorf("SearchItems including archived = %v, want both", got)
+	}
+	if got, err := repo.GetByID(ctx, userID, archived.ID); err != nil || got.DeletedAt == nil {
+		t.Errorf("GetByID of archived item = %+v, %v; want it with deleted_at set", got, err)
+	}
+
+	if err := repo.Restore(ctx, userID, archived.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got := listIDs(false); !sameIDs(got, []uuid.UUID{kept.ID, archived.ID}) {
+		t.Errorf("GetAll after Restore = %v, want both", got)
+	}
+	if err := repo.Restore(ctx, uuid.New(), archived.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("Restore by another user error = %v, want pgx.ErrNoRows", err)
+	}
+}
+
+func TestItemRepositoryIsolatesUsers(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	owner, other := uuid.New(), uuid.New()
+	item := createTestItem(t, repo, owner, "Private journal entry", func(item *models.Item) {
+		item.SourceURL = "https://example.com/journal"
+		item.NormalizedURL = "https://example.com/journal"
+	})
+	createTestItem(t, repo, other, "Shopping list", nil)
+
+	t.Run("get by ID", func(t *testing.T) {
+		if _, err := repo.GetByID(ctx, other, item.ID); !errors.Is(err, pgx.ErrNoRows) {
+			t.Errorf("GetByID as another user: err = %v, want pgx.ErrNoRows", err)
+		}
+		if _, err := repo.GetByID(ctx, owner, item.ID); err != nil {
+			t.Errorf("GetByID as owner: %v", err)
+		}
+	})
+
+	t.Run("get by IDs", func(t *testing.T) {
+		items, err := repo.GetByIDs(ctx, other, []uuid.UUID{item.ID})
+		if err != nil {
+			t.Fatalf("GetByIDs: %v", err)
+		}
+		if len(items) != 0 {
+			t.Errorf("GetByIDs as another user returned %d items, want 0", len(items))
+		}
+	})
+
+	t.Run("get by source URL", func(t *testing.T) {
+		if _, err := repo.GetBySourceURL(ctx, other, item.NormalizedURL); !errors.Is(err, pgx.ErrNoRows) {
+			t.Errorf("GetBySourceURL as another user: err = %v, want pgx.ErrNoRows", err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		items, err := repo.GetAll(ctx, other, true, models.SortNewest)
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		for _, got := range items {
+			if got.UserID != other {
+				t.Errorf("GetAll as %v returned %v's item %v", other, got.UserID, got.ID)
+			}
+		}
+		if len(items) != 1 {
+			t.Errorf("GetAll returned %d items, want 1", len(items))
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		results, err := repo.SearchItems(ctx, other, &models.QueryFilters{SearchTerms: "journal"}, 10, 0)
+		if err != nil {
+			t.Fatalf("SearchItems: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("SearchItems as another user = %v, want nothing", resultIDs(results))
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := repo.Delete(ctx, other, item.ID); !errors.Is(err, pgx.ErrNoRows) {
+			t.Errorf("Delete as another user: err = %v, want pgx.ErrNoRows", err)
+		}
+		stored, err := repo.GetByID(ctx, owner, item.ID)
+		if err != nil || stored.DeletedAt != nil {
+			t.Errorf("item after another user's Delete = %+v, %v; want it untouched", stored, err)
+		}
+	})
+}
+
+func TestSearchItemsPriceRange(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	withPrice := func(p float64) func(*models.Item) {
+		return func(item *models.Item) {
+			item.Type = "amazon"
+			item.Price = &p
+			item.Currency = "USD"
+		}
+	}
+	cheap := createTestItem(t, repo, userID, "Cheap headphones", withPrice(19.99))
+	pricey := createTestItem(t, repo, userID, "Studio headphones", withPrice(349))
+	// Items without a stored price are kept; the search service checks their content
+	unpriced := createTestItem(t, repo, userID, "Headphones review", nil)
+
+	bound := func(p float64) *float64 { return &p }
+	tests := []struct {
+		name    string
+		filters models.QueryFilters
+		want    []uuid.UUID
+	}{
+		{"no range", models.QueryFilters{}, []uuid.UUID{cheap.ID, pricey.ID, unpriced.ID}},
+		{"max", models.QueryFilters{PriceMax: bound(100)}, []uuid.UUID{cheap.ID, unpriced.ID}},
+		{"min", models.QueryFilters{PriceMin: bound(100)}, []uuid.UUID{pricey.ID, unpriced.ID}},
+		{"range", models.QueryFilters{PriceMin: bound(10), PriceMax: bound(20)}, []uuid.UUID{cheap.ID, unpriced.ID}},
+		{"inclusive", models.QueryFilters{PriceMin: bound(349), PriceMax: bound(349)}, []uuid.UUID{pricey.ID, unpriced.ID}},
+		{"with terms", models.QueryFilters{SearchTerms: "studio", PriceMin: bound(100)}, []uuid.UUID{pricey.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := repo.SearchItems(ctx, userID, &tt.filters, 10, 0)
+			if err != nil {
+				t.Fatalf("SearchItems: %v", err)
+			}
+			if got := resultIDs(results); !sameIDs(got, tt.want) {
+				t.Errorf("SearchItems(%+v) = %v, want %v", tt.filters, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchItemsExcludeTerms(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	k8s := createTestItem(t, repo, userID, "Kubernetes operators explained", nil)
+	helm := createTestItem(t, repo, userID, "Kubernetes packaging", func(item *models.Item) { item.Summary = "Deploying with Helm charts" })
+	literal := createTestItem(t, repo, userID, "Kubernetes 100% uptime", nil)
+
+	tests := []struct {
+		name    string
+		filters models.QueryFilters
+		want    []uuid.UUID
+	}{
+		{"no exclusions", models.QueryFilters{SearchTerms: "kubernetes"}, []uuid.UUID{k8s.ID, helm.ID, literal.ID}},
+		{"excluded in summary", models.QueryFilters{SearchTerms: "kubernetes", ExcludeTerms: []string{"helm"}}, []uuid.UUID{k8s.ID, literal.ID}},
+		{"several", models.QueryFilters{SearchTerms: "kubernetes", ExcludeTerms: []string{"helm", "operators"}}, []uuid.UUID{literal.ID}},
+		// LIKE wildcards in a term match literally
+		{"wildcards", models.QueryFilters{ExcludeTerms: []string{"100%"}}, []uuid.UUID{k8s.ID, helm.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := repo.SearchItems(ctx, userID, &tt.filters, 10, 0)
+			if err != nil {
+				t.Fatalf("SearchItems: %v", err)
+			}
+			if got := resultIDs(results); !sameIDs(got, tt.want) {
+				t.Errorf("SearchItems(%+v) = %v, want %v", tt.filters, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchItemsFuzzyTitles(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	if !db.TrigramAvailable(ctx) {
+		t.Skip("pg_trgm not available")
+	}
+	userID := uuid.New()
+	sourdough := createTestItem(t, repo, userID, "Sourdough starter guide", nil)
+	createTestItem(t, repo, userID, "Banana bread", nil)
+
+	tests := []struct {
+		name    string
+		filters models.QueryFilters
+		want    []uuid.UUID
+	}{
+		{"misspelled title word", models.QueryFilters{SearchTerms: "sourdogh", FuzzyThreshold: 0.5}, []uuid.UUID{sourdough.ID}},
+		{"fuzzy matching off", models.QueryFilters{SearchTerms: "sourdogh"}, nil},
+		{"threshold too strict", models.QueryFilters{SearchTerms: "sourdogh", FuzzyThreshold: 0.95}, nil},
+		{"correct spelling", models.QueryFilters{SearchTerms: "sourdough", FuzzyThreshold: 0.5}, []uuid.UUID{sourdough.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := repo.SearchItems(ctx, userID, &tt.filters, 10, 0)
+			if err != nil {
+				t.Fatalf("SearchItems: %v", err)
+			}
+			if got := resultIDs(results); !sameIDs(got, tt.want) {
+				t.Errorf("SearchItems(%+v) = %v, want %v", tt.filters, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchItemsPhrases(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	exact := createTestItem(t, repo, userID, "Machine learning for beginners", nil)
+	scattered := createTestItem(t, repo, userID, "Learning to fix a machine", nil)
+	inSummary := createTestItem(t, repo, userID, "Course notes", func(item *models.Item) {
+		item.Content = "Week one: machine basics. Week two: learning rates."
+		item.Summary = "An intro to Machine Learning"
+	})
+
+	tests := []struct {
+		name    string
+		filters models.QueryFilters
+		want    []uuid.UUID
+	}{
+		{"without quotes", models.QueryFilters{SearchTerms: "machine learning"}, []uuid.UUID{exact.ID, scattered.ID, inSummary.ID}},
+		{"with quotes", models.QueryFilters{SearchTerms: "machine learning", Phrases: []string{"machine learning"}}, []uuid.UUID{exact.ID, inSummary.ID}},
+		{"phrase and exclusion", models.QueryFilters{Phrases: []string{"machine learning"}, ExcludeTerms: []string{"beginners"}}, []uuid.UUID{inSummary.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := repo.SearchItems(ctx, userID, &tt.filters, 10, 0)
+			if err != nil {
+				t.Fatalf("SearchItems: %v", err)
+			}
+			if got := resultIDs(results); !sameIDs(got, tt.want) {
+				t.Errorf("SearchItems(%+v) = %v, want %v", tt.filters, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListOrderByRejectsUnknownOrders(t *testing.T) {
+	for _, sortBy := range []string{"", models.SortRelevance, models.SortNewest, models.SortOldest, models.SortTitle} {
+		if _, err := listOrderBy(sortBy); err != nil {
+			t.Errorf("listOrderBy(%q): %v", sortBy, err)
+		}
+	}
+	if _, err := listOrderBy("title; DROP TABLE items"); err == nil {
+		t.Error("listOrderBy accepted an unknown order")
+	}
+}
+
+func TestGetAllPaginatedSort(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	savedAgo := func(d time.Duration) func(*models.Item) {
+		return func(item *models.Item) { item.CreatedAt = now.Add(-d) }
+	}
+	oldest := createTestItem(t, repo, userID, "banana bread", savedAgo(2*time.Hour))
+	middle := createTestItem(t, repo, userID, "Apple pie", savedAgo(time.Hour))
+	newest := createTestItem(t, repo, userID, "cherry tart", nil)
+
+	tests := map[string][]uuid.UUID{
+		models.SortNewest: {newest.ID, middle.ID, oldest.ID},
+		models.SortOldest: {oldest.ID, middle.ID, newest.ID},
+		models.SortTitle:  {middle.ID, oldest.ID, newest.ID},
+	}
+	for sortBy, want := range tests {
+		items, total, err := repo.GetAllPaginated(ctx, userID, sortBy, 10, 0)
+		if err != nil {
+			t.Fatalf("GetAllPaginated(%q): %v", sortBy, err)
+		}
+		got := make([]uuid.UUID, len(items))
+		for i, item := range items {
+			got[i] = item.ID
+		}
+		if total != 3 || !reflect.DeepEqual(got, want) {
+			t.Errorf("GetAllPaginated(%q) = %v (total %d), want %v", sortBy, got, total, want)
+		}
+	}
+}
+
+func TestGetByTypeAndCategory(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	video := func(category string, ago time.Duration) func(*models.Item) {
+		return func(item *models.Item) {
+			item.Type = "video"
+			item.Category = category
+			item.CreatedAt = now.Add(-ago)
+		}
+	}
+	oldVideo := createTestItem(t, repo, userID, "Old talk", video("Technology", 2*time.Hour))
+	newVideo := createTestItem(t, repo, userID, "New talk", video("technology", time.Hour))
+	cooking := createTestItem(t, repo, userID, "Pasta", video("Food & Recipes", 0))
+	note := createTestItem(t, repo, userID, "Notes", func(item *models.Item) { item.Category = "Technology" })
+	createTestItem(t, repo, uuid.New(), "Their talk", video("Technology", 0))
+
+	tests := []struct {
+		name string
+		list func(limit, offset int) ([]models.Item, int, error)
+		// want is every match, newest first
+		want []uuid.UUID
+	}{
+		{"type", func(limit, offset int) ([]models.Item, int, error) {
+			return repo.GetByType(ctx, userID, "video", "", limit, offset)
+		}, []uuid.UUID{cooking.ID, newVideo.ID, oldVideo.ID}},
+		{"category in any case", func(limit, offset int) ([]models.Item, int, error) {
+			return repo.GetByCategory(ctx, userID, "TECHNOLOGY", "", limit, offset)
+		}, []uuid.UUID{note.ID, newVideo.ID, oldVideo.ID}},
+		{"unknown type", func(limit, offset int) ([]models.Item, int, error) {
+			return repo.GetByType(ctx, userID, "book", "", limit, offset)
+		}, []uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, total, err := tt.list(10, 0)
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			got := make([]uuid.UUID, len(items))
+			for i, item := range items {
+				got[i] = item.ID
+			}
+			if total != len(tt.want) || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v (total %d), want %v", got, total, tt.want)
+			}
+
+			// A later page holds the rest, with the same total
+			if len(tt.want) < 2 {
+				return
+			}
+			items, total, err = tt.list(1, 1)
+			if err != nil {
+				t.Fatalf("second page: %v", err)
+			}
+			if len(items) != 1 || items[0].ID != tt.want[1] || total != len(tt.want) {
+				t.Errorf("second page = %d items (total %d), want just %v", len(items), total, tt.want[1])
+			}
+		})
+	}
+}
+
+func TestGetByTag(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	tagged := func(tags ...string) func(*models.Item) {
+		return func(item *models.Item) { item.Tags = tags }
+	}
+	lower := createTestItem(t, repo, userID, "Effective Go", tagged("golang", "style"))
+	// Saved before tags were lowercased
+	mixed := createTestItem(t, repo, userID, "Go proverbs", tagged("GoLang"))
+	createTestItem(t, repo, userID, "Rust book", tagged("rust"))
+	createTestItem(t, repo, userID, "Go-kart track", tagged("golang-adjacent"))
+
+	tests := []struct {
+		tag  string
+		want []uuid.UUID
+	}{
+		{"golang", []uuid.UUID{lower.ID, mixed.ID}},
+		{"GOLANG", []uuid.UUID{lower.ID, mixed.ID}},
+		{"style", []uuid.UUID{lower.ID}},
+		{"go", []uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		items, total, err := repo.GetByTag(ctx, userID, tt.tag, "", 10, 0)
+		if err != nil {
+			t.Fatalf("GetByTag(%q): %v", tt.tag, err)
+		}
+		got := make([]uuid.UUID, len(items))
+		for i, item := range items {
+			got[i] = item.ID
+		}
+		if total != len(tt.want) || !sameIDs(got, tt.want) {
+			t.Errorf("GetByTag(%q) = %v (total %d), want %v", tt.tag, got, total, tt.want)
+		}
+	}
+}
+
+func TestGetByDateBucket(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	savedAt := func(month time.Month, day, hour int) func(*models.Item) {
+		return func(item *models.Item) { item.CreatedAt = time.Date(2024, month, day, hour, 0, 0, 0, time.UTC) }
+	}
+	// Monday and Sunday of ISO week 10, then the Monday after
+	mar4 := createTestItem(t, repo, userID, "Monday", savedAt(time.March, 4, 9))
+	mar10 := createTestItem(t, repo, userID, "Sunday", savedAt(time.March, 10, 23))
+	mar11 := createTestItem(t, repo, userID, "Next Monday", savedAt(time.March, 11, 1))
+	apr1 := createTestItem(t, repo, userID, "April", savedAt(time.April, 1, 12))
+
+	type bucket struct {
+		label string
+		items []uuid.UUID
+	}
+	// 05:00 on March 11 in UTC+5 is midnight UTC, so it excludes the Sunday item
+	fromPlus5 := time.Date(2024, time.March, 11, 5, 0, 0, 0, time.FixedZone("UTC+5", 5*60*60))
+	toMar10 := time.Date(2024, time.March, 10, 23, 59, 59, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		bucket   string
+		from, to *time.Time
+		limit    int
+		want     []bucket
+	}{
+		{"by day", models.BucketDay, nil, nil, 10, []bucket{
+			{"2024-04-01", []uuid.UUID{apr1.ID}}, {"2024-03-11", []uuid.UUID{mar11.ID}},
+			{"2024-03-10", []uuid.UUID{mar10.ID}}, {"2024-03-04", []uuid.UUID{mar4.ID}},
+		}},
+		{"by week", models.BucketWeek, nil, nil, 10, []bucket{
+			{"2024-W14", []uuid.UUID{apr1.ID}}, {"2024-W11", []uuid.UUID{mar11.ID}},
+			{"2024-W10", []uuid.UUID{mar10.ID, mar4.ID}},
+		}},
+		{"by month", models.BucketMonth, nil, nil, 10, []bucket{
+			{"2024-04", []uuid.UUID{apr1.ID}}, {"2024-03", []uuid.UUID{mar11.ID, mar10.ID, mar4.ID}},
+		}},
+		{"newest first within the limit", models.BucketMonth, nil, nil, 2, []bucket{
+			{"2024-04", []uuid.UUID{apr1.ID}}, {"2024-03", []uuid.UUID{mar11.ID}},
+		}},
+		{"from with an offset", models.BucketMonth, &fromPlus5, nil, 10, []bucket{
+			{"2024-04", []uuid.UUID{apr1.ID}}, {"2024-03", []uuid.UUID{mar11.ID}},
+		}},
+		{"to", models.BucketMonth, nil, &toMar10, 10, []bucket{
+			{"2024-03", []uuid.UUID{mar10.ID, mar4.ID}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets, err := repo.GetByDateBucket(ctx, userID, tt.bucket, tt.from, tt.to, tt.limit)
+			if err != nil {
+				t.Fatalf("GetByDateBucket: %v", err)
+			}
+			got := []bucket{}
+			for _, b := range buckets {
+				ids := []uuid.UUID{}
+				for _, item := range b.Items {
+					ids = append(ids, item.ID)
+				}
+				got = append(got, bucket{b.Label, ids})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetByDateBucket = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := repo.GetByDateBucket(ctx, userID, "year", nil, nil, 10); err == nil {
+		t.Error("GetByDateBucket with an unknown bucket returned no error")
+	}
+}
+
+func TestGetOnThisDayLimit(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	savedOn := func(year int) func(*models.Item) {
+		return func(item *models.Item) { item.CreatedAt = time.Date(year, time.March, 14, 12, 0, 0, 0, time.UTC) }
+	}
+	createTestItem(t, repo, userID, "Pi day 2021", savedOn(2021))
+	createTestItem(t, repo, userID, "Pi day 2022", savedOn(2022))
+	newest := createTestItem(t, repo, userID, "Pi day 2023", savedOn(2023))
+	// The current year and other days aren't memories
+	createTestItem(t, repo, userID, "Pi day 2025", savedOn(2025))
+	createTestItem(t, repo, userID, "Day after", func(item *models.Item) {
+		item.CreatedAt = time.Date(2022, time.March, 15, 0, 30, 0, 0, time.UTC)
+	})
+
+	buckets, err := repo.GetOnThisDay(ctx, userID, time.March, 14, 2025, 10)
+	if err != nil {
+		t.Fatalf("GetOnThisDay: %v", err)
+	}
+	if len(buckets) != 3 || buckets[0].Label != "2023" {
+		t.Errorf("GetOnThisDay = %+v, want buckets for 2023, 2022 and 2021", buckets)
+	}
+
+	buckets, err = repo.GetOnThisDay(ctx, userID, time.March, 14, 2025, 1)
+	if err != nil {
+		t.Fatalf("GetOnThisDay with limit 1: %v", err)
+	}
+	if len(buckets) != 1 || len(buckets[0].Items) != 1 || buckets[0].Items[0].ID != newest.ID {
+		t.Errorf("GetOnThisDay with limit 1 = %+v, want just %q", buckets, newest.Title)
+	}
+}
+
+func TestAutocompleteTags(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	withTags := func(tags ...string) func(*models.Item) {
+		return func(item *models.Item) { item.Tags = tags }
+	}
+	createTestItem(t, repo, userID, "One", withTags("kubernetes", "go"))
+	createTestItem(t, repo, userID, "Two", withTags("kubernetes", "kubectl"))
+	createTestItem(t, repo, userID, "Three", withTags("mykube", "100%_done"))
+	archived := createTestItem(t, repo, userID, "Four", withTags("kubeflow"))
+	if err := repo.Delete(ctx, userID, archived.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	// Another user's tags are never suggested
+	createTestItem(t, repo, uuid.New(), "Theirs", withTags("kubelet"))
+
+	tests := []struct {
+		prefix string
+		want   []models.Suggestion
+	}{
+		// Most used first, then alphabetical; only tags starting with the prefix
+		{"kub", []models.Suggestion{
+			{Type: models.SuggestionTag, Text: "kubernetes", Count: 2},
+			{Type: models.SuggestionTag, Text: "kubectl", Count: 1},
+		}},
+		{"KUBE", []models.Suggestion{{Type: models.SuggestionTag, Text: "kubernetes", Count: 2}}},
+		// LIKE wildcards match literally
+		{"100%", []models.Suggestion{{Type: models.SuggestionTag, Text: "100%_done", Count: 1}}},
+		{"10_", []models.Suggestion{}},
+		{"xyz", []models.Suggestion{}},
+	}
+
+	for _, tt := range tests {
+		got, err := repo.AutocompleteTags(ctx, userID, tt.prefix, 8)
+		if err != nil {
+			t.Fatalf("AutocompleteTags(%q): %v", tt.prefix, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("AutocompleteTags(%q) = %+v, want %+v", tt.prefix, got, tt.want)
+		}
+	}
+
+	if got, err := repo.AutocompleteTags(ctx, userID, "kub", 1); err != nil || len(got) != 1 {
+		t.Errorf("AutocompleteTags with limit 1 = %+v, %v; want one suggestion", got, err)
+	}
+}
+
+func TestAutocompleteTitles(t *testing.T) {
+	repo := testItemRepo(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	long := createTestItem(t, repo, userID, "Kubernetes in Action, Second Edition", nil)
+	short := createTestItem(t, repo, userID, "Kubernetes Patterns", nil)
+	createTestItem(t, repo, userID, "Learning Kubernetes", nil)
+	archived := createTestItem(t, repo, userID, "Kubernetes Up and Running", nil)
+	if err := repo.Delete(ctx, userID, archived.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	createTestItem(t, repo, uuid.New(), "Kubernetes Best Practices", nil)
+
+	got, err := repo.AutocompleteTitles(ctx, userID, "kubern", 8)
+	if err != nil {
+		t.Fatalf("AutocompleteTitles: %v", err)
+	}
+	// Only titles starting with the prefix, shortest first
+	want := []models.Suggestion{
+		{Type: models.SuggestionTitle, Text: short.Title, ItemID: &short.ID},
+		{Type: models.SuggestionTitle, Text: long.Title, ItemID: &long.ID},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AutocompleteTitles = %+v, want %+v", got, want)
+	}
+
+	if got, err := repo.AutocompleteTitles(ctx, userID, "kubern", 1); err != nil || len(got) != 1 || got[0].Text != short.Title {
+		t.Errorf("AutocompleteTitles with limit 1 = %+v, %v; want just %q", got, err, short.Title)
+	}
+}
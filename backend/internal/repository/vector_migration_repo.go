@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// VectorMigrationRepository records which one-time ChromaDB migrations have
+// finished, since ChromaDB has no schema of its own to version
+type VectorMigrationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewVectorMigrationRepository(pool *pgxpool.Pool) *VectorMigrationRepository {
+	return &VectorMigrationRepository{pool: pool}
+}
+
+// Done reports whether the migration called name has finished
+func (r *VectorMigrationRepository) Done(ctx context.Context, name string) (bool, error) {
+	var done bool
+	err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM vector_migrations WHERE name = $1)`, name).Scan(&done)
+	return done, err
+}
+
+// MarkDone records that the migration called name has finished
+func (r *VectorMigrationRepository) MarkDone(ctx context.Context, name string) error {
+	query := `INSERT INTO vector_migrations (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`
+	_, err := r.pool.Exec(ctx, query, name)
+	return err
+}
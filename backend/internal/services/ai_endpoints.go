@@ -0,0 +1,81 @@
+package services
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// openAIConfig says where OpenAI requests go: api.openai.com, an
+// OpenAI-compatible gateway such as LiteLLM, or an Azure OpenAI resource
+type openAIConfig struct {
+	// baseURL is OPENAI_BASE_URL, e.g. https://api.openai.com/v1; for Azure,
+	// the resource endpoint, e.g. https://my-resource.openai.azure.com
+	baseURL string
+	// azure is set by OPENAI_API_TYPE=azure. Azure addresses models by
+	// deployment, takes an api-version parameter, and authenticates with an
+	// api-key header instead of a bearer token.
+	azure           bool
+	apiVersion      string
+	chatDeployment  string
+	embedDeployment string
+}
+
+// geminiBaseURL is GEMINI_BASE_URL, defaulting to Google's public endpoint
+func geminiBaseURL() string {
+	if base := strings.TrimRight(os.Getenv("GEMINI_BASE_URL"), "/"); base != "" {
+		return base
+	}
+	return "https://generativelanguage.googleapis.com"
+}
+
+func loadOpenAIConfig() openAIConfig {
+	config := openAIConfig{
+		baseURL:         strings.TrimRight(os.Getenv("OPENAI_BASE_URL"), "/"),
+		azure:           strings.EqualFold(os.Getenv("OPENAI_API_TYPE"), "azure"),
+		apiVersion:      os.Getenv("OPENAI_API_VERSION"),
+		chatDeployment:  os.Getenv("AZURE_OPENAI_CHAT_DEPLOYMENT"),
+		embedDeployment: os.Getenv("AZURE_OPENAI_EMBEDDING_DEPLOYMENT"),
+	}
+	if config.baseURL == "" {
+		config.baseURL = "https://api.openai.com/v1"
+	}
+	if config.apiVersion == "" {
+		config.apiVersion = "2024-06-01"
+	}
+	// Deployments are commonly named after the model they serve
+	if config.chatDeployment == "" {
+		config.chatDeployment = "gpt-4o-mini"
+	}
+	if config.embedDeployment == "" {
+		config.embedDeployment = "text-embedding-3-small"
+	}
+	return config
+}
+
+// url is the address of an OpenAI endpoint, "chat/completions" or "embeddings"
+func (c openAIConfig) url(endpoint string) string {
+	if !c.azure {
+		return c.baseURL + "/" + endpoint
+	}
+	deployment := c.chatDeployment
+	if endpoint == "embeddings" {
+		deployment = c.embedDeployment
+	}
+	return c.baseURL + "/openai/deployments/" + url.PathEscape(deployment) + "/" + endpoint +
+		"?api-version=" + url.QueryEscape(c.apiVersion)
+}
+
+// auth returns what authenticates a request with key: Azure's api-key header, or a bearer token
+func (c openAIConfig) auth(key string) func(*http.Request) {
+	if c.azure {
+		return func(req *http.Request) { req.Header.Set("api-key", key) }
+	}
+	return bearerAuth(key)
+}
+
+// bearerAuth authenticates a request with an Authorization: Bearer token
+func bearerAuth(key string) func(*http.Request) {
+	return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+key) }
+}
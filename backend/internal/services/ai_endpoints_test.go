@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLoadOpenAIConfig(t *testing.T) {
+	t.Setenv("OPENAI_BASE_URL", "")
+	t.Setenv("OPENAI_API_TYPE", "")
+	t.Setenv("OPENAI_API_VERSION", "")
+	t.Setenv("AZURE_OPENAI_CHAT_DEPLOYMENT", "")
+	t.Setenv("AZURE_OPENAI_EMBEDDING_DEPLOYMENT", "")
+	want := openAIConfig{
+		baseURL:         "https://api.openai.com/v1",
+		apiVersion:      "2024-06-01",
+		chatDeployment:  "gpt-4o-mini",
+		embedDeployment: "text-embedding-3-small",
+	}
+	if got := loadOpenAIConfig(); got != want {
+		t.Errorf("defaults = %+v, want %+v", got, want)
+	}
+
+	t.Setenv("OPENAI_BASE_URL", "https://my-resource.openai.azure.com/")
+	t.Setenv("OPENAI_API_TYPE", "Azure")
+	t.Setenv("OPENAI_API_VERSION", "2024-10-21")
+	t.Setenv("AZURE_OPENAI_CHAT_DEPLOYMENT", "chat")
+	t.Setenv("AZURE_OPENAI_EMBEDDING_DEPLOYMENT", "embed")
+	want = openAIConfig{
+		baseURL:         "https://my-resource.openai.azure.com",
+		azure:           true,
+		apiVersion:      "2024-10-21",
+		chatDeployment:  "chat",
+		embedDeployment: "embed",
+	}
+	if got := loadOpenAIConfig(); got != want {
+		t.Errorf("Azure = %+v, want %+v", got, want)
+	}
+}
+
+func TestOpenAIConfigURL(t *testing.T) {
+	openAI := openAIConfig{baseURL: "http://litellm:4000/v1"}
+	azure := openAIConfig{
+		baseURL:         "https://my-resource.openai.azure.com",
+		azure:           true,
+		apiVersion:      "2024-06-01",
+		chatDeployment:  "gpt-4o-mini",
+		embedDeployment: "embeddings prod",
+	}
+
+	tests := []struct {
+		name     string
+		config   openAIConfig
+		endpoint string
+		want     string
+	}{
+		{"OpenAI chat", openAI, "chat/completions", "http://litellm:4000/v1/chat/completions"},
+		{"OpenAI embeddings", openAI, "embeddings", "http://litellm:4000/v1/embeddings"},
+		{"Azure chat", azure, "chat/completions",
+			"https://my-resource.openai.azure.com/openai/deployments/gpt-4o-mini/chat/completions?api-version=2024-06-01"},
+		{"Azure embeddings use their own escaped deployment", azure, "embeddings",
+			"https://my-resource.openai.azure.com/openai/deployments/embeddings%20prod/embeddings?api-version=2024-06-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.url(tt.endpoint); got != tt.want {
+				t.Errorf("url(%q) = %q, want %q", tt.endpoint, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIConfigAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		config openAIConfig
+		want   http.Header
+	}{
+		{"OpenAI sends a bearer token", openAIConfig{}, http.Header{"Authorization": {"Bearer sk-test"}}},
+		{"Azure sends an api-key header", openAIConfig{azure: true}, http.Header{"Api-Key": {"sk-test"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "http://example.com", nil)
+			tt.config.auth("sk-test")(req)
+			if !reflect.DeepEqual(req.Header, tt.want) {
+				t.Errorf("headers = %v, want %v", req.Header, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIEmbeddingOnAzure(t *testing.T) {
+	var gotPath, gotVersion, gotKey, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotVersion = r.URL.Path, r.URL.Query().Get("api-version")
+		gotKey, gotAuth = r.Header.Get("api-key"), r.Header.Get("Authorization")
+		w.Write([]byte(`{"data": [{"embedding": [0.5, 0.5], "index": 0}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AI_PROVIDER", "openai")
+	t.Setenv("OPENAI_API_KEY", "azure-key")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+	t.Setenv("OPENAI_API_TYPE", "azure")
+	t.Setenv("OPENAI_API_VERSION", "")
+	t.Setenv("AZURE_OPENAI_EMBEDDING_DEPLOYMENT", "embed")
+	s := NewAIService(nil, nil)
+
+	if _, err := s.GenerateEmbedding(context.Background(), "text"); err != nil {
+		t.Fatalf("GenerateEmbedding: %v", err)
+	}
+	if gotPath != "/openai/deployments/embed/embeddings" || gotVersion != "2024-06-01" {
+		t.Errorf("request went to %s?api-version=%s", gotPath, gotVersion)
+	}
+	if gotKey != "azure-key" || gotAuth != "" {
+		t.Errorf("api-key = %q, Authorization = %q; want only the api-key header", gotKey, gotAuth)
+	}
+}
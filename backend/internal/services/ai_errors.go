@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrAIAuth means the provider rejected our credentials; retrying won't help
+	ErrAIAuth = errors.New("AI provider authentication failed")
+	// ErrAIRateLimited means the provider throttled the request; retry later
+	ErrAIRateLimited = errors.New("AI provider rate limit exceeded")
+	// ErrAIQuotaExceeded means the account's usage quota is exhausted
+	ErrAIQuotaExceeded = errors.New("AI provider quota exceeded")
+)
+
+// AIError describes a failed provider call. It unwraps to one of the ErrAI*
+// sentinels when the failure could be classified.
+type AIError struct {
+	Provider   string
+	Model      string
+	StatusCode int
+	Code       string
+	Message    string
+	Kind       error
+}
+
+func (e *AIError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Provider)
+	b.WriteString(" API error (")
+	if e.Model != "" {
+		b.WriteString("model: ")
+		b.WriteString(e.Model)
+		b.WriteString(", ")
+	}
+	b.WriteString(fmt.Sprintf("status: %d)", e.StatusCode))
+	b.WriteString(": ")
+	b.WriteString(e.Message)
+	if e.Code != "" {
+		b.WriteString(" (code: ")
+		b.WriteString(e.Code)
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+func (e *AIError) Unwrap() error {
+	return e.Kind
+}
+
+// newAIError builds an AIError, classifying it from the HTTP status and provider error code
+func newAIError(provider, model string, statusCode int, code, message string) *AIError {
+	return &AIError{
+		Provider:   provider,
+		Model:      model,
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    message,
+		Kind:       classifyAIError(statusCode, code, message),
+	}
+}
+
+func classifyAIError(statusCode int, code, message string) error {
+	lowerCode := strings.ToLower(code)
+	lowerMessage := strings.ToLower(message)
+
+	switch {
+	case strings.Contains(lowerCode, "quota") || strings.Contains(lowerMessage, "quota"):
+		return ErrAIQuotaExceeded
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrAIAuth
+	case strings.Contains(lowerCode, "invalid_api_key") || strings.Contains(lowerMessage, "api key not valid"):
+		// Gemini reports bad keys as 400 INVALID_ARGUMENT
+		return ErrAIAuth
+	case statusCode == http.StatusTooManyRequests || strings.Contains(lowerCode, "rate_limit"):
+		return ErrAIRateLimited
+	}
+	return nil
+}
+
+// isTransientAIError reports whether a failed AI call is worth retrying later
+// (throttling, exhausted quota, provider outages, or timeouts) rather than a
+// permanent misconfiguration like a bad API key.
+func isTransientAIError(err error) bool {
+	if err == nil || errors.Is(err, ErrAIAuth) {
+		return false
+	}
+	if errors.Is(err, ErrAIRateLimited) || errors.Is(err, ErrAIQuotaExceeded) {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var aiErr *AIError
+	if errors.As(err, &aiErr) && aiErr.StatusCode >= 500 {
+		return true
+	}
+	return false
+}
+
+// isRateLimitError reports whether err came from throttling, quota exhaustion, or an overloaded provider
+func isRateLimitError(err error) bool {
+	if errors.Is(err, ErrAIRateLimited) || errors.Is(err, ErrAIQuotaExceeded) {
+		return true
+	}
+	var aiErr *AIError
+	return errors.As(err, &aiErr) && aiErr.StatusCode == http.StatusServiceUnavailable
+}
+
+// parseOpenAIError decodes an OpenAI-style `{"error": {...}}` body (also used by LiteLLM)
+func parseOpenAIError(provider, model string, statusCode int, body []byte) *AIError {
+	var apiError struct {
+		Error struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+			Code    string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error.Message != "" {
+		code := apiError.Error.Code
+		if code == "" {
+			code = apiError.Error.Type
+		}
+		return newAIError(provider, model, statusCode, code, apiError.Error.Message)
+	}
+	return newAIError(provider, model, statusCode, "", string(body))
+}
+
+// parseGeminiError decodes a Gemini `{"error": {"code", "message", "status"}}` body
+func parseGeminiError(model string, statusCode int, body []byte) *AIError {
+	var apiError struct {
+		Error struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error.Message != "" {
+		if apiError.Error.Code != 0 {
+			statusCode = apiError.Error.Code
+		}
+		return newAIError("Gemini", model, statusCode, apiError.Error.Status, apiError.Error.Message)
+	}
+	return newAIError("Gemini", model, statusCode, "", string(body))
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// embeddingDimensions are the vector sizes of each provider's embedding model.
+// Ollama's depends on OLLAMA_EMBED_MODEL, so it's unknown and never falls back.
+var embeddingDimensions = map[string]int{
+	"claude": 3072, // gemini-embedding-001 via LiteLLM
+	"gemini": 768,  // text-embedding-004
+	"openai": 1536, // text-embedding-3-small
+}
+
+// resolveProvider maps a configured provider name to the one actually called:
+// Claude needs its key, and anything unrecognised goes to OpenAI
+func (s *AIService) resolveProvider(name string) string {
+	switch name {
+	case "claude":
+		if s.claudeKey != "" {
+			return "claude"
+		}
+		return "openai"
+	case "gemini", "ollama":
+		return name
+	default:
+		return "openai"
+	}
+}
+
+// hasCredentials reports whether provider can be called at all
+func (s *AIService) hasCredentials(provider string) bool {
+	switch provider {
+	case "claude":
+		return s.claudeKey != ""
+	case "gemini":
+		return s.geminiKey != ""
+	case "ollama":
+		return true
+	default:
+		return s.openaiKey != ""
+	}
+}
+
+// configureFallback picks the provider tried when the primary one is throttled or
+// down. AI_FALLBACK_PROVIDER names it; when unset, Gemini falls back to OpenAI if
+// OPENAI_API_KEY is set, as summaries always have. Returns "" for no fallback.
+func (s *AIService) configureFallback() string {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("AI_FALLBACK_PROVIDER")))
+	if name == "" {
+		if s.provider == "gemini" && s.openaiKey != "" {
+			return "openai"
+		}
+		return ""
+	}
+
+	fallback := s.resolveProvider(name)
+	if fallback == s.resolveProvider(s.provider) {
+		return ""
+	}
+	if !s.hasCredentials(fallback) {
+		s.logger.Warn("AI_FALLBACK_PROVIDER has no API key configured, fallback disabled", "provider", name)
+		return ""
+	}
+	return fallback
+}
+
+// shouldFallBack reports whether err is worth retrying on another provider:
+// throttling, quota exhaustion, or a 5xx from the provider
+func shouldFallBack(err error) bool {
+	if isRateLimitError(err) {
+		return true
+	}
+	var aiErr *AIError
+	return errors.As(err, &aiErr) && aiErr.StatusCode >= http.StatusInternalServerError
+}
+
+// withFallback runs call against the primary provider and, if that fails in a
+// way another provider might not, once more against the fallback provider.
+// Each attempt is reported to metrics under operation.
+func withFallback[T any](ctx context.Context, s *AIService, operation string, allowFallback bool, call func(provider string) (T, error)) (T, error) {
+	attempt := func(provider string) (T, error) {
+		start := time.Now()
+		result, err := call(provider)
+		s.metrics.ObserveAICall(provider, operation, time.Since(start), err)
+		return result, err
+	}
+
+	primary := s.resolveProvider(s.provider)
+	result, err := attempt(primary)
+	if err == nil || !allowFallback || s.fallbackProvider == "" || !shouldFallBack(err) {
+		return result, err
+	}
+
+	s.logger.WarnContext(ctx, "AI call failed, falling back", "operation", operation, "provider", primary, "fallback_provider", s.fallbackProvider, "error", err)
+	return attempt(s.fallbackProvider)
+}
+
+// canFallBackForEmbeddings reports whether the fallback provider's vectors are
+// the same size as the primary's. Mixing sizes in one collection breaks search,
+// so embeddings only fall back when they match.
+func (s *AIService) canFallBackForEmbeddings() bool {
+	if s.fallbackProvider == "" {
+		return false
+	}
+	primary := embeddingDimensions[s.resolveProvider(s.provider)]
+	return primary != 0 && primary == embeddingDimensions[s.fallbackProvider]
+}
+
+// generate sends prompt to the text model of the primary provider, falling back if
+// configured; operation names the call in logs and metrics
+func (s *AIService) generate(ctx context.Context, operation, prompt string, maxTokens int) (string, error) {
+	return withFallback(ctx, s, operation, true, func(provider string) (string, error) {
+		return s.callProvider(ctx, provider, prompt, maxTokens, false)
+	})
+}
+
+// generatePro is generate for summaries, which prefer Gemini's Pro models on Gemini
+func (s *AIService) generatePro(ctx context.Context, operation, prompt string, maxTokens int) (string, error) {
+	return withFallback(ctx, s, operation, true, func(provider string) (string, error) {
+		return s.callProvider(ctx, provider, prompt, maxTokens, true)
+	})
+}
+
+// generateVision is generate with images attached, for the provider's vision model
+func (s *AIService) generateVision(ctx context.Context, operation, prompt string, maxTokens int, images ...imageData) (string, error) {
+	return withFallback(ctx, s, operation, true, func(provider string) (string, error) {
+		return s.callProvider(ctx, provider, prompt, maxTokens, false, images...)
+	})
+}
+
+func (s *AIService) callProvider(ctx context.Context, provider, prompt string, maxTokens int, pro bool, images ...imageData) (string, error) {
+	switch provider {
+	case "claude":
+		return s.callClaude(ctx, prompt, maxTokens, images...)
+	case "gemini":
+		if pro {
+			return s.callGeminiPro(ctx, prompt, maxTokens, images...)
+		}
+		return s.callGemini(ctx, prompt, maxTokens, images...)
+	case "ollama":
+		return s.callOllama(ctx, prompt, maxTokens, images...)
+	default:
+		return s.callChatGPT(ctx, prompt, maxTokens, images...)
+	}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeProvider serves an AI provider's API, failing with status while it's
+// nonzero and otherwise answering with body. Calls counts requests.
+type fakeProvider struct {
+	*httptest.Server
+	status atomic.Int32
+	calls  atomic.Int32
+}
+
+func newFakeProvider(t *testing.T, body string) *fakeProvider {
+	p := &fakeProvider{}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.calls.Add(1)
+		if status := int(p.status.Load()); status != 0 {
+			http.Error(w, `{"error": {"message": "unavailable"}}`, status)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestGenerateFallsBack(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		want         string
+		wantFallback bool
+	}{
+		{"server error", http.StatusServiceUnavailable, "from openai", true},
+		{"rate limited", http.StatusTooManyRequests, "from openai", true},
+		// The fallback would reject the same request
+		{"bad request", http.StatusBadRequest, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := newFakeProvider(t, `{"response": "from ollama"}`)
+			primary.status.Store(int32(tt.status))
+			fallback := newFakeProvider(t, `{"choices": [{"message": {"content": "from openai"}}]}`)
+
+			t.Setenv("AI_PROVIDER", "ollama")
+			t.Setenv("OLLAMA_HOST", primary.URL)
+			t.Setenv("AI_FALLBACK_PROVIDER", "openai")
+			t.Setenv("OPENAI_API_KEY", "test-key")
+			t.Setenv("OPENAI_BASE_URL", fallback.URL)
+			s := NewAIService(nil, nil)
+
+			got, err := s.generate(context.Background(), "summary", "Summarize this.", 100)
+			if got != tt.want || (err != nil) == tt.wantFallback {
+				t.Errorf("generate = %q, %v; want %q", got, err, tt.want)
+			}
+			if called := fallback.calls.Load() > 0; called != tt.wantFallback {
+				t.Errorf("fallback called: %v, want %v", called, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestEmbeddingFallbackIsCachedUnderItsOwnModel(t *testing.T) {
+	// Embeddings only fall back between models of the same size
+	saved := embeddingDimensions["openai"]
+	embeddingDimensions["openai"] = embeddingDimensions["claude"]
+	t.Cleanup(func() { embeddingDimensions["openai"] = saved })
+
+	primary := newFakeProvider(t, `{"data": [{"embedding": [1, 0], "index": 0}]}`)
+	fallback := newFakeProvider(t, `{"data": [{"embedding": [0, 1], "index": 0}]}`)
+	t.Setenv("AI_PROVIDER", "claude")
+	t.Setenv("ANTHROPIC_AUTH_TOKEN", "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", primary.URL)
+	t.Setenv("AI_FALLBACK_PROVIDER", "openai")
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("OPENAI_BASE_URL", fallback.URL)
+	s := NewAIService(nil, nil)
+	ctx := context.Background()
+
+	// embed returns text's embedding and fails the test if there isn't one
+	embed := func(text string) []float32 {
+		t.Helper()
+		embedding, err := s.GenerateEmbedding(ctx, text)
+		if err != nil {
+			t.Fatalf("GenerateEmbedding: %v", err)
+		}
+		return embedding
+	}
+
+	primary.status.Store(http.StatusServiceUnavailable)
+	if got, want := embed("text"), []float32{0, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("embedding while the primary is down = %v, want the fallback's %v", got, want)
+	}
+
+	// Once the primary is back its own vector is used, not the cached fallback one
+	primary.status.Store(0)
+	if got, want := embed("text"), []float32{1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("embedding after recovery = %v, want the primary's %v", got, want)
+	}
+	if got, want := embed("text"), []float32{1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cached embedding = %v, want the primary's %v", got, want)
+	}
+	// Batches share the cache
+	if got, err := s.GenerateEmbeddings(ctx, []string{"text"}); err != nil || !reflect.DeepEqual(got, [][]float32{{1, 0}}) {
+		t.Errorf("GenerateEmbeddings = %v, %v; want the cached primary vector", got, err)
+	}
+
+	if n := primary.calls.Load(); n != 2 {
+		t.Errorf("primary called %d times, want 2", n)
+	}
+	if n := fallback.calls.Load(); n != 1 {
+		t.Errorf("fallback called %d times, want 1", n)
+	}
+}
+
+func TestQueryEmbeddingFallbackIsCachedUnderItsOwnModel(t *testing.T) {
+	saved := embeddingDimensions["openai"]
+	embeddingDimensions["openai"] = embeddingDimensions["claude"]
+	t.Cleanup(func() { embeddingDimensions["openai"] = saved })
+
+	primary := newFakeProvider(t, `{"data": [{"embedding": [1, 0], "index": 0}]}`)
+	fallback := newFakeProvider(t, `{"data": [{"embedding": [0, 1], "index": 0}]}`)
+	t.Setenv("AI_PROVIDER", "claude")
+	t.Setenv("ANTHROPIC_AUTH_TOKEN", "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", primary.URL)
+	t.Setenv("AI_FALLBACK_PROVIDER", "openai")
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("OPENAI_BASE_URL", fallback.URL)
+	s := NewAIService(nil, nil)
+	ctx := context.Background()
+
+	embed := func(query string) []float32 {
+		t.Helper()
+		embedding, err := s.GenerateQueryEmbedding(ctx, query)
+		if err != nil {
+			t.Fatalf("GenerateQueryEmbedding: %v", err)
+		}
+		return embedding
+	}
+
+	primary.status.Store(http.StatusServiceUnavailable)
+	if got, want := embed("soup"), []float32{0, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("embedding while the primary is down = %v, want the fallback's %v", got, want)
+	}
+
+	primary.status.Store(0)
+	if got, want := embed("soup"), []float32{1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("embedding after recovery = %v, want the primary's %v", got, want)
+	}
+	if got, want := embed("soup"), []float32{1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cached embedding = %v, want the primary's %v", got, want)
+	}
+	// Queries are keyed by their exact text
+	embed("Soup")
+
+	if n := primary.calls.Load(); n != 3 {
+		t.Errorf("primary called %d times, want 3", n)
+	}
+	if n := fallback.calls.Load(); n != 1 {
+		t.Errorf("fallback called %d times, want 1", n)
+	}
+}
+
+func TestEmbeddingsDontFallBackAcrossDimensions(t *testing.T) {
+	primary := newFakeProvider(t, `{}`)
+	primary.status.Store(http.StatusServiceUnavailable)
+	fallback := newFakeProvider(t, `{"data": [{"embedding": [0, 1], "index": 0}]}`)
+	t.Setenv("AI_PROVIDER", "claude")
+	t.Setenv("ANTHROPIC_AUTH_TOKEN", "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", primary.URL)
+	t.Setenv("AI_FALLBACK_PROVIDER", "openai")
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("OPENAI_BASE_URL", fallback.URL)
+	s := NewAIService(nil, nil)
+
+	if _, err := s.GenerateEmbedding(context.Background(), "text"); err == nil {
+		t.Error("GenerateEmbedding succeeded though the primary is down and the fallback's vectors are another size")
+	}
+	if n := fallback.calls.Load(); n != 0 {
+		t.Errorf("fallback called %d times, want 0", n)
+	}
+}
+
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+type embeddingCallKey struct{}
+
+// withEmbeddingCall marks requests made with ctx as embedding calls, which are
+// rate limited separately from completions; see do
+func withEmbeddingCall(ctx context.Context) context.Context {
+	return context.WithValue(ctx, embeddingCallKey{}, true)
+}
+
+func isEmbeddingCall(ctx context.Context) bool {
+	embedding, _ := ctx.Value(embeddingCallKey{}).(bool)
+	return embedding
+}
+
+// newRPMLimiter returns a token bucket allowing rpm requests per minute, with
+// bursts of up to a second's worth; rpm <= 0 means unlimited
+func newRPMLimiter(rpm int) *rate.Limiter {
+	if rpm <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	burst := rpm / 60
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(float64(rpm)/60), burst)
+}
+
+// do sends an outbound AI request once the provider's per-minute budget
+// (embedRate or chatRate) allows it and one of s.slots is free, so bursts of
+// saves can't open unbounded provider connections and trip rate limits. The
+// slot is held until the response body is closed, which for streams means
+// until the stream ends. Waiting gives up when the request's context is done.
+func (s *AIService) do(req *http.Request) (*http.Response, error) {
+	limiter := s.chatRate
+	if isEmbeddingCall(req.Context()) {
+		limiter = s.embedRate
+	}
+	// Wait for a token before taking a slot, so throttled calls don't hold slots idle
+	if err := limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		<-s.slots
+		return nil, err
+	}
+	resp.Body = &slotReleasingBody{ReadCloser: resp.Body, release: func() { <-s.slots }}
+	return resp, nil
+}
+
+// slotReleasingBody frees a concurrency slot the first time it is closed
+type slotReleasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *slotReleasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
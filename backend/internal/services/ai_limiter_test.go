@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestDoCapsConcurrentRequests(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		for {
+			max := maxInFlight.Load()
+			if n <= max || maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		inFlight.Add(-1)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AI_MAX_CONCURRENCY", "2")
+	s := NewAIService(nil, nil)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 6)
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			resp, err := s.do(req)
+			if err != nil {
+				errs <- err
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("do: %v", err)
+	}
+	if got := maxInFlight.Load(); got > 2 {
+		t.Errorf("%d requests in flight at once, want at most 2", got)
+	}
+}
+
+func TestDoHoldsSlotUntilBodyIsClosed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AI_MAX_CONCURRENCY", "1")
+	s := NewAIService(nil, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	held, err := s.do(req)
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+
+	// The only slot is taken, so a second call waits until its context gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := s.do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("do while the slot is held: err = %v, want context.DeadlineExceeded", err)
+	}
+
+	// Closing twice releases the slot only once
+	held.Body.Close()
+	held.Body.Close()
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := s.do(req)
+	if err != nil {
+		t.Fatalf("do after the body was closed: %v", err)
+	}
+	resp.Body.Close()
+	if n := len(s.slots); n != 0 {
+		t.Errorf("%d slots still taken, want 0", n)
+	}
+}
+
+func TestNewRPMLimiter(t *testing.T) {
+	tests := []struct {
+		rpm       int
+		wantLimit rate.Limit
+		wantBurst int
+	}{
+		{0, rate.Inf, 0},
+		{-1, rate.Inf, 0},
+		{30, 0.5, 1},
+		{120, 2, 2},
+		{600, 10, 10},
+	}
+
+	for _, tt := range tests {
+		limiter := newRPMLimiter(tt.rpm)
+		if limiter.Limit() != tt.wantLimit || limiter.Burst() != tt.wantBurst {
+			t.Errorf("newRPMLimiter(%d) = %v/s with burst %d, want %v/s with burst %d", tt.rpm, limiter.Limit(), limiter.Burst(), tt.wantLimit, tt.wantBurst)
+		}
+	}
+}
+
+func TestDoRateLimitsEmbeddingsAndCompletionsSeparately(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		spent     bool // whether the first call is an embedding
+		next      bool // whether the second call is an embedding
+		wantWaits bool
+	}{
+		{"embedding after embedding", true, true, true},
+		{"completion after completion", false, false, true},
+		{"completion after embedding", true, false, false},
+		{"embedding after completion", false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// One request a minute for each kind, so the first call spends its budget
+			t.Setenv("AI_RPM_EMBED", "1")
+			t.Setenv("AI_RPM_CHAT", "1")
+			s := NewAIService(nil, nil)
+
+			call := func(ctx context.Context, embedding bool) error {
+				if embedding {
+					ctx = withEmbeddingCall(ctx)
+				}
+				req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+				resp, err := s.do(req)
+				if err != nil {
+					return err
+				}
+				resp.Body.Close()
+				return nil
+			}
+
+			if err := call(context.Background(), tt.spent); err != nil {
+				t.Fatalf("first call: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			err := call(ctx, tt.next)
+			if tt.wantWaits && err == nil {
+				t.Error("second call of the same kind wasn't throttled")
+			}
+			if !tt.wantWaits && err != nil {
+				t.Errorf("second call of the other kind: %v", err)
+			}
+		})
+	}
+}
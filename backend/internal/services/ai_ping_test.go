@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPingReusesResultWithinTTL(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"embedding": [0.1, 0.2]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AI_PROVIDER", "ollama")
+	t.Setenv("OLLAMA_HOST", server.URL)
+	s := NewAIService(nil, nil)
+
+	for i := 0; i < 3; i++ {
+		if err := s.Ping(context.Background()); err != nil {
+			t.Fatalf("Ping: %v", err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("provider called %d times, want 1", got)
+	}
+
+	// Once the result is stale the provider is probed again
+	s.pingTTL = 0
+	if err := s.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("provider called %d times, want 2", got)
+	}
+}
@@ -4,25 +4,74 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"log/slog"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
+	"synapse/internal/logging"
+	"synapse/internal/metrics"
 	"synapse/internal/models"
+	"time"
+
+	"golang.org/x/time/rate"
 )
 
 type AIService struct {
-	provider      string
-	geminiKey     string
-	openaiKey     string
-	claudeKey     string
-	claudeBaseURL string
-	client        *http.Client
+	provider         string
+	geminiKey        string
+	openaiKey        string
+	claudeKey        string
+	claudeBaseURL    string
+	// openaiAPI and geminiBaseURL are where OpenAI and Gemini requests go
+	openaiAPI        openAIConfig
+	geminiBaseURL    string
+	ollamaHost       string
+	ollamaModel      string
+	ollamaEmbedModel string
+	// ollamaVisionModel reads images for vision calls on Ollama, e.g. llava
+	ollamaVisionModel string
+	client            *http.Client
+	// imageClient downloads images for DescribeImage like a metadata fetch:
+	// with METADATA_USER_AGENT, and kept off private networks
+	imageClient *http.Client
+	embeddings  *embeddingCache
+	// queryEmbeddings caches search query vectors apart from content vectors,
+	// so a burst of saves can't evict the queries users repeat most
+	queryEmbeddings *embeddingCache
+	usage           *usageTracker
+	// requestTimeout bounds a single outbound provider request
+	requestTimeout time.Duration
+	// slots caps concurrent outbound provider requests; see do
+	slots chan struct{}
+	// embedRate and chatRate cap provider requests per minute for embeddings and completions
+	embedRate *rate.Limiter
+	chatRate  *rate.Limiter
+	// fallbackProvider is tried when the primary provider is throttled or down; see withFallback
+	fallbackProvider string
+	// maxTags caps the tags kept from GenerateTags
+	maxTags int
+	// inputLimits caps the content each prompt is given
+	inputLimits inputLimits
+	// prompts are the templates every AI prompt is rendered from
+	prompts promptTemplates
+	// pingTTL is how long a Ping result is reused; see Ping
+	pingTTL time.Duration
+	pingMu  sync.Mutex
+	pingAt  time.Time
+	pingErr error
+	metrics metrics.Metrics
+	logger  *slog.Logger
 }
 
-func NewAIService() *AIService {
+// NewAIService configures the provider from the environment; call latency and errors are reported to m (nil for none)
+func NewAIService(m metrics.Metrics, logger *slog.Logger) *AIService {
+	logger = logging.OrDefault(logger)
+
 	provider := os.Getenv("AI_PROVIDER")
 	if provider == "" {
 		provider = "claude" // Default to Claude
@@ -36,25 +85,179 @@ func NewAIService() *AIService {
 		claudeBaseURL = "https://litellm-339960399182.us-central1.run.app"
 	}
 
-	return &AIService{
-		provider:      provider,
-		geminiKey:     geminiKey,
-		openaiKey:     openaiKey,
-		claudeKey:     claudeKey,
-		claudeBaseURL: claudeBaseURL,
-		client:        &http.Client{},
+	ollamaHost := strings.TrimRight(os.Getenv("OLLAMA_HOST"), "/")
+	if ollamaHost == "" {
+		ollamaHost = "http://localhost:11434"
+	}
+	ollamaModel := os.Getenv("OLLAMA_MODEL")
+	if ollamaModel == "" {
+		ollamaModel = "llama3.2"
+	}
+	ollamaEmbedModel := os.Getenv("OLLAMA_EMBED_MODEL")
+	if ollamaEmbedModel == "" {
+		ollamaEmbedModel = "nomic-embed-text"
+	}
+	ollamaVisionModel := os.Getenv("OLLAMA_VISION_MODEL")
+	if ollamaVisionModel == "" {
+		ollamaVisionModel = "llava"
+	} else if ollamaVisionModel == "none" {
+		ollamaVisionModel = ""
 	}
+
+	requestTimeout := getEnvSeconds("AI_REQUEST_TIMEOUT_SECONDS", 30*time.Second)
+
+	maxConcurrency := getEnvInt("AI_MAX_CONCURRENCY", 8)
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+
+	s := &AIService{
+		provider:          provider,
+		geminiKey:         geminiKey,
+		openaiKey:         openaiKey,
+		claudeKey:         claudeKey,
+		claudeBaseURL:     claudeBaseURL,
+		openaiAPI:         loadOpenAIConfig(),
+		geminiBaseURL:     geminiBaseURL(),
+		ollamaHost:        ollamaHost,
+		ollamaModel:       ollamaModel,
+		ollamaEmbedModel:  ollamaEmbedModel,
+		ollamaVisionModel: ollamaVisionModel,
+		client:            newHTTPClient(getEnvSeconds("AI_HTTP_TIMEOUT_SECONDS", 60*time.Second), "", nil),
+		imageClient:       newHTTPClient(getEnvSeconds("METADATA_HTTP_TIMEOUT_SECONDS", 15*time.Second), metadataUserAgent(), loadURLGuard()),
+		embeddings:        newEmbeddingCache(getEnvInt("EMBEDDING_CACHE_SIZE", 1000)),
+		queryEmbeddings:   newEmbeddingCache(getEnvInt("QUERY_EMBEDDING_CACHE_SIZE", 1000)),
+		usage:             newUsageTracker(),
+		requestTimeout:    requestTimeout,
+		slots:             make(chan struct{}, maxConcurrency),
+		embedRate:         newRPMLimiter(getEnvInt("AI_RPM_EMBED", 0)),
+		chatRate:          newRPMLimiter(getEnvInt("AI_RPM_CHAT", 0)),
+		maxTags:           getEnvInt("MAX_TAGS", 5),
+		inputLimits:       loadInputLimits(),
+		prompts:           loadPrompts(logger),
+		pingTTL:           getEnvSeconds("AI_PING_CACHE_SECONDS", time.Minute),
+		metrics:           metrics.OrNoop(m),
+		logger:            logger,
+	}
+	s.fallbackProvider = s.configureFallback()
+	return s
 }
 
+// GenerateEmbedding returns the embedding for text, serving repeated inputs from the LRU cache.
+// Vectors are cached under the model that made them, so one from the fallback
+// provider is never served as the primary's.
 func (s *AIService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
-	// Use Claude/LiteLLM proxy for embeddings with gemini-embedding-001
-	if s.provider == "claude" && s.claudeKey != "" {
-		return s.generateEmbeddingClaude(ctx, text)
+	embedding, ok := s.embeddings.Get(embeddingCacheKey(s.EmbeddingModel(), text))
+	s.metrics.ObserveEmbeddingCache("content", ok)
+	if ok {
+		return embedding, nil
 	}
-	if s.provider == "gemini" {
-		return s.generateEmbeddingGemini(ctx, text)
+
+	embedding, model, err := s.generateEmbeddingUncached(ctx, text)
+	if err != nil {
+		return nil, err
 	}
-	return s.generateEmbeddingOpenAI(ctx, text)
+
+	s.embeddings.Put(embeddingCacheKey(model, text), embedding)
+	return embedding, nil
+}
+
+// GenerateQueryEmbedding embeds a search query, caching vectors by the exact
+// query string in their own LRU rather than the content embedding cache. Like
+// GenerateEmbedding, vectors are cached under the model that made them.
+func (s *AIService) GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error) {
+	embedding, ok := s.queryEmbeddings.Get(s.EmbeddingModel() + "\x00" + query)
+	s.metrics.ObserveEmbeddingCache("query", ok)
+	if ok {
+		return embedding, nil
+	}
+
+	embedding, model, err := s.generateEmbeddingUncached(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.queryEmbeddings.Put(model+"\x00"+query, embedding)
+	return embedding, nil
+}
+
+// Usage returns cumulative token usage across all AI calls since startup
+func (s *AIService) Usage() AIUsageReport {
+	return s.usage.report()
+}
+
+// EmbeddingCacheStats returns hit/miss counters for the embedding cache
+func (s *AIService) EmbeddingCacheStats() EmbeddingCacheStats {
+	return s.embeddings.Stats()
+}
+
+// QueryEmbeddingCacheStats returns hit/miss counters for the search query embedding cache
+func (s *AIService) QueryEmbeddingCacheStats() EmbeddingCacheStats {
+	return s.queryEmbeddings.Stats()
+}
+
+// EmbeddingModel identifies the provider and model embeddings are generated
+// with, mirroring the selection in generateEmbeddingUncached
+func (s *AIService) EmbeddingModel() string {
+	return s.embeddingModelOf(s.resolveProvider(s.provider))
+}
+
+// embeddingModelOf identifies the embedding model of a resolved provider
+func (s *AIService) embeddingModelOf(provider string) string {
+	switch provider {
+	case "claude":
+		return "claude/gemini-embedding-001"
+	case "gemini":
+		return "gemini/text-embedding-004"
+	case "ollama":
+		return "ollama/" + s.ollamaEmbedModel
+	default:
+		return "openai/text-embedding-3-small"
+	}
+}
+
+// Ping checks the configured provider is reachable and accepting our key by
+// embedding a fixed string. Each probe is a billed provider call, so its
+// result is reused for pingTTL; concurrent callers wait for one probe rather
+// than each making their own.
+func (s *AIService) Ping(ctx context.Context) error {
+	s.pingMu.Lock()
+	defer s.pingMu.Unlock()
+	if !s.pingAt.IsZero() && time.Since(s.pingAt) < s.pingTTL {
+		return s.pingErr
+	}
+
+	_, _, err := s.generateEmbeddingUncached(ctx, "ping")
+	if ctx.Err() != nil {
+		// The caller gave up, which says nothing about the provider
+		return err
+	}
+	s.pingAt, s.pingErr = time.Now(), err
+	return err
+}
+
+// generateEmbeddingUncached embeds text, returning the embedding model that
+// answered: the primary's, or the fallback provider's if it had to be used
+func (s *AIService) generateEmbeddingUncached(ctx context.Context, text string) ([]float32, string, error) {
+	ctx, cancel := context.WithTimeout(withEmbeddingCall(ctx), s.requestTimeout)
+	defer cancel()
+
+	var answered string
+	embedding, err := withFallback(ctx, s, "embedding", s.canFallBackForEmbeddings(), func(provider string) ([]float32, error) {
+		answered = provider
+		switch provider {
+		case "claude":
+			// Use Claude/LiteLLM proxy for embeddings with gemini-embedding-001
+			return s.generateEmbeddingClaude(ctx, text)
+		case "gemini":
+			return s.generateEmbeddingGemini(ctx, text)
+		case "ollama":
+			return s.generateEmbeddingOllama(ctx, text)
+		default:
+			return s.generateEmbeddingOpenAI(ctx, text)
+		}
+	})
+	return embedding, s.embeddingModelOf(answered), err
 }
 
 // generateEmbeddingClaude uses LiteLLM proxy with gemini-embedding-001 model
@@ -71,7 +274,7 @@ func (s *AIService) generateEmbeddingClaude(ctx context.Context, text string) ([
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+s.claudeKey)
 	
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
@@ -79,18 +282,20 @@ func (s *AIService) generateEmbeddingClaude(ctx context.Context, text string) ([
 	
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Claude/LiteLLM API error: %s", string(body))
+		return nil, parseOpenAIError("Claude/LiteLLM", "gemini-embedding-001", resp.StatusCode, body)
 	}
 	
 	var result struct {
 		Data []struct {
 			Embedding []float32 `json:"embedding"`
 		} `json:"data"`
+		Usage openAIUsage `json:"usage"`
 	}
 	
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	s.usage.record("claude", result.Usage.PromptTokens, 0, result.Usage.TotalTokens)
 	
 	if len(result.Data) == 0 {
 		return nil, fmt.Errorf("no embedding data returned")
@@ -101,7 +306,7 @@ func (s *AIService) generateEmbeddingClaude(ctx context.Context, text string) ([
 
 func (s *AIService) generateEmbeddingGemini(ctx context.Context, text string) ([]float32, error) {
 	// Gemini doesn't have a direct embeddings API, so we'll use text-embedding-004 model
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/text-embedding-004:embedContent?key=%s", s.geminiKey)
+	url := fmt.Sprintf("%s/v1beta/models/text-embedding-004:embedContent?key=%s", s.geminiBaseURL, s.geminiKey)
 	
 	payload := map[string]interface{}{
 		"model": "models/text-embedding-004",
@@ -116,7 +321,7 @@ func (s *AIService) generateEmbeddingGemini(ctx context.Context, text string) ([
 	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
 	
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
@@ -124,7 +329,7 @@ func (s *AIService) generateEmbeddingGemini(ctx context.Context, text string) ([
 	
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Gemini API error: %s", string(body))
+		return nil, parseGeminiError("text-embedding-004", resp.StatusCode, body)
 	}
 	
 	var result struct {
@@ -136,6 +341,8 @@ func (s *AIService) generateEmbeddingGemini(ctx context.Context, text string) ([
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	// embedContent doesn't report token usage, so only the request is counted
+	s.usage.record("gemini", 0, 0, 0)
 	
 	if len(result.Embedding.Values) == 0 {
 		return nil, fmt.Errorf("no embedding data returned")
@@ -145,7 +352,7 @@ func (s *AIService) generateEmbeddingGemini(ctx context.Context, text string) ([
 }
 
 func (s *AIService) generateEmbeddingOpenAI(ctx context.Context, text string) ([]float32, error) {
-	url := "https://api.openai.com/v1/embeddings"
+	url := s.openaiAPI.url("embeddings")
 	
 	payload := map[string]interface{}{
 		"input": text,
@@ -155,9 +362,9 @@ func (s *AIService) generateEmbeddingOpenAI(ctx context.Context, text string) ([
 	jsonData, _ := json.Marshal(payload)
 	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.openaiKey)
+	s.openaiAPI.auth(s.openaiKey)(req)
 	
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
@@ -165,28 +372,20 @@ func (s *AIService) generateEmbeddingOpenAI(ctx context.Context, text string) ([
 	
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		var apiError struct {
-			Error struct {
-				Message string `json:"message"`
-				Type    string `json:"type"`
-				Code    string `json:"code"`
-			} `json:"error"`
-		}
-		if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error.Message != "" {
-			return nil, fmt.Errorf("OpenAI API error: %s (code: %s)", apiError.Error.Message, apiError.Error.Code)
-		}
-		return nil, fmt.Errorf("OpenAI API error: %s", string(body))
+		return nil, parseOpenAIError("OpenAI", "text-embedding-3-small", resp.StatusCode, body)
 	}
 	
 	var result struct {
 		Data []struct {
 			Embedding []float32 `json:"embedding"`
 		} `json:"data"`
+		Usage openAIUsage `json:"usage"`
 	}
 	
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	s.usage.record("openai", result.Usage.PromptTokens, 0, result.Usage.TotalTokens)
 	
 	if len(result.Data) == 0 {
 		return nil, fmt.Errorf("no embedding data returned")
@@ -196,88 +395,89 @@ func (s *AIService) generateEmbeddingOpenAI(ctx context.Context, text string) ([
 }
 
 func (s *AIService) SummarizeContent(ctx context.Context, content string) (string, error) {
-	prompt := fmt.Sprintf(
-		"Summarize the following content in 2-3 concise sentences. Focus on the key points:\n\n%s",
-		content,
-	)
-	
-	if s.provider == "claude" && s.claudeKey != "" {
-		return s.callClaude(ctx, prompt, 150)
-	}
-	if s.provider == "gemini" {
-		return s.callGeminiPro(ctx, prompt, 150)
+	prompt, err := s.prompts.render("summary", promptData{Content: content, MaxTokens: 150})
+	if err != nil {
+		return "", err
 	}
-	return s.callChatGPT(ctx, prompt, 150)
+	
+	return s.generatePro(ctx, "summary", prompt, 150)
 }
 
 func (s *AIService) GenerateTags(ctx context.Context, content string) ([]string, error) {
 	// Truncate content if too long
-	truncated := content
-	if len(content) > 2000 {
-		truncated = content[:2000]
-	}
+	truncated := truncateForModel(content, s.inputLimits.tags)
 	
-	prompt := fmt.Sprintf(
-		"Extract 3-5 relevant tags for this content. Return only comma-separated tags, no explanations, no numbering, just tags separated by commas:\n\n%s",
-		truncated,
-	)
+	prompt, err := s.prompts.render("tags", promptData{Content: truncated, MaxTokens: 50})
+	if err != nil {
+		return nil, err
+	}
 	
 	var response string
-	var err error
 	
-	if s.provider == "claude" && s.claudeKey != "" {
-		response, err = s.callClaude(ctx, prompt, 50)
-	} else if s.provider == "gemini" {
-		response, err = s.callGemini(ctx, prompt, 50)
-	} else {
-		response, err = s.callChatGPT(ctx, prompt, 50)
-	}
+	response, err = s.generate(ctx, "tags", prompt, 50)
 	
 	if err != nil {
 		return nil, err
 	}
 	
-	// Parse comma-separated tags
-	tagsStr := strings.TrimSpace(response)
-	tags := strings.Split(tagsStr, ",")
+	// Models don't always follow the format, so normalize and dedupe what comes back
+	return parseTags(response, s.maxTags), nil
+}
+
+// DetectLanguage returns the ISO 639-1 code (e.g. "en", "es") of the text's language
+func (s *AIService) DetectLanguage(ctx context.Context, text string) (string, error) {
+	// A short sample is enough to identify the language
+	sample := truncateForModel(text, s.inputLimits.language)
 	
-	var cleanedTags []string
-	for _, tag := range tags {
-		cleaned := strings.TrimSpace(tag)
-		if cleaned != "" {
-			cleanedTags = append(cleanedTags, cleaned)
-		}
+	prompt, err := s.prompts.render("language", promptData{Content: sample, MaxTokens: 10})
+	if err != nil {
+		return "", err
 	}
 	
-	return cleanedTags, nil
+	var response string
+	
+	response, err = s.generate(ctx, "language", prompt, 10)
+	
+	if err != nil {
+		return "", err
+	}
+	
+	// Keep only the code, in case the model added punctuation or an explanation
+	code := strings.ToLower(strings.TrimSpace(response))
+	code = strings.Trim(code, ".\"'` ")
+	if fields := strings.Fields(code); len(fields) > 0 {
+		code = fields[0]
+	}
+	if len(code) != 2 {
+		return "", fmt.Errorf("unexpected language code from model: %q", response)
+	}
+	
+	return code, nil
+}
+
+// TranslateToEnglish translates text into English, preserving meaning and key terms
+func (s *AIService) TranslateToEnglish(ctx context.Context, text string) (string, error) {
+	// Truncate content if too long
+	truncated := truncateForModel(text, s.inputLimits.translate)
+	
+	prompt, err := s.prompts.render("translate", promptData{Content: truncated, MaxTokens: 1500})
+	if err != nil {
+		return "", err
+	}
+	
+	return s.generate(ctx, "translate", prompt, 1500)
 }
 
 // EnhanceSearchQuery uses Claude to understand and enhance search queries
 // Converts plain English into searchable terms with synonyms and related concepts
 func (s *AIService) EnhanceSearchQuery(ctx context.Context, query string) (string, error) {
-	prompt := fmt.Sprintf(`You are a search query enhancement assistant. Your goal is to help users find content even when they use plain English that doesn't match exact words in the content.
-
-Analyze the following search query and return an improved search query that will find relevant content using semantic understanding.
-
-Examples:
-- "things about AI" → "artificial intelligence machine learning neural networks AI"
-- "cooking ideas" → "recipes cooking food preparation ingredients"
-- "workout tips" → "exercise fitness training health workout"
-- "money saving" → "budget savings finance frugal economical"
-
-Your task:
-1. Understand the user's intent and what they're really looking for
-2. Expand with relevant synonyms, related terms, and alternative phrasings
-3. Include both formal and informal terms
-4. Keep the original meaning but add searchable keywords
-5. Return ONLY the enhanced query with expanded terms, nothing else
-
-Original query: "%s"
-
-Enhanced query:`, query)
+	prompt, err := s.prompts.render("enhance_query", promptData{Query: query, MaxTokens: 150})
+	if err != nil {
+		return query, nil
+	}
 	
 	if s.provider == "claude" && s.claudeKey != "" {
-		enhanced, err := s.callClaude(ctx, prompt, 150)
+		enhanced, err := s.generate(ctx, "enhance_query", prompt, 150)
 		if err == nil && enhanced != "" {
 			return enhanced, nil
 		}
@@ -286,35 +486,53 @@ Enhanced query:`, query)
 	return query, nil
 }
 
+// SuggestQuery proposes a spelling-corrected or broader form of a query that
+// found little, for a "Did you mean" prompt. topics are terms from the user's
+// library, such as their tags, to steer it. "" means no better query was found.
+func (s *AIService) SuggestQuery(ctx context.Context, query string, topics []string) (string, error) {
+	prompt, err := s.prompts.render("suggest_query", promptData{Query: query, Content: strings.Join(topics, ", "), MaxTokens: 30})
+	if err != nil {
+		return "", err
+	}
+
+	response, err := s.generate(ctx, "suggest_query", prompt, 30)
+	if err != nil {
+		return "", err
+	}
+
+	suggestion := strings.TrimSpace(response)
+	if i := strings.IndexByte(suggestion, '\n'); i != -1 {
+		suggestion = suggestion[:i]
+	}
+	suggestion = strings.Trim(strings.TrimSpace(suggestion), `"'*`)
+	if strings.EqualFold(suggestion, "NONE") || strings.EqualFold(suggestion, strings.TrimSpace(query)) {
+		return "", nil
+	}
+	return suggestion, nil
+}
+
 // ReRankSearchResults uses Claude to re-rank search results by relevance
 func (s *AIService) ReRankSearchResults(ctx context.Context, query string, results []models.SearchResult, topK int) ([]models.SearchResult, error) {
 	if len(results) == 0 {
 		return results, nil
 	}
 	
-	// Build context for Claude
-	var itemsContext strings.Builder
-	itemsContext.WriteString(fmt.Sprintf("Search query: %s\n\n", query))
-	itemsContext.WriteString("Search results to rank:\n")
-	
+	// Limit to top 10 for Claude context
+	var listed []promptResult
 	for i, result := range results {
-		if i >= 10 { // Limit to top 10 for Claude context
+		if i >= 10 {
 			break
 		}
-		itemsContext.WriteString(fmt.Sprintf("%d. Title: %s\n   Summary: %s\n   Type: %s\n\n", 
-			i+1, result.Item.Title, result.Item.Summary, result.Item.Type))
+		listed = append(listed, promptResult{Number: i + 1, Title: result.Item.Title, Summary: result.Item.Summary, Type: result.Item.Type})
 	}
 	
-	prompt := fmt.Sprintf(`You are a search result ranking assistant. Given a search query and a list of search results, rank them by relevance to the query.
-
-%s
-
-Return ONLY a comma-separated list of numbers (1, 2, 3, etc.) representing the order of relevance, with the most relevant first. For example: "3,1,5,2,4"
-
-Ranked order:`, itemsContext.String())
+	prompt, err := s.prompts.render("rerank", promptData{Query: query, Results: listed, MaxTokens: 50})
+	if err != nil {
+		return results, nil
+	}
 	
 	if s.provider == "claude" && s.claudeKey != "" {
-		rankedOrder, err := s.callClaude(ctx, prompt, 50)
+		rankedOrder, err := s.generate(ctx, "rerank", prompt, 50)
 		if err != nil {
 			// If Claude fails, return original order
 			return results, nil
@@ -373,141 +591,148 @@ func parseRankedIndices(rankedOrder string, maxLen int) []int {
 	return indices
 }
 
-// CategorizeContent uses AI to automatically categorize content into sections
-func (s *AIService) CategorizeContent(ctx context.Context, title, content, itemType string) (string, error) {
+// ErrNoMatchingCategory is returned by CategorizeContent when the model's answer isn't
+// one of the allowed categories and the list has no "Other" to fall back to
+var ErrNoMatchingCategory = errors.New("AI category doesn't match any allowed category")
+
+// CategorizeContent uses AI to put content into exactly one of allowed (matched
+// case-insensitively), or the default categories when allowed is nil. An answer
+// outside the list becomes "Other" if allowed has it, else ErrNoMatchingCategory.
+func (s *AIService) CategorizeContent(ctx context.Context, title, content, itemType string, allowed []string) (string, error) {
+	if len(allowed) == 0 {
+		allowed = categories
+	}
+
 	// Truncate content if too long
-	truncated := content
-	if len(content) > 1500 {
-		truncated = content[:1500]
-	}
-	
-	prompt := fmt.Sprintf(
-		`Categorize this content into ONE of these specific sections:
-- Technology
-- Food & Recipes
-- Books & Reading
-- Videos & Entertainment
-- Shopping & Products
-- Articles & News
-- Notes & Ideas
-- Design & Inspiration
-- Travel
-- Health & Fitness
-- Education & Learning
-- Other
-
-Title: %s
-Type: %s
-Content: %s
-
-Return ONLY the category name, nothing else.`,
-		title, itemType, truncated,
-	)
+	truncated := truncateForModel(content, s.inputLimits.categorize)
+	
+	prompt, err := s.prompts.render("categorize", promptData{
+		Title:      title,
+		Type:       itemType,
+		Content:    truncated,
+		Categories: allowed,
+		MaxTokens:  20,
+	})
+	if err != nil {
+		return "", err
+	}
 	
 	var response string
-	var err error
 	
-	if s.provider == "claude" && s.claudeKey != "" {
-		response, err = s.callClaude(ctx, prompt, 20)
-	} else if s.provider == "gemini" {
-		response, err = s.callGemini(ctx, prompt, 20)
-	} else {
-		response, err = s.callChatGPT(ctx, prompt, 20)
-	}
+	response, err = s.generate(ctx, "categorize", prompt, 20)
 	
 	if err != nil {
 		return "", err
 	}
 	
-	category := strings.TrimSpace(response)
+	if category := matchCategory(response, allowed); category != "" {
+		return category, nil
+	}
+	for _, category := range allowed {
+		if strings.EqualFold(category, "Other") {
+			return category, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrNoMatchingCategory, strings.TrimSpace(response))
+}
+
+// matchCategory finds which of allowed the model answered with, returning its
+// canonical spelling or "" if none. Models sometimes wrap the name in quotes or
+// a sentence, so failing an exact match the longest category mentioned wins.
+func matchCategory(response string, allowed []string) string {
+	answer := strings.TrimSpace(response)
 	// Clean up any extra text
-	if strings.Contains(category, "\n") {
-		category = strings.Split(category, "\n")[0]
+	if strings.Contains(answer, "\n") {
+		answer = strings.Split(answer, "\n")[0]
 	}
-	
-	return category, nil
+	answer = strings.TrimPrefix(answer, "Category:")
+	answer = strings.Trim(strings.TrimSpace(answer), `"'*.`)
+
+	for _, category := range allowed {
+		if strings.EqualFold(answer, category) {
+			return category
+		}
+	}
+
+	best := ""
+	lowerAnswer := strings.ToLower(answer)
+	for _, category := range allowed {
+		if len(category) > len(best) && strings.Contains(lowerAnswer, strings.ToLower(category)) {
+			best = category
+		}
+	}
+	return best
+}
+
+// maxTitleWords caps generated titles; models sometimes ignore the limit in the prompt
+const maxTitleWords = 8
+
+// GenerateTitle writes a short descriptive title for content saved without one
+func (s *AIService) GenerateTitle(ctx context.Context, content string) (string, error) {
+	// Truncate content if too long
+	truncated := truncateForModel(content, s.inputLimits.title)
+
+	prompt, err := s.prompts.render("title", promptData{Content: truncated, MaxWords: maxTitleWords, MaxTokens: 30})
+	if err != nil {
+		return "", err
+	}
+
+	var response string
+
+	response, err = s.generate(ctx, "title", prompt, 30)
+
+	if err != nil {
+		return "", err
+	}
+
+	title := strings.TrimSpace(response)
+	// Clean up any extra text
+	if strings.Contains(title, "\n") {
+		title = strings.Split(title, "\n")[0]
+	}
+	title = strings.TrimPrefix(title, "Title:")
+	title = strings.Trim(strings.TrimSpace(title), `"'*`)
+	if words := strings.Fields(title); len(words) > maxTitleWords {
+		title = strings.Join(words[:maxTitleWords], " ")
+	}
+
+	return title, nil
 }
 
 // GenerateSemanticSummary creates a concise semantic summary optimized for search
 // Uses Claude via LiteLLM proxy, falls back to Gemini/OpenAI if needed
 func (s *AIService) GenerateSemanticSummary(ctx context.Context, title, content string) (string, error) {
 	// Truncate content if too long
-	truncated := content
-	if len(content) > 3000 {
-		truncated = content[:3000]
-	}
-	
-	prompt := fmt.Sprintf(
-		`Create a concise semantic summary (2-3 sentences) of this content that captures key concepts, topics, and ideas. This summary will be used for search, so include important keywords and concepts:
-    
-    Title: %s
-    Content: %s
-    
-    Summary:`,
-		title, truncated,
-	)
-	
-	// Use Claude if available
-	if s.provider == "claude" && s.claudeKey != "" {
-		return s.callClaude(ctx, prompt, 200)
-	}
+	truncated := truncateForModel(content, s.inputLimits.semanticSummary)
 	
-	// Try Gemini first (if provider is gemini)
-	if s.provider == "gemini" {
-		summary, err := s.callGeminiPro(ctx, prompt, 200)
-		// If Gemini fails due to quota/rate limit and OpenAI is available, fallback to OpenAI
-		if err != nil && s.openaiKey != "" {
-			if strings.Contains(err.Error(), "quota") || strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "rate limit") || strings.Contains(err.Error(), "503") {
-				fmt.Printf("Gemini quota exceeded, falling back to OpenAI for summary generation\n")
-				return s.callChatGPT(ctx, prompt, 200)
-			}
-		}
-		return summary, err
+	prompt, err := s.prompts.render("semantic_summary", promptData{Title: title, Content: truncated, MaxTokens: 200})
+	if err != nil {
+		return "", err
 	}
-	return s.callChatGPT(ctx, prompt, 200)
+	
+	return s.generatePro(ctx, "semantic_summary", prompt, 200)
 }
 
-// SummarizeYouTubeVideo generates a short summary for a YouTube video
+// SummarizeYouTubeVideo generates a short summary for a YouTube video from its
+// description and, when it has captions, its transcript
 // Uses Claude via LiteLLM proxy, falls back to Gemini/OpenAI if needed
-func (s *AIService) SummarizeYouTubeVideo(ctx context.Context, videoURL, title, description string) (string, error) {
+func (s *AIService) SummarizeYouTubeVideo(ctx context.Context, videoURL, title, description, transcript string) (string, error) {
 	// Truncate description if too long (keep it reasonable for the API)
-	truncatedDesc := description
-	if len(description) > 5000 {
-		truncatedDesc = description[:5000] + "..."
+	truncatedDesc := truncateForModel(description, s.inputLimits.videoSummary)
+	if truncatedDesc != description {
+		truncatedDesc += "..."
 	}
 	
-	prompt := fmt.Sprintf(
-		`Create a SHORT, concise summary (2-3 sentences maximum) of this YouTube video. Focus only on the main topic and key points. Be brief and informative.
-
-Video Title: %s
-Video Description: %s
-
-Provide a brief summary:`,
-		title, truncatedDesc,
-	)
-	
-	// Use Claude if available
-	if s.provider == "claude" && s.claudeKey != "" {
-		return s.callClaude(ctx, prompt, 150)
+	prompt, err := s.prompts.render("video_summary", promptData{Title: title, Content: truncatedDesc, Transcript: previewText(transcript, s.inputLimits.transcript), MaxTokens: 150})
+	if err != nil {
+		return "", err
 	}
 	
-	// Try Gemini first (if provider is gemini)
-	if s.provider == "gemini" {
-		summary, err := s.callGeminiPro(ctx, prompt, 150)
-		// If Gemini fails due to quota/rate limit and OpenAI is available, fallback to OpenAI
-		if err != nil && s.openaiKey != "" {
-			if strings.Contains(err.Error(), "quota") || strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "rate limit") || strings.Contains(err.Error(), "503") {
-				fmt.Printf("Gemini quota exceeded, falling back to OpenAI for summary generation\n")
-				return s.callChatGPT(ctx, prompt, 150)
-			}
-		}
-		return summary, err
-	}
-	return s.callChatGPT(ctx, prompt, 150)
+	return s.generatePro(ctx, "video_summary", prompt, 150)
 }
 
 // callGeminiPro specifically uses Gemini 2.5 Pro for better quality summaries
-func (s *AIService) callGeminiPro(ctx context.Context, prompt string, maxTokens int) (string, error) {
+func (s *AIService) callGeminiPro(ctx context.Context, prompt string, maxTokens int, images ...imageData) (string, error) {
 	// Prioritize Gemini 2.5 Pro for summaries, with fallbacks
 	// Try v1 API first, then v1beta, with multiple model options
 	models := []struct {
@@ -522,10 +747,10 @@ func (s *AIService) callGeminiPro(ctx context.Context, prompt string, maxTokens
 		{"v1beta", "gemini-2.5-pro-preview-06-05"},
 	}
 	
-	return s.callGeminiWithModels(ctx, prompt, maxTokens, models)
+	return s.callGeminiWithModels(ctx, prompt, maxTokens, models, images...)
 }
 
-func (s *AIService) callGemini(ctx context.Context, prompt string, maxTokens int) (string, error) {
+func (s *AIService) callGemini(ctx context.Context, prompt string, maxTokens int, images ...imageData) (string, error) {
 	// Try multiple model names and API versions as fallback
 	// Updated to use Gemini 2.5 models which are currently available
 	models := []struct {
@@ -540,20 +765,26 @@ func (s *AIService) callGemini(ctx context.Context, prompt string, maxTokens int
 		{"v1beta", "gemini-1.5-pro-latest"},
 	}
 	
-	return s.callGeminiWithModels(ctx, prompt, maxTokens, models)
+	return s.callGeminiWithModels(ctx, prompt, maxTokens, models, images...)
 }
 
 func (s *AIService) callGeminiWithModels(ctx context.Context, prompt string, maxTokens int, models []struct {
 	apiVersion string
 	modelName  string
-}) (string, error) {
+}, images ...imageData) (string, error) {
 	
+	parts := []map[string]interface{}{
+		{"text": prompt},
+	}
+	for _, image := range images {
+		parts = append(parts, map[string]interface{}{
+			"inline_data": map[string]string{"mime_type": image.mimeType, "data": image.base64()},
+		})
+	}
 	payload := map[string]interface{}{
 		"contents": []map[string]interface{}{
 			{
-				"parts": []map[string]string{
-					{"text": prompt},
-				},
+				"parts": parts,
 			},
 		},
 		"generationConfig": map[string]interface{}{
@@ -566,20 +797,28 @@ func (s *AIService) callGeminiWithModels(ctx context.Context, prompt string, max
 	
 	var lastErr error
 	for _, model := range models {
-		url := fmt.Sprintf("https://generativelanguage.googleapis.com/%s/models/%s:generateContent?key=%s", 
-			model.apiVersion, model.modelName, s.geminiKey)
+		// Stop trying further models once the caller has given up
+		if ctx.Err() != nil {
+			break
+		}
+
+		url := fmt.Sprintf("%s/%s/models/%s:generateContent?key=%s",
+			s.geminiBaseURL, model.apiVersion, model.modelName, s.geminiKey)
 		
-		req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+		reqCtx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+		req, _ := http.NewRequestWithContext(reqCtx, "POST", url, bytes.NewBuffer(jsonData))
 		req.Header.Set("Content-Type", "application/json")
 		
-		resp, err := s.client.Do(req)
+		resp, err := s.do(req)
 		if err != nil {
+			cancel()
 			lastErr = fmt.Errorf("failed to call Gemini API: %w", err)
 			continue
 		}
 		
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
+		cancel()
 		
 		if resp.StatusCode == http.StatusOK {
 			var result struct {
@@ -595,16 +834,20 @@ func (s *AIService) callGeminiWithModels(ctx context.Context, prompt string, max
 					Message string `json:"message"`
 					Status  string `json:"status"`
 				} `json:"error"`
+				UsageMetadata geminiUsage `json:"usageMetadata"`
 			}
 			
 			if err := json.Unmarshal(body, &result); err != nil {
 				lastErr = fmt.Errorf("failed to decode response: %w", err)
 				continue
 			}
+			if result.Error == nil {
+				s.usage.record("gemini", result.UsageMetadata.PromptTokenCount, result.UsageMetadata.CandidatesTokenCount, result.UsageMetadata.TotalTokenCount)
+			}
 			
 			// Check for API errors in response
 			if result.Error != nil {
-				lastErr = fmt.Errorf("Gemini API error (model: %s, code: %d): %s", model.modelName, result.Error.Code, result.Error.Message)
+				lastErr = newAIError("Gemini", model.modelName, result.Error.Code, result.Error.Status, result.Error.Message)
 				// If it's a temporary error (503, 429), continue to next model
 				if result.Error.Code == 503 || result.Error.Code == 429 {
 					continue
@@ -636,29 +879,22 @@ func (s *AIService) callGeminiWithModels(ctx context.Context, prompt string, max
 		}
 		
 		// Handle non-200 status codes
-		var apiError struct {
-			Error struct {
-				Code    int    `json:"code"`
-				Message string `json:"message"`
-				Status  string `json:"status"`
-			} `json:"error"`
-		}
-		if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error.Message != "" {
-			lastErr = fmt.Errorf("Gemini API error (model: %s, code: %d): %s", model.modelName, apiError.Error.Code, apiError.Error.Message)
-			// For temporary errors, continue to next model
-			if apiError.Error.Code == 503 || apiError.Error.Code == 429 {
-				continue
-			}
-		} else {
-			lastErr = fmt.Errorf("Gemini API error (model: %s, status: %d): %s", model.modelName, resp.StatusCode, string(body))
+		apiErr := parseGeminiError(model.modelName, resp.StatusCode, body)
+		lastErr = apiErr
+		// A rejected key fails the same way for every model, so stop early
+		if errors.Is(apiErr, ErrAIAuth) {
+			break
 		}
 	}
 	
+	if lastErr == nil {
+		lastErr = ctx.Err()
+	}
 	return "", fmt.Errorf("all Gemini models failed, last error: %w", lastErr)
 }
 
 // callClaude uses Claude API via LiteLLM proxy for text generation
-func (s *AIService) callClaude(ctx context.Context, prompt string, maxTokens int) (string, error) {
+func (s *AIService) callClaude(ctx context.Context, prompt string, maxTokens int, images ...imageData) (string, error) {
 	url := fmt.Sprintf("%s/v1/chat/completions", s.claudeBaseURL)
 	
 	// Try different Claude model names available via LiteLLM proxy
@@ -670,12 +906,17 @@ func (s *AIService) callClaude(ctx context.Context, prompt string, maxTokens int
 	
 	var lastErr error
 	for _, model := range models {
+		// Stop trying further models once the caller has given up
+		if ctx.Err() != nil {
+			break
+		}
+
 		payload := map[string]interface{}{
 			"model": model,
 			"messages": []map[string]interface{}{
 				{
 					"role":    "user",
-					"content": prompt,
+					"content": chatContent(prompt, images),
 				},
 			},
 			"max_tokens": maxTokens,
@@ -683,16 +924,26 @@ func (s *AIService) callClaude(ctx context.Context, prompt string, maxTokens int
 		}
 		
 		jsonData, _ := json.Marshal(payload)
-		req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+		reqCtx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+		req, _ := http.NewRequestWithContext(reqCtx, "POST", url, bytes.NewBuffer(jsonData))
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Authorization", "Bearer "+s.claudeKey)
 		
-		resp, err := s.client.Do(req)
+		resp, err := s.do(req)
 		if err != nil {
+			cancel()
 			lastErr = fmt.Errorf("failed to call Claude API: %w", err)
 			continue
 		}
-		defer resp.Body.Close()
+		// Close and cancel per attempt rather than deferring, so a retry doesn't
+		// hold an extra AI concurrency slot or timer for the rest of the loop
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		cancel()
+		if err != nil {
+			lastErr = fmt.Errorf("failed to read Claude response: %w", err)
+			continue
+		}
 		
 		if resp.StatusCode == http.StatusOK {
 			var result struct {
@@ -701,12 +952,14 @@ func (s *AIService) callClaude(ctx context.Context, prompt string, maxTokens int
 						Content string `json:"content"`
 					} `json:"message"`
 				} `json:"choices"`
+				Usage openAIUsage `json:"usage"`
 			}
 			
-			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			if err := json.Unmarshal(body, &result); err != nil {
 				lastErr = fmt.Errorf("failed to decode response: %w", err)
 				continue
 			}
+			s.usage.record("claude", result.Usage.PromptTokens, result.Usage.CompletionTokens, result.Usage.TotalTokens)
 			
 			if len(result.Choices) == 0 {
 				lastErr = fmt.Errorf("no response from Claude")
@@ -716,46 +969,45 @@ func (s *AIService) callClaude(ctx context.Context, prompt string, maxTokens int
 			return strings.TrimSpace(result.Choices[0].Message.Content), nil
 		}
 		
-		body, _ := io.ReadAll(resp.Body)
-		var apiError struct {
-			Error struct {
-				Message string `json:"message"`
-				Type    string `json:"type"`
-				Code    string `json:"code"`
-			} `json:"error"`
-		}
-		if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error.Message != "" {
-			lastErr = fmt.Errorf("Claude API error (model: %s): %s (code: %s)", model, apiError.Error.Message, apiError.Error.Code)
-			// Continue to next model if this one fails
-			continue
+		apiErr := parseOpenAIError("Claude", model, resp.StatusCode, body)
+		lastErr = apiErr
+		// A rejected key fails the same way for every model, so stop early
+		if errors.Is(apiErr, ErrAIAuth) {
+			break
 		}
-		lastErr = fmt.Errorf("Claude API error (model: %s): %s", model, string(body))
+		// Continue to next model if this one fails
 	}
 	
+	if lastErr == nil {
+		lastErr = ctx.Err()
+	}
 	return "", fmt.Errorf("all Claude models failed, last error: %w", lastErr)
 }
 
-func (s *AIService) callChatGPT(ctx context.Context, prompt string, maxTokens int) (string, error) {
-	url := "https://api.openai.com/v1/chat/completions"
+func (s *AIService) callChatGPT(ctx context.Context, prompt string, maxTokens int, images ...imageData) (string, error) {
+	url := s.openaiAPI.url("chat/completions")
 	
 	payload := map[string]interface{}{
 		"model": "gpt-4o-mini",
-		"messages": []map[string]string{
+		"messages": []map[string]interface{}{
 			{
 				"role":    "user",
-				"content": prompt,
+				"content": chatContent(prompt, images),
 			},
 		},
 		"max_tokens": maxTokens,
 		"temperature": 0.7,
 	}
 	
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
 	jsonData, _ := json.Marshal(payload)
 	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.openaiKey)
+	s.openaiAPI.auth(s.openaiKey)(req)
 	
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to call OpenAI API: %w", err)
 	}
@@ -763,17 +1015,7 @@ func (s *AIService) callChatGPT(ctx context.Context, prompt string, maxTokens in
 	
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		var apiError struct {
-			Error struct {
-				Message string `json:"message"`
-				Type    string `json:"type"`
-				Code    string `json:"code"`
-			} `json:"error"`
-		}
-		if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error.Message != "" {
-			return "", fmt.Errorf("OpenAI API error: %s (code: %s)", apiError.Error.Message, apiError.Error.Code)
-		}
-		return "", fmt.Errorf("OpenAI API error: %s", string(body))
+		return "", parseOpenAIError("OpenAI", "gpt-4o-mini", resp.StatusCode, body)
 	}
 	
 	var result struct {
@@ -782,11 +1024,13 @@ func (s *AIService) callChatGPT(ctx context.Context, prompt string, maxTokens in
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage openAIUsage `json:"usage"`
 	}
 	
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
+	s.usage.record("openai", result.Usage.PromptTokens, result.Usage.CompletionTokens, result.Usage.TotalTokens)
 	
 	if len(result.Choices) == 0 {
 		return "", fmt.Errorf("no response from OpenAI")
@@ -794,3 +1038,108 @@ func (s *AIService) callChatGPT(ctx context.Context, prompt string, maxTokens in
 	
 	return strings.TrimSpace(result.Choices[0].Message.Content), nil
 }
+
+// callOllama uses a local Ollama server's /api/generate endpoint for text generation
+func (s *AIService) callOllama(ctx context.Context, prompt string, maxTokens int, images ...imageData) (string, error) {
+	url := fmt.Sprintf("%s/api/generate", s.ollamaHost)
+
+	payload := map[string]interface{}{
+		"model":  s.ollamaModel,
+		"prompt": prompt,
+		"stream": false,
+		"options": map[string]interface{}{
+			"num_predict": maxTokens,
+			"temperature": 0.7,
+		},
+	}
+	if len(images) > 0 {
+		// Text-only models ignore images, so vision calls use a multimodal model
+		payload["model"] = s.ollamaVisionModel
+		encoded := make([]string, len(images))
+		for i, image := range images {
+			encoded[i] = image.base64()
+		}
+		payload["images"] = encoded
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call Ollama API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		var apiError struct {
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error != "" {
+			return "", newAIError("Ollama", s.ollamaModel, resp.StatusCode, "", apiError.Error)
+		}
+		return "", newAIError("Ollama", s.ollamaModel, resp.StatusCode, "", string(body))
+	}
+
+	var result struct {
+		Response        string `json:"response"`
+		PromptEvalCount int    `json:"prompt_eval_count"`
+		EvalCount       int    `json:"eval_count"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	s.usage.record("ollama", result.PromptEvalCount, result.EvalCount, 0)
+
+	if result.Response == "" {
+		return "", fmt.Errorf("no response from Ollama")
+	}
+
+	return strings.TrimSpace(result.Response), nil
+}
+
+// generateEmbeddingOllama uses a local Ollama server's /api/embeddings endpoint
+func (s *AIService) generateEmbeddingOllama(ctx context.Context, text string) ([]float32, error) {
+	url := fmt.Sprintf("%s/api/embeddings", s.ollamaHost)
+
+	payload := map[string]interface{}{
+		"model":  s.ollamaEmbedModel,
+		"prompt": text,
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newAIError("Ollama", s.ollamaEmbedModel, resp.StatusCode, "", string(body))
+	}
+
+	var result struct {
+		Embedding []float32 `json:"embedding"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	s.usage.record("ollama", 0, 0, 0)
+
+	if len(result.Embedding) == 0 {
+		return nil, fmt.Errorf("no embedding data returned")
+	}
+
+	return result.Embedding, nil
+}
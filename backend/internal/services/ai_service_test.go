@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMatchCategory(t *testing.T) {
+	taxonomy := []string{"Papers", "Machine Learning", "Learning", "Other"}
+
+	tests := []struct {
+		name     string
+		response string
+		allowed  []string
+		want     string
+	}{
+		{"exact", "Papers", taxonomy, "Papers"},
+		{"any case", "machine LEARNING", taxonomy, "Machine Learning"},
+		{"quoted", `"Papers".`, taxonomy, "Papers"},
+		{"labelled", "Category: **Papers**", taxonomy, "Papers"},
+		{"first line only", "Papers\nBecause it cites other work.", taxonomy, "Papers"},
+		{"longest mention wins", "This is about machine learning.", taxonomy, "Machine Learning"},
+		{"no match", "Cooking", taxonomy, ""},
+		{"default list", "technology", categories, "Technology"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchCategory(tt.response, tt.allowed); got != tt.want {
+				t.Errorf("matchCategory(%q) = %q, want %q", tt.response, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCategorizeContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		allowed  []string
+		want     string
+		wantErr  error
+	}{
+		{"custom taxonomy", "ML Papers", []string{"ML Papers", "Recipes"}, "ML Papers", nil},
+		{"unlisted answer falls back to Other", "Gardening", []string{"Recipes", "other"}, "other", nil},
+		{"unlisted answer without Other", "Gardening", []string{"Recipes"}, "", ErrNoMatchingCategory},
+		{"nil uses the default list", "Technology", nil, "Technology", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompt string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var payload struct {
+					Prompt string `json:"prompt"`
+				}
+				json.NewDecoder(r.Body).Decode(&payload)
+				prompt = payload.Prompt
+				json.NewEncoder(w).Encode(map[string]string{"response": tt.response})
+			}))
+			defer server.Close()
+
+			t.Setenv("AI_PROVIDER", "ollama")
+			t.Setenv("OLLAMA_HOST", server.URL)
+			s := NewAIService(nil, nil)
+
+			got, err := s.CategorizeContent(context.Background(), "A title", "Some content", "text", tt.allowed)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("CategorizeContent = %q, %v; want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+			// The model is only offered the allowed categories
+			for _, category := range tt.allowed {
+				if !strings.Contains(prompt, "- "+category+"\n") {
+					t.Errorf("prompt doesn't list %q:\n%s", category, prompt)
+				}
+			}
+		})
+	}
+}
+
+func TestSuggestQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"plain", "sourdough bread", "sourdough bread"},
+		{"first line only", "sourdough bread\nThe query had a typo.", "sourdough bread"},
+		{"quotes trimmed", ` "sourdough bread" `, "sourdough bread"},
+		{"markdown bold trimmed", "**sourdough bread**", "sourdough bread"},
+		{"NONE", "NONE", ""},
+		{"NONE in any case", "None", ""},
+		{"echo of the query", "Sourdogh Bread", ""},
+		{"empty", "  \n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(map[string]string{"response": tt.response})
+			}))
+			defer server.Close()
+
+			t.Setenv("AI_PROVIDER", "ollama")
+			t.Setenv("OLLAMA_HOST", server.URL)
+			s := NewAIService(nil, nil)
+
+			got, err := s.SuggestQuery(context.Background(), " sourdogh bread ", []string{"baking"})
+			if err != nil {
+				t.Fatalf("SuggestQuery: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SuggestQuery = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// SummarizeContentStream is the streaming variant of SummarizeContent. Text
+// chunks are emitted on the first channel as the provider produces them; at
+// most one error is sent on the second. Both channels are closed when the
+// stream ends, and cancelling ctx aborts the upstream request.
+func (s *AIService) SummarizeContentStream(ctx context.Context, content string) (<-chan string, <-chan error) {
+	chunks := make(chan string, 16)
+	errs := make(chan error, 1)
+
+	prompt, err := s.prompts.render("summary", promptData{Content: content, MaxTokens: 150})
+	if err != nil {
+		errs <- err
+		close(chunks)
+		close(errs)
+		return chunks, errs
+	}
+
+	go func() {
+		defer close(chunks)
+		defer close(errs)
+
+		emit := func(text string) bool {
+			if text == "" {
+				return true
+			}
+			select {
+			case chunks <- text:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var err error
+		if s.provider == "claude" && s.claudeKey != "" {
+			url := fmt.Sprintf("%s/v1/chat/completions", s.claudeBaseURL)
+			err = s.streamOpenAIFormat(ctx, "Claude", url, bearerAuth(s.claudeKey), "claude-sonnet-4-5-20250929", prompt, 150, emit)
+		} else if s.provider == "gemini" {
+			err = s.streamGemini(ctx, "gemini-2.5-flash", prompt, 150, emit)
+		} else if s.provider == "ollama" {
+			err = s.streamOllama(ctx, prompt, 150, emit)
+		} else {
+			err = s.streamOpenAIFormat(ctx, "OpenAI", s.openaiAPI.url("chat/completions"), s.openaiAPI.auth(s.openaiKey), "gpt-4o-mini", prompt, 150, emit)
+		}
+
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return chunks, errs
+}
+
+// forEachStreamLine calls fn for every line of a streaming response body until fn returns false
+func forEachStreamLine(body io.Reader, fn func(line string) bool) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if !fn(strings.TrimSpace(scanner.Text())) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// streamOpenAIFormat consumes an OpenAI-compatible chat completion SSE stream; auth sets the request's credentials
+func (s *AIService) streamOpenAIFormat(ctx context.Context, providerName, url string, auth func(*http.Request), model, prompt string, maxTokens int, emit func(string) bool) error {
+	payload := map[string]interface{}{
+		"model": model,
+		"messages": []map[string]string{
+			{
+				"role":    "user",
+				"content": prompt,
+			},
+		},
+		"max_tokens":  maxTokens,
+		"temperature": 0.7,
+		"stream":      true,
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	auth(req)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s API: %w", providerName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return parseOpenAIError(providerName, model, resp.StatusCode, body)
+	}
+
+	var decodeErr error
+	err = forEachStreamLine(resp.Body, func(line string) bool {
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			return true
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return false
+		}
+
+		var event struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			decodeErr = fmt.Errorf("failed to decode stream event: %w", err)
+			return false
+		}
+		if len(event.Choices) == 0 {
+			return true
+		}
+		return emit(event.Choices[0].Delta.Content)
+	})
+	if decodeErr != nil {
+		return decodeErr
+	}
+	return err
+}
+
+// streamGemini consumes Gemini's streamGenerateContent SSE stream
+func (s *AIService) streamGemini(ctx context.Context, model, prompt string, maxTokens int, emit func(string) bool) error {
+	url := fmt.Sprintf("%s/v1beta/models/%s:streamGenerateContent?alt=sse&key=%s", s.geminiBaseURL, model, s.geminiKey)
+
+	payload := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"parts": []map[string]string{
+					{"text": prompt},
+				},
+			},
+		},
+		"generationConfig": map[string]interface{}{
+			"maxOutputTokens": maxTokens,
+			"temperature":     0.7,
+		},
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Gemini API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return parseGeminiError(model, resp.StatusCode, body)
+	}
+
+	var decodeErr error
+	err = forEachStreamLine(resp.Body, func(line string) bool {
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			return true
+		}
+
+		var event struct {
+			Candidates []struct {
+				Content struct {
+					Parts []struct {
+						Text string `json:"text"`
+					} `json:"parts"`
+				} `json:"content"`
+			} `json:"candidates"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			decodeErr = fmt.Errorf("failed to decode stream event: %w", err)
+			return false
+		}
+		if len(event.Candidates) == 0 {
+			return true
+		}
+		for _, part := range event.Candidates[0].Content.Parts {
+			if !emit(part.Text) {
+				return false
+			}
+		}
+		return true
+	})
+	if decodeErr != nil {
+		return decodeErr
+	}
+	return err
+}
+
+// streamOllama consumes Ollama's newline-delimited JSON /api/generate stream
+func (s *AIService) streamOllama(ctx context.Context, prompt string, maxTokens int, emit func(string) bool) error {
+	url := fmt.Sprintf("%s/api/generate", s.ollamaHost)
+
+	payload := map[string]interface{}{
+		"model":  s.ollamaModel,
+		"prompt": prompt,
+		"stream": true,
+		"options": map[string]interface{}{
+			"num_predict": maxTokens,
+			"temperature": 0.7,
+		},
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Ollama API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return newAIError("Ollama", s.ollamaModel, resp.StatusCode, "", string(body))
+	}
+
+	var decodeErr error
+	err = forEachStreamLine(resp.Body, func(line string) bool {
+		if line == "" {
+			return true
+		}
+
+		var event struct {
+			Response string `json:"response"`
+			Done     bool   `json:"done"`
+		}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			decodeErr = fmt.Errorf("failed to decode stream event: %w", err)
+			return false
+		}
+		if !emit(event.Response) {
+			return false
+		}
+		return !event.Done
+	})
+	if decodeErr != nil {
+		return decodeErr
+	}
+	return err
+}
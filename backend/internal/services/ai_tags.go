@@ -0,0 +1,62 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+// tagListMarker matches list markers models sometimes put before a tag: "1.", "2)", "-", "*", "•", "#"
+var tagListMarker = regexp.MustCompile(`^(?:\d+[.)]|[-*•#])\s*`)
+
+// tagQuotes are stripped from around tags, including curly quotes
+const tagQuotes = "\"'`“”‘’"
+
+// parseTags turns a model's tag list into clean tags: split on commas or lines,
+// list markers and quotes stripped, lowercased, deduplicated in order, and capped at maxTags
+func parseTags(response string, maxTags int) []string {
+	fields := strings.FieldsFunc(response, func(r rune) bool {
+		return r == ',' || r == '\n' || r == ';'
+	})
+
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, field := range fields {
+		tag := normalizeTag(field)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+		if maxTags > 0 && len(tags) == maxTags {
+			break
+		}
+	}
+	return tags
+}
+
+// normalizeTag cleans one tag; it returns "" if nothing is left
+func normalizeTag(tag string) string {
+	tag = strings.Trim(strings.TrimSpace(tag), tagQuotes)
+	tag = tagListMarker.ReplaceAllString(strings.TrimSpace(tag), "")
+	tag = strings.Trim(strings.TrimSpace(tag), tagQuotes+".")
+	// Collapse inner runs of whitespace so "machine  learning" matches "machine learning"
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// mergeTags returns the given tags normalized, followed by generated tags not already among them
+func mergeTags(given, generated []string) []string {
+	if len(given) == 0 {
+		return generated
+	}
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, tag := range append(append([]string{}, given...), generated...) {
+		tag = normalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		maxTags  int
+		want     []string
+	}{
+		{"comma separated", "go, testing, tdd", 0, []string{"go", "testing", "tdd"}},
+		{"numbered lines", "1. Machine Learning\n2) Python\n3. Data", 0, []string{"machine learning", "python", "data"}},
+		{"bullets and hashes", "- golang\n* concurrency\n• channels\n#goroutines", 0, []string{"golang", "concurrency", "channels", "goroutines"}},
+		{"quotes", "\"travel\", 'japan', “food”, `tokyo`", 0, []string{"travel", "japan", "food", "tokyo"}},
+		{"case and whitespace duplicates", "Go, go ,  GO, machine  learning, Machine Learning", 0, []string{"go", "machine learning"}},
+		{"semicolons and trailing period", "recipes; baking; bread.", 0, []string{"recipes", "baking", "bread"}},
+		{"empty entries", ", ,\n\n-,", 0, []string{}},
+		{"capped", "a1, b2, c3, d4", 2, []string{"a1", "b2"}},
+		{"cap counts distinct tags", "x, x, y, z", 2, []string{"x", "y"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTags(tt.response, tt.maxTags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTags(%q, %d) = %q, want %q", tt.response, tt.maxTags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		given     []string
+		generated []string
+		want      []string
+	}{
+		{"none given", nil, []string{"go", "web"}, []string{"go", "web"}},
+		{"given first", []string{"Work"}, []string{"go", "web"}, []string{"work", "go", "web"}},
+		{"duplicates dropped", []string{"Go", " web "}, []string{"go", "api"}, []string{"go", "web", "api"}},
+		{"empty given tags dropped", []string{"", "  "}, []string{"go"}, []string{"go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeTags(tt.given, tt.generated); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeTags(%q, %q) = %q, want %q", tt.given, tt.generated, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import "sync"
+
+// AIUsage is a cumulative token count for AI calls
+type AIUsage struct {
+	Requests         int64 `json:"requests"`
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// AIUsageReport breaks cumulative usage down by provider
+type AIUsageReport struct {
+	Total      AIUsage            `json:"total"`
+	ByProvider map[string]AIUsage `json:"by_provider"`
+}
+
+// usageTracker accumulates token usage reported by provider responses
+type usageTracker struct {
+	mu         sync.Mutex
+	byProvider map[string]AIUsage
+}
+
+func newUsageTracker() *usageTracker {
+	return &usageTracker{byProvider: make(map[string]AIUsage)}
+}
+
+// record adds one call's usage; totalTokens may be 0 when the provider doesn't report it
+func (t *usageTracker) record(provider string, promptTokens, completionTokens, totalTokens int) {
+	if totalTokens == 0 {
+		totalTokens = promptTokens + completionTokens
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage := t.byProvider[provider]
+	usage.Requests++
+	usage.PromptTokens += int64(promptTokens)
+	usage.CompletionTokens += int64(completionTokens)
+	usage.TotalTokens += int64(totalTokens)
+	t.byProvider[provider] = usage
+}
+
+func (t *usageTracker) report() AIUsageReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := AIUsageReport{ByProvider: make(map[string]AIUsage, len(t.byProvider))}
+	for provider, usage := range t.byProvider {
+		report.ByProvider[provider] = usage
+		report.Total.Requests += usage.Requests
+		report.Total.PromptTokens += usage.PromptTokens
+		report.Total.CompletionTokens += usage.CompletionTokens
+		report.Total.TotalTokens += usage.TotalTokens
+	}
+	return report
+}
+
+// openAIUsage is the `usage` object returned by OpenAI-compatible APIs (OpenAI, LiteLLM)
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// geminiUsage is the `usageMetadata` object returned by Gemini
+type geminiUsage struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
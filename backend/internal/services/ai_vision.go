@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// ErrNoImageText is returned by ExtractImageText when the model finds nothing to read or describe
+var ErrNoImageText = errors.New("no text or description extracted from image")
+
+// ErrVisionUnsupported is returned by image calls when the AI provider can't read images
+var ErrVisionUnsupported = errors.New("AI provider does not support images")
+
+// visionProviders are the providers whose models accept images: Claude and
+// GPT-4o natively, Gemini's flash and pro models, and Ollama through
+// OLLAMA_VISION_MODEL
+var visionProviders = map[string]bool{
+	"claude": true,
+	"gemini": true,
+	"openai": true,
+	"ollama": true,
+}
+
+// SupportsVision reports whether the configured provider can read images.
+// Ollama can only when OLLAMA_VISION_MODEL names a model ("none" disables it).
+func (s *AIService) SupportsVision() bool {
+	provider := s.resolveProvider(s.provider)
+	if provider == "ollama" && s.ollamaVisionModel == "" {
+		return false
+	}
+	return visionProviders[provider] && s.hasCredentials(provider)
+}
+
+// imageData is an image attached to a vision prompt
+type imageData struct {
+	data     []byte
+	mimeType string
+}
+
+func (i imageData) base64() string {
+	return base64.StdEncoding.EncodeToString(i.data)
+}
+
+// chatContent is the content of an OpenAI-style chat message: the plain prompt,
+// or with images attached, a list of text and image_url parts carrying data URLs
+func chatContent(prompt string, images []imageData) interface{} {
+	if len(images) == 0 {
+		return prompt
+	}
+	parts := []map[string]interface{}{
+		{"type": "text", "text": prompt},
+	}
+	for _, image := range images {
+		parts = append(parts, map[string]interface{}{
+			"type":      "image_url",
+			"image_url": map[string]string{"url": "data:" + image.mimeType + ";base64," + image.base64()},
+		})
+	}
+	return parts
+}
+
+// ExtractImageText transcribes the text in an image with the provider's vision
+// model, or describes the image when it has little text, e.g. a photo
+func (s *AIService) ExtractImageText(ctx context.Context, data []byte, mimeType string) (string, error) {
+	prompt, err := s.prompts.render("image_text", promptData{MaxTokens: 1000})
+	if err != nil {
+		return "", err
+	}
+
+	text, err := s.generateVision(ctx, "image_text", prompt, 1000, imageData{data: data, mimeType: mimeType})
+	if err != nil {
+		return "", err
+	}
+	if text == "" {
+		return "", ErrNoImageText
+	}
+	return text, nil
+}
+
+// DescribeImage returns a one-paragraph description of what the image at
+// imageURL shows, for alt text and so images without text surface in semantic
+// search. It returns ErrVisionUnsupported when the provider can't read images.
+func (s *AIService) DescribeImage(ctx context.Context, imageURL string) (string, error) {
+	if !s.SupportsVision() {
+		return "", ErrVisionUnsupported
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	data, mimeType, err := loadImage(loadCtx, s.imageClient, imageURL)
+	cancel()
+	if err != nil {
+		return "", err
+	}
+
+	prompt, err := s.prompts.render("image_description", promptData{MaxTokens: 300})
+	if err != nil {
+		return "", err
+	}
+
+	description, err := s.generateVision(ctx, "image_description", prompt, 300, imageData{data: data, mimeType: mimeType})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(description), " "), nil
+}
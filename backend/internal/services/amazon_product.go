@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Product is the structured data of a shopping page. Price is nil when the
+// page doesn't show one (e.g. out of stock).
+type Product struct {
+	Title    string   `json:"title"`
+	Price    *float64 `json:"price,omitempty"`
+	Currency string   `json:"currency,omitempty"`
+	ImageURL string   `json:"image_url"`
+}
+
+// currencySymbols maps price prefixes/suffixes to ISO 4217 codes. Longer
+// symbols come first so "US$" isn't read as "$".
+var currencySymbols = []struct {
+	symbol string
+	code   string
+}{
+	{"US$", "USD"}, {"CA$", "CAD"}, {"A$", "AUD"}, {"R$", "BRL"}, {"₹", "INR"},
+	{"Rs.", "INR"}, {"€", "EUR"}, {"£", "GBP"}, {"¥", "JPY"}, {"$", "USD"},
+}
+
+// GetAmazonProduct fetches an Amazon product page and extracts its title,
+// current price, currency, and main image. JSON-LD Product data is preferred,
+// then product/Open Graph meta tags, then Amazon's own page elements.
+func (s *MetadataService) GetAmazonProduct(ctx context.Context, url string) (*Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept-Language", "en")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", url, resp.StatusCode)
+	}
+
+	product := parseProductPage(io.LimitReader(resp.Body, 4*1024*1024))
+	if product.Title == "" && product.Price == nil {
+		// Amazon serves a captcha page to clients it suspects are bots
+		return nil, fmt.Errorf("no product data found on %s", url)
+	}
+	return product, nil
+}
+
+// parseProductPage extracts product data from a page's HTML
+func parseProductPage(r io.Reader) *Product {
+	var ldJSON []string
+	var metaTitle, metaImage, metaPrice, metaCurrency string
+	var domTitle, domImage, domPrice string
+
+	tokenizer := html.NewTokenizer(r)
+	// capture is the element whose text is being collected, if any
+	var capture string
+	var text strings.Builder
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			product := productFromLDJSON(ldJSON)
+			setIfEmpty(&product.Title, metaTitle)
+			setIfEmpty(&product.Title, strings.Join(strings.Fields(domTitle), " "))
+			setIfEmpty(&product.ImageURL, metaImage)
+			setIfEmpty(&product.ImageURL, domImage)
+			if product.Price == nil {
+				if price, currency, ok := parseProductPrice(metaPrice); ok {
+					product.Price, product.Currency = &price, currency
+				} else if price, currency, ok := parseProductPrice(domPrice); ok {
+					product.Price, product.Currency = &price, currency
+				}
+			}
+			if metaCurrency != "" && product.Price != nil {
+				// An explicit currency code beats one guessed from a symbol
+				product.Currency = strings.ToUpper(metaCurrency)
+			}
+			return product
+		case html.TextToken:
+			if capture != "" {
+				text.Write(tokenizer.Text())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch {
+			case token.Data == "script" && strings.EqualFold(tokenAttr(token, "type"), "application/ld+json"):
+				capture = "ld+json"
+			case token.Data == "meta":
+				key, content := metaKeyAndContent(token)
+				switch key {
+				case "og:title":
+					setIfEmpty(&metaTitle, content)
+				case "og:image":
+					setIfEmpty(&metaImage, content)
+				case "product:price:amount", "og:price:amount":
+					setIfEmpty(&metaPrice, content)
+				case "product:price:currency", "og:price:currency":
+					setIfEmpty(&metaCurrency, content)
+				}
+			case tokenAttr(token, "id") == "productTitle":
+				capture = "title"
+			case token.Data == "img" && (tokenAttr(token, "id") == "landingImage" || tokenAttr(token, "id") == "imgBlkFront"):
+				// data-old-hires is the full-size image; src is often a placeholder
+				setIfEmpty(&domImage, tokenAttr(token, "data-old-hires"))
+				setIfEmpty(&domImage, tokenAttr(token, "src"))
+			case token.Data == "span" && domPrice == "" && strings.Contains(" "+tokenAttr(token, "class")+" ", " a-offscreen "):
+				// The first screen-reader price is the buy box price, e.g. "$1,299.99"
+				capture = "price"
+			}
+		case html.EndTagToken:
+			if capture == "" {
+				continue
+			}
+			switch capture {
+			case "ld+json":
+				ldJSON = append(ldJSON, text.String())
+			case "title":
+				setIfEmpty(&domTitle, text.String())
+			case "price":
+				setIfEmpty(&domPrice, strings.TrimSpace(text.String()))
+			}
+			capture = ""
+			text.Reset()
+		}
+	}
+}
+
+// productFromLDJSON returns the first schema.org Product found in the JSON-LD blocks
+func productFromLDJSON(blocks []string) *Product {
+	for _, block := range blocks {
+		var data interface{}
+		if err := json.Unmarshal([]byte(block), &data); err != nil {
+			continue
+		}
+		if product := findLDProduct(data); product != nil {
+			return product
+		}
+	}
+	return &Product{}
+}
+
+// findLDProduct walks a JSON-LD value (object, array, or @graph) for a Product
+func findLDProduct(data interface{}) *Product {
+	switch v := data.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if product := findLDProduct(item); product != nil {
+				return product
+			}
+		}
+	case map[string]interface{}:
+		if graph, ok := v["@graph"]; ok {
+			return findLDProduct(graph)
+		}
+		if !ldTypeIs(v["@type"], "Product") {
+			return nil
+		}
+		product := &Product{
+			Title:    ldString(v["name"]),
+			ImageURL: ldString(v["image"]),
+		}
+		offers := v["offers"]
+		if list, ok := offers.([]interface{}); ok && len(list) > 0 {
+			offers = list[0]
+		}
+		if offer, ok := offers.(map[string]interface{}); ok {
+			price := offer["price"]
+			if price == nil {
+				// AggregateOffer lists a range; the low price is what's shown first
+				price = offer["lowPrice"]
+			}
+			if amount, currency, ok := parseProductPrice(ldString(price)); ok {
+				product.Price = &amount
+				product.Currency = currency
+			}
+			if code := ldString(offer["priceCurrency"]); code != "" && product.Price != nil {
+				product.Currency = strings.ToUpper(code)
+			}
+		}
+		return product
+	}
+	return nil
+}
+
+// ldTypeIs reports whether a JSON-LD @type (a string or list of strings) includes want
+func ldTypeIs(value interface{}, want string) bool {
+	switch v := value.(type) {
+	case string:
+		return v == want
+	case []interface{}:
+		for _, t := range v {
+			if t == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ldString flattens a JSON-LD value to a string: numbers are formatted, lists
+// yield their first element, and objects their "url" (as for ImageObject)
+func ldString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		if len(v) > 0 {
+			return ldString(v[0])
+		}
+	case map[string]interface{}:
+		return ldString(v["url"])
+	}
+	return ""
+}
+
+// parseProductPrice parses a displayed price such as "$1,299.99", "₹ 2,499",
+// "19.99" or "1.299,99 €", returning the amount and the currency implied by its
+// symbol ("" if there is none)
+func parseProductPrice(raw string) (float64, string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, "", false
+	}
+
+	currency := ""
+	for _, c := range currencySymbols {
+		if strings.Contains(raw, c.symbol) {
+			currency = c.code
+			raw = strings.ReplaceAll(raw, c.symbol, "")
+			break
+		}
+	}
+
+	// Keep only the first run of digits and separators
+	var number strings.Builder
+	for _, r := range strings.TrimSpace(raw) {
+		if (r >= '0' && r <= '9') || r == ',' || r == '.' {
+			number.WriteRune(r)
+		} else if number.Len() > 0 {
+			break
+		}
+	}
+	digits := strings.Trim(number.String(), ".,")
+	if digits == "" {
+		return 0, "", false
+	}
+
+	// Whichever separator comes last is the decimal point if two digits or
+	// fewer follow it; otherwise every separator groups thousands
+	lastSep := strings.LastIndexAny(digits, ".,")
+	if lastSep >= 0 && len(digits)-lastSep-1 <= 2 {
+		whole := strings.NewReplacer(",", "", ".", "").Replace(digits[:lastSep])
+		digits = whole + "." + digits[lastSep+1:]
+	} else {
+		digits = strings.NewReplacer(",", "", ".", "").Replace(digits)
+	}
+
+	amount, err := strconv.ParseFloat(digits, 64)
+	if err != nil || amount <= 0 {
+		return 0, "", false
+	}
+	return amount, currency, true
+}
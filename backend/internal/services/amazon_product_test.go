@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProductPrice(t *testing.T) {
+	tests := []struct {
+		raw      string
+		amount   float64
+		currency string
+		ok       bool
+	}{
+		{"$1,299.99", 1299.99, "USD", true},
+		{"19.99", 19.99, "", true},
+		{"₹ 2,499", 2499, "INR", true},
+		{"1.299,99 €", 1299.99, "EUR", true},
+		{"£15", 15, "GBP", true},
+		{"US$ 42.50", 42.50, "USD", true},
+		{"CA$12", 12, "CAD", true},
+		{"¥1,200", 1200, "JPY", true},
+		{"$5.99 - $9.99", 5.99, "USD", true},
+		{"", 0, "", false},
+		{"Currently unavailable", 0, "", false},
+		{"$0.00", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		amount, currency, ok := parseProductPrice(tt.raw)
+		if ok != tt.ok || amount != tt.amount || currency != tt.currency {
+			t.Errorf("parseProductPrice(%q) = %v, %q, %v; want %v, %q, %v", tt.raw, amount, currency, ok, tt.amount, tt.currency, tt.ok)
+		}
+	}
+}
+
+func TestParseProductPage(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		title    string
+		price    float64 // 0 means no price
+		currency string
+		image    string
+	}{
+		{
+			name: "JSON-LD product",
+			html: `<script type="application/ld+json">{"@type": "Product", "name": "Kindle", "image": ["https://m.media-amazon.com/kindle.jpg"],
+				"offers": {"@type": "Offer", "price": 99.99, "priceCurrency": "usd"}}</script>`,
+			title: "Kindle", price: 99.99, currency: "USD", image: "https://m.media-amazon.com/kindle.jpg",
+		},
+		{
+			name: "JSON-LD graph with an aggregate offer",
+			html: `<script type="application/ld+json">{"@graph": [{"@type": "WebPage"}, {"@type": ["Thing", "Product"], "name": "Desk",
+				"image": {"url": "https://example.com/desk.jpg"}, "offers": {"lowPrice": "149.00", "priceCurrency": "EUR"}}]}</script>`,
+			title: "Desk", price: 149, currency: "EUR", image: "https://example.com/desk.jpg",
+		},
+		{
+			name: "meta tags",
+			html: `<meta property="og:title" content="Headphones"><meta property="og:image" content="https://example.com/hp.jpg">
+				<meta property="product:price:amount" content="249.00"><meta property="product:price:currency" content="gbp">`,
+			title: "Headphones", price: 249, currency: "GBP", image: "https://example.com/hp.jpg",
+		},
+		{
+			name: "Amazon page elements",
+			html: `<span id="productTitle">  Echo   Dot  </span>
+				<img id="landingImage" src="placeholder.gif" data-old-hires="https://m.media-amazon.com/echo.jpg">
+				<span class="a-price"><span class="a-offscreen">$49.99</span></span><span class="a-offscreen">$59.99</span>`,
+			title: "Echo Dot", price: 49.99, currency: "USD", image: "https://m.media-amazon.com/echo.jpg",
+		},
+		{
+			name: "JSON-LD wins over meta tags",
+			html: `<meta property="og:title" content="Amazon.com: Kindle"><meta property="og:price:amount" content="120">
+				<script type="application/ld+json">{"@type": "Product", "name": "Kindle", "offers": [{"price": "89.99"}]}</script>`,
+			title: "Kindle", price: 89.99,
+		},
+		{
+			name:  "out of stock",
+			html:  `<span id="productTitle">Sold out lamp</span><span class="a-offscreen">Currently unavailable</span>`,
+			title: "Sold out lamp",
+		},
+		{
+			name:  "malformed JSON-LD falls back to the page",
+			html:  `<script type="application/ld+json">{"@type": "Product",</script><meta property="og:title" content="Lamp">`,
+			title: "Lamp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := parseProductPage(strings.NewReader(tt.html))
+			if product.Title != tt.title {
+				t.Errorf("title = %q, want %q", product.Title, tt.title)
+			}
+			if product.ImageURL != tt.image {
+				t.Errorf("image = %q, want %q", product.ImageURL, tt.image)
+			}
+			switch {
+			case tt.price == 0 && product.Price != nil:
+				t.Errorf("price = %v, want none", *product.Price)
+			case tt.price != 0 && (product.Price == nil || *product.Price != tt.price):
+				t.Errorf("price = %v, want %v", product.Price, tt.price)
+			}
+			if product.Currency != tt.currency {
+				t.Errorf("currency = %q, want %q", product.Currency, tt.currency)
+			}
+		})
+	}
+}
+
+func TestGetAmazonProductRejectsPagesWithoutProductData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>Enter the characters you see below</body></html>`))
+	}))
+	defer server.Close()
+
+	s := &MetadataService{client: server.Client(), requestTimeout: 5 * time.Second}
+	if product, err := s.GetAmazonProduct(context.Background(), server.URL+"/dp/B000"); err == nil {
+		t.Errorf("GetAmazonProduct on a captcha page = %+v, want an error", product)
+	}
+}
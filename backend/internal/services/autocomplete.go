@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"synapse/internal/models"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// autocompleteLimit is how many suggestions Autocomplete returns; titles get
+// at least half of them when there are enough
+const autocompleteLimit = 8
+
+// autocompleteMinPrefix is the shortest prefix Autocomplete looks up. The
+// trigram indexes the lookups use can't narrow shorter ones, which would scan
+// every item.
+const autocompleteMinPrefix = 3
+
+// Autocomplete suggests userID's tags, then item titles, starting with prefix.
+// Prefixes shorter than autocompleteMinPrefix get no suggestions.
+func (s *ItemService) Autocomplete(ctx context.Context, userID uuid.UUID, prefix string) ([]models.Suggestion, error) {
+	prefix = strings.TrimSpace(prefix)
+	if utf8.RuneCountInString(prefix) < autocompleteMinPrefix {
+		return []models.Suggestion{}, nil
+	}
+
+	tags, err := s.itemRepo.AutocompleteTags(ctx, userID, prefix, autocompleteLimit)
+	if err != nil {
+		return nil, err
+	}
+	titles, err := s.itemRepo.AutocompleteTitles(ctx, userID, prefix, autocompleteLimit)
+	if err != nil {
+		return nil, err
+	}
+	return mergeSuggestions(tags, titles, autocompleteLimit), nil
+}
+
+// mergeSuggestions lists tags before titles, limit in all. Tags fill whatever
+// titles leave of the limit, up to half of it.
+func mergeSuggestions(tags, titles []models.Suggestion, limit int) []models.Suggestion {
+	tagCount := min(len(tags), limit-min(len(titles), limit/2))
+	suggestions := append(tags[:tagCount:tagCount], titles...)
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestMergeSuggestions(t *testing.T) {
+	suggestions := func(kind string, n int) []models.Suggestion {
+		out := make([]models.Suggestion, n)
+		for i := range out {
+			out[i] = models.Suggestion{Type: kind, Text: string(rune('a' + i))}
+		}
+		return out
+	}
+	count := func(list []models.Suggestion) (tags, titles int) {
+		for _, s := range list {
+			if s.Type == models.SuggestionTag {
+				tags++
+			} else {
+				titles++
+			}
+		}
+		return tags, titles
+	}
+
+	tests := []struct {
+		name                 string
+		tags, titles         int
+		wantTags, wantTitles int
+	}{
+		{"both plentiful", 8, 8, 4, 4},
+		{"few titles", 8, 2, 6, 2},
+		{"no titles", 8, 0, 8, 0},
+		{"few tags", 1, 8, 1, 7},
+		{"no tags", 0, 8, 0, 8},
+		{"both few", 2, 3, 2, 3},
+		{"none", 0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags := suggestions(models.SuggestionTag, tt.tags)
+			got := mergeSuggestions(tags, suggestions(models.SuggestionTitle, tt.titles), 8)
+			if gotTags, gotTitles := count(got); gotTags != tt.wantTags || gotTitles != tt.wantTitles {
+				t.Errorf("mergeSuggestions = %d tags and %d titles, want %d and %d", gotTags, gotTitles, tt.wantTags, tt.wantTitles)
+			}
+			// Tags come first, in the order given
+			if tt.wantTags > 0 && !reflect.DeepEqual(got[:tt.wantTags], tags[:tt.wantTags]) {
+				t.Errorf("mergeSuggestions tags = %v, want %v first", got[:tt.wantTags], tags[:tt.wantTags])
+			}
+		})
+	}
+}
+
+func TestAutocompleteShortPrefix(t *testing.T) {
+	// Short prefixes return before reaching the repository, which is nil here
+	s := &ItemService{}
+	for _, prefix := range []string{"", "  ", "k", "ku", " ku ", "日本"} {
+		got, err := s.Autocomplete(context.Background(), uuid.New(), prefix)
+		if err != nil || len(got) != 0 {
+			t.Errorf("Autocomplete(%q) = %v, %v; want no suggestions", prefix, got, err)
+		}
+	}
+}
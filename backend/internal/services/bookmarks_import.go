@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"synapse/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/html"
+)
+
+// bookmarkImportBatch is how many bookmarks are handed to BulkCreate at a time
+const bookmarkImportBatch = 500
+
+// rootBookmarkFolders are the folders browsers put every bookmark under; they
+// say nothing about the bookmark, so they don't become tags
+var rootBookmarkFolders = map[string]bool{
+	"bookmarks bar": true, "bookmarks toolbar": true, "bookmarks menu": true,
+	"other bookmarks": true, "mobile bookmarks": true, "favorites bar": true, "favorites": true,
+}
+
+// Bookmark is one link from a Netscape bookmark file
+type Bookmark struct {
+	URL     string
+	Title   string
+	AddedAt time.Time
+	// Folders is the path of folders the bookmark was filed under, outermost first
+	Folders []string
+	// Tags are the browser's own tags (Firefox's TAGS attribute)
+	Tags []string
+}
+
+// BookmarkImportError records a bookmark that couldn't be imported
+type BookmarkImportError struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// BookmarkImportReport summarizes a bookmark import
+type BookmarkImportReport struct {
+	Total   int `json:"total"`
+	Created int `json:"created"`
+	// Duplicates were already saved and were left alone
+	Duplicates int                   `json:"duplicates"`
+	Failed     int                   `json:"failed"`
+	Errors     []BookmarkImportError `json:"errors"`
+}
+
+// StartBookmarkImport reads a browser's Netscape-format bookmark export and
+// saves every bookmark in it as a "url" item of userID in the background,
+// returning the job tracking it; the job's result is a BookmarkImportReport.
+// Each item is backdated to its ADD_DATE, and the folders a bookmark was filed
+// under become its tags. The file is read before returning, so a malformed
+// file, or one with more than maxBookmarks bookmarks, is an error rather than
+// a failed job.
+func (s *ItemService) StartBookmarkImport(ctx context.Context, userID uuid.UUID, r io.Reader, maxBookmarks int) (*ImportJob, error) {
+	bookmarks, err := parseBookmarksHTML(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(bookmarks) == 0 {
+		return nil, fmt.Errorf("no bookmarks found; expected a Netscape bookmark HTML export")
+	}
+	if len(bookmarks) > maxBookmarks {
+		return nil, fmt.Errorf("found %d bookmarks; at most %d can be imported at once", len(bookmarks), maxBookmarks)
+	}
+
+	return s.startImportJob(ctx, userID, func(ctx context.Context) (any, error) {
+		return s.importBookmarks(ctx, userID, bookmarks)
+	}), nil
+}
+
+// importBookmarks saves bookmarks through BulkCreate's worker pool; ones
+// already saved are counted as duplicates rather than saved twice
+func (s *ItemService) importBookmarks(ctx context.Context, userID uuid.UUID, bookmarks []Bookmark) (*BookmarkImportReport, error) {
+	report := &BookmarkImportReport{Total: len(bookmarks), Errors: []BookmarkImportError{}}
+	for start := 0; start < len(bookmarks); start += bookmarkImportBatch {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		end := start + bookmarkImportBatch
+		if end > len(bookmarks) {
+			end = len(bookmarks)
+		}
+
+		reqs := make([]*models.CreateItemRequest, 0, end-start)
+		for _, bookmark := range bookmarks[start:end] {
+			reqs = append(reqs, bookmark.createRequest())
+		}
+
+		_, errs := s.BulkCreate(ctx, userID, reqs)
+		for i, err := range errs {
+			var dupErr *DuplicateItemError
+			switch {
+			case err == nil:
+				report.Created++
+			case errors.As(err, &dupErr):
+				report.Duplicates++
+			default:
+				report.Failed++
+				report.Errors = append(report.Errors, BookmarkImportError{URL: reqs[i].SourceURL, Error: err.Error()})
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// createRequest turns a bookmark into a "url" item request tagged with its folders
+func (b *Bookmark) createRequest() *models.CreateItemRequest {
+	var tags []string
+	for _, folder := range b.Folders {
+		if !rootBookmarkFolders[normalizeTag(folder)] {
+			tags = append(tags, folder)
+		}
+	}
+	return &models.CreateItemRequest{
+		Title:     b.Title,
+		SourceURL: b.URL,
+		Type:      "url",
+		Tags:      append(tags, b.Tags...),
+		CreatedAt: b.AddedAt,
+	}
+}
+
+// parseBookmarksHTML reads a Netscape bookmark file: each <DT><H3> names a
+// folder whose contents are the <DL> that follows it, and each <DT><A HREF>
+// is a bookmark. Links that aren't http(s), such as javascript: bookmarklets
+// and Firefox place: queries, are skipped.
+func parseBookmarksHTML(r io.Reader) ([]Bookmark, error) {
+	tokenizer := html.NewTokenizer(r)
+
+	var bookmarks []Bookmark
+	// folders is the open folder path; pendingFolder is the last <H3>, which
+	// names the next <DL>
+	var folders []string
+	var pendingFolder string
+	var current *Bookmark
+	inFolderName := false
+	var text strings.Builder
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, fmt.Errorf("failed to read bookmarks: %w", err)
+			}
+			return bookmarks, nil
+		case html.TextToken:
+			if current != nil || inFolderName {
+				text.Write(tokenizer.Text())
+			}
+		case html.StartTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "h3":
+				inFolderName = true
+				text.Reset()
+			case "dl":
+				folders = append(folders, pendingFolder)
+				pendingFolder = ""
+			case "a":
+				current = &Bookmark{
+					URL:     strings.TrimSpace(tokenAttr(token, "href")),
+					AddedAt: parseBookmarkDate(tokenAttr(token, "add_date")),
+					Folders: nonEmpty(folders),
+				}
+				for _, tag := range strings.Split(tokenAttr(token, "tags"), ",") {
+					if tag = strings.TrimSpace(tag); tag != "" {
+						current.Tags = append(current.Tags, tag)
+					}
+				}
+				text.Reset()
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "h3":
+				if inFolderName {
+					pendingFolder = strings.Join(strings.Fields(text.String()), " ")
+					inFolderName = false
+				}
+			case "dl":
+				if len(folders) > 0 {
+					folders = folders[:len(folders)-1]
+				}
+			case "a":
+				if current == nil {
+					continue
+				}
+				current.Title = strings.Join(strings.Fields(text.String()), " ")
+				if isHTTPURL(current.URL) {
+					if current.Title == "" {
+						current.Title = current.URL
+					}
+					bookmarks = append(bookmarks, *current)
+				}
+				current = nil
+			}
+		}
+	}
+}
+
+// parseBookmarkDate parses an ADD_DATE, which is Unix seconds; zero if missing or invalid
+func parseBookmarkDate(value string) time.Time {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
+
+// isHTTPURL reports whether value is an http or https link
+func isHTTPURL(value string) bool {
+	lower := strings.ToLower(value)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// nonEmpty copies the non-empty strings of values
+func nonEmpty(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
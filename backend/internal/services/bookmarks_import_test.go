@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestStartBookmarkImportRejectsTooManyBookmarks(t *testing.T) {
+	s := &ItemService{}
+	file := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<DL><p>
+<DT><A HREF="https://example.com/a">A</A>
+<DT><A HREF="https://example.com/b">B</A>
+<DT><A HREF="https://example.com/c">C</A>
+</DL><p>`
+
+	job, err := s.StartBookmarkImport(context.Background(), uuid.New(), strings.NewReader(file), 2)
+	if err == nil || !strings.Contains(err.Error(), "found 3 bookmarks") {
+		t.Fatalf("StartBookmarkImport = %v, %v; want an error naming the count", job, err)
+	}
+	if len(s.importJobs.jobs) != 0 {
+		t.Error("a job was started for a rejected file")
+	}
+}
+
+// chromeBookmarks is a Netscape bookmark export as Chrome writes it, with a
+// Firefox-style TAGS attribute and links that aren't web pages
+const chromeBookmarks = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file. -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1700000000" PERSONAL_TOOLBAR_FOLDER="true">Bookmarks bar</H3>
+    <DL><p>
+        <DT><A HREF="https://go.dev/" ADD_DATE="1700000100">The Go
+            Programming Language</A>
+        <DT><H3>Recipes</H3>
+        <DL><p>
+            <DT><H3>Soups</H3>
+            <DL><p>
+                <DT><A HREF="https://example.com/tomato-soup" ADD_DATE="1700000200" TAGS="dinner, vegetarian">Tomato soup</A>
+            </DL><p>
+            <DT><A HREF="HTTPS://example.com/bread">Bread</A>
+        </DL><p>
+        <DT><A HREF="javascript:alert('bookmarklet')">Bookmarklet</A>
+        <DT><A HREF="place:sort=8&maxResults=10">Most visited</A>
+    </DL><p>
+    <DT><A HREF="https://example.com/untitled" ADD_DATE="not a date"></A>
+</DL><p>
+`
+
+func TestParseBookmarksHTML(t *testing.T) {
+	bookmarks, err := parseBookmarksHTML(strings.NewReader(chromeBookmarks))
+	if err != nil {
+		t.Fatalf("parseBookmarksHTML: %v", err)
+	}
+
+	want := []Bookmark{
+		{URL: "https://go.dev/", Title: "The Go Programming Language", AddedAt: time.Unix(1700000100, 0).UTC(), Folders: []string{"Bookmarks bar"}},
+		{URL: "https://example.com/tomato-soup", Title: "Tomato soup", AddedAt: time.Unix(1700000200, 0).UTC(),
+			Folders: []string{"Bookmarks bar", "Recipes", "Soups"}, Tags: []string{"dinner", "vegetarian"}},
+		{URL: "HTTPS://example.com/bread", Title: "Bread", Folders: []string{"Bookmarks bar", "Recipes"}},
+		// Untitled bookmarks are named by their link; a bad ADD_DATE means now
+		{URL: "https://example.com/untitled", Title: "https://example.com/untitled"},
+	}
+	if !reflect.DeepEqual(bookmarks, want) {
+		t.Errorf("parseBookmarksHTML =\n%+v\nwant\n%+v", bookmarks, want)
+	}
+}
+
+func TestBookmarkCreateRequest(t *testing.T) {
+	added := time.Unix(1700000200, 0).UTC()
+	bookmark := Bookmark{
+		URL:     "https://example.com/tomato-soup",
+		Title:   "Tomato soup",
+		AddedAt: added,
+		Folders: []string{"Bookmarks bar", "Recipes", "Soups"},
+		Tags:    []string{"dinner"},
+	}
+
+	req := bookmark.createRequest()
+	// Browser root folders say nothing about the bookmark
+	if want := []string{"Recipes", "Soups", "dinner"}; !reflect.DeepEqual(req.Tags, want) {
+		t.Errorf("tags = %v, want %v", req.Tags, want)
+	}
+	if req.Type != "url" || req.SourceURL != bookmark.URL || req.Title != bookmark.Title || !req.CreatedAt.Equal(added) {
+		t.Errorf("request = %+v, want a url item backdated to %v", req, added)
+	}
+}
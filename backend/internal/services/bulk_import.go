@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"synapse/internal/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// embeddingBatchWait is how long the bulk import batcher holds an embedding
+// request waiting for others to share a provider call with
+const embeddingBatchWait = 100 * time.Millisecond
+
+// StartBulkCreate imports reqs in the background and returns the job tracking
+// it; the job's result is a models.BulkCreateItemsResponse. Requests with no
+// title, content, or source_url fail without stopping the rest.
+func (s *ItemService) StartBulkCreate(ctx context.Context, userID uuid.UUID, reqs []models.CreateItemRequest) *ImportJob {
+	return s.startImportJob(ctx, userID, func(ctx context.Context) (any, error) {
+		return s.bulkCreateResponse(ctx, userID, reqs), nil
+	})
+}
+
+// bulkCreateResponse saves reqs with BulkCreate and reports each one's outcome
+// in request order
+func (s *ItemService) bulkCreateResponse(ctx context.Context, userID uuid.UUID, reqs []models.CreateItemRequest) *models.BulkCreateItemsResponse {
+	results := make([]models.BulkCreateItemResult, len(reqs))
+
+	// Same validation as CreateItem
+	var valid []*models.CreateItemRequest
+	var validIndexes []int
+	for i := range reqs {
+		req := &reqs[i]
+		if req.Title == "" && req.Content == "" && req.SourceURL == "" {
+			results[i].Error = "title, content, or source_url is required"
+			continue
+		}
+		valid = append(valid, req)
+		validIndexes = append(validIndexes, i)
+	}
+
+	items, errs := s.BulkCreate(ctx, userID, valid)
+	for j, i := range validIndexes {
+		if errs[j] != nil {
+			results[i].Error = errs[j].Error()
+			var dupErr *DuplicateItemError
+			if errors.As(errs[j], &dupErr) {
+				results[i].DuplicateOf = &dupErr.Existing.ID
+			}
+			continue
+		}
+		results[i].Item = items[j]
+	}
+
+	response := &models.BulkCreateItemsResponse{Results: results}
+	for _, result := range results {
+		if result.Error != "" {
+			response.Failed++
+		} else {
+			response.Created++
+		}
+	}
+	return response
+}
+
+// BulkCreate saves many items, e.g. an imported bookmark collection, using a
+// pool of s.bulkConcurrency workers. Embedding requests from concurrent saves
+// are batched into single provider calls. Each request succeeds or fails on
+// its own: items[i] and errs[i] describe reqs[i], and AI failures for
+// summaries or tags still save the item, exactly as in CreateItem.
+func (s *ItemService) BulkCreate(ctx context.Context, userID uuid.UUID, reqs []*models.CreateItemRequest) ([]*models.Item, []error) {
+	items := make([]*models.Item, len(reqs))
+	errs := make([]error, len(reqs))
+
+	if len(reqs) == 0 {
+		return items, errs
+	}
+
+	workers := s.bulkConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(reqs) {
+		workers = len(reqs)
+	}
+	batcher := newEmbeddingBatcher(ctx, s.aiService, workers, s.logger)
+	defer batcher.Close()
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				items[i], errs[i] = s.createItem(ctx, userID, reqs[i], batcher.Embed)
+			}
+		}()
+	}
+
+	for i := range reqs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return items, errs
+}
+
+// embeddingBatcher coalesces concurrent single-text embedding requests into
+// GenerateEmbeddings calls of up to maxBatch texts. Shared calls run under the
+// batcher's own context rather than any one caller's, so one caller giving up
+// doesn't fail the others' embeddings.
+type embeddingBatcher struct {
+	ctx       context.Context
+	aiService AIProvider
+	logger    *slog.Logger
+	maxBatch  int
+	requests  chan embeddingRequest
+	done      chan struct{}
+}
+
+type embeddingRequest struct {
+	ctx    context.Context
+	text   string
+	result chan embeddingResponse
+}
+
+type embeddingResponse struct {
+	embedding []float32
+	err       error
+}
+
+func newEmbeddingBatcher(ctx context.Context, aiService AIProvider, maxBatch int, logger *slog.Logger) *embeddingBatcher {
+	if maxBatch > embeddingBatchSize {
+		maxBatch = embeddingBatchSize
+	}
+	if maxBatch < 1 {
+		maxBatch = 1
+	}
+	b := &embeddingBatcher{
+		ctx:       ctx,
+		aiService: aiService,
+		logger:    logger,
+		maxBatch:  maxBatch,
+		requests:  make(chan embeddingRequest),
+		done:      make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Embed has the same signature as AIService.GenerateEmbedding
+func (b *embeddingBatcher) Embed(ctx context.Context, text string) ([]float32, error) {
+	req := embeddingRequest{ctx: ctx, text: text, result: make(chan embeddingResponse, 1)}
+	select {
+	case b.requests <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	res := <-req.result
+	return res.embedding, res.err
+}
+
+// Close stops the batcher once all Embed calls have returned
+func (b *embeddingBatcher) Close() {
+	close(b.requests)
+	<-b.done
+}
+
+func (b *embeddingBatcher) run() {
+	defer close(b.done)
+	for {
+		first, ok := <-b.requests
+		if !ok {
+			return
+		}
+		batch := []embeddingRequest{first}
+
+		timer := time.NewTimer(embeddingBatchWait)
+	collect:
+		for len(batch) < b.maxBatch {
+			select {
+			case req, ok := <-b.requests:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, req)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		b.flush(batch)
+	}
+}
+
+// flush embeds a batch in one call, retrying one at a time if the batch fails
+// so a single bad input doesn't fail the rest
+func (b *embeddingBatcher) flush(batch []embeddingRequest) {
+	texts := make([]string, len(batch))
+	for i, req := range batch {
+		texts[i] = req.text
+	}
+
+	embeddings, err := b.aiService.GenerateEmbeddings(b.ctx, texts)
+	if err == nil {
+		for i, req := range batch {
+			req.result <- embeddingResponse{embedding: embeddings[i]}
+		}
+		return
+	}
+
+	if len(batch) > 1 {
+		b.logger.WarnContext(b.ctx, "batch embedding failed, retrying items individually", "operation", "bulk_import", "batch_size", len(batch), "error", err)
+	}
+	for _, req := range batch {
+		embedding, err := b.aiService.GenerateEmbedding(req.ctx, req.text)
+		req.result <- embeddingResponse{embedding: embedding, err: err}
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// batchingAI embeds each text as a vector holding its length, recording every
+// call. Batches fail when failBatch is set, and the text bad always fails.
+type batchingAI struct {
+	AIProvider
+	failBatch bool
+	bad       string
+
+	mu      sync.Mutex
+	batches [][]string
+	singles []string
+}
+
+func (f *batchingAI) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	f.mu.Lock()
+	f.batches = append(f.batches, texts)
+	f.mu.Unlock()
+	if f.failBatch {
+		return nil, errors.New("batch failed")
+	}
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = []float32{float32(len(text))}
+	}
+	return embeddings, nil
+}
+
+func (f *batchingAI) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	f.mu.Lock()
+	f.singles = append(f.singles, text)
+	f.mu.Unlock()
+	if text == f.bad {
+		return nil, errors.New("bad input")
+	}
+	return []float32{float32(len(text))}, nil
+}
+
+// embedAll calls b.Embed for every text at once, returning the results in order
+func embedAll(b *embeddingBatcher, texts []string) ([][]float32, []error) {
+	embeddings := make([][]float32, len(texts))
+	errs := make([]error, len(texts))
+	var wg sync.WaitGroup
+	for i, text := range texts {
+		wg.Add(1)
+		go func(i int, text string) {
+			defer wg.Done()
+			embeddings[i], errs[i] = b.Embed(context.Background(), text)
+		}(i, text)
+	}
+	wg.Wait()
+	return embeddings, errs
+}
+
+func TestEmbeddingBatcher(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	texts := []string{"a", "bb", "ccc", "dddd"}
+
+	tests := []struct {
+		name        string
+		ai          *batchingAI
+		wantErrs    []bool
+		wantBatches int
+		wantSingles int
+	}{
+		{"one call for concurrent requests", &batchingAI{}, []bool{false, false, false, false}, 1, 0},
+		{"failed batch is retried one at a time", &batchingAI{failBatch: true}, []bool{false, false, false, false}, 1, 4},
+		{"one bad input fails alone", &batchingAI{failBatch: true, bad: "ccc"}, []bool{false, false, true, false}, 1, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newEmbeddingBatcher(context.Background(), tt.ai, len(texts), logger)
+			embeddings, errs := embedAll(b, texts)
+			b.Close()
+
+			for i, text := range texts {
+				if gotErr := errs[i] != nil; gotErr != tt.wantErrs[i] {
+					t.Errorf("Embed(%q) error = %v, want error %v", text, errs[i], tt.wantErrs[i])
+					continue
+				}
+				// Each caller gets the vector for its own text
+				if want := []float32{float32(len(text))}; errs[i] == nil && !reflect.DeepEqual(embeddings[i], want) {
+					t.Errorf("Embed(%q) = %v, want %v", text, embeddings[i], want)
+				}
+			}
+			if len(tt.ai.batches) != tt.wantBatches || len(tt.ai.singles) != tt.wantSingles {
+				t.Errorf("%d batch and %d single calls, want %d and %d", len(tt.ai.batches), len(tt.ai.singles), tt.wantBatches, tt.wantSingles)
+			}
+			if len(tt.ai.batches) > 0 && len(tt.ai.batches[0]) != len(texts) {
+				t.Errorf("batch of %d texts, want all %d", len(tt.ai.batches[0]), len(texts))
+			}
+		})
+	}
+}
+
+func TestEmbeddingBatcherSplitsAtMaxBatch(t *testing.T) {
+	ai := &batchingAI{}
+	b := newEmbeddingBatcher(context.Background(), ai, 2, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	_, errs := embedAll(b, []string{"a", "bb", "ccc", "dddd", "eeeee"})
+	b.Close()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("Embed: %v", err)
+		}
+	}
+	embedded := 0
+	for _, batch := range ai.batches {
+		if len(batch) > 2 {
+			t.Errorf("batch of %d texts, want at most 2", len(batch))
+		}
+		embedded += len(batch)
+	}
+	if embedded != 5 {
+		t.Errorf("%d texts embedded, want 5", embedded)
+	}
+}
+
+func TestNewEmbeddingBatcherClampsMaxBatch(t *testing.T) {
+	tests := []struct {
+		maxBatch int
+		want     int
+	}{
+		{0, 1},
+		{-3, 1},
+		{8, 8},
+		{embeddingBatchSize + 1, embeddingBatchSize},
+	}
+
+	for _, tt := range tests {
+		b := newEmbeddingBatcher(context.Background(), &batchingAI{}, tt.maxBatch, nil)
+		b.Close()
+		if b.maxBatch != tt.want {
+			t.Errorf("newEmbeddingBatcher(%d).maxBatch = %d, want %d", tt.maxBatch, b.maxBatch, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrCollectionNameTaken is returned when a user already has a collection with that name
+var ErrCollectionNameTaken = errors.New("a collection with that name already exists")
+
+// ErrCollectionNameRequired is returned when creating or renaming a collection to a blank name
+var ErrCollectionNameRequired = errors.New("collection name is required")
+
+type CollectionService struct {
+	collectionRepo *repository.CollectionRepository
+	itemRepo       *repository.ItemRepository
+	// changeHooks are called with the owner after a collection's items change
+	changeHooks []func(userID uuid.UUID)
+}
+
+func NewCollectionService(collectionRepo *repository.CollectionRepository, itemRepo *repository.ItemRepository) *CollectionService {
+	return &CollectionService{
+		collectionRepo: collectionRepo,
+		itemRepo:       itemRepo,
+	}
+}
+
+// OnItemsChanged registers fn to be called with the owner whenever items are
+// added to or removed from one of their collections, or a collection is
+// deleted, e.g. to drop cached searches of the collection. Register hooks
+// before serving requests.
+func (s *CollectionService) OnItemsChanged(fn func(userID uuid.UUID)) {
+	s.changeHooks = append(s.changeHooks, fn)
+}
+
+func (s *CollectionService) itemsChanged(userID uuid.UUID) {
+	for _, fn := range s.changeHooks {
+		fn(userID)
+	}
+}
+
+// CreateCollection creates an empty collection owned by userID
+func (s *CollectionService) CreateCollection(ctx context.Context, userID uuid.UUID, req *models.CreateCollectionRequest) (*models.Collection, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrCollectionNameRequired
+	}
+
+	collection := &models.Collection{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Name:        name,
+		Description: req.Description,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.collectionRepo.Create(ctx, collection); err != nil {
+		return nil, collectionError(err)
+	}
+	return collection, nil
+}
+
+func (s *CollectionService) GetCollections(ctx context.Context, userID uuid.UUID) ([]models.Collection, error) {
+	return s.collectionRepo.GetAll(ctx, userID)
+}
+
+func (s *CollectionService) GetCollection(ctx context.Context, userID, id uuid.UUID) (*models.Collection, error) {
+	return s.collectionRepo.GetByID(ctx, userID, id)
+}
+
+// UpdateCollection renames or re-describes a collection
+func (s *CollectionService) UpdateCollection(ctx context.Context, userID, id uuid.UUID, req *models.UpdateCollectionRequest) (*models.Collection, error) {
+	collection, err := s.collectionRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		collection.Name = strings.TrimSpace(*req.Name)
+		if collection.Name == "" {
+			return nil, ErrCollectionNameRequired
+		}
+	}
+	if req.Description != nil {
+		collection.Description = *req.Description
+	}
+
+	if err := s.collectionRepo.Update(ctx, collection); err != nil {
+		return nil, collectionError(err)
+	}
+	return collection, nil
+}
+
+// DeleteCollection deletes a collection; the items in it are kept
+func (s *CollectionService) DeleteCollection(ctx context.Context, userID, id uuid.UUID) error {
+	if err := s.collectionRepo.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+	s.itemsChanged(userID)
+	return nil
+}
+
+// AddItemToCollection puts one of userID's items in one of their collections.
+// Either not belonging to userID is reported as pgx.ErrNoRows.
+func (s *CollectionService) AddItemToCollection(ctx context.Context, userID, collectionID, itemID uuid.UUID) error {
+	if _, err := s.collectionRepo.GetByID(ctx, userID, collectionID); err != nil {
+		return err
+	}
+	if _, err := s.itemRepo.GetByID(ctx, userID, itemID); err != nil {
+		return err
+	}
+	if err := s.collectionRepo.AddItem(ctx, collectionID, itemID); err != nil {
+		return err
+	}
+	s.itemsChanged(userID)
+	return nil
+}
+
+// RemoveItemFromCollection takes an item out of a collection without deleting it
+func (s *CollectionService) RemoveItemFromCollection(ctx context.Context, userID, collectionID, itemID uuid.UUID) error {
+	if _, err := s.collectionRepo.GetByID(ctx, userID, collectionID); err != nil {
+		return err
+	}
+	if err := s.collectionRepo.RemoveItem(ctx, collectionID, itemID); err != nil {
+		return err
+	}
+	s.itemsChanged(userID)
+	return nil
+}
+
+// ListItemsInCollection returns the items in a collection, most recently added first
+func (s *CollectionService) ListItemsInCollection(ctx context.Context, userID, collectionID uuid.UUID) ([]models.Item, error) {
+	if _, err := s.collectionRepo.GetByID(ctx, userID, collectionID); err != nil {
+		return nil, err
+	}
+	return s.itemRepo.GetByCollection(ctx, userID, collectionID)
+}
+
+// collectionError maps a unique-name violation to ErrCollectionNameTaken
+func collectionError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrCollectionNameTaken
+	}
+	return fmt.Errorf("failed to save collection: %w", err)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Hosts whose links are almost always one kind of content. A domain matches
+// itself and its subdomains.
+var (
+	amazonHosts = append(withTLDs("amazon", amazonTLDs), "amzn.to", "amzn.eu", "a.co")
+	videoHosts  = []string{"youtube.com", "youtu.be", "vimeo.com", "tiktok.com", "twitch.tv", "dailymotion.com"}
+	bookHosts   = append([]string{"goodreads.com", "openlibrary.org", "bookshop.org"}, withTLDs("books.google", googleBooksTLDs)...)
+	blogHosts   = []string{"medium.com", "substack.com", "dev.to", "hashnode.dev", "blogspot.com", "wordpress.com", "ghost.io"}
+	recipeHosts = []string{"allrecipes.com", "seriouseats.com", "bonappetit.com", "epicurious.com", "foodnetwork.com", "budgetbytes.com"}
+)
+
+// The top-level domains of Amazon's and Google Books' regional stores. Listing
+// them keeps lookalikes such as amazon.evil.example from matching.
+var (
+	amazonTLDs = []string{
+		"com", "ca", "com.mx", "com.br", "co.uk", "de", "fr", "it", "es", "nl", "se", "pl", "com.be",
+		"com.tr", "ae", "sa", "eg", "in", "co.jp", "sg", "com.au", "cn",
+	}
+	googleBooksTLDs = []string{"com", "ca", "co.uk", "de", "fr", "it", "es", "nl", "co.in", "co.jp", "com.au", "com.br"}
+)
+
+// withTLDs returns name under each of tlds: "amazon" and "co.uk" make "amazon.co.uk"
+func withTLDs(name string, tlds []string) []string {
+	domains := make([]string, len(tlds))
+	for i, tld := range tlds {
+		domains[i] = name + "." + tld
+	}
+	return domains
+}
+
+var (
+	imagePathPattern  = regexp.MustCompile(`(?i)\.(png|jpe?g|gif|webp|svg|avif)$`)
+	blogPathPattern   = regexp.MustCompile(`(?i)/(blog|posts?|articles?|news)/|/\d{4}/\d{2}/`)
+	recipeTextPattern = regexp.MustCompile(`(?i)\bingredients\b`)
+	recipeStepPattern = regexp.MustCompile(`(?i)\b(instructions|directions|method|preheat)\b`)
+)
+
+// detectContentType infers an item type for saves that don't specify one,
+// using the same types the extension sends: video, amazon, book, recipe,
+// blog, image, url, or text for notes without a link.
+func (s *ItemService) detectContentType(title, content, sourceURL string) string {
+	text := title + "\n" + content
+	looksLikeRecipe := recipeTextPattern.MatchString(text) && recipeStepPattern.MatchString(text)
+
+	if sourceURL == "" {
+		if looksLikeRecipe {
+			return "recipe"
+		}
+		if extractISBN(text) != "" {
+			return "book"
+		}
+		return "text"
+	}
+
+	parsed, err := url.Parse(sourceURL)
+	if err != nil || parsed.Host == "" {
+		return "url"
+	}
+	host := strings.ToLower(parsed.Hostname())
+	path := strings.ToLower(parsed.Path)
+
+	switch {
+	case hostMatches(host, videoHosts):
+		return "video"
+	case hostMatches(host, amazonHosts):
+		return "amazon"
+	case hostMatches(host, bookHosts) || extractISBN(text) != "":
+		return "book"
+	case hostMatches(host, recipeHosts) || strings.Contains(path, "/recipe") || looksLikeRecipe:
+		return "recipe"
+	case imagePathPattern.MatchString(path):
+		return "image"
+	case hostMatches(host, blogHosts) || blogPathPattern.MatchString(path):
+		return "blog"
+	}
+	return "url"
+}
+
+// hostMatches reports whether host is, or is a subdomain of, one of domains.
+// Matching whole labels keeps "notyoutube.com" from counting as YouTube.
+func hostMatches(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package services
+
+import "testing"
+
+func TestHostMatches(t *testing.T) {
+	tests := []struct {
+		host    string
+		domains []string
+		want    bool
+	}{
+		{"youtube.com", videoHosts, true},
+		{"www.youtube.com", videoHosts, true},
+		{"m.youtube.com", videoHosts, true},
+		{"notyoutube.com", videoHosts, false},
+		{"youtube.com.evil.example", videoHosts, false},
+		{"dev.to", blogHosts, true},
+		{"mydev.to", blogHosts, false},
+		{"books.google.com", bookHosts, true},
+		{"books.google.co.uk", bookHosts, true},
+		{"mybooks.google.com", bookHosts, false},
+		{"www.amazon.de", amazonHosts, true},
+		{"smile.amazon.co.uk", amazonHosts, true},
+		{"amzn.to", amazonHosts, true},
+		{"notamazon.com", amazonHosts, false},
+		{"amazon.evil.example", amazonHosts, false},
+		{"www.amazon.com.evil.example", amazonHosts, false},
+		{"amazon.co.uk.attacker.net", amazonHosts, false},
+		{"amazon.example", amazonHosts, false},
+		{"books.google.evil.example", bookHosts, false},
+	}
+
+	for _, tt := range tests {
+		if got := hostMatches(tt.host, tt.domains); got != tt.want {
+			t.Errorf("hostMatches(%q, %q) = %v, want %v", tt.host, tt.domains, got, tt.want)
+		}
+	}
+}
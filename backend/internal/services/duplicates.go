@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"synapse/internal/db"
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrDuplicate is wrapped by DuplicateItemError; match it with errors.Is
+var ErrDuplicate = errors.New("duplicate item")
+
+// DuplicateItemError is returned by CreateItem when the item was already saved
+type DuplicateItemError struct {
+	Existing *models.Item
+	// Reason is "source_url" for the same link or "similar_content" for a near-duplicate
+	Reason     string
+	Similarity float64
+}
+
+func (e *DuplicateItemError) Error() string {
+	return fmt.Sprintf("duplicate of item %s (%s)", e.Existing.ID, e.Reason)
+}
+
+func (e *DuplicateItemError) Unwrap() error {
+	return ErrDuplicate
+}
+
+// findDuplicateByURL returns the item already saved from the same link, or nil
+func (s *ItemService) findDuplicateByURL(ctx context.Context, userID uuid.UUID, sourceURL string) (*models.Item, error) {
+	normalized := normalizeURL(sourceURL)
+	if normalized == "" {
+		return nil, nil
+	}
+
+	item, err := s.itemRepo.GetBySourceURL(ctx, userID, normalized)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return item, err
+}
+
+// findNearDuplicate returns userID's most similar saved item if it is at least
+// s.duplicateSimilarity similar to embedding, or nil
+func (s *ItemService) findNearDuplicate(ctx context.Context, userID uuid.UUID, embedding []float32) (*models.Item, float64, error) {
+	ids, distances, _, err := db.Chroma.Query(s.collectionName, embedding, 1, userWhere(userID))
+	if err != nil || len(ids) == 0 {
+		return nil, 0, err
+	}
+
+	// Same distance-to-similarity conversion as semantic search
+	similarity := db.Similarity(distances[0])
+	if similarity < s.duplicateSimilarity {
+		return nil, 0, nil
+	}
+
+	itemID, err := uuid.Parse(ids[0])
+	if err != nil {
+		return nil, 0, nil
+	}
+	item, err := s.itemRepo.GetByID(ctx, userID, itemID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Stale vector for a deleted item, or (for the unfiltered default user) someone else's
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return item, similarity, nil
+}
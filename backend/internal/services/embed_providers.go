@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// embedResult is what an embed provider produces for a matched URL
+type embedResult struct {
+	EmbedHTML  string
+	ImageURL   string
+	Title      string
+	AuthorName string
+}
+
+// embedProvider recognises URLs from one media site and builds a player embed for them
+type embedProvider struct {
+	name string
+	// match returns the provider-specific media ID when the URL belongs to this provider
+	match func(s *MetadataService, pageURL string) (string, bool)
+	embed func(ctx context.Context, s *MetadataService, pageURL, id string) (*embedResult, error)
+}
+
+// embedProviders is checked in order by GetURLMetadata; add new media sites here
+var embedProviders = []embedProvider{
+	{
+		name: "youtube",
+		match: func(s *MetadataService, pageURL string) (string, bool) {
+			if !strings.Contains(pageURL, "youtube.com") && !strings.Contains(pageURL, "youtu.be") {
+				return "", false
+			}
+			id := s.extractYouTubeID(pageURL)
+			return id, id != ""
+		},
+		embed: func(ctx context.Context, s *MetadataService, pageURL, id string) (*embedResult, error) {
+			result := &embedResult{
+				EmbedHTML: responsiveIframe(fmt.Sprintf("https://www.youtube.com/embed/%s?rel=0", id)),
+				// maxresdefault.jpg 404s for many videos; hqdefault always exists
+				ImageURL: fmt.Sprintf("https://img.youtube.com/vi/%s/hqdefault.jpg", id),
+			}
+			s.applyOEmbed(ctx, "https://www.youtube.com/oembed", pageURL, result)
+			return result, nil
+		},
+	},
+	{
+		name:  "vimeo",
+		match: regexpMatcher(`(?:^|//)(?:www\.|player\.)?vimeo\.com/(?:video/|channels/[^/]+/|groups/[^/]+/videos/)?(\d+)`),
+		embed: func(ctx context.Context, s *MetadataService, pageURL, id string) (*embedResult, error) {
+			result := &embedResult{
+				EmbedHTML: responsiveIframe(fmt.Sprintf("https://player.vimeo.com/video/%s", id)),
+			}
+			s.applyOEmbed(ctx, "https://vimeo.com/api/oembed.json", pageURL, result)
+			return result, nil
+		},
+	},
+	{
+		name:  "spotify",
+		match: regexpMatcher(`open\.spotify\.com/(?:intl-[a-z-]+/)?((?:track|album|playlist|episode|show|artist)/[A-Za-z0-9]+)`),
+		embed: func(ctx context.Context, s *MetadataService, pageURL, id string) (*embedResult, error) {
+			result := &embedResult{
+				EmbedHTML: responsiveIframe(fmt.Sprintf("https://open.spotify.com/embed/%s", id)),
+			}
+			s.applyOEmbed(ctx, "https://open.spotify.com/oembed", pageURL, result)
+			return result, nil
+		},
+	},
+	{
+		name:  "soundcloud",
+		match: regexpMatcher(`(?:^|//)(?:www\.|m\.)?soundcloud\.com/([^/?#]+/[^?#]+)`),
+		embed: func(ctx context.Context, s *MetadataService, pageURL, id string) (*embedResult, error) {
+			// SoundCloud's player is addressed by the track URL rather than an ID
+			playerURL := "https://w.soundcloud.com/player/?url=" + url.QueryEscape("https://soundcloud.com/"+id)
+			result := &embedResult{
+				EmbedHTML: responsiveIframe(playerURL),
+			}
+			s.applyOEmbed(ctx, "https://soundcloud.com/oembed", pageURL, result)
+			return result, nil
+		},
+	},
+	{
+		name: "twitter",
+		match: func(s *MetadataService, pageURL string) (string, bool) {
+			_, id, ok := parseTweetURL(pageURL)
+			return id, ok
+		},
+		embed: func(ctx context.Context, s *MetadataService, pageURL, id string) (*embedResult, error) {
+			handle, _, _ := parseTweetURL(pageURL)
+			result := &embedResult{
+				EmbedHTML: responsiveIframe(tweetEmbedURL(id)),
+			}
+			s.applyOEmbed(ctx, "https://publish.twitter.com/oembed", canonicalTweetURL(handle, id), result)
+			// Twitter's oEmbed has no title, so posts are named by their author
+			if result.Title == "" {
+				result.Title = (&Tweet{AuthorName: result.AuthorName, AuthorHandle: handle}).Title()
+			}
+			if result.AuthorName == "" {
+				result.AuthorName = "@" + handle
+			}
+			return result, nil
+		},
+	},
+}
+
+// regexpMatcher builds a match func that returns the pattern's first capture group
+func regexpMatcher(pattern string) func(*MetadataService, string) (string, bool) {
+	re := regexp.MustCompile(pattern)
+	return func(_ *MetadataService, pageURL string) (string, bool) {
+		matches := re.FindStringSubmatch(pageURL)
+		if len(matches) > 1 {
+			return matches[1], true
+		}
+		return "", false
+	}
+}
+
+// responsiveIframe generates iframe HTML that fills the frontend's embed wrapper
+func responsiveIframe(src string) string {
+	return fmt.Sprintf(`<iframe width="100%%" height="100%%" src="%s" frameborder="0" allow="accelerometer; autoplay; clipboard-write; encrypted-media; gyroscope; picture-in-picture; web-share" allowfullscreen style="position: absolute; top: 0; left: 0; width: 100%%; height: 100%%;"></iframe>`, src)
+}
+
+// oEmbedResponse is the subset of the oEmbed spec we use
+type oEmbedResponse struct {
+	Title        string `json:"title"`
+	AuthorName   string `json:"author_name"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	HTML         string `json:"html"`
+}
+
+// fetchOEmbed queries an oEmbed endpoint for the given page URL
+func (s *MetadataService) fetchOEmbed(ctx context.Context, endpoint, pageURL string) (*oEmbedResponse, error) {
+	requestURL := fmt.Sprintf("%s?format=json&url=%s", endpoint, url.QueryEscape(pageURL))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oEmbed request failed: status %d", resp.StatusCode)
+	}
+
+	var result oEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode oEmbed response: %w", err)
+	}
+	return &result, nil
+}
+
+// applyOEmbed fills in title, author, and thumbnail from oEmbed. Failures are
+// ignored: the player embed built from the URL works without them.
+func (s *MetadataService) applyOEmbed(ctx context.Context, endpoint, pageURL string, result *embedResult) {
+	oembed, err := s.fetchOEmbed(ctx, endpoint, pageURL)
+	if err != nil {
+		return
+	}
+	setIfNotEmpty(&result.Title, oembed.Title)
+	setIfNotEmpty(&result.AuthorName, oembed.AuthorName)
+	setIfNotEmpty(&result.ImageURL, oembed.ThumbnailURL)
+}
+
+// setIfNotEmpty overwrites field only when value is non-empty, keeping URL-derived defaults
+func setIfNotEmpty(field *string, value string) {
+	if value != "" {
+		*field = value
+	}
+}
+
+// matchEmbedProvider returns the embed for the first registered provider matching the URL
+func (s *MetadataService) matchEmbedProvider(ctx context.Context, pageURL string) (*embedResult, bool) {
+	for _, provider := range embedProviders {
+		id, ok := provider.match(s, pageURL)
+		if !ok {
+			continue
+		}
+		result, err := provider.embed(ctx, s, pageURL, id)
+		if err != nil || result == nil {
+			continue
+		}
+		return result, true
+	}
+	return nil, false
+}
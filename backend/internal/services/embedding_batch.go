@@ -0,0 +1,247 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// embeddingBatchSize caps how many inputs are sent per provider request
+// (Gemini's batchEmbedContents accepts at most 100)
+const embeddingBatchSize = 100
+
+// EmbeddingBatchError identifies which inputs of a batch failed to embed.
+// Indexes holds one input when the provider rejected just that one, and every
+// input of a request that failed as a whole.
+type EmbeddingBatchError struct {
+	Indexes []int
+	Err     error
+}
+
+func (e *EmbeddingBatchError) Error() string {
+	if len(e.Indexes) == 1 {
+		return fmt.Sprintf("failed to generate embedding for input %d: %v", e.Indexes[0], e.Err)
+	}
+	return fmt.Sprintf("failed to generate embeddings for inputs %v: %v", e.Indexes, e.Err)
+}
+
+func (e *EmbeddingBatchError) Unwrap() error {
+	return e.Err
+}
+
+// GenerateEmbeddings embeds many texts using the provider's batch endpoint.
+// The returned slice matches the order of texts; cached inputs are not re-sent.
+func (s *AIService) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	results := make([][]float32, len(texts))
+	model := s.EmbeddingModel()
+
+	var missing []int
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			return nil, &EmbeddingBatchError{Indexes: []int{i}, Err: fmt.Errorf("input text is empty")}
+		}
+		embedding, ok := s.embeddings.Get(embeddingCacheKey(model, text))
+		s.metrics.ObserveEmbeddingCache("content", ok)
+		if ok {
+			results[i] = embedding
+			continue
+		}
+		missing = append(missing, i)
+	}
+
+	for start := 0; start < len(missing); start += embeddingBatchSize {
+		end := start + embeddingBatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+		chunk := missing[start:end]
+
+		batch := make([]string, len(chunk))
+		for j, idx := range chunk {
+			batch[j] = texts[idx]
+		}
+
+		embeddings, answered, err := s.generateEmbeddingsUncached(ctx, batch)
+		if err != nil {
+			// Translate chunk-relative indexes back to the caller's indexes
+			var batchErr *EmbeddingBatchError
+			if errors.As(err, &batchErr) {
+				indexes := make([]int, len(batchErr.Indexes))
+				for j, idx := range batchErr.Indexes {
+					indexes[j] = chunk[idx]
+				}
+				return nil, &EmbeddingBatchError{Indexes: indexes, Err: batchErr.Err}
+			}
+			// The request failed as a whole, so every input in it failed
+			return nil, &EmbeddingBatchError{Indexes: append([]int(nil), chunk...), Err: err}
+		}
+
+		// Cached under the model that answered, as in GenerateEmbedding
+		for j, idx := range chunk {
+			results[idx] = embeddings[j]
+			s.embeddings.Put(embeddingCacheKey(answered, texts[idx]), embeddings[j])
+		}
+	}
+
+	return results, nil
+}
+
+// generateEmbeddingsUncached embeds texts in one request, returning the
+// embedding model that answered as generateEmbeddingUncached does
+func (s *AIService) generateEmbeddingsUncached(ctx context.Context, texts []string) ([][]float32, string, error) {
+	ctx, cancel := context.WithTimeout(withEmbeddingCall(ctx), s.requestTimeout)
+	defer cancel()
+
+	var answered string
+	embeddings, err := withFallback(ctx, s, "embedding_batch", s.canFallBackForEmbeddings(), func(provider string) ([][]float32, error) {
+		answered = provider
+		switch provider {
+		case "claude":
+			// Use Claude/LiteLLM proxy for embeddings with gemini-embedding-001
+			url := fmt.Sprintf("%s/v1/embeddings", s.claudeBaseURL)
+			return s.generateEmbeddingsOpenAIFormat(ctx, "Claude/LiteLLM", "claude", url, bearerAuth(s.claudeKey), "gemini-embedding-001", texts)
+		case "gemini":
+			return s.generateEmbeddingsGemini(ctx, texts)
+		case "ollama":
+			return s.generateEmbeddingsOllama(ctx, texts)
+		default:
+			return s.generateEmbeddingsOpenAIFormat(ctx, "OpenAI", "openai", s.openaiAPI.url("embeddings"), s.openaiAPI.auth(s.openaiKey), "text-embedding-3-small", texts)
+		}
+	})
+	return embeddings, s.embeddingModelOf(answered), err
+}
+
+// generateEmbeddingsOpenAIFormat calls an OpenAI-compatible embeddings endpoint with an array input; auth sets the request's credentials
+func (s *AIService) generateEmbeddingsOpenAIFormat(ctx context.Context, providerName, usageProvider, url string, auth func(*http.Request), model string, texts []string) ([][]float32, error) {
+	payload := map[string]interface{}{
+		"input": texts,
+		"model": model,
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	auth(req)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, parseOpenAIError(providerName, model, resp.StatusCode, body)
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Usage openAIUsage `json:"usage"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	s.usage.record(usageProvider, result.Usage.PromptTokens, 0, result.Usage.TotalTokens)
+
+	// Responses carry an explicit index; don't rely on array order
+	sort.Slice(result.Data, func(i, j int) bool {
+		return result.Data[i].Index < result.Data[j].Index
+	})
+
+	embeddings := make([][]float32, len(texts))
+	for _, data := range result.Data {
+		if data.Index >= 0 && data.Index < len(texts) {
+			embeddings[data.Index] = data.Embedding
+		}
+	}
+
+	for i, embedding := range embeddings {
+		if len(embedding) == 0 {
+			return nil, &EmbeddingBatchError{Indexes: []int{i}, Err: fmt.Errorf("no embedding data returned")}
+		}
+	}
+
+	return embeddings, nil
+}
+
+// generateEmbeddingsGemini uses Gemini's batchEmbedContents endpoint
+func (s *AIService) generateEmbeddingsGemini(ctx context.Context, texts []string) ([][]float32, error) {
+	url := fmt.Sprintf("%s/v1beta/models/text-embedding-004:batchEmbedContents?key=%s", s.geminiBaseURL, s.geminiKey)
+
+	requests := make([]map[string]interface{}, len(texts))
+	for i, text := range texts {
+		requests[i] = map[string]interface{}{
+			"model": "models/text-embedding-004",
+			"content": map[string]interface{}{
+				"parts": []map[string]string{
+					{"text": text},
+				},
+			},
+		}
+	}
+
+	payload := map[string]interface{}{
+		"requests": requests,
+	}
+
+	jsonData, _ := json.Marshal(payload)
+	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, parseGeminiError("text-embedding-004", resp.StatusCode, body)
+	}
+
+	var result struct {
+		Embeddings []struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	// batchEmbedContents doesn't report token usage, so only the request is counted
+	s.usage.record("gemini", 0, 0, 0)
+
+	// Gemini returns embeddings in request order
+	embeddings := make([][]float32, len(texts))
+	for i := range texts {
+		if i >= len(result.Embeddings) || len(result.Embeddings[i].Values) == 0 {
+			return nil, &EmbeddingBatchError{Indexes: []int{i}, Err: fmt.Errorf("no embedding data returned")}
+		}
+		embeddings[i] = result.Embeddings[i].Values
+	}
+
+	return embeddings, nil
+}
+
+// generateEmbeddingsOllama embeds each text in turn, since /api/embeddings takes a single prompt
+func (s *AIService) generateEmbeddingsOllama(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embedding, err := s.generateEmbeddingOllama(ctx, text)
+		if err != nil {
+			return nil, &EmbeddingBatchError{Indexes: []int{i}, Err: err}
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
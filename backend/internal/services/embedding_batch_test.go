@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateEmbeddingsAttributesFailedRequestToEveryInput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"message": "bad request"}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AI_PROVIDER", "claude")
+	t.Setenv("ANTHROPIC_AUTH_TOKEN", "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", server.URL)
+	s := NewAIService(nil, nil)
+
+	_, err := s.GenerateEmbeddings(context.Background(), []string{"first", "second", "third"})
+	var batchErr *EmbeddingBatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("GenerateEmbeddings error = %v, want an EmbeddingBatchError", err)
+	}
+	if want := []int{0, 1, 2}; !reflect.DeepEqual(batchErr.Indexes, want) {
+		t.Errorf("Indexes = %v, want %v", batchErr.Indexes, want)
+	}
+}
+
+func TestGenerateEmbeddingsAttributesOneBadInput(t *testing.T) {
+	s := NewAIService(nil, nil)
+
+	_, err := s.GenerateEmbeddings(context.Background(), []string{"first", " ", "third"})
+	var batchErr *EmbeddingBatchError
+	if !errors.As(err, &batchErr) || !reflect.DeepEqual(batchErr.Indexes, []int{1}) {
+		t.Errorf("GenerateEmbeddings error = %v, want input 1 to fail", err)
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+)
+
+// EmbeddingCacheStats reports embedding cache usage for observability
+type EmbeddingCacheStats struct {
+	Hits     int64 `json:"hits"`
+	Misses   int64 `json:"misses"`
+	Size     int   `json:"size"`
+	Capacity int   `json:"capacity"`
+}
+
+// embeddingCache is a concurrency-safe LRU cache of embeddings keyed by content hash
+type embeddingCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+	hits     int64
+	misses   int64
+}
+
+type embeddingCacheEntry struct {
+	key       string
+	embedding []float32
+}
+
+func newEmbeddingCache(capacity int) *embeddingCache {
+	return &embeddingCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// embeddingCacheKey hashes the normalized text together with the embedding
+// model (see EmbeddingModel), since different models produce incompatible
+// vectors for the same text
+func embeddingCacheKey(model, text string) string {
+	normalized := strings.Join(strings.Fields(text), " ")
+	sum := sha256.Sum256([]byte(model + "\x00" + normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *embeddingCache) Get(key string) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capacity <= 0 {
+		c.misses++
+		return nil, false
+	}
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*embeddingCacheEntry).embedding, true
+}
+
+func (c *embeddingCache) Put(key string, embedding []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capacity <= 0 {
+		return
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*embeddingCacheEntry).embedding = embedding
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&embeddingCacheEntry{key: key, embedding: embedding})
+	c.entries[key] = elem
+
+	// Evict least recently used entries once over capacity
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*embeddingCacheEntry).key)
+	}
+}
+
+func (c *embeddingCache) Stats() EmbeddingCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return EmbeddingCacheStats{
+		Hits:     c.hits,
+		Misses:   c.misses,
+		Size:     c.order.Len(),
+		Capacity: c.capacity,
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"synapse/internal/db"
+	"synapse/internal/logging"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrEmbeddingMismatch is wrapped by EmbeddingMismatchError; match it with errors.Is
+var ErrEmbeddingMismatch = errors.New("embedding dimension mismatch")
+
+// EmbeddingMismatchError is returned when the current embedding model's vectors
+// don't fit the ChromaDB collection, typically after changing AI_PROVIDER
+type EmbeddingMismatchError struct {
+	Expected  models.EmbeddingConfig
+	Model     string
+	Dimension int
+}
+
+func (e *EmbeddingMismatchError) Error() string {
+	return fmt.Sprintf(
+		"embedding model %s produces %d-dimensional vectors, but ChromaDB collection %q holds %d-dimensional vectors from %s; "+
+			"set CHROMA_COLLECTION to a new collection and run POST /api/admin/reindex, or switch back to the previous AI_PROVIDER",
+		e.Model, e.Dimension, e.Expected.CollectionName, e.Expected.Dimension, e.Expected.Model,
+	)
+}
+
+func (e *EmbeddingMismatchError) Unwrap() error {
+	return ErrEmbeddingMismatch
+}
+
+// EmbeddingGuard checks that embeddings match the model and dimension recorded
+// for the ChromaDB collection. The first embedding checked against a new
+// collection records them. Share one guard between services.
+type EmbeddingGuard struct {
+	configRepo     *repository.EmbeddingConfigRepository
+	aiService      AIProvider
+	collectionName string
+	logger         *slog.Logger
+
+	mu          sync.Mutex
+	config      *models.EmbeddingConfig
+	warnedModel bool
+}
+
+func NewEmbeddingGuard(configRepo *repository.EmbeddingConfigRepository, aiService AIProvider, logger *slog.Logger) *EmbeddingGuard {
+	return &EmbeddingGuard{
+		configRepo:     configRepo,
+		aiService:      aiService,
+		collectionName: db.CollectionName(),
+		logger:         logging.OrDefault(logger),
+	}
+}
+
+// Check returns an *EmbeddingMismatchError if embedding can't be stored in or
+// compared against the collection. Failing to read the recorded config is
+// logged rather than returned, so a database hiccup doesn't block saves.
+func (g *EmbeddingGuard) Check(ctx context.Context, embedding []float32) error {
+	model := g.aiService.EmbeddingModel()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.config == nil {
+		config, err := g.load(ctx, model, len(embedding))
+		if err != nil {
+			g.logger.WarnContext(ctx, "failed to load embedding config", "operation", "embedding_guard", "collection", g.collectionName, "error", err)
+			return nil
+		}
+		g.config = config
+	}
+
+	if len(embedding) != g.config.Dimension {
+		return &EmbeddingMismatchError{Expected: *g.config, Model: model, Dimension: len(embedding)}
+	}
+	if model != g.config.Model && !g.warnedModel {
+		// Same size, so ChromaDB accepts it, but similarities across models are meaningless
+		g.logger.WarnContext(ctx, "embedding model differs from the one the collection was built with; reindex for accurate search",
+			"operation", "embedding_guard", "collection", g.collectionName, "model", model, "collection_model", g.config.Model)
+		g.warnedModel = true
+	}
+	return nil
+}
+
+// load reads the collection's recorded config, recording this model's if there is none
+func (g *EmbeddingGuard) load(ctx context.Context, model string, dimension int) (*models.EmbeddingConfig, error) {
+	config, err := g.configRepo.Get(ctx, g.collectionName)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return config, err
+	}
+
+	err = g.configRepo.Create(ctx, &models.EmbeddingConfig{
+		CollectionName: g.collectionName,
+		Model:          model,
+		Dimension:      dimension,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Re-read in case another instance recorded it first
+	return g.configRepo.Get(ctx, g.collectionName)
+}
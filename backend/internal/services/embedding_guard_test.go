@@ -0,0 +1,52 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"synapse/internal/services"
+	"synapse/internal/services/servicestest"
+)
+
+func TestEmbeddingGuardRejectsOtherDimensions(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+
+	// The first save records the collection's 3-dimensional model
+	s.save(t, userID, "Tomato soup", "Roast the tomatoes.", []float32{1, 0, 0})
+
+	// e.g. after switching AI_PROVIDER to a model with bigger vectors
+	s.ai.Model = "other/embedding"
+	s.ai.Embedding = []float32{1, 0, 0, 0}
+	_, err := s.items.CreateItem(ctx, userID, &models.CreateItemRequest{Title: "Bike repair", Content: "Adjust the screws.", Type: "text"})
+	var mismatch *services.EmbeddingMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("CreateItem error = %v, want an EmbeddingMismatchError", err)
+	}
+	if mismatch.Expected.Dimension != 3 || mismatch.Dimension != 4 || mismatch.Model != "other/embedding" {
+		t.Errorf("mismatch = %+v, want 4 dimensions from other/embedding against 3", mismatch)
+	}
+
+	// Search reports it rather than quietly returning text matches only
+	if _, err := s.search.Search(ctx, userID, "tomato soup", nil, "", 10, 0); !errors.Is(err, services.ErrEmbeddingMismatch) {
+		t.Errorf("Search error = %v, want ErrEmbeddingMismatch", err)
+	}
+
+	// A guard started later reads the recorded config rather than recording its own
+	guard := services.NewEmbeddingGuard(repository.NewEmbeddingConfigRepository(s.pool), s.ai, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := guard.Check(ctx, s.ai.Embedding); !errors.Is(err, services.ErrEmbeddingMismatch) {
+		t.Errorf("new guard's Check error = %v, want ErrEmbeddingMismatch", err)
+	}
+
+	// Another model of the same size is accepted, with a warning
+	s.ai.Embedding = []float32{0, 1, 0}
+	if err := guard.Check(ctx, s.ai.Embedding); err != nil {
+		t.Errorf("Check of a same-size vector from another model: %v", err)
+	}
+}
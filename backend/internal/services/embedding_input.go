@@ -0,0 +1,126 @@
+package services
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+
+	"synapse/internal/models"
+)
+
+// EmbeddingInput chooses which of an item's fields its vector is generated
+// from, set with EMBEDDING_INPUT. Changing it only affects vectors generated
+// afterwards, so reindex to apply it to saved items.
+type EmbeddingInput string
+
+const (
+	// EmbeddingInputSummary embeds the title and semantic summary, or the content
+	// when there's no summary. Summaries are written for search while raw content
+	// is often padded with boilerplate, so this is the default.
+	EmbeddingInputSummary EmbeddingInput = "summary"
+	// EmbeddingInputSummaryOnly embeds just the semantic summary, or the content
+	// when there's no summary, so titles like "Untitled" don't skew matches
+	EmbeddingInputSummaryOnly EmbeddingInput = "summary_only"
+	// EmbeddingInputContent embeds the title and full content, for libraries of
+	// notes and quotes where the exact wording matters more than the gist
+	EmbeddingInputContent EmbeddingInput = "content"
+	// EmbeddingInputTitleSummaryTags embeds the title, semantic summary (or
+	// content), and tags, so searching a tag's topic finds the items carrying it
+	EmbeddingInputTitleSummaryTags EmbeddingInput = "title_summary_tags"
+)
+
+// loadEmbeddingInput reads EMBEDDING_INPUT, falling back to EmbeddingInputSummary
+func loadEmbeddingInput(logger *slog.Logger) EmbeddingInput {
+	value := EmbeddingInput(strings.TrimSpace(strings.ToLower(os.Getenv("EMBEDDING_INPUT"))))
+	switch value {
+	case EmbeddingInputSummary, EmbeddingInputSummaryOnly, EmbeddingInputContent, EmbeddingInputTitleSummaryTags:
+		return value
+	case "":
+		return EmbeddingInputSummary
+	}
+	logger.Warn("unknown EMBEDDING_INPUT, using summary", "embedding_input", value)
+	return EmbeddingInputSummary
+}
+
+// embeddingSource holds the fields an EmbeddingInput composes its text from
+type embeddingSource struct {
+	title            string
+	summary          string
+	content          string
+	tags             []string
+	imageDescription string
+}
+
+// usesTags reports whether the tags are part of the embedded text, in which
+// case new items are embedded once their tags are generated
+func (input EmbeddingInput) usesTags() bool {
+	return input == EmbeddingInputTitleSummaryTags
+}
+
+// compose builds the text to embed from src. An image's description is always
+// added, since its content rarely says what it shows.
+func (input EmbeddingInput) compose(src embeddingSource) string {
+	// The gist of the item: its summary, else its content, else just the title
+	gist := src.summary
+	if gist == "" {
+		gist = src.content
+	}
+
+	var text string
+	switch input {
+	case EmbeddingInputSummaryOnly:
+		text = gist
+	case EmbeddingInputContent:
+		text = joinNonEmpty(src.title, src.content)
+	case EmbeddingInputTitleSummaryTags:
+		text = joinNonEmpty(src.title, gist)
+		if len(src.tags) > 0 {
+			text += "\nTags: " + strings.Join(src.tags, ", ")
+		}
+	default:
+		if src.summary != "" {
+			text = src.title + "\n" + src.summary
+		} else {
+			text = src.content
+		}
+	}
+	if text == "" {
+		text = src.title
+	}
+	if src.imageDescription != "" {
+		text += "\n" + src.imageDescription
+	}
+	return text
+}
+
+// maxEmbeddingRunes caps the text an item's vector is generated from, such as
+// the full text of a PDF, to what embedding models accept
+const maxEmbeddingRunes = 8000
+
+// embeddingText is what a saved item's vector is generated from
+func (s *ItemService) embeddingText(item *models.Item) string {
+	return s.composeEmbeddingText(embeddingSource{
+		title:            item.Title,
+		summary:          item.Summary,
+		content:          item.Content,
+		tags:             item.Tags,
+		imageDescription: item.ImageDescription,
+	})
+}
+
+// composeEmbeddingText builds the text to embed from src, truncated like a
+// prompt so long content such as a PDF's full text fits the embedding model
+func (s *ItemService) composeEmbeddingText(src embeddingSource) string {
+	return truncateForModel(s.embeddingInput.compose(src), s.embeddingLimit)
+}
+
+// joinNonEmpty joins the non-empty parts with newlines
+func joinNonEmpty(parts ...string) string {
+	kept := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
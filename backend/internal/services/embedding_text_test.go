@@ -0,0 +1,104 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"synapse/internal/models"
+)
+
+func TestEmbeddingInputCompose(t *testing.T) {
+	full := embeddingSource{title: "Pasta", summary: "A tomato sauce.", content: "Boil water.", tags: []string{"cooking", "italian"}}
+	untitled := embeddingSource{title: "Untitled", content: "Boil water."}
+	image := embeddingSource{title: "Receipt", summary: "A grocery receipt.", imageDescription: "A paper receipt on a table."}
+
+	tests := []struct {
+		input EmbeddingInput
+		src   embeddingSource
+		want  string
+	}{
+		{EmbeddingInputSummary, full, "Pasta\nA tomato sauce."},
+		// Without a summary the content stands in for it
+		{EmbeddingInputSummary, untitled, "Boil water."},
+		{EmbeddingInputSummaryOnly, full, "A tomato sauce."},
+		{EmbeddingInputSummaryOnly, untitled, "Boil water."},
+		{EmbeddingInputContent, full, "Pasta\nBoil water."},
+		{EmbeddingInputTitleSummaryTags, full, "Pasta\nA tomato sauce.\nTags: cooking, italian"},
+		{EmbeddingInputTitleSummaryTags, untitled, "Untitled\nBoil water."},
+		// With nothing else to go on the title is embedded
+		{EmbeddingInputSummaryOnly, embeddingSource{title: "Pasta"}, "Pasta"},
+		{EmbeddingInputSummaryOnly, image, "A grocery receipt.\nA paper receipt on a table."},
+	}
+
+	for _, tt := range tests {
+		if got := tt.input.compose(tt.src); got != tt.want {
+			t.Errorf("%s.compose(%+v) = %q, want %q", tt.input, tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestEmbeddingTextIsTruncated(t *testing.T) {
+	s := &ItemService{embeddingLimit: 100}
+	// e.g. the full text of a PDF
+	item := &models.Item{Title: "Report", Content: strings.Repeat("word ", 1000)}
+
+	text := s.embeddingText(item)
+	if n := len([]rune(text)); n > 100 {
+		t.Errorf("embeddingText is %d runes, want at most 100", n)
+	}
+	if !strings.HasPrefix(text, "word word") || strings.HasSuffix(text, " ") {
+		t.Errorf("embeddingText = %q, want the start of the content cut between words", text)
+	}
+
+	// Inputs that add the title are cut the same way
+	s.embeddingInput = EmbeddingInputContent
+	text = s.embeddingText(item)
+	if n := len([]rune(text)); n > 100 || !strings.HasPrefix(text, "Report\nword word") || strings.HasSuffix(text, " ") {
+		t.Errorf("embeddingText = %q, want the start of the title and content cut between words", text)
+	}
+
+	// 0 embeds the text whole
+	s.embeddingLimit = 0
+	if text := s.embeddingText(item); text != "Report\n"+item.Content {
+		t.Errorf("embeddingText with no limit cut the text to %d runes", len([]rune(text)))
+	}
+}
+
+func TestEmbeddingSourceHash(t *testing.T) {
+	hash := embeddingSourceHash("Report\nQuarterly numbers")
+	if len(hash) != 64 {
+		t.Errorf("embeddingSourceHash = %q, want 64 hex characters", hash)
+	}
+	if again := embeddingSourceHash("Report\nQuarterly numbers"); again != hash {
+		t.Errorf("embeddingSourceHash isn't stable: %q then %q", hash, again)
+	}
+	for _, other := range []string{"Report\nQuarterly Numbers", "Report\nQuarterly numbers ", ""} {
+		if embeddingSourceHash(other) == hash {
+			t.Errorf("embeddingSourceHash(%q) matches a different text's hash", other)
+		}
+	}
+}
+
+func TestEmbeddingUpToDate(t *testing.T) {
+	text := "Report\nQuarterly numbers"
+	tests := []struct {
+		name string
+		item models.Item
+		want bool
+	}{
+		{"same text", models.Item{EmbeddingID: "id", EmbeddingSourceHash: embeddingSourceHash(text)}, true},
+		{"text changed", models.Item{EmbeddingID: "id", EmbeddingSourceHash: embeddingSourceHash("Report")}, false},
+		// Vectors stored before hashes were recorded are always regenerated
+		{"no stored hash", models.Item{EmbeddingID: "id"}, false},
+		// Items saved during an embedding outage have no vector to keep
+		{"no vector", models.Item{EmbeddingSourceHash: embeddingSourceHash(text)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := embeddingUpToDate(&tt.item, text); got != tt.want {
+				t.Errorf("embeddingUpToDate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// getEnvInt reads an integer from the environment, returning def when unset or invalid
+func getEnvInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return def
+	}
+	return parsed
+}
+
+// getEnvSeconds reads a duration in whole seconds from the environment, returning def when unset or invalid
+func getEnvSeconds(key string, def time.Duration) time.Duration {
+	seconds := getEnvInt(key, -1)
+	if seconds <= 0 {
+		return def
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// getEnvBool reports whether an environment flag is set to a true value ("true", "1", ...)
+func getEnvBool(key string) bool {
+	parsed, err := strconv.ParseBool(os.Getenv(key))
+	return err == nil && parsed
+}
+
+// getEnvFloat reads a float from the environment, returning def when unset or invalid
+func getEnvFloat(key string, def float64) float64 {
+	parsed, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return def
+	}
+	return parsed
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"synapse/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// exportBatchSize is how many items are read from the database at a time while exporting
+const exportBatchSize = 500
+
+// exportCSVHeader lists the ExportCSV columns, in order
+var exportCSVHeader = []string{
+	"id", "title", "content", "summary", "source_url", "type", "category", "tags",
+	"image_url", "ocr_text", "language", "reading_time_minutes", "price", "currency", "created_at",
+}
+
+// ExportJSON streams every item userID owns (archived items excluded) to w as
+// a JSON array, newest first. Items are read a page at a time, so memory use
+// doesn't grow with the library.
+func (s *ItemService) ExportJSON(ctx context.Context, userID uuid.UUID, w io.Writer) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	first := true
+	err := s.forEachItemPage(ctx, userID, func(items []models.Item) error {
+		for i := range items {
+			data, err := json.Marshal(&items[i])
+			if err != nil {
+				return err
+			}
+			separator := ",\n"
+			if first {
+				separator = "\n"
+				first = false
+			}
+			if _, err := io.WriteString(w, separator); err != nil {
+				return err
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n]\n")
+	return err
+}
+
+// ExportCSV streams every item userID owns (archived items excluded) to w as
+// CSV with a header row, newest first. Tags are joined with ";" and created_at
+// is RFC 3339.
+func (s *ItemService) ExportCSV(ctx context.Context, userID uuid.UUID, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportCSVHeader); err != nil {
+		return err
+	}
+
+	err := s.forEachItemPage(ctx, userID, func(items []models.Item) error {
+		for _, item := range items {
+			price := ""
+			if item.Price != nil {
+				price = strconv.FormatFloat(*item.Price, 'f', -1, 64)
+			}
+			record := []string{
+				item.ID.String(), csvCell(item.Title), csvCell(item.Content), csvCell(item.Summary),
+				csvCell(item.SourceURL), csvCell(item.Type), csvCell(item.Category),
+				csvCell(strings.Join(item.Tags, ";")), csvCell(item.ImageURL), csvCell(item.OcrText), csvCell(item.Language),
+				strconv.Itoa(item.ReadingTimeMinutes), price, csvCell(item.Currency), item.CreatedAt.Format(time.RFC3339),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		// Flush each page so rows reach the client as they're read
+		writer.Flush()
+		return writer.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvCell keeps a text value from being read as a formula when the export is
+// opened in a spreadsheet: a value starting with =, +, -, @, a tab or a
+// carriage return is prefixed with a single quote
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// forEachItemPage calls fn with successive pages of userID's items, newest
+// first, paging by cursor so late pages don't rescan skipped rows
+func (s *ItemService) forEachItemPage(ctx context.Context, userID uuid.UUID, fn func([]models.Item) error) error {
+	cursor := ""
+	for {
+		page, err := s.GetItemsPage(ctx, userID, models.SortNewest, exportBatchSize, 0, cursor)
+		if err != nil {
+			return err
+		}
+		if err := fn(page.Items); err != nil {
+			return err
+		}
+
+		cursor = page.NextCursor
+		if cursor == "" {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"synapse/internal/models"
+	"synapse/internal/services/servicestest"
+
+	"github.com/google/uuid"
+)
+
+func TestExportJSON(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+
+	var buf bytes.Buffer
+	if err := s.items.ExportJSON(ctx, userID, &buf); err != nil {
+		t.Fatalf("ExportJSON of an empty library: %v", err)
+	}
+	var items []models.Item
+	if err := json.Unmarshal(buf.Bytes(), &items); err != nil || len(items) != 0 {
+		t.Fatalf("empty export = %q, want an empty JSON array", buf.String())
+	}
+
+	older := s.save(t, userID, "Tomato soup", "Roast the tomatoes.", []float32{1, 0, 0})
+	newer := s.save(t, userID, "Bread", "Knead the dough.", []float32{0, 1, 0})
+	archived := s.save(t, userID, "Old note", "Archived.", []float32{0, 0, 1})
+	if err := s.items.DeleteItem(ctx, userID, archived.ID); err != nil {
+		t.Fatalf("DeleteItem: %v", err)
+	}
+
+	buf.Reset()
+	if err := s.items.ExportJSON(ctx, userID, &buf); err != nil {
+		t.Fatalf("ExportJSON: %v", err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &items); err != nil {
+		t.Fatalf("export isn't valid JSON: %v\n%s", err, buf.String())
+	}
+	got := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		got[i] = item.ID
+	}
+	if want := []uuid.UUID{newer.ID, older.ID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("exported items = %v, want %v newest first without the archived one", got, want)
+	}
+	if items[1].Title != older.Title || items[1].Content != older.Content {
+		t.Errorf("exported item = %+v, want the saved %q", items[1], older.Title)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+	s.ai.Tags = []string{"math", "spreadsheets"}
+	item := s.save(t, userID, "=1+1", "Formula-looking title, with a comma", []float32{1, 0, 0})
+
+	var buf bytes.Buffer
+	if err := s.items.ExportCSV(ctx, userID, &buf); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("export isn't valid CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("export has %d rows, want a header and one item", len(records))
+	}
+
+	row := make(map[string]string)
+	for i, column := range records[0] {
+		row[column] = records[1][i]
+	}
+	if row["id"] != item.ID.String() {
+		t.Errorf("id = %q, want %q", row["id"], item.ID)
+	}
+	// A leading = would run as a formula in a spreadsheet
+	if row["title"] != "'=1+1" {
+		t.Errorf("title = %q, want it quoted", row["title"])
+	}
+	if row["content"] != item.Content {
+		t.Errorf("content = %q, want %q", row["content"], item.Content)
+	}
+	if row["tags"] != "math;spreadsheets" {
+		t.Errorf("tags = %q, want them joined with ;", row["tags"])
+	}
+	createdAt, err := time.Parse(time.RFC3339, row["created_at"])
+	if err != nil || !createdAt.Equal(item.CreatedAt.Truncate(time.Second)) {
+		t.Errorf("created_at = %q, want %v in RFC 3339", row["created_at"], item.CreatedAt)
+	}
+}
+
+func TestImportJSONRoundTrip(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+	soup := s.save(t, userID, "Tomato soup", "Roast the tomatoes.", []float32{1, 0, 0})
+	bread := s.save(t, userID, "Bread", "Knead the dough.", []float32{0, 1, 0})
+
+	var export bytes.Buffer
+	if err := s.items.ExportJSON(ctx, userID, &export); err != nil {
+		t.Fatalf("ExportJSON: %v", err)
+	}
+	for _, item := range []*models.Item{soup, bread} {
+		if err := s.items.HardDeleteItem(ctx, userID, item.ID); err != nil {
+			t.Fatalf("HardDeleteItem: %v", err)
+		}
+	}
+
+	report, err := s.items.ImportJSON(ctx, userID, bytes.NewReader(export.Bytes()), false)
+	if err != nil {
+		t.Fatalf("ImportJSON: %v", err)
+	}
+	if report.Created != 2 || report.Failed != 0 || report.Unindexed != 0 {
+		t.Fatalf("import report = %+v, want 2 created", report)
+	}
+	for _, want := range []*models.Item{soup, bread} {
+		got, err := s.repo.GetByID(ctx, userID, want.ID)
+		if err != nil {
+			t.Fatalf("imported item %q not found: %v", want.Title, err)
+		}
+		if got.Title != want.Title || got.Content != want.Content || !got.CreatedAt.Equal(want.CreatedAt.Truncate(time.Microsecond)) {
+			t.Errorf("imported item = %q saved %v, want %q saved %v", got.Title, got.CreatedAt, want.Title, want.CreatedAt)
+		}
+		if got.EmbeddingID == "" || !s.chroma.Has(got.EmbeddingID) {
+			t.Errorf("imported item %q has no vector", got.Title)
+		}
+	}
+
+	// Importing again skips what exists, unless asked to overwrite it
+	report, err = s.items.ImportJSON(ctx, userID, bytes.NewReader(export.Bytes()), false)
+	if err != nil || report.Skipped != 2 || report.Created != 0 {
+		t.Errorf("second import = %+v, %v; want 2 skipped", report, err)
+	}
+	report, err = s.items.ImportJSON(ctx, userID, bytes.NewReader(export.Bytes()), true)
+	if err != nil || report.Updated != 2 || report.Created != 0 {
+		t.Errorf("upserting import = %+v, %v; want 2 updated", report, err)
+	}
+}
+
+func TestImportJSONRejectsMalformedInput(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+
+	tests := []struct {
+		name        string
+		input       string
+		wantReport  bool // whether the items before the error are reported
+		wantCreated int
+	}{
+		{"not JSON", "title,content\n", false, 0},
+		{"an object, not an array", `{"title": "Soup"}`, false, 0},
+		{"wrong field type", `[{"title": 42}]`, true, 0},
+		{"truncated after one item", `[{"title": "Soup", "content": "Roast the tomatoes.", "type": "text"}, {"title": "Br`, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := s.items.ImportJSON(ctx, userID, strings.NewReader(tt.input), false)
+			if err == nil {
+				t.Fatalf("ImportJSON(%q) = %+v, want an error", tt.input, report)
+			}
+			if (report != nil) != tt.wantReport {
+				t.Fatalf("report = %+v, want one: %v", report, tt.wantReport)
+			}
+			if report != nil && report.Created != tt.wantCreated {
+				t.Errorf("created %d items before the error, want %d", report.Created, tt.wantCreated)
+			}
+		})
+	}
+
+	// An empty array imports nothing
+	report, err := s.items.ImportJSON(ctx, userID, strings.NewReader("[]"), false)
+	if err != nil || report.Created+report.Skipped+report.Failed != 0 {
+		t.Errorf("ImportJSON([]) = %+v, %v; want an empty report", report, err)
+	}
+}
@@ -0,0 +1,22 @@
+package services
+
+import "testing"
+
+func TestCSVCell(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"plain title":             "plain title",
+		"=HYPERLINK(\"x\",\"y\")": "'=HYPERLINK(\"x\",\"y\")",
+		"+1 555 0100":             "'+1 555 0100",
+		"-2+3":                    "'-2+3",
+		"@SUM(A1:A2)":             "'@SUM(A1:A2)",
+		"\t=1":                    "'\t=1",
+		"\r=1":                    "'\r=1",
+		"a=b":                     "a=b",
+	}
+	for input, want := range tests {
+		if got := csvCell(input); got != want {
+			t.Errorf("csvCell(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GetFavicon returns the absolute URL of a page's site icon: the page's first
+// <link rel="icon">, else the site's /favicon.ico if it exists, else Google's
+// favicon service when FAVICON_GOOGLE_FALLBACK is set. It returns "" with no
+// error when the site has no icon.
+func (s *MetadataService) GetFavicon(ctx context.Context, pageURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	base, err := url.Parse(pageURL)
+	if err != nil || base.Host == "" {
+		return "", fmt.Errorf("invalid url %q", pageURL)
+	}
+
+	// PDFs and unreachable pages have no <head> to read, but the site may still have an icon
+	var iconHrefs []string
+	var fetchErr error
+	if !isPDFURL(pageURL) {
+		base, iconHrefs, fetchErr = s.fetchIconLinks(ctx, base)
+	}
+
+	favicon := s.resolveFavicon(ctx, base, iconHrefs)
+	if favicon == "" && fetchErr != nil {
+		return "", fetchErr
+	}
+	return favicon, nil
+}
+
+// fetchIconLinks fetches a page and returns the base its links resolve
+// against along with its icon hrefs
+func (s *MetadataService) fetchIconLinks(ctx context.Context, pageURL *url.URL) (*url.URL, []string, error) {
+	resp, err := s.fetchHTML(ctx, pageURL.String())
+	if err != nil {
+		return pageURL, nil, err
+	}
+	defer resp.Body.Close()
+
+	head := parseHTMLHead(resp.Body)
+	return headBase(resp.Request.URL, head.baseHref), head.iconHrefs, nil
+}
+
+// headBase is the URL a page's relative links resolve against: its
+// post-redirect URL, or <base href> when the page sets one
+func headBase(pageURL *url.URL, baseHref string) *url.URL {
+	if baseHref != "" {
+		if baseRef, err := pageURL.Parse(baseHref); err == nil {
+			return baseRef
+		}
+	}
+	return pageURL
+}
+
+// resolveFavicon picks a site icon given a page's base URL and icon hrefs,
+// checking that the /favicon.ico fallback exists before returning it
+func (s *MetadataService) resolveFavicon(ctx context.Context, base *url.URL, iconHrefs []string) string {
+	for _, href := range iconHrefs {
+		// Skip inline data: icons; they'd bloat every item row
+		if icon := resolveURL(base, href); isHTTPURL(icon) {
+			return icon
+		}
+	}
+
+	// Browsers fall back to /favicon.ico at the site root
+	fallback := (&url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/favicon.ico"}).String()
+	if s.urlExists(ctx, fallback) {
+		return fallback
+	}
+
+	if s.faviconGoogleFallback && base.Hostname() != "" {
+		return "https://www.google.com/s2/favicons?sz=64&domain=" + url.QueryEscape(base.Hostname())
+	}
+	return ""
+}
+
+// urlExists HEAD-checks that target serves something other than an HTML
+// page, since many sites answer missing files with a 200 error page
+func (s *MetadataService) urlExists(ctx context.Context, target string) bool {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", target, nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
+	return resp.StatusCode < 300 && !strings.HasPrefix(contentType, "text/html")
+}
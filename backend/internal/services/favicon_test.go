@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetFavicon(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		head     string // the page's <head>; "" serves a 500 for the page
+		icoType  string // Content-Type of /favicon.ico; "" means it's missing
+		google   bool
+		want     string // with "{server}" for the test server's URL
+		wantHEAD int32  // requests for /favicon.ico
+		wantErr  bool
+	}{
+		{
+			name: "link rel icon",
+			path: "/post",
+			head: `<link rel="stylesheet" href="/style.css"><link rel="icon" href="/static/icon.png">`,
+			want: "{server}/static/icon.png",
+		},
+		{
+			name: "shortcut icon resolved against base href",
+			path: "/post",
+			head: `<base href="https://cdn.example.com/assets/"><link rel="shortcut icon" href="fav.png">`,
+			want: "https://cdn.example.com/assets/fav.png",
+		},
+		{
+			name:     "inline data icon is skipped",
+			path:     "/post",
+			head:     `<link rel="icon" href="data:image/png;base64,iVBORw0KGgo=">`,
+			icoType:  "image/x-icon",
+			want:     "{server}/favicon.ico",
+			wantHEAD: 1,
+		},
+		{
+			name:     "site root favicon.ico",
+			path:     "/post",
+			head:     `<title>No icon</title>`,
+			icoType:  "image/vnd.microsoft.icon",
+			want:     "{server}/favicon.ico",
+			wantHEAD: 1,
+		},
+		{
+			name:     "favicon.ico answered with an HTML error page",
+			path:     "/post",
+			head:     `<title>No icon</title>`,
+			icoType:  "text/html; charset=utf-8",
+			want:     "",
+			wantHEAD: 1,
+		},
+		{
+			name:     "Google fallback",
+			path:     "/post",
+			head:     `<title>No icon</title>`,
+			google:   true,
+			want:     "https://www.google.com/s2/favicons?sz=64&domain=127.0.0.1",
+			wantHEAD: 1,
+		},
+		{
+			name:     "PDF links skip the page",
+			path:     "/paper.pdf",
+			icoType:  "image/x-icon",
+			want:     "{server}/favicon.ico",
+			wantHEAD: 1,
+		},
+		{
+			name:     "unreachable page without an icon",
+			path:     "/post",
+			wantHEAD: 1,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var icoRequests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/favicon.ico":
+					icoRequests.Add(1)
+					if tt.icoType == "" {
+						http.NotFound(w, r)
+						return
+					}
+					w.Header().Set("Content-Type", tt.icoType)
+				case r.URL.Path == "/paper.pdf":
+					t.Error("the PDF was fetched")
+				case tt.head == "":
+					http.Error(w, "boom", http.StatusInternalServerError)
+				default:
+					w.Header().Set("Content-Type", "text/html")
+					w.Write([]byte("<html><head>" + tt.head + "</head><body></body></html>"))
+				}
+			}))
+			defer server.Close()
+
+			s := &MetadataService{client: server.Client(), requestTimeout: 5 * time.Second, htmlMaxBytes: 1 << 20, faviconGoogleFallback: tt.google}
+			got, err := s.GetFavicon(context.Background(), server.URL+tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetFavicon error = %v, want error %v", err, tt.wantErr)
+			}
+			if want := strings.ReplaceAll(tt.want, "{server}", server.URL); got != want {
+				t.Errorf("GetFavicon = %q, want %q", got, want)
+			}
+			if n := icoRequests.Load(); n != tt.wantHEAD {
+				t.Errorf("/favicon.ico requested %d times, want %d", n, tt.wantHEAD)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// githubAPIURL is the GitHub REST API root
+const githubAPIURL = "https://api.github.com"
+
+// githubReadmeExcerptChars is how much of a repository's README is kept
+const githubReadmeExcerptChars = 1000
+
+// githubReservedPaths are github.com pages whose path looks like /{owner}/{repo}
+// but isn't a repository
+var githubReservedPaths = map[string]bool{
+	"about": true, "collections": true, "customer-stories": true, "enterprise": true,
+	"explore": true, "features": true, "marketplace": true, "orgs": true, "pricing": true,
+	"settings": true, "sponsors": true, "topics": true, "trending": true, "users": true,
+}
+
+// GitHubRepo is a GitHub repository's metadata from the GitHub API
+type GitHubRepo struct {
+	FullName    string   `json:"full_name"`
+	Description string   `json:"description"`
+	Language    string   `json:"language"`
+	Stars       int      `json:"stargazers_count"`
+	Topics      []string `json:"topics"`
+	HTMLURL     string   `json:"html_url"`
+	// ImageURL is the repository's social preview image
+	ImageURL string `json:"-"`
+	// ReadmeExcerpt is the opening prose of the README, as plain text
+	ReadmeExcerpt string `json:"-"`
+}
+
+// parseGitHubRepoURL returns the owner and repository of a github.com
+// repository link, including links to a page within one (/owner/repo/issues/1)
+func parseGitHubRepoURL(link string) (owner, repo string, ok bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", "", false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if host != "github.com" {
+		return "", "", false
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" || githubReservedPaths[strings.ToLower(parts[0])] {
+		return "", "", false
+	}
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), true
+}
+
+// GetGitHubRepo fetches a github.com repository link's description, primary
+// language, star count, and topics from the GitHub API, along with its social
+// preview image and an excerpt of its README. GITHUB_TOKEN, if set, raises
+// the API's rate limit from 60 to 5000 requests an hour.
+func (s *MetadataService) GetGitHubRepo(ctx context.Context, link string) (*GitHubRepo, error) {
+	owner, name, ok := parseGitHubRepoURL(link)
+	if !ok {
+		return nil, fmt.Errorf("not a GitHub repository link: %s", link)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	path := "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name)
+	body, err := s.githubAPIGet(ctx, path, "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	var repo GitHubRepo
+	if err := json.Unmarshal(body, &repo); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub repository: %w", err)
+	}
+	// GitHub renders the same card it shows in link previews for any repository
+	repo.ImageURL = "https://opengraph.githubassets.com/1/" + repo.FullName
+
+	// The README only adds detail; the repository is still useful without it
+	readme, err := s.githubAPIGet(ctx, path+"/readme", "application/vnd.github.raw")
+	if err != nil {
+		s.logger.DebugContext(ctx, "failed to fetch GitHub README", "repo", repo.FullName, "error", err)
+	} else {
+		repo.ReadmeExcerpt = readmeExcerpt(string(readme), githubReadmeExcerptChars)
+	}
+	return &repo, nil
+}
+
+// githubAPIGet makes an authenticated (when GITHUB_TOKEN is set) GET request to the GitHub API
+func (s *MetadataService) githubAPIGet(ctx context.Context, path, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", githubAPIURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if s.githubToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.githubToken)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call GitHub API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// 403 and 429 with no remaining quota are rate limiting, common without a token
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			return nil, fmt.Errorf("GitHub API rate limit exceeded; set GITHUB_TOKEN for a higher limit")
+		}
+		return nil, fmt.Errorf("GitHub API %s: status %d", path, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+}
+
+// Content is the text a repository is summarized, tagged, and embedded from:
+// its description, language, stars, and README excerpt
+func (r *GitHubRepo) Content() string {
+	var parts []string
+	if r.Description != "" {
+		parts = append(parts, r.Description)
+	}
+	var facts []string
+	if r.Language != "" {
+		facts = append(facts, "Language: "+r.Language)
+	}
+	facts = append(facts, "Stars: "+strconv.Itoa(r.Stars))
+	if len(r.Topics) > 0 {
+		facts = append(facts, "Topics: "+strings.Join(r.Topics, ", "))
+	}
+	parts = append(parts, strings.Join(facts, " | "))
+	if r.ReadmeExcerpt != "" {
+		parts = append(parts, r.ReadmeExcerpt)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+var (
+	markdownImageRe = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	markdownLinkRe  = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	markdownMarkRe  = regexp.MustCompile("[*_`]+")
+)
+
+// readmeExcerpt returns the opening prose of a Markdown README as plain text,
+// up to maxRunes. Badges, images, HTML, code blocks, and tables are skipped,
+// since they say little about what the project is.
+func readmeExcerpt(markdown string, maxRunes int) string {
+	var paragraphs []string
+	length := 0
+	inCode := false
+	for _, line := range strings.Split(markdown, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		if inCode || line == "" || strings.HasPrefix(line, "<") || strings.HasPrefix(line, "|") ||
+			strings.Trim(line, "-=*_ ") == "" {
+			continue
+		}
+
+		line = markdownImageRe.ReplaceAllString(line, "")
+		line = markdownLinkRe.ReplaceAllString(line, "$1")
+		line = markdownMarkRe.ReplaceAllString(line, "")
+		line = strings.TrimSpace(strings.TrimLeft(line, "#> "))
+		if line == "" {
+			continue
+		}
+
+		paragraphs = append(paragraphs, line)
+		length += len([]rune(line))
+		if length >= maxRunes {
+			break
+		}
+	}
+	return previewText(strings.Join(paragraphs, "\n"), maxRunes)
+}
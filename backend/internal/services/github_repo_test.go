@@ -0,0 +1,71 @@
+package services
+
+import "testing"
+
+func TestParseGitHubRepoURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		link      string
+		wantOwner string
+		wantRepo  string
+		wantOK    bool
+	}{
+		{"repository", "https://github.com/golang/go", "golang", "go", true},
+		{"www and trailing slash", "https://www.github.com/golang/go/", "golang", "go", true},
+		{"page within", "https://github.com/golang/go/issues/1?q=1#top", "golang", "go", true},
+		{"clone URL", "https://github.com/golang/go.git", "golang", "go", true},
+		{"host case", "https://GitHub.com/golang/go", "golang", "go", true},
+		{"owner only", "https://github.com/golang", "", "", false},
+		{"reserved path", "https://github.com/topics/go", "", "", false},
+		{"reserved path case", "https://github.com/Marketplace/actions", "", "", false},
+		{"other host", "https://gitlab.com/golang/go", "", "", false},
+		{"subdomain", "https://gist.github.com/someone/abc123", "", "", false},
+		{"invalid", "://github.com/golang/go", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, repo, ok := parseGitHubRepoURL(tt.link)
+			if owner != tt.wantOwner || repo != tt.wantRepo || ok != tt.wantOK {
+				t.Errorf("parseGitHubRepoURL(%q) = %q, %q, %v; want %q, %q, %v", tt.link, owner, repo, ok, tt.wantOwner, tt.wantRepo, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestReadmeExcerpt(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		maxRunes int
+		want     string
+	}{
+		{
+			name:     "badges, headings, and links",
+			markdown: "# Cobra\n\n[![Build](https://ci.example.com/badge.svg)](https://ci.example.com)\n\nCobra is a library for creating **powerful** modern CLI applications, used by [Kubernetes](https://kubernetes.io).\n",
+			maxRunes: 1000,
+			want:     "Cobra\nCobra is a library for creating powerful modern CLI applications, used by Kubernetes.",
+		},
+		{
+			name:     "code, HTML, tables, and rules skipped",
+			markdown: "<p align=\"center\"><img src=\"logo.png\"></p>\n\nFast JSON.\n\n```go\nimport \"fast/json\"\n```\n\n| Op | ns |\n|----|----|\n\n---\n\n> Zero `allocations`.\n",
+			maxRunes: 1000,
+			want:     "Fast JSON.\nZero allocations.",
+		},
+		{
+			name:     "cut at a word",
+			markdown: "A tiny web framework for building fast services.",
+			maxRunes: 20,
+			want:     "A tiny web framework...",
+		},
+		{name: "nothing but badges", markdown: "[![CI](x.svg)](y)\n![logo](logo.png)", maxRunes: 1000, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readmeExcerpt(tt.markdown, tt.maxRunes); got != tt.want {
+				t.Errorf("readmeExcerpt = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"synapse/internal/db"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusDown = "down"
+)
+
+// DependencyHealth is the result of probing one dependency
+type DependencyHealth struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthReport aggregates dependency checks; Status is "ok" only if every check passed
+type HealthReport struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyHealth `json:"dependencies"`
+}
+
+// Ready reports whether the server can take traffic
+func (r HealthReport) Ready() bool {
+	return r.Status == HealthStatusOK
+}
+
+type HealthService struct {
+	pool    *pgxpool.Pool
+	ai      *AIService
+	timeout time.Duration
+}
+
+func NewHealthService(pool *pgxpool.Pool, ai *AIService) *HealthService {
+	return &HealthService{
+		pool:    pool,
+		ai:      ai,
+		timeout: getEnvSeconds("HEALTH_CHECK_TIMEOUT_SECONDS", 5*time.Second),
+	}
+}
+
+// Check probes Postgres, ChromaDB and the AI provider concurrently, each bounded
+// by the health check timeout so one hung dependency can't stall the report
+func (s *HealthService) Check(ctx context.Context) HealthReport {
+	checks := map[string]func(context.Context) error{
+		"postgres": s.pool.Ping,
+		"chromadb": func(ctx context.Context) error {
+			if db.Chroma == nil {
+				return fmt.Errorf("ChromaDB client not initialized")
+			}
+			return db.Chroma.Ping(ctx)
+		},
+		"ai": s.ai.Ping,
+	}
+
+	report := HealthReport{
+		Status:       HealthStatusOK,
+		Dependencies: make(map[string]DependencyHealth, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+			result := s.probe(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Dependencies[name] = result
+			if result.Status != HealthStatusOK {
+				report.Status = HealthStatusDown
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (s *HealthService) probe(ctx context.Context, check func(context.Context) error) DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := DependencyHealth{
+		Status:    HealthStatusOK,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = HealthStatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
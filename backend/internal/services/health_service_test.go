@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHealthProbe(t *testing.T) {
+	s := &HealthService{timeout: 50 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		check     func(context.Context) error
+		wantState string
+		wantError string
+	}{
+		{"ok", func(context.Context) error { return nil }, HealthStatusOK, ""},
+		{"failing", func(context.Context) error { return errors.New("connection refused") }, HealthStatusDown, "connection refused"},
+		// A hung dependency is cut off at the timeout rather than stalling the report
+		{"hung", func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }, HealthStatusDown, context.DeadlineExceeded.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			got := s.probe(context.Background(), tt.check)
+			if got.Status != tt.wantState || got.Error != tt.wantError {
+				t.Errorf("probe = %+v, want status %q and error %q", got, tt.wantState, tt.wantError)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("probe took %v, want it bounded by the timeout", elapsed)
+			}
+		})
+	}
+}
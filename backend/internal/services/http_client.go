@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ErrNotHTML is returned when a page fetched for its metadata turns out to be
+// something else, such as an image, a video, or a download
+var ErrNotHTML = errors.New("not an HTML page")
+
+// defaultUserAgent is a current desktop browser's; many sites block or strip
+// pages for bot-looking User-Agents
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+
+// metadataUserAgent is the User-Agent sent when fetching saved pages and their
+// images: METADATA_USER_AGENT, else defaultUserAgent
+func metadataUserAgent() string {
+	if userAgent := os.Getenv("METADATA_USER_AGENT"); userAgent != "" {
+		return userAgent
+	}
+	return defaultUserAgent
+}
+
+// newHTTPClient returns a client that goes through the proxy named by
+// HTTP_PROXY/HTTPS_PROXY (minus NO_PROXY hosts) and, when userAgent is set,
+// sends it on every request that doesn't set its own. Clients fetching
+// user-supplied URLs pass a guard to keep them off private networks.
+func newHTTPClient(timeout time.Duration, userAgent string, guard *urlGuard) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	var roundTripper http.RoundTripper = transport
+	if guard != nil {
+		transport.DialContext = guard.dialContext
+		roundTripper = &guardTransport{base: transport, guard: guard, proxy: transport.Proxy}
+	}
+	if userAgent != "" {
+		roundTripper = &userAgentTransport{base: roundTripper, userAgent: userAgent}
+	}
+	return &http.Client{Timeout: timeout, Transport: roundTripper}
+}
+
+// limitRedirects is an http.Client CheckRedirect that stops after max redirects
+func limitRedirects(max int) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > max {
+			return fmt.Errorf("stopped after %d redirects", max)
+		}
+		return nil
+	}
+}
+
+// fetchHTML GETs an HTML page for parsing. Responses that aren't HTML fail
+// with ErrNotHTML before their body is read, and the body is cut off after
+// METADATA_MAX_HTML_BYTES, so a huge file or an endless stream can't exhaust
+// memory. The caller closes the body.
+func (s *MetadataService) fetchHTML(ctx context.Context, pageURL string) (*http.Response, error) {
+	resp, err := s.requestPage(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch %s: status %d", pageURL, resp.StatusCode)
+	}
+	if mediaType, ok := htmlMediaType(resp); !ok {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s is %s", ErrNotHTML, pageURL, mediaType)
+	}
+
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(resp.Body, s.htmlMaxBytes), resp.Body}
+	return resp, nil
+}
+
+// requestPage GETs pageURL asking for HTML and returns the response whatever
+// its status. The caller closes the body.
+func (s *MetadataService) requestPage(ctx context.Context, pageURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", pageURL, err)
+	}
+	return resp, nil
+}
+
+// htmlMediaType returns resp's media type and whether it's an HTML page. A
+// missing Content-Type counts as HTML; the parsers cope with whatever arrives.
+func htmlMediaType(resp *http.Response) (string, bool) {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		return "", true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType, mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// userAgentTransport sets a default User-Agent on outgoing requests
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		// RoundTrippers must not modify the caller's request
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.base.RoundTrip(req)
+}
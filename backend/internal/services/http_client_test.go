@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserAgentTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestUA string
+		want      string
+	}{
+		{"default set", "", "TestAgent/1.0"},
+		{"caller's kept", "Custom/2.0", "Custom/2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get("User-Agent")
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: &userAgentTransport{base: http.DefaultTransport, userAgent: "TestAgent/1.0"}}
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			if tt.requestUA != "" {
+				req.Header.Set("User-Agent", tt.requestUA)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			resp.Body.Close()
+
+			if got != tt.want {
+				t.Errorf("server saw User-Agent %q, want %q", got, tt.want)
+			}
+			// RoundTrippers must leave the caller's request alone
+			if ua := req.Header.Get("User-Agent"); ua != tt.requestUA {
+				t.Errorf("caller's request User-Agent changed to %q", ua)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		guard     *urlGuard
+	}{
+		{"plain", "", nil},
+		{"with User-Agent", "TestAgent/1.0", nil},
+		{"guarded", "TestAgent/1.0", &urlGuard{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newHTTPClient(7*time.Second, tt.userAgent, tt.guard)
+			if client.Timeout != 7*time.Second {
+				t.Errorf("timeout = %v, want 7s", client.Timeout)
+			}
+
+			roundTripper := client.Transport
+			if ua, ok := roundTripper.(*userAgentTransport); ok != (tt.userAgent != "") {
+				t.Fatalf("User-Agent transport = %v, want it only with a User-Agent", ok)
+			} else if ok {
+				if ua.userAgent != tt.userAgent {
+					t.Errorf("User-Agent = %q, want %q", ua.userAgent, tt.userAgent)
+				}
+				roundTripper = ua.base
+			}
+			if guarded, ok := roundTripper.(*guardTransport); ok != (tt.guard != nil) {
+				t.Fatalf("guard transport = %v, want it only with a guard", ok)
+			} else if ok {
+				if guarded.guard != tt.guard || guarded.proxy == nil {
+					t.Error("guard transport doesn't use the guard and the proxy")
+				}
+				roundTripper = guarded.base
+			}
+
+			transport, ok := roundTripper.(*http.Transport)
+			if !ok {
+				t.Fatalf("innermost transport is %T, want *http.Transport", roundTripper)
+			}
+			// HTTP_PROXY and HTTPS_PROXY are honored
+			if transport.Proxy == nil {
+				t.Error("transport doesn't use a proxy from the environment")
+			}
+			// The shared default transport is cloned, not modified
+			if transport == http.DefaultTransport {
+				t.Error("client modifies http.DefaultTransport")
+			}
+		})
+	}
+}
+
+func TestFetchHTML(t *testing.T) {
+	page := "<html><head><title>Hi</title></head><body>" + strings.Repeat("x", 100) + "</body></html>"
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		wantBody    string
+		wantErr     error
+		wantFail    bool
+	}{
+		{"html", http.StatusOK, "text/html; charset=utf-8", page[:64], nil, false},
+		{"xhtml", http.StatusOK, "application/xhtml+xml", page[:64], nil, false},
+		{"no content type", http.StatusOK, "", page[:64], nil, false},
+		{"image", http.StatusOK, "image/png", "", ErrNotHTML, true},
+		{"download", http.StatusOK, "application/octet-stream", "", ErrNotHTML, true},
+		{"error status", http.StatusNotFound, "text/html", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if accept := r.Header.Get("Accept"); !strings.HasPrefix(accept, "text/html") {
+					t.Errorf("Accept = %q, want HTML first", accept)
+				}
+				// Go sniffs a Content-Type when none is set; an empty one is sent as-is
+				w.Header()["Content-Type"] = []string{tt.contentType}
+				w.WriteHeader(tt.status)
+				io.WriteString(w, page)
+			}))
+			defer server.Close()
+
+			s := &MetadataService{client: server.Client(), htmlMaxBytes: 64}
+			resp, err := s.fetchHTML(context.Background(), server.URL)
+			if (err != nil) != tt.wantFail || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("fetchHTML error = %v, want failure %v (%v)", err, tt.wantFail, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+			// The body is cut off at htmlMaxBytes
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %q, want the first 64 bytes %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestLimitRedirects(t *testing.T) {
+	tests := []struct {
+		name      string
+		redirects int
+		max       int
+		wantErr   bool
+	}{
+		{"none", 0, 2, false},
+		{"at the limit", 2, 2, false},
+		{"over the limit", 3, 2, true},
+		{"none allowed", 1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// /hop/N redirects N more times before the page
+				n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hop/"))
+				if n > 0 {
+					http.Redirect(w, r, fmt.Sprintf("/hop/%d", n-1), http.StatusFound)
+				}
+			}))
+			defer server.Close()
+
+			client := server.Client()
+			client.CheckRedirect = limitRedirects(tt.max)
+			resp, err := client.Get(fmt.Sprintf("%s/hop/%d", server.URL, tt.redirects))
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("following %d redirects with a limit of %d: error = %v, wantErr %v", tt.redirects, tt.max, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxImageBytes caps images downloaded for text extraction; larger ones are
+// rejected rather than sent to the vision model
+const maxImageBytes = 10 * 1024 * 1024
+
+// ExtractImageText reads the text in the image at imageURL (an http(s) or
+// data: URL) with the AI provider's vision model, describing the image instead
+// when it has little text. It makes screenshots and photos searchable.
+func (s *MetadataService) ExtractImageText(ctx context.Context, imageURL string) (string, error) {
+	if s.aiService == nil || !s.aiService.SupportsVision() {
+		return "", ErrVisionUnsupported
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	data, mimeType, err := loadImage(ctx, s.client, imageURL)
+	if err != nil {
+		return "", err
+	}
+	return s.aiService.ExtractImageText(ctx, data, mimeType)
+}
+
+// loadImage returns the bytes and MIME type of an image URL, decoding data: URLs in place
+func loadImage(ctx context.Context, client *http.Client, imageURL string) ([]byte, string, error) {
+	if strings.HasPrefix(imageURL, "data:") {
+		// data:image/png;base64,<data>
+		header, encoded, found := strings.Cut(strings.TrimPrefix(imageURL, "data:"), ",")
+		if !found || !strings.HasSuffix(header, ";base64") {
+			return nil, "", fmt.Errorf("unsupported data URL; expected base64-encoded image data")
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid base64 image data: %w", err)
+		}
+		return checkImage(data, strings.TrimSuffix(header, ";base64"))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, "", fmt.Errorf("failed to download image: status %d", resp.StatusCode)
+	}
+
+	// Read one byte past the limit to tell a full-size image from a truncated one
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image: %w", err)
+	}
+	return checkImage(data, resp.Header.Get("Content-Type"))
+}
+
+// checkImage validates downloaded image data, sniffing the MIME type when the
+// declared one is missing or generic
+func checkImage(data []byte, mimeType string) ([]byte, string, error) {
+	if len(data) > maxImageBytes {
+		return nil, "", fmt.Errorf("image is larger than %d bytes", maxImageBytes)
+	}
+	mimeType = strings.TrimSpace(strings.Split(mimeType, ";")[0])
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, "", fmt.Errorf("not an image (%s)", mimeType)
+	}
+	return data, mimeType, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// pngHeader is enough of a PNG file for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestCheckImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		mimeType string
+		want     string
+		wantErr  bool
+	}{
+		{"declared", []byte("jpeg bytes"), "image/jpeg", "image/jpeg", false},
+		{"declared with parameters", pngHeader, " image/png; charset=binary", "image/png", false},
+		{"generic type is sniffed", pngHeader, "application/octet-stream", "image/png", false},
+		{"missing type is sniffed", pngHeader, "", "image/png", false},
+		{"not an image", []byte("<html><body>hi</body></html>"), "text/html", "", true},
+		{"too large", make([]byte, maxImageBytes+1), "image/png", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, mimeType, err := checkImage(tt.data, tt.mimeType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkImage error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if mimeType != tt.want {
+				t.Errorf("checkImage MIME type = %q, want %q", mimeType, tt.want)
+			}
+			if !tt.wantErr && !bytes.Equal(data, tt.data) {
+				t.Error("checkImage changed the image data")
+			}
+		})
+	}
+}
+
+func TestLoadImage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(pngHeader)
+		case "/huge.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(make([]byte, maxImageBytes+1))
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	encoded := base64.StdEncoding.EncodeToString(pngHeader)
+	tests := []struct {
+		name     string
+		imageURL string
+		want     string
+		wantErr  bool
+	}{
+		{"download", server.URL + "/photo.png", "image/png", false},
+		{"data URL", "data:image/png;base64," + encoded, "image/png", false},
+		{"data URL without a type", "data:;base64," + encoded, "image/png", false},
+		{"data URL not base64", "data:image/png," + encoded, "", true},
+		{"data URL with bad base64", "data:image/png;base64,!!!", "", true},
+		{"missing", server.URL + "/gone.png", "", true},
+		{"too large", server.URL + "/huge.png", "", true},
+		{"not an image", server.URL + "/page", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, mimeType, err := loadImage(context.Background(), server.Client(), tt.imageURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadImage error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if mimeType != tt.want {
+				t.Errorf("loadImage MIME type = %q, want %q", mimeType, tt.want)
+			}
+			if !tt.wantErr && !bytes.Equal(data, pngHeader) {
+				t.Errorf("loadImage data = %q, want the PNG", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"synapse/internal/logging"
+
+	"github.com/google/uuid"
+)
+
+// importJobRetention is how long a finished import job can still be polled
+const importJobRetention = time.Hour
+
+// Import job statuses
+const (
+	ImportJobRunning = "running"
+	ImportJobDone    = "done"
+	ImportJobFailed  = "failed"
+)
+
+// ImportJob is a bulk or bookmark import running in the background. Result is
+// the import's report once Status is done; a failed job may still carry a
+// partial report alongside Error.
+type ImportJob struct {
+	ID         uuid.UUID  `json:"id"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Result     any        `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+
+	userID uuid.UUID
+}
+
+// importJobs holds the import jobs of every user, in memory; jobs don't
+// survive a restart
+type importJobs struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*ImportJob
+}
+
+// startImportJob runs fn in the background for userID and returns the job
+// tracking it. fn gets a context that keeps ctx's values, such as the request
+// ID, but isn't cancelled when the request that started it ends.
+func (s *ItemService) startImportJob(ctx context.Context, userID uuid.UUID, fn func(ctx context.Context) (any, error)) *ImportJob {
+	job := &ImportJob{ID: uuid.New(), Status: ImportJobRunning, CreatedAt: time.Now(), userID: userID}
+
+	s.importJobs.mu.Lock()
+	if s.importJobs.jobs == nil {
+		s.importJobs.jobs = map[uuid.UUID]*ImportJob{}
+	}
+	// Forget jobs nobody polled within the retention window
+	for id, old := range s.importJobs.jobs {
+		if old.FinishedAt != nil && time.Since(*old.FinishedAt) > importJobRetention {
+			delete(s.importJobs.jobs, id)
+		}
+	}
+	s.importJobs.jobs[job.ID] = job
+	snapshot := *job
+	s.importJobs.mu.Unlock()
+
+	go func(ctx context.Context) {
+		result, err := fn(ctx)
+
+		s.importJobs.mu.Lock()
+		defer s.importJobs.mu.Unlock()
+		now := time.Now()
+		job.FinishedAt = &now
+		job.Result = result
+		job.Status = ImportJobDone
+		if err != nil {
+			job.Status = ImportJobFailed
+			job.Error = err.Error()
+			logging.OrDefault(s.logger).WarnContext(ctx, "import job failed", "operation", "import_job", "job_id", job.ID, "error", err)
+		}
+	}(context.WithoutCancel(ctx))
+
+	return &snapshot
+}
+
+// GetImportJob returns a copy of userID's import job id; false if there's no
+// such job or it belongs to someone else
+func (s *ItemService) GetImportJob(userID, id uuid.UUID) (*ImportJob, bool) {
+	s.importJobs.mu.Lock()
+	defer s.importJobs.mu.Unlock()
+	job, ok := s.importJobs.jobs[id]
+	if !ok || job.userID != userID {
+		return nil, false
+	}
+	snapshot := *job
+	return &snapshot, true
+}
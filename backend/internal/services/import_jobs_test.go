@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestImportJob(t *testing.T) {
+	s := &ItemService{}
+	owner := uuid.New()
+
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	started := s.startImportJob(ctx, owner, func(ctx context.Context) (any, error) {
+		<-release
+		// The request that started the job has ended by now
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return "report", errors.New("partly failed")
+	})
+	if started.Status != ImportJobRunning {
+		t.Fatalf("status = %q, want %q", started.Status, ImportJobRunning)
+	}
+	cancel()
+
+	if _, ok := s.GetImportJob(uuid.New(), started.ID); ok {
+		t.Error("another user can see the job")
+	}
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, ok := s.GetImportJob(owner, started.ID)
+		if !ok {
+			t.Fatal("job not found")
+		}
+		if job.Status != ImportJobRunning {
+			if job.Status != ImportJobFailed || job.Error != "partly failed" || job.Result != "report" || job.FinishedAt == nil {
+				t.Errorf("job = %+v, want failed with its partial report", job)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job never finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,740 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"synapse/internal/db"
+	"synapse/internal/events"
+	"synapse/internal/models"
+)
+
+// EnrichedItem is an item built by enrichItem, ready to be saved
+type EnrichedItem struct {
+	Item      *models.Item
+	Embedding []float32
+	// AIContent is what the AI worked from: the content, its English translation, or an image's text
+	AIContent string
+	// Summarized is set when Item.Summary is already the AI summary rather than a placeholder
+	Summarized bool
+}
+
+// enrichItem builds a new item from req: it fills in the title, content, and
+// images from the source, and generates the category, tags, summary, and
+// embedding. Nothing is stored. A nil embed skips the embedding and the
+// near-duplicate check that needs it.
+func (s *ItemService) enrichItem(ctx context.Context, userID uuid.UUID, req *models.CreateItemRequest, embed func(context.Context, string) ([]float32, error)) (*EnrichedItem, error) {
+	// Generate ID
+	itemID := uuid.New()
+	embeddingID := itemID.String()
+
+	// Infer the type when the caller didn't send one
+	if req.Type == "" {
+		req.Type = s.detectContentType(req.Title, req.Content, req.SourceURL)
+	}
+
+	// Saving the same link twice returns the existing item instead
+	checkDuplicates := !s.allowDuplicates && !req.AllowDuplicate
+	if checkDuplicates && req.SourceURL != "" {
+		existing, err := s.findDuplicateByURL(ctx, userID, req.SourceURL)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to check for duplicate", "operation", "create_item", "item_id", itemID, "source_url", req.SourceURL, "error", err)
+		} else if existing != nil {
+			return nil, &DuplicateItemError{Existing: existing, Reason: "source_url"}
+		}
+	}
+
+	// Saved PDFs are summarized and embedded from the document's own text
+	pdfLink := req.SourceURL != "" && isPDFURL(req.SourceURL)
+	if pdfLink && req.Content == "" {
+		text, err := s.metadataService.ExtractPDFText(ctx, req.SourceURL)
+		if errors.Is(err, ErrNoExtractableText) {
+			s.logger.InfoContext(ctx, "PDF has no extractable text, likely scanned", "operation", "create_item", "item_id", itemID, "source_url", req.SourceURL)
+		} else if err != nil {
+			s.logger.WarnContext(ctx, "failed to extract PDF text", "operation", "create_item", "item_id", itemID, "source_url", req.SourceURL, "error", err)
+		} else {
+			req.Content = text
+		}
+	}
+
+	// Amazon products get their title, image, and a structured price from the product page
+	var product *Product
+	if req.Type == "amazon" && req.SourceURL != "" {
+		var err error
+		product, err = s.metadataService.GetAmazonProduct(ctx, req.SourceURL)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to fetch product data", "operation", "create_item", "item_id", itemID, "source_url", req.SourceURL, "error", err)
+		} else {
+			if req.Title == "" {
+				req.Title = product.Title
+			}
+			if req.ImageURL == "" {
+				req.ImageURL = product.ImageURL
+			}
+		}
+	}
+
+	// GitHub repositories are described by the GitHub API rather than their page:
+	// the description, language, stars, and README become the content, and topics
+	// become tags. If the API fails, the page's own metadata is used below.
+	if req.SourceURL != "" && (req.Type == "url" || req.Type == "blog") {
+		if _, _, ok := parseGitHubRepoURL(req.SourceURL); ok {
+			repo, err := s.metadataService.GetGitHubRepo(ctx, req.SourceURL)
+			if err != nil {
+				s.logger.WarnContext(ctx, "failed to fetch GitHub repository", "operation", "create_item", "item_id", itemID, "source_url", req.SourceURL, "error", err)
+			} else {
+				if req.Title == "" {
+					req.Title = repo.FullName
+				}
+				if req.Content == "" || looksLikeHTMLPage(req.Content) {
+					req.Content = repo.Content()
+				}
+				if req.ImageURL == "" {
+					req.ImageURL = repo.ImageURL
+				}
+				// Topics are cleaned up like generated tags and join the given ones once each
+				req.Tags = mergeTags(req.Tags, parseTags(strings.Join(repo.Topics, ","), 0))
+			}
+		}
+	}
+
+	// X/Twitter posts are rendered by JavaScript, so their page has no text to
+	// read; the post text and author come from Twitter's oEmbed endpoint instead.
+	// The post's embed comes with them, so oEmbed isn't asked again for the preview.
+	var tweetEmbedHTML string
+	if req.SourceURL != "" && (req.Type == "url" || req.Type == "blog") {
+		if _, _, ok := parseTweetURL(req.SourceURL); ok {
+			tweet, err := s.metadataService.GetTweet(ctx, req.SourceURL)
+			if err != nil {
+				s.logger.WarnContext(ctx, "failed to fetch tweet", "operation", "create_item", "item_id", itemID, "source_url", req.SourceURL, "error", err)
+			} else {
+				if req.Title == "" {
+					req.Title = tweet.Title()
+				}
+				if (req.Content == "" || looksLikeHTMLPage(req.Content)) && tweet.Text != "" {
+					req.Content = tweet.Text
+				}
+				if req.ImageURL == "" {
+					req.ImageURL = tweet.ImageURL
+				}
+				if req.Type == "url" {
+					tweetEmbedHTML = tweet.EmbedHTML
+				}
+			}
+		}
+	}
+
+	// Pages captured as raw HTML are reduced to their article body, so boilerplate
+	// (nav, ads, footers) doesn't end up in the summary and embedding
+	if (req.Type == "url" || req.Type == "blog") && looksLikeHTMLPage(req.Content) {
+		text := extractReadableText(strings.NewReader(req.Content))
+		if text == "" && req.SourceURL != "" {
+			var err error
+			text, err = s.metadataService.ExtractReadableText(ctx, req.SourceURL)
+			if err != nil {
+				s.logger.WarnContext(ctx, "failed to extract readable text", "operation", "create_item", "item_id", itemID, "source_url", req.SourceURL, "error", err)
+			}
+		}
+		if text != "" {
+			req.Content = text
+		}
+	}
+
+	// For bare link saves, fill in the title and description from the page itself
+	var pageFaviconURL, pageDescription string
+	// pageFetched records that the page's icon was already looked for, found or not
+	pageFetched := false
+	if req.SourceURL != "" && !pdfLink && (req.Type == "url" || req.Type == "blog") && (req.Title == "" || req.Content == "") {
+		page, err := s.metadataService.GetPageMetadata(ctx, req.SourceURL)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to fetch page metadata", "operation", "create_item", "item_id", itemID, "source_url", req.SourceURL, "error", err)
+		} else {
+			if req.Title == "" {
+				req.Title = page.Title
+			}
+			if req.Content == "" {
+				req.Content = page.Description
+			}
+			// The page's own description is the summary until the AI's is ready
+			pageDescription = page.Description
+			if req.ImageURL == "" {
+				req.ImageURL = page.ImageURL
+			}
+			pageFaviconURL = page.FaviconURL
+			pageFetched = true
+		}
+	}
+	// Videos saved as a bare link get their real title from the provider
+	var videoEmbedHTML, videoImageURL string
+	if req.Type == "video" && req.SourceURL != "" && req.Title == "" {
+		embedHTML, imageURL, title, err := s.metadataService.GetURLMetadata(ctx, req.SourceURL)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to fetch video metadata", "operation", "create_item", "item_id", itemID, "source_url", req.SourceURL, "error", err)
+		} else {
+			req.Title = title
+			videoEmbedHTML, videoImageURL = embedHTML, imageURL
+		}
+	}
+	// Images carry no text of their own, so read it off the image with the AI
+	// provider's vision model; it's what gets summarized, tagged, and embedded.
+	// The image's description is slower and left to describeImageAsync.
+	var imageText string
+	if imageURL := imageSource(req); imageURL != "" && req.Content == "" && s.aiService.SupportsVision() {
+		text, err := s.metadataService.ExtractImageText(ctx, imageURL)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to extract image text", "operation", "create_item", "item_id", itemID, "error", err)
+		} else {
+			imageText = text
+		}
+	}
+	// Untitled quick saves (a text snippet, a link with no page title) get an AI title
+	if req.Title == "" && (req.Content != "" || req.SourceURL != "" || imageText != "") {
+		titleInput := req.Content
+		if titleInput == "" {
+			titleInput = imageText
+		}
+		if titleInput == "" {
+			titleInput = req.SourceURL
+		}
+		title, err := s.aiService.GenerateTitle(ctx, titleInput)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to generate title", "operation", "create_item", "item_id", itemID, "error", err)
+		} else {
+			req.Title = title
+		}
+	}
+	if req.Title == "" {
+		req.Title = req.SourceURL
+	}
+
+	// Prepare content for processing
+	content := req.Content
+	if content == "" {
+		content = req.Title
+	}
+
+	// aiContent is what summaries, tags, and embeddings are generated from.
+	// When translation is requested it holds the English translation, while
+	// the original content is still what gets stored on the item.
+	aiContent := content
+	languageChan := make(chan string, 1)
+	if req.TranslateToEnglish || s.translateToEnglish {
+		// Translation must finish before the AI fan-out, so detect synchronously
+		language := s.detectLanguage(ctx, content)
+		if language != "" && language != "en" {
+			translated, err := s.aiService.TranslateToEnglish(ctx, content)
+			if err != nil || translated == "" {
+				s.logger.WarnContext(ctx, "failed to translate content to English, using original", "operation", "create_item", "item_id", itemID, "language", language, "error", err)
+			} else {
+				aiContent = translated
+			}
+		}
+		languageChan <- language
+	} else {
+		go func() {
+			languageChan <- s.detectLanguage(ctx, content)
+		}()
+	}
+	if imageText != "" {
+		// The extracted text is stored as the item's OCR text, not its content
+		aiContent = strings.TrimSpace(req.Title + "\n\n" + imageText)
+	}
+
+	// Generate category, tags, and embedding in parallel (synchronous for initial save)
+	type categoryResult struct {
+		category string
+		err      error
+	}
+	type embeddingResult struct {
+		embedding []float32
+		// semanticSummary is what was embedded, "" if the summary couldn't be generated
+		semanticSummary string
+		sourceHash      string
+		err             error
+	}
+
+	categoryChan := make(chan categoryResult, 1)
+	tagsChan := make(chan []string, 1)
+	// Only filled when the embedding includes the tags
+	embedTagsChan := make(chan []string, 1)
+	embeddingChan := make(chan embeddingResult, 1)
+
+	// Generate category (AI-powered categorization)
+	go func() {
+		category, err := s.aiService.CategorizeContent(ctx, req.Title, aiContent, req.Type, nil)
+		categoryChan <- categoryResult{category: category, err: err}
+	}()
+
+	// Generate tags
+	go func() {
+		tags, err := s.aiService.GenerateTags(ctx, aiContent)
+		if err != nil {
+			// Tags are optional, continue with empty tags
+			tags = []string{}
+		}
+		tags = mergeTags(req.Tags, tags)
+		tagsChan <- tags
+		if s.embeddingInput.usesTags() {
+			embedTagsChan <- tags
+		}
+	}()
+
+	// Generate the semantic summary, then embed it. Videos get a video-specific
+	// summary asynchronously after the save, so their content is embedded directly.
+	// Without an embed func (previews) only the summary is generated.
+	go func() {
+		var semanticSummary string
+		if req.Type != "video" {
+			summary, err := s.aiService.GenerateSemanticSummary(ctx, req.Title, aiContent)
+			if err != nil {
+				// Optional - the summary is retried asynchronously and the content embedded instead
+				s.logger.WarnContext(ctx, "failed to generate semantic summary, embedding content instead", "operation", "create_item", "item_id", itemID, "error", err)
+			} else {
+				semanticSummary = strings.TrimSpace(summary)
+			}
+		}
+		if embed == nil {
+			embeddingChan <- embeddingResult{semanticSummary: semanticSummary}
+			return
+		}
+		source := embeddingSource{title: req.Title, summary: semanticSummary, content: aiContent}
+		if s.embeddingInput.usesTags() {
+			source.tags = <-embedTagsChan
+		}
+		text := s.composeEmbeddingText(source)
+		embedding, err := embed(ctx, text)
+		embeddingChan <- embeddingResult{embedding: embedding, semanticSummary: semanticSummary, sourceHash: embeddingSourceHash(text), err: err}
+	}()
+
+	// Wait for all results
+	categoryRes := <-categoryChan
+	tags := <-tagsChan
+	embeddingRes := <-embeddingChan
+	language := <-languageChan
+
+	// Handle errors - make AI features optional if API fails
+	if categoryRes.err != nil {
+		// If categorization fails, use a default category based on type
+		categoryRes.category = s.getDefaultCategory(req.Type, req.SourceURL)
+	}
+
+	// Override category for YouTube videos - always "Videos & Entertainment"
+	if req.SourceURL != "" && (strings.Contains(req.SourceURL, "youtube.com") || strings.Contains(req.SourceURL, "youtu.be")) {
+		categoryRes.category = "Videos & Entertainment"
+	}
+
+	// Override category for video type - always "Videos & Entertainment"
+	if req.Type == "video" {
+		categoryRes.category = "Videos & Entertainment"
+	}
+	if embed == nil {
+		embeddingID = ""
+	} else if embeddingRes.err != nil {
+		if !isTransientAIError(embeddingRes.err) {
+			// Auth and other permanent failures won't fix themselves - fail fast
+			return nil, fmt.Errorf("failed to generate embedding (check AI API key): %w", embeddingRes.err)
+		}
+		// Transient failures (rate limits, outages) shouldn't lose the save;
+		// keep the item without a vector so it can be reindexed later
+		s.logger.WarnContext(ctx, "embedding generation temporarily failed, saving item without embedding", "operation", "create_item", "item_id", itemID, "error", embeddingRes.err)
+		embeddingID = ""
+	}
+	if embeddingID != "" {
+		// A vector of the wrong size would be rejected by ChromaDB or make search nonsense
+		if err := s.embeddingGuard.Check(ctx, embeddingRes.embedding); err != nil {
+			return nil, err
+		}
+	}
+
+	// Near-duplicate content (same article from a different URL, a re-pasted note)
+	if checkDuplicates && s.duplicateSimilarity > 0 && embeddingID != "" {
+		existing, similarity, err := s.findNearDuplicate(ctx, userID, embeddingRes.embedding)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to check for near-duplicate content", "operation", "create_item", "item_id", itemID, "error", err)
+		} else if existing != nil {
+			return nil, &DuplicateItemError{Existing: existing, Reason: "similar_content", Similarity: similarity}
+		}
+	}
+
+	// Get metadata (embeds, covers, images) in parallel
+	type metadataResult struct {
+		embedHTML   string
+		imageURL    string
+		readingTime int
+		faviconURL  string
+		err         error
+	}
+	metadataChan := make(chan metadataResult, 1)
+
+	go func() {
+		embedHTML, imageURL := tweetEmbedHTML, ""
+		var err error
+
+		// For videos, ALWAYS get embed HTML (required for embedded playback)
+		if req.Type == "video" && req.SourceURL != "" {
+			if videoEmbedHTML != "" {
+				embedHTML, imageURL = videoEmbedHTML, videoImageURL
+			} else {
+				embedHTML, imageURL, _, err = s.metadataService.GetURLMetadata(ctx, req.SourceURL)
+			}
+			// If GetURLMetadata didn't return embed, try to generate it from URL
+			if embedHTML == "" && (strings.Contains(req.SourceURL, "youtube.com") || strings.Contains(req.SourceURL, "youtu.be")) {
+				videoID := s.extractYouTubeIDFromURL(req.SourceURL)
+				if videoID != "" {
+					embedHTML = fmt.Sprintf(`<iframe width="100%%" height="100%%" src="https://www.youtube.com/embed/%s?rel=0" frameborder="0" allow="accelerometer; autoplay; clipboard-write; encrypted-media; gyroscope; picture-in-picture; web-share" allowfullscreen style="position: absolute; top: 0; left: 0; width: 100%%; height: 100%%;"></iframe>`, videoID)
+					if imageURL == "" {
+						imageURL = fmt.Sprintf("https://img.youtube.com/vi/%s/hqdefault.jpg", videoID)
+					}
+				}
+			}
+		}
+
+		// Use pre-extracted image URL if provided (from extension) - but don't override embed
+		if req.ImageURL != "" && imageURL == "" {
+			imageURL = req.ImageURL
+		}
+
+		// If URL type (not video), get embed and preview
+		// This will also handle PDFs via GetURLMetadata
+		if req.Type == "url" && req.SourceURL != "" && embedHTML == "" {
+			var previewImageURL string
+			embedHTML, previewImageURL, _, err = s.metadataService.GetURLMetadata(ctx, req.SourceURL)
+			// Pages without a preview image keep the one sent or found with the page metadata
+			if previewImageURL != "" {
+				imageURL = previewImageURL
+			}
+		}
+
+		// Check if URL is a PDF and generate embed if needed (fallback if GetURLMetadata didn't catch it)
+		if embedHTML == "" && pdfLink {
+			embedHTML = fmt.Sprintf(`<iframe width="100%%" height="100%%" src="%s" frameborder="0" style="position: absolute; top: 0; left: 0; width: 100%%; height: 100%%;" type="application/pdf"></iframe>`, req.SourceURL)
+		}
+
+		// Check if URL is a YouTube video even if type is not "video"
+		if embedHTML == "" && req.SourceURL != "" && (strings.Contains(req.SourceURL, "youtube.com") || strings.Contains(req.SourceURL, "youtu.be")) {
+			videoID := s.extractYouTubeIDFromURL(req.SourceURL)
+			if videoID != "" {
+				embedHTML = fmt.Sprintf(`<iframe width="100%%" height="100%%" src="https://www.youtube.com/embed/%s?rel=0" frameborder="0" allow="accelerometer; autoplay; clipboard-write; encrypted-media; gyroscope; picture-in-picture; web-share" allowfullscreen style="position: absolute; top: 0; left: 0; width: 100%%; height: 100%%;"></iframe>`, videoID)
+				if imageURL == "" {
+					imageURL = fmt.Sprintf("https://img.youtube.com/vi/%s/hqdefault.jpg", videoID)
+				}
+			}
+		}
+
+		// For Amazon products, use metadata image if available
+		if req.Type == "amazon" && req.Metadata != nil && req.Metadata["image"] != "" {
+			imageURL = req.Metadata["image"]
+		}
+
+		// For blogs, use metadata image if available
+		if req.Type == "blog" && req.Metadata != nil && req.Metadata["image"] != "" {
+			imageURL = req.Metadata["image"]
+		}
+
+		// For videos, use thumbnail if available (fallback if GetURLMetadata didn't work)
+		if req.Type == "video" && imageURL == "" && req.Metadata != nil && req.Metadata["thumbnail"] != "" {
+			imageURL = req.Metadata["thumbnail"]
+		}
+
+		// Detect and get book cover
+		if imageURL == "" {
+			bookCover, err2 := s.metadataService.DetectBookAndGetCover(ctx, req.Title, aiContent)
+			if err2 == nil && bookCover != "" {
+				imageURL = bookCover
+				if req.Type == "" {
+					req.Type = "book"
+				}
+			}
+		}
+
+		// Detect and get recipe image
+		if imageURL == "" {
+			recipeImage, err2 := s.metadataService.DetectRecipeAndGetImage(ctx, req.Title, aiContent)
+			if err2 == nil && recipeImage != "" {
+				imageURL = recipeImage
+				if req.Type == "" {
+					req.Type = "recipe"
+				}
+			}
+		}
+
+		// If still no image, try to fetch a relevant image based on category
+		// This should work for all content types (text, blog, etc.)
+		if imageURL == "" {
+			if categoryRes.category != "" {
+				// Use category-based image fetching
+				relevantImage, err2 := s.metadataService.FetchRelevantImage(ctx, req.Title, aiContent, req.Type, categoryRes.category)
+				if err2 == nil && relevantImage != "" {
+					imageURL = relevantImage
+				}
+			} else if req.Type != "" {
+				// Fallback: use type-based default category
+				defaultCategory := s.getDefaultCategory(req.Type, req.SourceURL)
+				if defaultCategory != "" {
+					relevantImage, err2 := s.metadataService.FetchRelevantImage(ctx, req.Title, aiContent, req.Type, defaultCategory)
+					if err2 == nil && relevantImage != "" {
+						imageURL = relevantImage
+					}
+				}
+			}
+		}
+
+		// Reading time: a video's length, otherwise the content's word count
+		var readingTime int
+		if req.Type == "video" {
+			readingTime = s.videoDurationMinutes(ctx, req)
+		} else {
+			readingTime = estimateReadingTime(content)
+		}
+
+		// Links show their site's icon; reuse the page metadata's lookup when
+		// there was one, rather than fetching the page and /favicon.ico again
+		faviconURL := pageFaviconURL
+		if !pageFetched && req.SourceURL != "" && (req.Type == "url" || req.Type == "blog" || req.Type == "amazon") {
+			favicon, err2 := s.metadataService.GetFavicon(ctx, req.SourceURL)
+			if err2 != nil {
+				s.logger.WarnContext(ctx, "failed to resolve favicon", "operation", "create_item", "item_id", itemID, "source_url", req.SourceURL, "error", err2)
+			}
+			faviconURL = favicon
+		}
+
+		metadataChan <- metadataResult{embedHTML: embedHTML, imageURL: imageURL, readingTime: readingTime, faviconURL: faviconURL, err: err}
+	}()
+
+	metadataRes := <-metadataChan
+
+	// Set initial summary (will be replaced by async AI summary)
+	initialSummary := ""
+	if req.Type == "video" && req.Metadata != nil && req.Metadata["description"] != "" {
+		// For videos, use a truncated version of description as initial summary
+		// The full description stays in content, summary will be replaced by AI
+		initialSummary = previewText(req.Metadata["description"], 200)
+	} else if req.Type == "video" && content != "" {
+		// Extract description from content if it contains "Description:" marker
+		var desc string
+		if descIdx := strings.Index(content, "Description:"); descIdx != -1 {
+			desc = strings.TrimSpace(content[descIdx+len("Description:"):])
+		} else {
+			desc = content
+		}
+		initialSummary = previewText(desc, 200)
+	} else if embeddingRes.semanticSummary != "" {
+		// Already generated for the embedding, so no async summary is needed
+		initialSummary = embeddingRes.semanticSummary
+	} else if pageDescription != "" {
+		initialSummary = previewText(pageDescription, 200)
+	} else {
+		// For non-videos, use truncated content
+		initialSummary = previewText(content, 200)
+	}
+
+	item := &models.Item{
+		ID:          itemID,
+		Title:       req.Title,
+		Content:     content,
+		Summary:     initialSummary, // Temporary unless the semantic summary was generated above
+		SourceURL:   req.SourceURL,
+		Type:        req.Type,
+		Category:    categoryRes.category,
+		Tags:        tags,
+		EmbeddingID: embeddingID,
+		ImageURL:    metadataRes.imageURL,
+		EmbedHTML:   metadataRes.embedHTML,
+		OcrText:     imageText, // Updated asynchronously by OCR when empty
+		Language:    language,
+		CreatedAt:   time.Now().UTC(),
+		UserID:      userID,
+	}
+	if !req.CreatedAt.IsZero() {
+		// created_at is a TIMESTAMP holding UTC; pgx would store the local wall clock
+		item.CreatedAt = req.CreatedAt.UTC()
+	}
+	item.ReadingTimeMinutes = metadataRes.readingTime
+	item.FaviconURL = metadataRes.faviconURL
+	if embeddingID != "" {
+		item.EmbeddingSourceHash = embeddingRes.sourceHash
+	}
+	if product != nil {
+		item.Price, item.Currency = product.Price, product.Currency
+	}
+	if item.Price == nil && (req.Type == "amazon" || item.Category == "Shopping & Products") {
+		item.Price, item.Currency = shoppingPrice(req, content)
+	}
+	if req.SourceURL != "" {
+		// The original link is kept as-is; the normalized form is what duplicates are matched on
+		item.NormalizedURL = normalizeURL(req.SourceURL)
+	}
+	return &EnrichedItem{
+		Item:       item,
+		Embedding:  embeddingRes.embedding,
+		AIContent:  aiContent,
+		Summarized: embeddingRes.semanticSummary != "",
+	}, nil
+}
+
+// imageSource is the URL of the picture an image or screenshot save is of, or
+// "" for other saves
+func imageSource(req *models.CreateItemRequest) string {
+	if req.Type != "image" && req.Type != "screenshot" {
+		return ""
+	}
+	if req.ImageURL != "" {
+		return req.ImageURL
+	}
+	return req.SourceURL
+}
+
+// videoSummaryDescription is the video description its summary is generated
+// from: the one sent by the extension, else the content after its
+// "Description:" marker, else the whole content
+func videoSummaryDescription(req *models.CreateItemRequest, aiContent string) string {
+	if req.Metadata != nil && req.Metadata["description"] != "" {
+		return req.Metadata["description"]
+	}
+	return contentDescription(aiContent)
+}
+
+// contentDescription is a saved video's description: its content after the
+// "Description:" marker, or the whole content without one
+func contentDescription(content string) string {
+	if descIdx := strings.Index(content, "Description:"); descIdx != -1 {
+		return strings.TrimSpace(content[descIdx+len("Description:"):])
+	}
+	return content
+}
+
+// RegenerateEnrichment redoes the AI-generated fields of a saved item picked by
+// opts, for when the first attempt was poor, and returns the updated item. The
+// content, title, and creation time are left alone. Generated tags replace the
+// item's tags. When the summary changes the item is re-embedded from it, and
+// when only the category changes its vector's metadata is updated to match.
+func (s *ItemService) RegenerateEnrichment(ctx context.Context, userID, id uuid.UUID, opts models.RegenerateOptions) (*models.Item, error) {
+	item, err := s.itemRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if item.DeletedAt != nil {
+		return nil, ErrItemArchived
+	}
+	if !opts.Summary && !opts.Tags && !opts.Category {
+		opts.Summary, opts.Tags = true, true
+	}
+
+	// The same input the item was enriched from: an image's text stands in for its content
+	aiContent := item.Content
+	if item.OcrText != "" && (item.Type == "image" || item.Type == "screenshot") {
+		aiContent = strings.TrimSpace(item.Title + "\n\n" + item.OcrText)
+	}
+	if aiContent == "" {
+		aiContent = item.Title
+	}
+
+	if opts.Summary {
+		var summary string
+		if item.Type == "video" && item.SourceURL != "" {
+			transcript := s.videoTranscript(ctx, id, item.SourceURL)
+			summary = s.summarizeVideo(ctx, id, item.SourceURL, item.Title, contentDescription(item.Content), transcript)
+			if summary == "" {
+				return nil, errors.New("failed to regenerate video summary")
+			}
+		} else {
+			summary, err = s.aiService.GenerateSemanticSummary(ctx, item.Title, aiContent)
+			if err != nil {
+				return nil, fmt.Errorf("failed to regenerate summary: %w", err)
+			}
+		}
+		summary = strings.TrimSpace(summary)
+		item.Summary = summary
+	}
+	if opts.Tags {
+		tags, err := s.aiService.GenerateTags(ctx, aiContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to regenerate tags: %w", err)
+		}
+		if tags == nil {
+			tags = []string{}
+		}
+		item.Tags = tags
+	}
+	metadata := embeddingMetadata(item)
+	// Videos are always filed under "Videos & Entertainment"
+	if opts.Category && item.Type != "video" {
+		category, err := s.aiService.CategorizeContent(ctx, item.Title, aiContent, item.Type, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to regenerate category: %w", err)
+		}
+		item.Category = category
+	}
+
+	// Saved before the vector is touched, so a failed save leaves the two consistent
+	if err := s.itemRepo.UpdateEnrichment(ctx, item); err != nil {
+		return nil, fmt.Errorf("failed to update item: %w", err)
+	}
+
+	// Videos are embedded from their content and transcript, not their summary
+	if item.Type != "video" && s.reembed(ctx, "regenerate_enrichment", item) {
+		if err := s.itemRepo.UpdateEmbeddingID(ctx, item.ID, item.EmbeddingID, item.EmbeddingSourceHash); err != nil {
+			s.logger.WarnContext(ctx, "failed to save re-embedded item", "operation", "regenerate_enrichment", "item_id", item.ID, "error", err)
+		}
+	} else {
+		s.updateVectorMetadata(ctx, "regenerate_enrichment", item, metadata)
+	}
+	s.itemsChanged(userID)
+	s.emit(ctx, events.ItemUpdated, item)
+
+	return item, nil
+}
+
+// updateVectorMetadata stores item's title, category and image with its vector
+// when they differ from before, the metadata from before the edit. Searches
+// filter on these and show them, so they're kept current even when the
+// embedded text, and so the vector, is unchanged.
+func (s *ItemService) updateVectorMetadata(ctx context.Context, operation string, item *models.Item, before map[string]interface{}) {
+	metadata := embeddingMetadata(item)
+	if item.EmbeddingID == "" || reflect.DeepEqual(metadata, before) {
+		return
+	}
+	if err := db.Chroma.UpdateMetadata(s.collectionName, []string{item.EmbeddingID}, []map[string]interface{}{metadata}); err != nil {
+		s.logger.WarnContext(ctx, "failed to update embedding metadata", "operation", operation, "item_id", item.ID, "error", err)
+	}
+}
+
+// reembed regenerates item's vector from its current fields, reporting whether
+// it did. Failures are logged under operation and leave the old vector, which is
+// stale but still searchable.
+func (s *ItemService) reembed(ctx context.Context, operation string, item *models.Item) bool {
+	return s.reembedText(ctx, operation, item, s.embeddingText(item))
+}
+
+// reembedText is reembed for a vector generated from text rather than item's
+// fields, such as a video's content and transcript
+func (s *ItemService) reembedText(ctx context.Context, operation string, item *models.Item, text string) bool {
+	if embeddingUpToDate(item, text) {
+		s.logger.DebugContext(ctx, "embedded text unchanged, skipping re-embedding", "operation", operation, "item_id", item.ID)
+		return false
+	}
+	embedding, err := s.aiService.GenerateEmbedding(ctx, text)
+	if err == nil {
+		err = s.embeddingGuard.Check(ctx, embedding)
+	}
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to regenerate embedding", "operation", operation, "item_id", item.ID, "error", err)
+		return false
+	}
+	if item.EmbeddingID == "" {
+		// Items saved during an embedding outage have no vector yet
+		item.EmbeddingID = item.ID.String()
+	}
+	if err := db.Chroma.UpsertEmbedding(s.collectionName, item.EmbeddingID, embedding, embeddingMetadata(item)); err != nil {
+		s.logger.WarnContext(ctx, "failed to update embedding in ChromaDB", "operation", operation, "item_id", item.ID, "error", err)
+		return false
+	}
+	item.EmbeddingSourceHash = embeddingSourceHash(text)
+	return true
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeEnrichmentAI answers the AI calls enrichItem makes with its fields, or
+// Err. Any other call panics on the nil embedded AIProvider.
+type fakeEnrichmentAI struct {
+	AIProvider
+	Category    string
+	Tags        []string
+	Summary     string
+	Language    string
+	Translation string
+	Err         error
+	calls       map[string]int
+}
+
+func (f *fakeEnrichmentAI) called(method string) {
+	if f.calls == nil {
+		f.calls = map[string]int{}
+	}
+	f.calls[method]++
+}
+
+func (f *fakeEnrichmentAI) CategorizeContent(ctx context.Context, title, content, itemType string, allowed []string) (string, error) {
+	f.called("CategorizeContent")
+	return f.Category, f.Err
+}
+
+func (f *fakeEnrichmentAI) GenerateTags(ctx context.Context, content string) ([]string, error) {
+	f.called("GenerateTags")
+	return f.Tags, f.Err
+}
+
+func (f *fakeEnrichmentAI) GenerateTitle(ctx context.Context, content string) (string, error) {
+	f.called("GenerateTitle")
+	return "", f.Err
+}
+
+func (f *fakeEnrichmentAI) GenerateSemanticSummary(ctx context.Context, title, content string) (string, error) {
+	f.called("GenerateSemanticSummary")
+	return f.Summary, f.Err
+}
+
+func (f *fakeEnrichmentAI) DetectLanguage(ctx context.Context, text string) (string, error) {
+	f.called("DetectLanguage")
+	return f.Language, f.Err
+}
+
+func (f *fakeEnrichmentAI) TranslateToEnglish(ctx context.Context, text string) (string, error) {
+	f.called("TranslateToEnglish")
+	return f.Translation, f.Err
+}
+
+func (f *fakeEnrichmentAI) SupportsVision() bool { return false }
+
+func (f *fakeEnrichmentAI) DescribeImage(ctx context.Context, imageURL string) (string, error) {
+	f.called("DescribeImage")
+	return "", f.Err
+}
+
+// fakeEnrichmentMetadata returns Page for page metadata and nothing for every
+// other fetch enrichItem makes
+type fakeEnrichmentMetadata struct {
+	MetadataProvider
+	Page *PageMetadata
+}
+
+func (f *fakeEnrichmentMetadata) GetURLMetadata(ctx context.Context, url string) (string, string, string, error) {
+	return "", "", "", nil
+}
+
+func (f *fakeEnrichmentMetadata) GetPageMetadata(ctx context.Context, pageURL string) (*PageMetadata, error) {
+	if f.Page == nil {
+		return nil, errors.New("no page")
+	}
+	page := *f.Page
+	return &page, nil
+}
+
+func (f *fakeEnrichmentMetadata) GetFavicon(ctx context.Context, pageURL string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeEnrichmentMetadata) ExtractReadableText(ctx context.Context, url string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeEnrichmentMetadata) ExtractPDFText(ctx context.Context, url string) (string, error) {
+	return "", ErrNoExtractableText
+}
+
+func (f *fakeEnrichmentMetadata) ExtractImageText(ctx context.Context, imageURL string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeEnrichmentMetadata) GetAmazonProduct(ctx context.Context, url string) (*Product, error) {
+	return nil, errors.New("no product")
+}
+
+func (f *fakeEnrichmentMetadata) GetGitHubRepo(ctx context.Context, link string) (*GitHubRepo, error) {
+	return nil, errors.New("no repository")
+}
+
+func (f *fakeEnrichmentMetadata) GetTweet(ctx context.Context, link string) (*Tweet, error) {
+	return nil, errors.New("no tweet")
+}
+
+func (f *fakeEnrichmentMetadata) GetYouTubeDuration(ctx context.Context, videoID string) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeEnrichmentMetadata) GetYouTubeTranscript(ctx context.Context, videoID string) (string, error) {
+	return "", ErrNoTranscript
+}
+
+func (f *fakeEnrichmentMetadata) DetectBookAndGetCover(ctx context.Context, title, content string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeEnrichmentMetadata) DetectRecipeAndGetImage(ctx context.Context, title, content string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeEnrichmentMetadata) FetchRelevantImage(ctx context.Context, title, content, itemType, category string) (string, error) {
+	return "", nil
+}
+
+// enrichmentService is an ItemService that enriches through fakes. Without an
+// embed func nothing reaches ChromaDB, and requests with a source URL set
+// AllowDuplicate to skip the duplicate lookup in PostgreSQL.
+func enrichmentService(ai *fakeEnrichmentAI, metadata *fakeEnrichmentMetadata) *ItemService {
+	return &ItemService{
+		aiService:       ai,
+		metadataService: metadata,
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func TestEnrichItemLink(t *testing.T) {
+	ai := &fakeEnrichmentAI{
+		Summary:  "  How the scheduler works.  ",
+		Tags:     []string{"go", "concurrency"},
+		Category: "Technology",
+		Language: "en",
+	}
+	metadata := &fakeEnrichmentMetadata{
+		Page: &PageMetadata{
+			Title:       "Inside the Go scheduler",
+			Description: "A tour of goroutines, threads, and processors.",
+			ImageURL:    "https://example.com/cover.png",
+			FaviconURL:  "https://example.com/favicon.ico",
+		},
+	}
+	s := enrichmentService(ai, metadata)
+
+	enriched, err := s.enrichItem(context.Background(), uuid.New(), &models.CreateItemRequest{
+		SourceURL:      "https://Example.com/go-scheduler/?utm_source=feed",
+		Type:           "url",
+		Tags:           []string{"Go"},
+		AllowDuplicate: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("enrichItem: %v", err)
+	}
+	item := enriched.Item
+
+	if item.EmbeddingID != "" || enriched.Embedding != nil {
+		t.Errorf("embedding ID %q and %d-dim embedding without an embed func, want neither", item.EmbeddingID, len(enriched.Embedding))
+	}
+	if item.Title != "Inside the Go scheduler" || item.Content != "A tour of goroutines, threads, and processors." {
+		t.Errorf("title, content = %q, %q, want the page's", item.Title, item.Content)
+	}
+	// The page has no Open Graph preview, so its metadata image is kept
+	if item.ImageURL != "https://example.com/cover.png" || item.FaviconURL != "https://example.com/favicon.ico" {
+		t.Errorf("image, favicon = %q, %q, want the page's", item.ImageURL, item.FaviconURL)
+	}
+	// Given tags come first, and generated ones aren't repeated
+	if want := []string{"go", "concurrency"}; !reflect.DeepEqual(item.Tags, want) {
+		t.Errorf("tags = %q, want %q", item.Tags, want)
+	}
+	if item.Category != "Technology" || item.Summary != "How the scheduler works." || item.Language != "en" {
+		t.Errorf("category, summary, language = %q, %q, %q", item.Category, item.Summary, item.Language)
+	}
+	if !enriched.Summarized {
+		t.Error("Summarized = false, want the semantic summary reused")
+	}
+	if item.NormalizedURL != "https://example.com/go-scheduler" {
+		t.Errorf("normalized URL = %q", item.NormalizedURL)
+	}
+}
+
+func TestEnrichItemWithoutAI(t *testing.T) {
+	ai := &fakeEnrichmentAI{Err: errors.New("provider down")}
+	s := enrichmentService(ai, &fakeEnrichmentMetadata{})
+
+	content := "Remember to renew the passport before the trip."
+	enriched, err := s.enrichItem(context.Background(), uuid.New(), &models.CreateItemRequest{
+		Title:   "Passport",
+		Content: content,
+		Type:    "text",
+		Tags:    []string{"Travel"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("enrichItem: %v", err)
+	}
+	item := enriched.Item
+
+	// Every AI field is optional: defaults stand in for what failed
+	if item.Category != "Notes & Ideas" {
+		t.Errorf("category = %q, want the text default", item.Category)
+	}
+	if want := []string{"travel"}; !reflect.DeepEqual(item.Tags, want) {
+		t.Errorf("tags = %q, want the given tags %q", item.Tags, want)
+	}
+	if item.Summary != content || enriched.Summarized {
+		t.Errorf("summary = %q (summarized %v), want the content as a placeholder", item.Summary, enriched.Summarized)
+	}
+}
+
+func TestEnrichItemYouTube(t *testing.T) {
+	ai := &fakeEnrichmentAI{Category: "Technology"}
+	s := enrichmentService(ai, &fakeEnrichmentMetadata{})
+
+	enriched, err := s.enrichItem(context.Background(), uuid.New(), &models.CreateItemRequest{
+		Title:          "Errors are values",
+		Content:        "Description: Rob Pike on error handling in Go.",
+		SourceURL:      "https://youtu.be/PAAkCSZUG1c",
+		Type:           "video",
+		AllowDuplicate: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("enrichItem: %v", err)
+	}
+	item := enriched.Item
+
+	// YouTube always files under videos, whatever the AI says
+	if item.Category != "Videos & Entertainment" {
+		t.Errorf("category = %q", item.Category)
+	}
+	if !strings.Contains(item.EmbedHTML, "https://www.youtube.com/embed/PAAkCSZUG1c") {
+		t.Errorf("embed HTML = %q, want the video's player", item.EmbedHTML)
+	}
+	if item.ImageURL != "https://img.youtube.com/vi/PAAkCSZUG1c/hqdefault.jpg" {
+		t.Errorf("image = %q, want the video's thumbnail", item.ImageURL)
+	}
+	// Videos get their own summary after enrichment
+	if n := ai.calls["GenerateSemanticSummary"]; n != 0 {
+		t.Errorf("GenerateSemanticSummary called %d times for a video, want 0", n)
+	}
+}
+
+func TestEnrichItemTranslation(t *testing.T) {
+	ai := &fakeEnrichmentAI{
+		Language:    "fr",
+		Translation: "Bread recipe",
+		Summary:     "How to bake bread.",
+	}
+	s := enrichmentService(ai, &fakeEnrichmentMetadata{})
+
+	enriched, err := s.enrichItem(context.Background(), uuid.New(), &models.CreateItemRequest{
+		Title:              "Pain",
+		Content:            "Recette du pain",
+		Type:               "text",
+		TranslateToEnglish: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("enrichItem: %v", err)
+	}
+
+	// The original is stored; the translation is only what the AI works from
+	if enriched.Item.Content != "Recette du pain" || enriched.Item.Language != "fr" {
+		t.Errorf("content, language = %q, %q, want the original", enriched.Item.Content, enriched.Item.Language)
+	}
+	if enriched.AIContent != "Bread recipe" {
+		t.Errorf("AI content = %q, want the translation", enriched.AIContent)
+	}
+	if n := ai.calls["TranslateToEnglish"]; n != 1 {
+		t.Errorf("TranslateToEnglish called %d times, want 1", n)
+	}
+}
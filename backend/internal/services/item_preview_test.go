@@ -0,0 +1,338 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"synapse/internal/models"
+	"synapse/internal/services"
+	"synapse/internal/services/servicestest"
+
+	"github.com/google/uuid"
+)
+
+// previewService is an ItemService over fakes. Previews never touch ChromaDB,
+// so it has no repository or embedding guard; requests with a source URL set
+// AllowDuplicate to skip the duplicate lookup in PostgreSQL.
+func previewService(ai *servicestest.FakeAI, metadata *servicestest.FakeMetadata) *services.ItemService {
+	s := services.NewItemService(nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.SetAIProvider(ai)
+	s.SetMetadataProvider(metadata)
+	return s
+}
+
+func TestPreviewItemLink(t *testing.T) {
+	ai := &servicestest.FakeAI{
+		Summary:  "  How the scheduler works.  ",
+		Tags:     []string{"go", "concurrency"},
+		Category: "Technology",
+		Language: "en",
+	}
+	metadata := &servicestest.FakeMetadata{
+		Page: &services.PageMetadata{
+			Title:       "Inside the Go scheduler",
+			Description: "A tour of goroutines, threads, and processors.",
+			ImageURL:    "https://example.com/cover.png",
+			FaviconURL:  "https://example.com/favicon.ico",
+		},
+	}
+	s := previewService(ai, metadata)
+
+	item, err := s.PreviewItem(context.Background(), uuid.New(), &models.CreateItemRequest{
+		SourceURL:      "https://Example.com/go-scheduler/?utm_source=feed",
+		Type:           "url",
+		Tags:           []string{"Go"},
+		AllowDuplicate: true,
+	})
+	if err != nil {
+		t.Fatalf("PreviewItem: %v", err)
+	}
+
+	if item.ID != uuid.Nil || item.EmbeddingID != "" {
+		t.Errorf("preview has ID %v and embedding ID %q, want neither", item.ID, item.EmbeddingID)
+	}
+	if item.Title != "Inside the Go scheduler" || item.Content != "A tour of goroutines, threads, and processors." {
+		t.Errorf("title, content = %q, %q, want the page's", item.Title, item.Content)
+	}
+	// The page has no Open Graph preview, so its metadata image is kept
+	if item.ImageURL != "https://example.com/cover.png" || item.FaviconURL != "https://example.com/favicon.ico" {
+		t.Errorf("image, favicon = %q, %q, want the page's", item.ImageURL, item.FaviconURL)
+	}
+	// Given tags come first, and generated ones aren't repeated
+	if want := []string{"go", "concurrency"}; !reflect.DeepEqual(item.Tags, want) {
+		t.Errorf("tags = %q, want %q", item.Tags, want)
+	}
+	if item.Category != "Technology" || item.Summary != "How the scheduler works." || item.Language != "en" {
+		t.Errorf("category, summary, language = %q, %q, %q", item.Category, item.Summary, item.Language)
+	}
+	if item.NormalizedURL != "https://example.com/go-scheduler" {
+		t.Errorf("normalized URL = %q", item.NormalizedURL)
+	}
+	// Previews aren't embedded, and the summary made during enrichment is reused
+	if n := ai.Called("GenerateEmbedding") + ai.Called("GenerateEmbeddings"); n != 0 {
+		t.Errorf("preview generated %d embeddings, want none", n)
+	}
+	if n := ai.Called("GenerateSemanticSummary"); n != 1 {
+		t.Errorf("GenerateSemanticSummary called %d times, want 1", n)
+	}
+}
+
+func TestPreviewItemFavicon(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      models.CreateItemRequest
+		metadata servicestest.FakeMetadata
+		want     string
+	}{
+		{
+			name: "found with the page",
+			req:  models.CreateItemRequest{SourceURL: "https://example.com/post", Type: "url"},
+			metadata: servicestest.FakeMetadata{
+				Page:       &services.PageMetadata{Title: "Post", FaviconURL: "https://example.com/icon.png"},
+				FaviconURL: "https://example.com/favicon.ico",
+			},
+			want: "https://example.com/icon.png",
+		},
+		{
+			// The page lookup already tried /favicon.ico, so it isn't looked up again
+			name: "page without an icon",
+			req:  models.CreateItemRequest{SourceURL: "https://example.com/post", Type: "url"},
+			metadata: servicestest.FakeMetadata{
+				Page:       &services.PageMetadata{Title: "Post"},
+				FaviconURL: "https://example.com/favicon.ico",
+			},
+			want: "",
+		},
+		{
+			name:     "page not fetched",
+			req:      models.CreateItemRequest{Title: "Post", Content: "Notes on the post.", SourceURL: "https://example.com/post", Type: "url"},
+			metadata: servicestest.FakeMetadata{FaviconURL: "https://example.com/favicon.ico"},
+			want:     "https://example.com/favicon.ico",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := previewService(&servicestest.FakeAI{}, &tt.metadata)
+			tt.req.AllowDuplicate = true
+			item, err := s.PreviewItem(context.Background(), uuid.New(), &tt.req)
+			if err != nil {
+				t.Fatalf("PreviewItem: %v", err)
+			}
+			if item.FaviconURL != tt.want {
+				t.Errorf("favicon = %q, want %q", item.FaviconURL, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewItemLinkWithNotes(t *testing.T) {
+	// The semantic summary failed, so nothing better than the page's own description exists
+	ai := &servicestest.FakeAI{Category: "Technology"}
+	metadata := &servicestest.FakeMetadata{
+		Page: &services.PageMetadata{
+			Title:       "Inside the Go scheduler",
+			Description: "A tour of goroutines, threads, and processors.",
+		},
+	}
+	s := previewService(ai, metadata)
+
+	item, err := s.PreviewItem(context.Background(), uuid.New(), &models.CreateItemRequest{
+		SourceURL:      "https://example.com/go-scheduler",
+		Content:        "Read before the concurrency talk.",
+		Type:           "url",
+		AllowDuplicate: true,
+	})
+	if err != nil {
+		t.Fatalf("PreviewItem: %v", err)
+	}
+
+	// The notes are kept as the content; the page fills the blank title and the summary
+	if item.Title != "Inside the Go scheduler" || item.Content != "Read before the concurrency talk." {
+		t.Errorf("title, content = %q, %q, want the page's title and the notes", item.Title, item.Content)
+	}
+	if item.Summary != "A tour of goroutines, threads, and processors." {
+		t.Errorf("summary = %q, want the page's description", item.Summary)
+	}
+}
+
+func TestPreviewItemWithoutAI(t *testing.T) {
+	ai := &servicestest.FakeAI{Err: errors.New("provider down")}
+	s := previewService(ai, &servicestest.FakeMetadata{})
+
+	content := "Remember to renew the passport before the trip."
+	item, err := s.PreviewItem(context.Background(), uuid.New(), &models.CreateItemRequest{
+		Title:   "Passport",
+		Content: content,
+		Type:    "text",
+		Tags:    []string{"Travel"},
+	})
+	if err != nil {
+		t.Fatalf("PreviewItem: %v", err)
+	}
+
+	// Every AI field is optional: defaults stand in for what failed
+	if item.Category != "Notes & Ideas" {
+		t.Errorf("category = %q, want the text default", item.Category)
+	}
+	if want := []string{"travel"}; !reflect.DeepEqual(item.Tags, want) {
+		t.Errorf("tags = %q, want the given tags %q", item.Tags, want)
+	}
+	if item.Summary != content {
+		t.Errorf("summary = %q, want the content", item.Summary)
+	}
+}
+
+func TestPreviewItemYouTube(t *testing.T) {
+	ai := &servicestest.FakeAI{
+		VideoSummary: "A talk about error handling.",
+		Category:     "Technology",
+	}
+	s := previewService(ai, &servicestest.FakeMetadata{})
+
+	item, err := s.PreviewItem(context.Background(), uuid.New(), &models.CreateItemRequest{
+		Title:          "Errors are values",
+		Content:        "Description: Rob Pike on error handling in Go.",
+		SourceURL:      "https://youtu.be/PAAkCSZUG1c",
+		Type:           "video",
+		AllowDuplicate: true,
+	})
+	if err != nil {
+		t.Fatalf("PreviewItem: %v", err)
+	}
+
+	// YouTube always files under videos, whatever the AI says
+	if item.Category != "Videos & Entertainment" {
+		t.Errorf("category = %q", item.Category)
+	}
+	if !strings.Contains(item.EmbedHTML, "https://www.youtube.com/embed/PAAkCSZUG1c") {
+		t.Errorf("embed HTML = %q, want the video's player", item.EmbedHTML)
+	}
+	if item.ImageURL != "https://img.youtube.com/vi/PAAkCSZUG1c/hqdefault.jpg" {
+		t.Errorf("image = %q, want the video's thumbnail", item.ImageURL)
+	}
+	if item.Summary != "A talk about error handling." {
+		t.Errorf("summary = %q, want the video summary", item.Summary)
+	}
+	if n := ai.Called("GenerateSemanticSummary"); n != 0 {
+		t.Errorf("GenerateSemanticSummary called %d times for a video, want 0", n)
+	}
+}
+
+func TestPreviewItemTranslation(t *testing.T) {
+	ai := &servicestest.FakeAI{
+		Language:    "fr",
+		Translation: "Bread recipe",
+		Summary:     "How to bake bread.",
+	}
+	s := previewService(ai, &servicestest.FakeMetadata{})
+
+	item, err := s.PreviewItem(context.Background(), uuid.New(), &models.CreateItemRequest{
+		Title:              "Pain",
+		Content:            "Recette du pain",
+		Type:               "text",
+		TranslateToEnglish: true,
+	})
+	if err != nil {
+		t.Fatalf("PreviewItem: %v", err)
+	}
+
+	// The original is stored; the translation is only what the AI works from
+	if item.Content != "Recette du pain" || item.Language != "fr" {
+		t.Errorf("content, language = %q, %q, want the original", item.Content, item.Language)
+	}
+	if n := ai.Called("TranslateToEnglish"); n != 1 {
+		t.Errorf("TranslateToEnglish called %d times, want 1", n)
+	}
+}
+
+func TestPreviewItemImage(t *testing.T) {
+	ai := &servicestest.FakeAI{Vision: true, ImageDescription: " A handwritten shopping list. ", Title: "Shopping list"}
+	metadata := &servicestest.FakeMetadata{ImageText: "eggs, milk, flour"}
+	s := previewService(ai, metadata)
+
+	item, err := s.PreviewItem(context.Background(), uuid.New(), &models.CreateItemRequest{
+		ImageURL: "https://example.com/list.jpg",
+		Type:     "image",
+	})
+	if err != nil {
+		t.Fatalf("PreviewItem: %v", err)
+	}
+	if item.OcrText != "eggs, milk, flour" || item.ImageDescription != "A handwritten shopping list." {
+		t.Errorf("OCR text, description = %q, %q", item.OcrText, item.ImageDescription)
+	}
+
+	// Without vision neither is read
+	ai = &servicestest.FakeAI{ImageDescription: "unused", Title: "Shopping list"}
+	item, err = previewService(ai, metadata).PreviewItem(context.Background(), uuid.New(), &models.CreateItemRequest{
+		ImageURL: "https://example.com/list.jpg",
+		Type:     "image",
+	})
+	if err != nil {
+		t.Fatalf("PreviewItem without vision: %v", err)
+	}
+	if item.OcrText != "" || item.ImageDescription != "" || ai.Called("DescribeImage") != 0 {
+		t.Errorf("without vision: OCR text %q, description %q", item.OcrText, item.ImageDescription)
+	}
+}
+
+func TestPreviewItemGitHubRepo(t *testing.T) {
+	ai := &servicestest.FakeAI{Tags: []string{"cli", "golang"}, Summary: "A CLI library."}
+	metadata := &servicestest.FakeMetadata{Repo: &services.GitHubRepo{
+		FullName:    "spf13/cobra",
+		Description: "A Commander for modern Go CLI interactions",
+		Stars:       38000,
+		Topics:      []string{"go", "cli", "CLI", "go"},
+		ImageURL:    "https://opengraph.githubassets.com/1/spf13/cobra",
+	}}
+	s := previewService(ai, metadata)
+
+	item, err := s.PreviewItem(context.Background(), uuid.New(), &models.CreateItemRequest{
+		SourceURL:      "https://github.com/spf13/cobra",
+		Type:           "url",
+		Tags:           []string{"Go", "tools"},
+		AllowDuplicate: true,
+	})
+	if err != nil {
+		t.Fatalf("PreviewItem: %v", err)
+	}
+	if item.Title != "spf13/cobra" || !strings.HasPrefix(item.Content, "A Commander for modern Go CLI interactions") {
+		t.Errorf("title, content = %q, %q, want the repository's", item.Title, item.Content)
+	}
+	// Given tags, then topics, then generated tags, each once
+	if want := []string{"go", "tools", "cli", "golang"}; !reflect.DeepEqual(item.Tags, want) {
+		t.Errorf("tags = %q, want %q", item.Tags, want)
+	}
+}
+
+func TestPreviewItemTweet(t *testing.T) {
+	// GetURLMetadata isn't stubbed, so the embed can only come from the tweet
+	metadata := &servicestest.FakeMetadata{Tweet: &services.Tweet{
+		ID:           "42",
+		AuthorName:   "Jane Doe",
+		AuthorHandle: "jane",
+		Text:         "Shipping the new release today.",
+		EmbedHTML:    `<iframe src="https://platform.twitter.com/embed/Tweet.html?dnt=true&id=42"></iframe>`,
+	}}
+	s := previewService(&servicestest.FakeAI{}, metadata)
+
+	item, err := s.PreviewItem(context.Background(), uuid.New(), &models.CreateItemRequest{
+		SourceURL:      "https://x.com/jane/status/42",
+		Type:           "url",
+		AllowDuplicate: true,
+	})
+	if err != nil {
+		t.Fatalf("PreviewItem: %v", err)
+	}
+	if item.Title != "Jane Doe (@jane) on X" || item.Content != "Shipping the new release today." {
+		t.Errorf("title, content = %q, %q, want the post's", item.Title, item.Content)
+	}
+	if item.EmbedHTML != metadata.Tweet.EmbedHTML {
+		t.Errorf("embed = %q, want the tweet's", item.EmbedHTML)
+	}
+}
@@ -2,345 +2,271 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"regexp"
+	"log/slog"
+	"os"
 	"strings"
 	"synapse/internal/db"
+	"synapse/internal/events"
+	"synapse/internal/logging"
 	"synapse/internal/models"
 	"synapse/internal/repository"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
+// ErrItemArchived is returned when editing or regenerating an archived item;
+// restore it first
+var ErrItemArchived = errors.New("item is archived")
+
 type ItemService struct {
 	itemRepo        *repository.ItemRepository
-	aiService       *AIService
-	metadataService *MetadataService
-	ocrService      *OCRService
+	aiService       AIProvider
+	metadataService MetadataProvider
+	embeddingGuard  *EmbeddingGuard
 	collectionName  string
+	// transcriptLimit caps the video transcript text that's embedded
+	transcriptLimit int
+	// translateToEnglish translates every non-English save, not just those that request it
+	translateToEnglish bool
+	// allowDuplicates saves items even when the same link or content already exists
+	allowDuplicates bool
+	// duplicateSimilarity is the embedding similarity at or above which content counts
+	// as a near-duplicate; 0 disables the check
+	duplicateSimilarity float64
+	// bulkConcurrency is how many items BulkCreate saves at once
+	bulkConcurrency int
+	// importJobs tracks bulk and bookmark imports running in the background
+	importJobs importJobs
+	// embeddingInput picks which fields an item's vector is generated from
+	embeddingInput EmbeddingInput
+	// embeddingLimit caps the text an item's vector is generated from
+	embeddingLimit int
+	// changeHooks are called with the owner after items are saved, edited, or deleted
+	changeHooks []func(userID uuid.UUID)
+	// emitter publishes item lifecycle events, e.g. to a webhook
+	emitter events.Emitter
+	logger  *slog.Logger
 }
 
-func NewItemService(itemRepo *repository.ItemRepository, aiService *AIService) *ItemService {
+func NewItemService(itemRepo *repository.ItemRepository, aiService *AIService, embeddingGuard *EmbeddingGuard, logger *slog.Logger) *ItemService {
+	logger = logging.OrDefault(logger)
+	metadataService := NewMetadataService(logger)
+	metadataService.aiService = aiService
 	return &ItemService{
-		itemRepo:        itemRepo,
-		aiService:       aiService,
-		metadataService: NewMetadataService(),
-		ocrService:      NewOCRService(),
-		collectionName:  "synapse_items",
+		itemRepo:           itemRepo,
+		aiService:          aiService,
+		embeddingGuard:     embeddingGuard,
+		logger:             logger,
+		metadataService:    metadataService,
+		collectionName:     db.CollectionName(),
+		transcriptLimit:    loadInputLimits().transcript,
+		translateToEnglish: getEnvBool("TRANSLATE_TO_ENGLISH"),
+		allowDuplicates:    os.Getenv("DUPLICATE_POLICY") == "allow",
+		// e.g. 0.95; off by default since similar isn't always the same
+		duplicateSimilarity: getEnvFloat("DUPLICATE_SIMILARITY_THRESHOLD", 0),
+		bulkConcurrency:     getEnvInt("BULK_IMPORT_CONCURRENCY", 8),
+		embeddingInput:      loadEmbeddingInput(logger),
+		embeddingLimit:      maxEmbeddingRunes,
+		emitter:             events.Noop{},
 	}
 }
 
-func (s *ItemService) CreateItem(ctx context.Context, req *models.CreateItemRequest) (*models.Item, error) {
-	// Generate ID
-	itemID := uuid.New()
-	embeddingID := itemID.String()
+// SetAIProvider replaces the AI service items are enriched and embedded with,
+// e.g. with a fake in tests. Set it before serving requests.
+func (s *ItemService) SetAIProvider(ai AIProvider) {
+	s.aiService = ai
+}
 
-	// Prepare content for processing
-	content := req.Content
-	if content == "" {
-		content = req.Title
-	}
+// SetMetadataProvider replaces the page and media fetching items are enriched
+// with, e.g. with a fake in tests. Set it before serving requests.
+func (s *ItemService) SetMetadataProvider(metadata MetadataProvider) {
+	s.metadataService = metadata
+}
 
-	// Generate category, tags, and embedding in parallel (synchronous for initial save)
-	type categoryResult struct {
-		category string
-		err      error
-	}
-	type tagsResult struct {
-		tags []string
-		err  error
-	}
-	type embeddingResult struct {
-		embedding []float32
-		err       error
-	}
+// SetEventEmitter publishes item created, updated, and deleted events to e
+// (nil turns them off). Set it before serving requests.
+func (s *ItemService) SetEventEmitter(e events.Emitter) {
+	s.emitter = events.OrNoop(e)
+}
 
-	categoryChan := make(chan categoryResult, 1)
-	tagsChan := make(chan tagsResult, 1)
-	embeddingChan := make(chan embeddingResult, 1)
+// emit publishes an event about item; the emitter delivers it in the background
+func (s *ItemService) emit(ctx context.Context, eventType events.Type, item *models.Item) {
+	s.emitter.Emit(ctx, events.New(eventType, item))
+}
 
-	// Generate category (AI-powered categorization)
-	go func() {
-		category, err := s.aiService.CategorizeContent(ctx, req.Title, content, req.Type)
-		categoryChan <- categoryResult{category: category, err: err}
-	}()
+// emitDeleted publishes item.deleted for an archived item, or with Permanent
+// set for one deleted for good
+func (s *ItemService) emitDeleted(ctx context.Context, item *models.Item, permanent bool) {
+	event := events.New(events.ItemDeleted, item)
+	event.Permanent = permanent
+	s.emitter.Emit(ctx, event)
+}
 
-	// Generate tags
-	go func() {
-		tags, err := s.aiService.GenerateTags(ctx, content)
-		tagsChan <- tagsResult{tags: tags, err: err}
-	}()
+// OnItemsChanged registers fn to be called with the owner whenever one of their
+// items is saved, edited, archived, restored, or deleted, e.g. to drop cached
+// search results. Register hooks before serving requests.
+func (s *ItemService) OnItemsChanged(fn func(userID uuid.UUID)) {
+	s.changeHooks = append(s.changeHooks, fn)
+}
 
-	// Generate embedding
-	go func() {
-		embedding, err := s.aiService.GenerateEmbedding(ctx, content)
-		embeddingChan <- embeddingResult{embedding: embedding, err: err}
-	}()
+func (s *ItemService) itemsChanged(userID uuid.UUID) {
+	for _, fn := range s.changeHooks {
+		fn(userID)
+	}
+}
 
-	// Wait for all results
-	categoryRes := <-categoryChan
-	tagsRes := <-tagsChan
-	embeddingRes := <-embeddingChan
+// CreateItem saves a new item owned by userID
+func (s *ItemService) CreateItem(ctx context.Context, userID uuid.UUID, req *models.CreateItemRequest) (*models.Item, error) {
+	return s.createItem(ctx, userID, req, s.aiService.GenerateEmbedding)
+}
 
-	// Handle errors - make AI features optional if API fails
-	if categoryRes.err != nil {
-		// If categorization fails, use a default category based on type
-		categoryRes.category = s.getDefaultCategory(req.Type, req.SourceURL)
+// createItem is CreateItem with the embedding function injected, so BulkCreate
+// can batch embeddings across concurrent saves. Building the item is left to
+// enrichItem; this only stores it and starts the follow-up work.
+func (s *ItemService) createItem(ctx context.Context, userID uuid.UUID, req *models.CreateItemRequest, embed func(context.Context, string) ([]float32, error)) (*models.Item, error) {
+	enriched, err := s.enrichItem(ctx, userID, req, embed)
+	if err != nil {
+		return nil, err
 	}
-	
-	// Override category for YouTube videos - always "Videos & Entertainment"
-	if req.SourceURL != "" && (strings.Contains(req.SourceURL, "youtube.com") || strings.Contains(req.SourceURL, "youtu.be")) {
-		categoryRes.category = "Videos & Entertainment"
+	item := enriched.Item
+
+	// Save to database first, so a failed insert leaves no orphaned vector in
+	// ChromaDB and no background job updating a row that doesn't exist
+	if err := s.itemRepo.Create(ctx, item); err != nil {
+		return nil, fmt.Errorf("failed to save item: %w", err)
 	}
-	
-	// Override category for video type - always "Videos & Entertainment"
-	if req.Type == "video" {
-		categoryRes.category = "Videos & Entertainment"
+
+	// Store embedding in ChromaDB (optional - if it fails, continue without vector search)
+	if item.EmbeddingID != "" {
+		metadata := embeddingMetadata(item)
+		if err := db.Chroma.AddEmbedding(s.collectionName, item.EmbeddingID, enriched.Embedding, metadata); err != nil {
+			// Log error but continue - the item is kept without an embedding, and
+			// semantic search won't find it until it's reindexed or re-embedded
+			s.logger.WarnContext(ctx, "failed to store embedding in ChromaDB, keeping item without it", "operation", "create_item", "item_id", item.ID, "error", err)
+			item.EmbeddingID, item.EmbeddingSourceHash = "", ""
+			if err := s.itemRepo.UpdateEmbeddingID(ctx, item.ID, "", ""); err != nil {
+				s.logger.WarnContext(ctx, "failed to clear embedding ID", "operation", "create_item", "item_id", item.ID, "error", err)
+			}
+		}
 	}
-	if tagsRes.err != nil {
-		// Tags are optional, continue with empty tags
-		tagsRes.tags = []string{}
+	s.itemsChanged(userID)
+	s.emit(ctx, events.ItemCreated, item)
+
+	// Extract OCR text from images/screenshots asynchronously
+	if item.OcrText == "" && (item.Type == "image" || item.Type == "screenshot") && item.ImageURL != "" {
+		// Extract OCR text in background
+		go func() {
+			// Outlive the request, but keep its request ID for logging
+			bgCtx := context.WithoutCancel(ctx)
+			extractedText, err := s.metadataService.ExtractImageText(bgCtx, item.ImageURL)
+			if err == nil && extractedText != "" {
+				// Update item with OCR text
+				s.updateOCRText(bgCtx, userID, item.ID, extractedText)
+			}
+		}()
 	}
-	if embeddingRes.err != nil {
-		// If embedding fails, we can't proceed - return error
-		return nil, fmt.Errorf("failed to generate embedding (check AI API key): %w", embeddingRes.err)
+
+	// Describe images in the background; the description is embedded once it's ready
+	if imageURL := imageSource(req); imageURL != "" && s.aiService.SupportsVision() {
+		go s.describeImageAsync(context.WithoutCancel(ctx), userID, item.ID, imageURL)
 	}
 
-	// Get metadata (embeds, covers, images) in parallel
-	type metadataResult struct {
-		embedHTML string
-		imageURL  string
-		err       error
+	// Asynchronously generate AI summary (doesn't affect description/content)
+	// For videos, fetch the captions, then generate a short summary
+	if item.Type == "video" && item.SourceURL != "" {
+		go s.enrichVideoAsync(context.WithoutCancel(ctx), userID, item.ID, videoSummaryDescription(req, enriched.AIContent), enriched.AIContent)
+	} else if !enriched.Summarized {
+		// For non-videos, generate regular summary
+		go s.generateAndUpdateSummaryAsync(context.WithoutCancel(ctx), userID, item.ID, item.Title, enriched.AIContent)
 	}
-	metadataChan := make(chan metadataResult, 1)
-	
-	go func() {
-		var embedHTML, imageURL string
-		var err error
-		
-		// For videos, ALWAYS get embed HTML (required for embedded playback)
-		if req.Type == "video" && req.SourceURL != "" {
-			embedHTML, imageURL, err = s.metadataService.GetURLMetadata(ctx, req.SourceURL)
-			// If GetURLMetadata didn't return embed, try to generate it from URL
-			if embedHTML == "" && (strings.Contains(req.SourceURL, "youtube.com") || strings.Contains(req.SourceURL, "youtu.be")) {
-				videoID := s.extractYouTubeIDFromURL(req.SourceURL)
-				if videoID != "" {
-					embedHTML = fmt.Sprintf(`<iframe width="100%%" height="100%%" src="https://www.youtube.com/embed/%s?rel=0" frameborder="0" allow="accelerometer; autoplay; clipboard-write; encrypted-media; gyroscope; picture-in-picture; web-share" allowfullscreen style="position: absolute; top: 0; left: 0; width: 100%%; height: 100%%;"></iframe>`, videoID)
-					if imageURL == "" {
-						imageURL = fmt.Sprintf("https://img.youtube.com/vi/%s/maxresdefault.jpg", videoID)
-					}
-				}
-			}
-		}
-		
-		// Use pre-extracted image URL if provided (from extension) - but don't override embed
-		if req.ImageURL != "" && imageURL == "" {
-			imageURL = req.ImageURL
-		}
-		
-		// If URL type (not video), get embed and preview
-		// This will also handle PDFs via GetURLMetadata
-		if req.Type == "url" && req.SourceURL != "" && embedHTML == "" {
-			embedHTML, imageURL, err = s.metadataService.GetURLMetadata(ctx, req.SourceURL)
-		}
-		
-		// Check if URL is a PDF and generate embed if needed (fallback if GetURLMetadata didn't catch it)
-		if embedHTML == "" && req.SourceURL != "" && (strings.HasSuffix(strings.ToLower(req.SourceURL), ".pdf") || strings.Contains(strings.ToLower(req.SourceURL), ".pdf?")) {
-			embedHTML = fmt.Sprintf(`<iframe width="100%%" height="100%%" src="%s" frameborder="0" style="position: absolute; top: 0; left: 0; width: 100%%; height: 100%%;" type="application/pdf"></iframe>`, req.SourceURL)
-		}
-		
-		// Check if URL is a YouTube video even if type is not "video"
-		if embedHTML == "" && req.SourceURL != "" && (strings.Contains(req.SourceURL, "youtube.com") || strings.Contains(req.SourceURL, "youtu.be")) {
-			videoID := s.extractYouTubeIDFromURL(req.SourceURL)
-			if videoID != "" {
-				embedHTML = fmt.Sprintf(`<iframe width="100%%" height="100%%" src="https://www.youtube.com/embed/%s?rel=0" frameborder="0" allow="accelerometer; autoplay; clipboard-write; encrypted-media; gyroscope; picture-in-picture; web-share" allowfullscreen style="position: absolute; top: 0; left: 0; width: 100%%; height: 100%%;"></iframe>`, videoID)
-				if imageURL == "" {
-					imageURL = fmt.Sprintf("https://img.youtube.com/vi/%s/maxresdefault.jpg", videoID)
-				}
-			}
-		}
-		
-		// For Amazon products, use metadata image if available
-		if req.Type == "amazon" && req.Metadata != nil && req.Metadata["image"] != "" {
-			imageURL = req.Metadata["image"]
-		}
-		
-		// For blogs, use metadata image if available
-		if req.Type == "blog" && req.Metadata != nil && req.Metadata["image"] != "" {
-			imageURL = req.Metadata["image"]
-		}
-		
-		// For videos, use thumbnail if available (fallback if GetURLMetadata didn't work)
-		if req.Type == "video" && imageURL == "" && req.Metadata != nil && req.Metadata["thumbnail"] != "" {
-			imageURL = req.Metadata["thumbnail"]
-		}
-		
-		// Detect and get book cover
-		if imageURL == "" {
-			bookCover, err2 := s.metadataService.DetectBookAndGetCover(ctx, req.Title, content)
-			if err2 == nil && bookCover != "" {
-				imageURL = bookCover
-				if req.Type == "" {
-					req.Type = "book"
-				}
-			}
-		}
-		
-		// Detect and get recipe image
-		if imageURL == "" {
-			recipeImage, err2 := s.metadataService.DetectRecipeAndGetImage(ctx, req.Title, content)
-			if err2 == nil && recipeImage != "" {
-				imageURL = recipeImage
-				if req.Type == "" {
-					req.Type = "recipe"
-				}
-			}
-		}
-		
-		// If still no image, try to fetch a relevant image based on category
-		// This should work for all content types (text, blog, etc.)
-		if imageURL == "" {
-			if categoryRes.category != "" {
-				// Use category-based image fetching
-				relevantImage, err2 := s.metadataService.FetchRelevantImage(ctx, req.Title, content, req.Type, categoryRes.category)
-				if err2 == nil && relevantImage != "" {
-					imageURL = relevantImage
-				}
-			} else if req.Type != "" {
-				// Fallback: use type-based default category
-				defaultCategory := s.getDefaultCategory(req.Type, req.SourceURL)
-				if defaultCategory != "" {
-					relevantImage, err2 := s.metadataService.FetchRelevantImage(ctx, req.Title, content, req.Type, defaultCategory)
-					if err2 == nil && relevantImage != "" {
-						imageURL = relevantImage
-					}
-				}
-			}
-		}
-		
-		metadataChan <- metadataResult{embedHTML: embedHTML, imageURL: imageURL, err: err}
-	}()
-	
-	metadataRes := <-metadataChan
 
-	// Store embedding in ChromaDB (optional - if it fails, continue without vector search)
-	metadata := map[string]interface{}{
-		"title": req.Title,
-		"type":  req.Type,
-	}
-	if err := db.Chroma.AddEmbedding(s.collectionName, embeddingID, embeddingRes.embedding, metadata); err != nil {
-		// Log error but continue - item will be saved without embedding
-		fmt.Printf("Warning: Failed to store embedding in ChromaDB: %v\n", err)
-		fmt.Println("Item will be saved but semantic search may not work until ChromaDB is fixed")
-		// Continue without embedding - item can still be saved
-	}
-
-		// Extract OCR text from images/screenshots asynchronously
-		var ocrText string
-		if (req.Type == "image" || req.Type == "screenshot") && metadataRes.imageURL != "" {
-			// Extract OCR text in background
-			go func() {
-				extractedText, err := s.ocrService.ExtractTextFromImage(context.Background(), metadataRes.imageURL)
-				if err == nil && extractedText != "" {
-					// Update item with OCR text
-					s.updateOCRText(context.Background(), itemID, extractedText)
-				}
-			}()
-		}
+	return item, nil
+}
 
-		// Set initial summary (will be replaced by async AI summary)
-		initialSummary := ""
-		if req.Type == "video" && req.Metadata != nil && req.Metadata["description"] != "" {
-			// For videos, use a truncated version of description as initial summary
-			// The full description stays in content, summary will be replaced by AI
-			desc := req.Metadata["description"]
-			if len(desc) > 200 {
-				initialSummary = desc[:200] + "..."
-			} else {
-				initialSummary = desc
-			}
-		} else if req.Type == "video" && content != "" {
-			// Extract description from content if it contains "Description:" marker
-			var desc string
-			if descIdx := strings.Index(content, "Description:"); descIdx != -1 {
-				desc = strings.TrimSpace(content[descIdx+len("Description:"):])
-			} else {
-				desc = content
-			}
-			if len(desc) > 200 {
-				initialSummary = desc[:200] + "..."
-			} else {
-				initialSummary = desc
-			}
-		} else {
-			// For non-videos, use truncated content
-			if len(content) > 200 {
-				initialSummary = content[:200] + "..."
-			} else {
-				initialSummary = content
-			}
-		}
+// PreviewItem runs CreateItem's enrichment (title, summary, tags, category,
+// images, reading time) and returns the resulting item without writing it to
+// Postgres or ChromaDB, so the user can review and edit it before saving.
+// Previews aren't embedded, and the summary is generated before returning.
+func (s *ItemService) PreviewItem(ctx context.Context, userID uuid.UUID, req *models.CreateItemRequest) (*models.Item, error) {
+	enriched, err := s.enrichItem(ctx, userID, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	item := enriched.Item
+	// Never saved, so it has no ID yet
+	item.ID = uuid.Nil
 
-		item := &models.Item{
-			ID:          itemID,
-			Title:       req.Title,
-			Content:     content,
-			Summary:     initialSummary, // Temporary summary, will be replaced asynchronously
-			SourceURL:   req.SourceURL,
-			Type:        req.Type,
-			Category:    categoryRes.category,
-			Tags:        tagsRes.tags,
-			EmbeddingID: embeddingID,
-			ImageURL:    metadataRes.imageURL,
-			EmbedHTML:   metadataRes.embedHTML,
-			OcrText:     ocrText, // Will be updated asynchronously for images
-			CreatedAt:   time.Now(),
+	if item.Type == "video" && item.SourceURL != "" {
+		description := videoSummaryDescription(req, enriched.AIContent)
+		transcript := s.videoTranscript(ctx, item.ID, item.SourceURL)
+		if summary := s.summarizeVideo(ctx, item.ID, item.SourceURL, item.Title, description, transcript); summary != "" {
+			item.Summary = summary
 		}
-
-		// Save to database
-		if err := s.itemRepo.Create(ctx, item); err != nil {
-			return nil, fmt.Errorf("failed to save item: %w", err)
+	} else if !enriched.Summarized {
+		summary, err := s.aiService.GenerateSemanticSummary(ctx, item.Title, enriched.AIContent)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to generate semantic summary for preview", "operation", "preview_item", "error", err)
+		} else if summary = strings.TrimSpace(summary); summary != "" {
+			item.Summary = summary
+		}
+	}
+	if imageURL := imageSource(req); imageURL != "" && s.aiService.SupportsVision() {
+		description, err := s.aiService.DescribeImage(ctx, imageURL)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to describe image for preview", "operation", "preview_item", "error", err)
 		}
+		item.ImageDescription = strings.TrimSpace(description)
+	}
+	return item, nil
+}
 
-		// Asynchronously generate AI summary (doesn't affect description/content)
-		// For videos, extract description and generate a short summary
-		if req.Type == "video" && req.SourceURL != "" {
-			// Extract description from metadata if available, otherwise use content
-			description := ""
-			if req.Metadata != nil && req.Metadata["description"] != "" {
-				description = req.Metadata["description"]
-			} else if content != "" {
-				// Try to extract description from content if it contains "Description:" marker
-				if descIdx := strings.Index(content, "Description:"); descIdx != -1 {
-					description = strings.TrimSpace(content[descIdx+len("Description:"):])
-				} else {
-					description = content
-				}
-			}
-			
-			if description != "" {
-				// Generate short AI summary asynchronously (description stays unchanged)
-				go s.generateAndUpdateVideoSummaryAsync(context.Background(), itemID, req.SourceURL, req.Title, description)
-			}
-		} else {
-			// For non-videos, generate regular summary
-			go s.generateAndUpdateSummaryAsync(context.Background(), itemID, req.Title, content)
+// videoDurationMinutes returns a video's length in minutes, preferring the duration
+// sent by the extension and falling back to the YouTube watch page; 0 if unknown
+func (s *ItemService) videoDurationMinutes(ctx context.Context, req *models.CreateItemRequest) int {
+	if req.Metadata != nil && req.Metadata["duration"] != "" {
+		if seconds := parseDurationSeconds(req.Metadata["duration"]); seconds > 0 {
+			return durationToMinutes(seconds)
 		}
+	}
 
-	return item, nil
+	videoID := s.extractYouTubeIDFromURL(req.SourceURL)
+	if videoID == "" {
+		return 0
+	}
+	seconds, err := s.metadataService.GetYouTubeDuration(ctx, videoID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to get YouTube video duration", "operation", "create_item", "video_id", videoID, "error", err)
+		return 0
+	}
+	return durationToMinutes(seconds)
+}
+
+// videoTranscript returns the captions of a YouTube video link, or "" for
+// other links and videos without captions
+func (s *ItemService) videoTranscript(ctx context.Context, itemID uuid.UUID, videoURL string) string {
+	videoID := s.extractYouTubeIDFromURL(videoURL)
+	if videoID == "" {
+		return ""
+	}
+	transcript, err := s.metadataService.GetYouTubeTranscript(ctx, videoID)
+	if err != nil {
+		if !errors.Is(err, ErrNoTranscript) {
+			s.logger.WarnContext(ctx, "failed to fetch YouTube transcript", "operation", "fetch_transcript", "item_id", itemID, "video_id", videoID, "error", err)
+		}
+		return ""
+	}
+	return transcript
 }
 
 // extractYouTubeIDFromURL extracts YouTube video ID from URL
 func (s *ItemService) extractYouTubeIDFromURL(url string) string {
-	patterns := []string{
-		`youtube\.com/watch\?v=([a-zA-Z0-9_-]+)`,
-		`youtu\.be/([a-zA-Z0-9_-]+)`,
-		`youtube\.com/embed/([a-zA-Z0-9_-]+)`,
-	}
-	
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
+	for _, re := range youTubeIDPatterns {
 		matches := re.FindStringSubmatch(url)
 		if len(matches) > 1 {
 			return matches[1]
@@ -349,78 +275,204 @@ func (s *ItemService) extractYouTubeIDFromURL(url string) string {
 	return ""
 }
 
+// shoppingPrice finds a shopping item's price when the product page couldn't be
+// read: first the extension's "price" metadata, then a labeled price in content
+func shoppingPrice(req *models.CreateItemRequest, content string) (*float64, string) {
+	price, currency, ok := parseProductPrice(req.Metadata["price"])
+	if !ok {
+		price, currency, ok = priceFromContent(content)
+	}
+	if !ok {
+		return nil, ""
+	}
+	if code := strings.TrimSpace(req.Metadata["currency"]); code != "" {
+		currency = strings.ToUpper(code)
+	}
+	return &price, currency
+}
+
+// embeddingSourceHash identifies the text a vector was generated from, stored
+// as the item's EmbeddingSourceHash
+func embeddingSourceHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// embeddingUpToDate reports whether item's vector was generated from text, so
+// re-embedding it would change nothing. Items without a stored hash never are.
+func embeddingUpToDate(item *models.Item, text string) bool {
+	return item.EmbeddingID != "" && item.EmbeddingSourceHash != "" && item.EmbeddingSourceHash == embeddingSourceHash(text)
+}
+
 // generateAndUpdateSummaryAsync generates a semantic summary asynchronously and updates the item
-func (s *ItemService) generateAndUpdateSummaryAsync(ctx context.Context, itemID uuid.UUID, title, content string) {
+func (s *ItemService) generateAndUpdateSummaryAsync(ctx context.Context, userID, itemID uuid.UUID, title, content string) {
 	// Generate semantic summary using Gemini
 	summary, err := s.aiService.GenerateSemanticSummary(ctx, title, content)
 	if err != nil {
-		fmt.Printf("Warning: Failed to generate semantic summary for item %s: %v\n", itemID, err)
+		s.logger.WarnContext(ctx, "failed to generate semantic summary", "operation", "summarize", "item_id", itemID, "error", err)
 		return
 	}
 
 	// Update the item's summary in the database
 	if err := s.itemRepo.UpdateSummary(ctx, itemID, summary); err != nil {
-		fmt.Printf("Warning: Failed to update summary for item %s: %v\n", itemID, err)
+		s.logger.WarnContext(ctx, "failed to update summary", "operation", "summarize", "item_id", itemID, "error", err)
 		return
 	}
+	s.itemsChanged(userID)
 
-	fmt.Printf("Successfully generated and updated semantic summary for item %s\n", itemID)
+	s.logger.InfoContext(ctx, "updated semantic summary", "operation", "summarize", "item_id", itemID)
+}
+
+// detectLanguage returns the content's language code, or "" if detection fails
+func (s *ItemService) detectLanguage(ctx context.Context, content string) string {
+	language, err := s.aiService.DetectLanguage(ctx, content)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to detect content language", "operation", "detect_language", "error", err)
+		return ""
+	}
+	return language
 }
 
 // updateOCRText updates the OCR text for an item
-func (s *ItemService) updateOCRText(ctx context.Context, itemID uuid.UUID, ocrText string) {
+func (s *ItemService) updateOCRText(ctx context.Context, userID, itemID uuid.UUID, ocrText string) {
 	if err := s.itemRepo.UpdateOCRText(ctx, itemID, ocrText); err != nil {
-		fmt.Printf("Warning: Failed to update OCR text for item %s: %v\n", itemID, err)
+		s.logger.WarnContext(ctx, "failed to update OCR text", "operation", "ocr", "item_id", itemID, "error", err)
 		return
 	}
-	fmt.Printf("Successfully updated OCR text for item %s\n", itemID)
+	s.itemsChanged(userID)
+	s.logger.InfoContext(ctx, "updated OCR text", "operation", "ocr", "item_id", itemID)
 }
 
-// generateAndUpdateVideoSummaryAsync generates a video-specific summary asynchronously
-func (s *ItemService) generateAndUpdateVideoSummaryAsync(ctx context.Context, itemID uuid.UUID, videoURL, title, description string) {
-	// Log what we're working with
-	fmt.Printf("Generating video summary for item %s - Title: %s, Description length: %d\n", itemID, title, len(description))
-	
-	// Ensure we have a description to work with
-	if description == "" {
-		fmt.Printf("Warning: No description provided for video summary, item %s\n", itemID)
-		// Fallback to regular summary with title
-		s.generateAndUpdateSummaryAsync(ctx, itemID, title, title)
+// describeImageAsync has the vision model describe a saved image, stores the
+// description, and re-embeds the item so photos without text surface in
+// semantic search
+func (s *ItemService) describeImageAsync(ctx context.Context, userID, itemID uuid.UUID, imageURL string) {
+	description, err := s.aiService.DescribeImage(ctx, imageURL)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to describe image", "operation", "describe_image", "item_id", itemID, "error", err)
 		return
 	}
-	
-	// Generate video summary using Gemini
-	summary, err := s.aiService.SummarizeYouTubeVideo(ctx, videoURL, title, description)
+	if description = strings.TrimSpace(description); description == "" {
+		return
+	}
+
+	if err := s.itemRepo.UpdateImageDescription(ctx, itemID, description); err != nil {
+		s.logger.WarnContext(ctx, "failed to update image description", "operation", "describe_image", "item_id", itemID, "error", err)
+		return
+	}
+	// Re-read the item so a summary saved in the meantime is embedded too
+	item, err := s.itemRepo.GetByID(ctx, userID, itemID)
 	if err != nil {
-		// Check if it's a quota/rate limit error
-		if strings.Contains(err.Error(), "quota") || strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "rate limit") {
-			fmt.Printf("Warning: Gemini API quota exceeded for item %s. Summary generation skipped. Error: %v\n", itemID, err)
-		} else {
-			fmt.Printf("Warning: Failed to generate video summary for item %s: %v\n", itemID, err)
-			// Fallback to regular summary only if it's not a quota issue
-			s.generateAndUpdateSummaryAsync(ctx, itemID, title, description)
+		s.logger.WarnContext(ctx, "failed to load described image", "operation", "describe_image", "item_id", itemID, "error", err)
+		return
+	}
+	if item.DeletedAt == nil && s.reembed(ctx, "describe_image", item) {
+		if err := s.itemRepo.UpdateEmbeddingID(ctx, itemID, item.EmbeddingID, item.EmbeddingSourceHash); err != nil {
+			s.logger.WarnContext(ctx, "failed to save re-embedded image", "operation", "describe_image", "item_id", itemID, "error", err)
 		}
+	}
+	s.itemsChanged(userID)
+	s.logger.InfoContext(ctx, "updated image description", "operation", "describe_image", "item_id", itemID)
+}
+
+// enrichVideoAsync finishes a saved video in the background: a talk says far
+// more than its description, so YouTube captions are fetched and the video is
+// re-embedded with them, then its summary is generated from the description
+// and transcript. Videos without captions are summarized from the description alone.
+func (s *ItemService) enrichVideoAsync(ctx context.Context, userID, itemID uuid.UUID, description, aiContent string) {
+	item, err := s.itemRepo.GetByID(ctx, userID, itemID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to load saved video", "operation", "fetch_transcript", "item_id", itemID, "error", err)
 		return
 	}
 
-	// Ensure we got a valid summary
+	transcript := s.videoTranscript(ctx, itemID, item.SourceURL)
+	if transcript != "" && item.DeletedAt == nil {
+		text := s.composeEmbeddingText(embeddingSource{title: item.Title, content: videoEmbeddingContent(aiContent, transcript, s.transcriptLimit), tags: item.Tags})
+		if s.reembedText(ctx, "fetch_transcript", item, text) {
+			if err := s.itemRepo.UpdateEmbeddingID(ctx, itemID, item.EmbeddingID, item.EmbeddingSourceHash); err != nil {
+				s.logger.WarnContext(ctx, "failed to save re-embedded video", "operation", "fetch_transcript", "item_id", itemID, "error", err)
+			}
+		}
+	}
+
+	if description != "" || transcript != "" {
+		// Generate short AI summary (description stays unchanged)
+		s.generateAndUpdateVideoSummaryAsync(ctx, userID, itemID, item.SourceURL, item.Title, description, transcript)
+	}
+}
+
+// videoEmbeddingContent is the content a video with captions is embedded from:
+// what the AI worked from, then as much of the transcript as limit allows
+func videoEmbeddingContent(aiContent, transcript string, limit int) string {
+	return aiContent + "\n\nTranscript: " + truncateForModel(transcript, limit)
+}
+
+// generateAndUpdateVideoSummaryAsync generates a video-specific summary asynchronously
+func (s *ItemService) generateAndUpdateVideoSummaryAsync(ctx context.Context, userID, itemID uuid.UUID, videoURL, title, description, transcript string) {
+	summary := s.summarizeVideo(ctx, itemID, videoURL, title, description, transcript)
 	if summary == "" {
-		fmt.Printf("Warning: Empty summary generated for item %s, using fallback\n", itemID)
-		s.generateAndUpdateSummaryAsync(ctx, itemID, title, description)
 		return
 	}
 
 	// Update the item's summary in the database
 	if err := s.itemRepo.UpdateSummary(ctx, itemID, summary); err != nil {
-		fmt.Printf("Warning: Failed to update video summary for item %s: %v\n", itemID, err)
+		s.logger.WarnContext(ctx, "failed to update video summary", "operation", "summarize_video", "item_id", itemID, "error", err)
 		return
 	}
+	s.itemsChanged(userID)
+
+	s.logger.InfoContext(ctx, "updated video summary", "operation", "summarize_video", "item_id", itemID, "summary", previewText(summary, 100))
+}
 
-	summaryPreview := summary
-	if len(summary) > 100 {
-		summaryPreview = summary[:100] + "..."
+// summarizeVideo generates a video's summary from its description and transcript,
+// falling back to a regular summary; "" if neither could be generated
+func (s *ItemService) summarizeVideo(ctx context.Context, itemID uuid.UUID, videoURL, title, description, transcript string) string {
+	// Log what we're working with
+	s.logger.InfoContext(ctx, "generating video summary", "operation", "summarize_video", "item_id", itemID, "title", title, "description_length", len(description), "transcript_length", len(transcript))
+	
+	// Ensure we have a description or transcript to work with
+	if description == "" && transcript == "" {
+		s.logger.WarnContext(ctx, "no description for video summary, summarizing title", "operation", "summarize_video", "item_id", itemID)
+		// Fallback to regular summary with title
+		return s.summarizeFallback(ctx, itemID, title, title)
 	}
-	fmt.Printf("Successfully generated and updated video summary for item %s: %s\n", itemID, summaryPreview)
+	
+	// The regular summary fallbacks work from the description, or the transcript without one
+	fallbackContent := description
+	if fallbackContent == "" {
+		fallbackContent = truncateForModel(transcript, s.transcriptLimit)
+	}
+
+	// Generate video summary using Gemini
+	summary, err := s.aiService.SummarizeYouTubeVideo(ctx, videoURL, title, description, transcript)
+	if err != nil {
+		// Check if it's a quota/rate limit error
+		if isRateLimitError(err) {
+			s.logger.WarnContext(ctx, "AI quota exceeded, video summary skipped", "operation", "summarize_video", "item_id", itemID, "error", err)
+			return ""
+		}
+		s.logger.WarnContext(ctx, "failed to generate video summary", "operation", "summarize_video", "item_id", itemID, "error", err)
+		// Fallback to regular summary only if it's not a quota issue
+		return s.summarizeFallback(ctx, itemID, title, fallbackContent)
+	}
+
+	// Ensure we got a valid summary
+	if summary == "" {
+		s.logger.WarnContext(ctx, "empty video summary generated, using fallback", "operation", "summarize_video", "item_id", itemID)
+		return s.summarizeFallback(ctx, itemID, title, fallbackContent)
+	}
+	return summary
+}
+
+// summarizeFallback is the regular semantic summary used when a video summary can't be generated
+func (s *ItemService) summarizeFallback(ctx context.Context, itemID uuid.UUID, title, content string) string {
+	summary, err := s.aiService.GenerateSemanticSummary(ctx, title, content)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to generate semantic summary", "operation", "summarize_video", "item_id", itemID, "error", err)
+		return ""
+	}
+	return summary
 }
 
 // getDefaultCategory returns a default category based on item type and URL
@@ -448,48 +500,314 @@ func (s *ItemService) getDefaultCategory(itemType, sourceURL string) string {
 	return "Other"
 }
 
-func (s *ItemService) GetItem(ctx context.Context, id uuid.UUID) (*models.Item, error) {
-	return s.itemRepo.GetByID(ctx, id)
+func (s *ItemService) GetItem(ctx context.Context, userID, id uuid.UUID) (*models.Item, error) {
+	return s.itemRepo.GetByID(ctx, userID, id)
 }
 
-func (s *ItemService) GetAllItems(ctx context.Context) ([]models.Item, error) {
-	return s.itemRepo.GetAll(ctx)
+func (s *ItemService) GetAllItems(ctx context.Context, userID uuid.UUID, includeArchived bool, sortBy string) ([]models.Item, error) {
+	return s.itemRepo.GetAll(ctx, userID, includeArchived, sortBy)
 }
 
-func (s *ItemService) DeleteItem(ctx context.Context, id uuid.UUID) error {
-	return s.itemRepo.Delete(ctx, id)
+// GetItemsByType returns one page of userID's items of itemType in sortBy order
+func (s *ItemService) GetItemsByType(ctx context.Context, userID uuid.UUID, itemType, sortBy string, limit, offset int) (*models.ItemPage, error) {
+	items, total, err := s.itemRepo.GetByType(ctx, userID, itemType, sortBy, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ItemPage{Items: items, Total: total, Limit: limit, Offset: offset}, nil
 }
 
-// RefreshImageForItem refreshes the image URL for an existing item
-func (s *ItemService) RefreshImageForItem(ctx context.Context, id uuid.UUID) error {
-	item, err := s.itemRepo.GetByID(ctx, id)
+// GetItemsByCategory returns one page of userID's items in category in sortBy order
+func (s *ItemService) GetItemsByCategory(ctx context.Context, userID uuid.UUID, category, sortBy string, limit, offset int) (*models.ItemPage, error) {
+	items, total, err := s.itemRepo.GetByCategory(ctx, userID, category, sortBy, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ItemPage{Items: items, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// GetItemsByTag returns one page of userID's items tagged tag in sortBy order
+func (s *ItemService) GetItemsByTag(ctx context.Context, userID uuid.UUID, tag, sortBy string, limit, offset int) (*models.ItemPage, error) {
+	items, total, err := s.itemRepo.GetByTag(ctx, userID, tag, sortBy, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ItemPage{Items: items, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// ListTags returns userID's tags with usage counts, most used first
+func (s *ItemService) ListTags(ctx context.Context, userID uuid.UUID) ([]models.TagCount, error) {
+	return s.itemRepo.ListTags(ctx, userID)
+}
+
+// GetItemsPage returns one page of userID's items in sortBy order (one of the
+// models.Sort* orders; relevance lists newest first). A non-empty cursor (from
+// a previous page's NextCursor) takes precedence over offset; cursors only
+// page the newest-first order.
+func (s *ItemService) GetItemsPage(ctx context.Context, userID uuid.UUID, sortBy string, limit, offset int, cursor string) (*models.ItemPage, error) {
+	newestFirst := sortBy == "" || sortBy == models.SortRelevance || sortBy == models.SortNewest
+	if cursor != "" && !newestFirst {
+		return nil, fmt.Errorf("%w: cursors only page newest first", models.ErrInvalidCursor)
+	}
+
+	var items []models.Item
+	var total int
+	var err error
+	if cursor != "" {
+		after, decodeErr := models.DecodeItemCursor(cursor)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		items, total, err = s.itemRepo.GetAllAfter(ctx, userID, after, limit)
+		offset = 0
+	} else {
+		items, total, err = s.itemRepo.GetAllPaginated(ctx, userID, sortBy, limit, offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.ItemPage{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	if len(items) == limit && newestFirst {
+		last := items[len(items)-1]
+		page.NextCursor = models.ItemCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	return page, nil
+}
+
+// Timeline returns userID's most recent limit items, optionally within [from, to],
+// grouped into day, week, or month buckets (models.Bucket*)
+func (s *ItemService) Timeline(ctx context.Context, userID uuid.UUID, bucket string, from, to *time.Time, limit int) ([]models.DateBucket, error) {
+	return s.itemRepo.GetByDateBucket(ctx, userID, bucket, from, to, limit)
+}
+
+// OnThisDay returns up to limit of userID's items saved on date's month and
+// day in earlier years, grouped by year
+func (s *ItemService) OnThisDay(ctx context.Context, userID uuid.UUID, date time.Time, limit int) ([]models.DateBucket, error) {
+	return s.itemRepo.GetOnThisDay(ctx, userID, date.Month(), date.Day(), date.Year(), limit)
+}
+
+// Stats returns userID's item counts overall, by type, and by category
+func (s *ItemService) Stats(ctx context.Context, userID uuid.UUID) (*models.ItemStats, error) {
+	total, err := s.itemRepo.Count(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byType, err := s.itemRepo.CountByType(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byCategory, err := s.itemRepo.CountByCategory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ItemStats{
+		Total:      total,
+		ByType:     byType,
+		ByCategory: byCategory,
+	}, nil
+}
+
+// DeleteItem archives an item. Its ChromaDB vector is removed so semantic
+// search doesn't return it; RestoreItem brings both back. Deleting an item
+// that's already archived does nothing.
+func (s *ItemService) DeleteItem(ctx context.Context, userID, id uuid.UUID) error {
+	item, err := s.itemRepo.GetByID(ctx, userID, id)
 	if err != nil {
 		return err
 	}
+	if item.DeletedAt != nil {
+		return nil
+	}
 
-	// Check if image URL is from deprecated Unsplash Source API
-	if item.ImageURL != "" && strings.Contains(item.ImageURL, "source.unsplash.com") {
-		// Generate new image URL using Picsum Photos
-		newImageURL, err := s.metadataService.FetchRelevantImage(ctx, item.Title, item.Content, item.Type, item.Category)
-		if err == nil && newImageURL != "" {
-			return s.itemRepo.UpdateImageURL(ctx, id, newImageURL)
+	if err := s.itemRepo.Delete(ctx, userID, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Archived by a concurrent request, which already sent the event
+			return nil
 		}
+		return err
+	}
+	s.deleteItemEmbedding(ctx, item)
+	s.itemsChanged(userID)
+	archivedAt := time.Now()
+	item.DeletedAt = &archivedAt
+	s.emitDeleted(ctx, item, false)
+	return nil
+}
+
+// HardDeleteItem permanently deletes an item, archived or not, and its ChromaDB vector
+func (s *ItemService) HardDeleteItem(ctx context.Context, userID, id uuid.UUID) error {
+	item, err := s.itemRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return err
 	}
 
-	// If no image exists, try to fetch one
-	if item.ImageURL == "" {
-		newImageURL, err := s.metadataService.FetchRelevantImage(ctx, item.Title, item.Content, item.Type, item.Category)
-		if err == nil && newImageURL != "" {
-			return s.itemRepo.UpdateImageURL(ctx, id, newImageURL)
+	if err := s.itemRepo.HardDelete(ctx, userID, id); err != nil {
+		return err
+	}
+	s.deleteItemEmbedding(ctx, item)
+	s.itemsChanged(userID)
+	s.emitDeleted(ctx, item, true)
+	return nil
+}
+
+// RestoreItem un-archives an item and re-adds its embedding to ChromaDB.
+// Restoring an item that isn't archived returns it unchanged.
+func (s *ItemService) RestoreItem(ctx context.Context, userID, id uuid.UUID) (*models.Item, error) {
+	item, err := s.itemRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if item.DeletedAt == nil {
+		return item, nil
+	}
+
+	if err := s.itemRepo.Restore(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	item.DeletedAt = nil
+
+	text := s.embeddingText(item)
+	embedding, err := s.aiService.GenerateEmbedding(ctx, text)
+	if err == nil {
+		err = s.upsertItemEmbedding(ctx, item, text, embedding)
+	}
+	if err != nil {
+		// The item is restored either way; a reindex will pick the vector up later
+		s.logger.WarnContext(ctx, "failed to restore embedding", "operation", "restore_item", "item_id", id, "error", err)
+	}
+	s.itemsChanged(userID)
+	s.emit(ctx, events.ItemUpdated, item)
+
+	return item, nil
+}
+
+// deleteItemEmbedding removes an item's vector from ChromaDB, logging failures
+func (s *ItemService) deleteItemEmbedding(ctx context.Context, item *models.Item) {
+	// Items saved while ChromaDB or the embedding provider was down have no vector
+	if item.EmbeddingID == "" {
+		return
+	}
+	if err := db.Chroma.DeleteEmbedding(s.collectionName, item.EmbeddingID); err != nil {
+		s.logger.WarnContext(ctx, "failed to delete embedding from ChromaDB", "operation", "delete_item", "item_id", item.ID, "error", err)
+	}
+}
+
+// UpdateItem applies an edit to a saved item and returns the updated item.
+// When req.Regenerate is set the embedding is regenerated (keeping the same
+// ChromaDB ID) if the text it's generated from changed, and a content change
+// also regenerates the summary unless the edit supplies one.
+func (s *ItemService) UpdateItem(ctx context.Context, userID, id uuid.UUID, req *models.UpdateItemRequest) (*models.Item, error) {
+	item, err := s.itemRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	// Re-embedding would put an archived item back into semantic search
+	if item.DeletedAt != nil {
+		return nil, ErrItemArchived
+	}
+
+	metadata := embeddingMetadata(item)
+	contentChanged := req.Content != nil && *req.Content != item.Content
+	if req.Title != nil {
+		item.Title = *req.Title
+	}
+	if req.Content != nil {
+		item.Content = *req.Content
+	}
+	if req.Summary != nil {
+		item.Summary = *req.Summary
+	}
+	if req.Category != nil {
+		item.Category = *req.Category
+	}
+	if req.Tags != nil {
+		item.Tags = *req.Tags
+	}
+	if req.ImageURL != nil {
+		item.ImageURL = *req.ImageURL
+	}
+	if req.EmbedHTML != nil {
+		item.EmbedHTML = *req.EmbedHTML
+	}
+
+	reembedded := false
+	if req.Regenerate {
+		if contentChanged && req.Summary == nil {
+			// The old summary describes the old content
+			summary, err := s.aiService.GenerateSemanticSummary(ctx, item.Title, item.Content)
+			if err != nil {
+				s.logger.WarnContext(ctx, "failed to regenerate summary", "operation", "update_item", "item_id", id, "error", err)
+			} else {
+				item.Summary = strings.TrimSpace(summary)
+			}
 		}
+
+		// Skipped when the embedded text didn't change, e.g. a tags-only edit.
+		// On failure the edit is kept; the old vector is stale but search still works.
+		reembedded = s.reembed(ctx, "update_item", item)
+	}
+	if !reembedded {
+		s.updateVectorMetadata(ctx, "update_item", item, metadata)
+	}
+
+	if err := s.itemRepo.Update(ctx, item); err != nil {
+		return nil, fmt.Errorf("failed to update item: %w", err)
+	}
+	s.itemsChanged(userID)
+	s.emit(ctx, events.ItemUpdated, item)
+
+	return item, nil
+}
+
+// RefreshImageForItem refreshes the image URL for an existing item
+func (s *ItemService) RefreshImageForItem(ctx context.Context, userID, id uuid.UUID) error {
+	item, err := s.itemRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	// Replace images from the deprecated Unsplash Source API, and fetch one for
+	// items that have none
+	if item.ImageURL != "" && !strings.Contains(item.ImageURL, "source.unsplash.com") {
+		return nil
 	}
 
+	// Generate new image URL using Picsum Photos
+	newImageURL, err := s.metadataService.FetchRelevantImage(ctx, item.Title, item.Content, item.Type, item.Category)
+	if err != nil || newImageURL == "" {
+		return nil
+	}
+	if err := s.itemRepo.UpdateImageURL(ctx, id, newImageURL); err != nil {
+		return err
+	}
+	metadata := embeddingMetadata(item)
+	item.ImageURL = newImageURL
+	s.updateVectorMetadata(ctx, "refresh_image", item, metadata)
+	s.itemsChanged(userID)
 	return nil
 }
 
+// StreamSummary streams a freshly generated summary of an item's content
+func (s *ItemService) StreamSummary(ctx context.Context, userID, id uuid.UUID) (<-chan string, <-chan error, error) {
+	item, err := s.itemRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chunks, errs := s.aiService.SummarizeContentStream(ctx, item.Content)
+	return chunks, errs, nil
+}
+
 // RefreshSummaryForItem regenerates the summary for an existing item
-func (s *ItemService) RefreshSummaryForItem(ctx context.Context, id uuid.UUID) error {
-	item, err := s.itemRepo.GetByID(ctx, id)
+func (s *ItemService) RefreshSummaryForItem(ctx context.Context, userID, id uuid.UUID) error {
+	item, err := s.itemRepo.GetByID(ctx, userID, id)
 	if err != nil {
 		return err
 	}
@@ -508,16 +826,15 @@ func (s *ItemService) RefreshSummaryForItem(ctx context.Context, id uuid.UUID) e
 			}
 		}
 		
-		if description != "" {
-			// Regenerate video summary asynchronously
-			go s.generateAndUpdateVideoSummaryAsync(context.Background(), id, item.SourceURL, item.Title, description)
-		} else {
-			// Fallback to regular summary
-			go s.generateAndUpdateSummaryAsync(context.Background(), id, item.Title, item.Content)
-		}
+		// Regenerate video summary asynchronously, fetching the transcript first;
+		// without a description or transcript it falls back to a regular summary
+		go func(ctx context.Context) {
+			transcript := s.videoTranscript(ctx, id, item.SourceURL)
+			s.generateAndUpdateVideoSummaryAsync(ctx, userID, id, item.SourceURL, item.Title, description, transcript)
+		}(context.WithoutCancel(ctx))
 	} else {
 		// For non-videos, use regular summarization
-		go s.generateAndUpdateSummaryAsync(context.Background(), id, item.Title, item.Content)
+		go s.generateAndUpdateSummaryAsync(context.WithoutCancel(ctx), userID, id, item.Title, item.Content)
 	}
 
 	return nil
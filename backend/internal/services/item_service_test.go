@@ -0,0 +1,599 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"synapse/internal/db"
+	"synapse/internal/models"
+	"synapse/internal/repository"
+	"synapse/internal/services"
+	"synapse/internal/services/servicestest"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testStack is an ItemService and SearchService over PostgreSQL, a fake
+// ChromaDB, and fake AI and metadata providers
+type testStack struct {
+	pool     *pgxpool.Pool
+	repo     *repository.ItemRepository
+	chroma   *servicestest.FakeChroma
+	ai       *servicestest.FakeAI
+	metadata *servicestest.FakeMetadata
+	items    *services.ItemService
+	search   *services.SearchService
+}
+
+// newTestStack builds a testStack, skipping the test without TEST_DATABASE_URL.
+// Every item is embedded as ai.Embedding, so set it before saving.
+func newTestStack(t *testing.T) *testStack {
+	t.Helper()
+	pool := servicestest.Postgres(t)
+	chroma := servicestest.NewChroma(t)
+	collection := db.CollectionName()
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM embedding_config WHERE collection_name = $1`, collection)
+	})
+
+	ai := &servicestest.FakeAI{Embedding: []float32{1, 0, 0}, Category: "Notes & Ideas"}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	itemRepo := repository.NewItemRepository(pool)
+	guard := services.NewEmbeddingGuard(repository.NewEmbeddingConfigRepository(pool), ai, logger)
+	metadata := &servicestest.FakeMetadata{}
+	items := services.NewItemService(itemRepo, nil, guard, logger)
+	items.SetAIProvider(ai)
+	items.SetMetadataProvider(metadata)
+	search := services.NewSearchService(ai, itemRepo, repository.NewCollectionRepository(pool), guard, nil, logger)
+	search.SetTrigramAvailable(db.TrigramAvailable(context.Background()))
+	items.OnItemsChanged(search.InvalidateCache)
+	return &testStack{pool: pool, repo: itemRepo, chroma: chroma, ai: ai, metadata: metadata, items: items, search: search}
+}
+
+// save creates a note with the given embedding and fails the test if it can't
+func (s *testStack) save(t *testing.T, userID uuid.UUID, title, content string, embedding []float32) *models.Item {
+	t.Helper()
+	s.ai.Embedding = embedding
+	s.ai.Summary = content
+	item, err := s.items.CreateItem(context.Background(), userID, &models.CreateItemRequest{
+		Title:   title,
+		Content: content,
+		Type:    "text",
+	})
+	if err != nil {
+		t.Fatalf("CreateItem(%q): %v", title, err)
+	}
+	return item
+}
+
+func TestCreateItemIsSearchable(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+
+	item := s.save(t, userID, "Tomato soup", "Roast the tomatoes, then blend with stock.", []float32{1, 0, 0})
+	s.save(t, userID, "Bike repair", "Adjusting derailleur limit screws.", []float32{0, 1, 0})
+
+	if item.EmbeddingID == "" || !s.chroma.Has(item.EmbeddingID) {
+		t.Fatalf("saved item's vector %q is not in ChromaDB", item.EmbeddingID)
+	}
+	if got := s.chroma.Metadata(item.EmbeddingID)["user_id"]; got != userID.String() {
+		t.Errorf("vector user_id = %v, want %v", got, userID)
+	}
+
+	s.ai.Embedding = []float32{0.9, 0.1, 0}
+	results, err := s.search.Search(ctx, userID, "tomato soup", nil, "", 10, 0)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) == 0 || results[0].Item.ID != item.ID {
+		t.Fatalf("Search results = %v, want %v first", results, item.ID)
+	}
+	if results[0].SemanticScore == 0 || results[0].TextScore == 0 {
+		t.Errorf("top result scores semantic %v, text %v; want it found both ways", results[0].SemanticScore, results[0].TextScore)
+	}
+	if n := s.ai.Called("GenerateQueryEmbedding"); n != 1 {
+		t.Errorf("GenerateQueryEmbedding called %d times, want 1", n)
+	}
+}
+
+func TestCreateItemEmbedsSemanticSummary(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+	s.ai.Category = "Food & Recipes"
+	s.ai.Summary = "A tomato soup recipe: roast, then blend."
+
+	item, err := s.items.CreateItem(ctx, userID, &models.CreateItemRequest{
+		Title:   "Tomato soup",
+		Content: "Grandma's notes. Roast the tomatoes at 200C for 40 minutes, then blend with stock.",
+		Type:    "text",
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+
+	stored, err := s.items.GetItem(ctx, userID, item.ID)
+	if err != nil {
+		t.Fatalf("GetItem: %v", err)
+	}
+	if stored.Category != "Food & Recipes" || stored.Summary != s.ai.Summary {
+		t.Errorf("stored category, summary = %q, %q; want the AI's", stored.Category, stored.Summary)
+	}
+	// The vector is generated from the title and semantic summary, not the raw content
+	if len(s.ai.Embedded) == 0 {
+		t.Fatal("nothing was embedded")
+	}
+	if got, want := s.ai.Embedded[0], "Tomato soup\n"+s.ai.Summary; got != want {
+		t.Errorf("embedded %q, want %q", got, want)
+	}
+	if n := s.ai.Called("GenerateSemanticSummary"); n != 1 {
+		t.Errorf("GenerateSemanticSummary called %d times, want 1", n)
+	}
+}
+
+func TestUpdateItemCategoryUpdatesVectorMetadata(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+
+	item := s.save(t, userID, "Sourdough", "Feed the starter the night before.", []float32{1, 0, 0})
+	embedded := s.ai.Called("GenerateEmbedding")
+
+	category := "Food & Recipes"
+	if _, err := s.items.UpdateItem(ctx, userID, item.ID, &models.UpdateItemRequest{Category: &category}); err != nil {
+		t.Fatalf("UpdateItem: %v", err)
+	}
+
+	// Category filters run in ChromaDB, so the vector's copy must follow the edit
+	metadata := s.chroma.Metadata(item.EmbeddingID)
+	if metadata["category"] != category || metadata["category_key"] != "food & recipes" {
+		t.Errorf("vector category, category_key = %v, %v; want the edit's", metadata["category"], metadata["category_key"])
+	}
+	if n := s.ai.Called("GenerateEmbedding") - embedded; n != 0 {
+		t.Errorf("category edit generated %d embeddings, want none", n)
+	}
+}
+
+func TestRegenerateEnrichment(t *testing.T) {
+	tests := []struct {
+		name                    string
+		opts                    models.RegenerateOptions
+		summary, tags, category bool // which fields are regenerated
+	}{
+		{"empty body defaults to summary and tags", models.RegenerateOptions{}, true, true, false},
+		{"summary", models.RegenerateOptions{Summary: true}, true, false, false},
+		{"tags", models.RegenerateOptions{Tags: true}, false, true, false},
+		{"category", models.RegenerateOptions{Category: true}, false, false, true},
+		{"everything", models.RegenerateOptions{Summary: true, Tags: true, Category: true}, true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStack(t)
+			ctx := context.Background()
+			userID := servicestest.NewUser(t, s.pool)
+			s.ai.Tags = []string{"bread"}
+			item := s.save(t, userID, "Sourdough", "Feed the starter the night before.", []float32{1, 0, 0})
+
+			s.ai.Summary = "How to keep a sourdough starter alive."
+			s.ai.Tags = []string{"baking", "fermentation"}
+			s.ai.Category = "Food & Recipes"
+			embedded := s.ai.Called("GenerateEmbedding")
+
+			got, err := s.items.RegenerateEnrichment(ctx, userID, item.ID, tt.opts)
+			if err != nil {
+				t.Fatalf("RegenerateEnrichment: %v", err)
+			}
+			stored, err := s.items.GetItem(ctx, userID, item.ID)
+			if err != nil {
+				t.Fatalf("GetItem: %v", err)
+			}
+			for _, it := range []*models.Item{got, stored} {
+				if it.Title != item.Title || it.Content != item.Content || !it.CreatedAt.Equal(item.CreatedAt) {
+					t.Errorf("title, content, created_at = %q, %q, %v; want them unchanged", it.Title, it.Content, it.CreatedAt)
+				}
+			}
+
+			if regenerated := stored.Summary != item.Summary; regenerated != tt.summary {
+				t.Errorf("summary = %q, regenerated %v, want %v", stored.Summary, regenerated, tt.summary)
+			}
+			if regenerated := strings.Join(stored.Tags, ",") != strings.Join(item.Tags, ","); regenerated != tt.tags {
+				t.Errorf("tags = %v, regenerated %v, want %v", stored.Tags, regenerated, tt.tags)
+			}
+			if regenerated := stored.Category != item.Category; regenerated != tt.category {
+				t.Errorf("category = %q, regenerated %v, want %v", stored.Category, regenerated, tt.category)
+			}
+
+			// The item is re-embedded from a new summary, and only then
+			reembedded := s.ai.Called("GenerateEmbedding") - embedded
+			if tt.summary {
+				if reembedded != 1 || !strings.Contains(s.ai.Embedded[len(s.ai.Embedded)-1], s.ai.Summary) {
+					t.Errorf("re-embedded %d times, last %q; want once, from the new summary", reembedded, s.ai.Embedded[len(s.ai.Embedded)-1])
+				}
+				if stored.EmbeddingSourceHash == item.EmbeddingSourceHash {
+					t.Error("stored embedding source hash wasn't updated")
+				}
+			} else if reembedded != 0 {
+				t.Errorf("re-embedded %d times without a new summary", reembedded)
+			}
+			if metadata := s.chroma.Metadata(stored.EmbeddingID); metadata["category"] != stored.Category {
+				t.Errorf("vector category = %v, want %q", metadata["category"], stored.Category)
+			}
+		})
+	}
+}
+
+func TestRegenerateEnrichmentKeepsVideoCategory(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+	item, err := s.items.CreateItem(ctx, userID, &models.CreateItemRequest{
+		Title:   "Go scheduler deep dive",
+		Content: "Description: Conference talk.",
+		Type:    "video",
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+
+	s.ai.Category = "Technology"
+	categorized := s.ai.Called("CategorizeContent")
+	got, err := s.items.RegenerateEnrichment(ctx, userID, item.ID, models.RegenerateOptions{Category: true})
+	if err != nil {
+		t.Fatalf("RegenerateEnrichment: %v", err)
+	}
+	if got.Category != "Videos & Entertainment" {
+		t.Errorf("category = %q, want Videos & Entertainment", got.Category)
+	}
+	if n := s.ai.Called("CategorizeContent") - categorized; n != 0 {
+		t.Errorf("CategorizeContent called %d times for a video, want none", n)
+	}
+}
+
+func TestDeleteItemRemovesVector(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+	item := s.save(t, userID, "Sourdough starter", "Feed the starter twice a day.", []float32{1, 0, 0})
+
+	// searchIDs returns the IDs a search for the item finds
+	searchIDs := func() []uuid.UUID {
+		t.Helper()
+		results, err := s.search.Search(ctx, userID, "sourdough starter", nil, "", 10, 0)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		ids := []uuid.UUID{}
+		for _, result := range results {
+			ids = append(ids, result.Item.ID)
+		}
+		return ids
+	}
+
+	if err := s.items.DeleteItem(ctx, userID, item.ID); err != nil {
+		t.Fatalf("DeleteItem: %v", err)
+	}
+	if s.chroma.Has(item.EmbeddingID) {
+		t.Error("archived item's vector is still in ChromaDB")
+	}
+	if ids := searchIDs(); len(ids) != 0 {
+		t.Errorf("Search after delete = %v, want nothing", ids)
+	}
+	// Deleting again is a no-op, not an error
+	if err := s.items.DeleteItem(ctx, userID, item.ID); err != nil {
+		t.Errorf("second DeleteItem: %v", err)
+	}
+
+	if _, err := s.items.RestoreItem(ctx, userID, item.ID); err != nil {
+		t.Fatalf("RestoreItem: %v", err)
+	}
+	if !s.chroma.Has(item.EmbeddingID) {
+		t.Error("restored item's vector wasn't re-added")
+	}
+	if ids := searchIDs(); len(ids) != 1 || ids[0] != item.ID {
+		t.Errorf("Search after restore = %v, want %v", ids, item.ID)
+	}
+	// Restoring again is a no-op: nothing is re-embedded
+	embeddings := s.ai.Called("GenerateEmbedding")
+	if _, err := s.items.RestoreItem(ctx, userID, item.ID); err != nil {
+		t.Errorf("second RestoreItem: %v", err)
+	}
+	if n := s.ai.Called("GenerateEmbedding"); n != embeddings {
+		t.Errorf("second RestoreItem embedded the item again (%d GenerateEmbedding calls, want %d)", n, embeddings)
+	}
+
+	if err := s.items.HardDeleteItem(ctx, userID, item.ID); err != nil {
+		t.Fatalf("HardDeleteItem: %v", err)
+	}
+	if s.chroma.Has(item.EmbeddingID) {
+		t.Error("permanently deleted item's vector is still in ChromaDB")
+	}
+}
+
+func TestCreateItemRejectsDuplicateURL(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+
+	first, err := s.items.CreateItem(ctx, userID, &models.CreateItemRequest{
+		Title:     "Release notes",
+		Content:   "What's new in this release.",
+		SourceURL: "https://example.com/releases/1.2?utm_source=newsletter",
+		Type:      "url",
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+
+	// The same page, shared with different tracking and formatting
+	_, err = s.items.CreateItem(ctx, userID, &models.CreateItemRequest{
+		Title:     "Release notes again",
+		Content:   "What's new in this release.",
+		SourceURL: "https://Example.com/releases/1.2/#changes",
+		Type:      "url",
+	})
+	var duplicate *services.DuplicateItemError
+	if !errors.As(err, &duplicate) {
+		t.Fatalf("second CreateItem error = %v, want a DuplicateItemError", err)
+	}
+	if duplicate.Existing.ID != first.ID || duplicate.Reason != "source_url" {
+		t.Errorf("duplicate of %v by %q, want %v by source_url", duplicate.Existing.ID, duplicate.Reason, first.ID)
+	}
+
+	// Another user saving the same link isn't a duplicate
+	if _, err := s.items.CreateItem(ctx, servicestest.NewUser(t, s.pool), &models.CreateItemRequest{
+		Title:     "Release notes",
+		SourceURL: "https://example.com/releases/1.2",
+		Type:      "url",
+	}); err != nil {
+		t.Errorf("another user's CreateItem: %v", err)
+	}
+}
+
+func TestCreateItemRejectsSimilarContent(t *testing.T) {
+	t.Setenv("DUPLICATE_SIMILARITY_THRESHOLD", "0.95")
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+	first := s.save(t, userID, "Tomato soup", "Roast the tomatoes, then blend.", []float32{1, 0, 0})
+
+	// The same recipe pasted again embeds almost identically
+	s.ai.Embedding = []float32{0.99, 0.05, 0}
+	_, err := s.items.CreateItem(ctx, userID, &models.CreateItemRequest{
+		Title:   "Tomato soup (copy)",
+		Content: "Roast the tomatoes and blend.",
+		Type:    "text",
+	})
+	var duplicate *services.DuplicateItemError
+	if !errors.As(err, &duplicate) {
+		t.Fatalf("CreateItem error = %v, want a DuplicateItemError", err)
+	}
+	if duplicate.Existing.ID != first.ID || duplicate.Reason != "similar_content" || duplicate.Similarity < 0.95 {
+		t.Errorf("duplicate of %v by %q at %v, want %v by similar_content at >= 0.95", duplicate.Existing.ID, duplicate.Reason, duplicate.Similarity, first.ID)
+	}
+
+	// Below the threshold is a different item
+	s.save(t, userID, "Bike repair", "Adjust the limit screws.", []float32{0, 1, 0})
+
+	// AllowDuplicate saves it anyway
+	s.ai.Embedding = []float32{0.99, 0.05, 0}
+	if _, err := s.items.CreateItem(ctx, userID, &models.CreateItemRequest{
+		Title:          "Tomato soup (copy)",
+		Content:        "Roast the tomatoes and blend.",
+		Type:           "text",
+		AllowDuplicate: true,
+	}); err != nil {
+		t.Errorf("CreateItem with AllowDuplicate: %v", err)
+	}
+}
+
+func TestSearchIsolatesUsers(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	owner := servicestest.NewUser(t, s.pool)
+	other := servicestest.NewUser(t, s.pool)
+
+	// Identical text and vectors, so only ownership tells them apart
+	mine := s.save(t, owner, "Quarterly budget", "Quarterly budget spreadsheet notes.", []float32{1, 0, 0})
+	theirs := s.save(t, other, "Quarterly budget", "Quarterly budget spreadsheet notes.", []float32{1, 0, 0})
+
+	for _, tt := range []struct {
+		userID uuid.UUID
+		want   uuid.UUID
+	}{
+		{owner, mine.ID},
+		{other, theirs.ID},
+	} {
+		results, err := s.search.Search(ctx, tt.userID, "quarterly budget", nil, "", 10, 0)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if len(results) != 1 || results[0].Item.ID != tt.want {
+			t.Errorf("Search as %v = %v, want only %v", tt.userID, results, tt.want)
+		}
+	}
+
+	// Another user's item can't be used as a starting point either
+	if _, err := s.search.RelatedItems(ctx, other, mine.ID, 10); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("RelatedItems of another user's item: err = %v, want pgx.ErrNoRows", err)
+	}
+	related, err := s.search.RelatedItems(ctx, owner, mine.ID, 10)
+	if err != nil {
+		t.Fatalf("RelatedItems: %v", err)
+	}
+	if len(related) != 0 {
+		t.Errorf("RelatedItems = %v, want nothing (the only neighbor is another user's)", related)
+	}
+
+	// Nor deleted by them
+	if err := s.items.DeleteItem(ctx, other, mine.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("DeleteItem of another user's item: err = %v, want pgx.ErrNoRows", err)
+	}
+	if !s.chroma.Has(mine.EmbeddingID) {
+		t.Error("another user's DeleteItem removed the vector")
+	}
+}
+
+func TestFindBrokenLinksChecksOldestFirst(t *testing.T) {
+	t.Setenv("LINK_CHECK_MAX_PER_RUN", "2")
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+
+	// saveLink saves a link last checked checkedAgo ago, or never if zero
+	saveLink := func(path string, checkedAgo time.Duration) *models.Item {
+		t.Helper()
+		item, err := s.items.CreateItem(ctx, userID, &models.CreateItemRequest{
+			Title:     path,
+			SourceURL: "https://example.com/" + path,
+			Type:      "url",
+		})
+		if err != nil {
+			t.Fatalf("CreateItem(%q): %v", path, err)
+		}
+		if checkedAgo > 0 {
+			checkedAt := time.Now().Add(-checkedAgo)
+			item.HTTPStatus = 200
+			item.LastCheckedAt = &checkedAt
+			if err := s.repo.UpdateLinkStatus(ctx, item); err != nil {
+				t.Fatalf("UpdateLinkStatus: %v", err)
+			}
+		}
+		return item
+	}
+	recent := saveLink("recent", 30*time.Hour)
+	oldest := saveLink("oldest", 72*time.Hour)
+	never := saveLink("never", 0)
+	s.metadata.LinkStatus = 404
+
+	report, err := s.items.FindBrokenLinks(ctx, userID, false)
+	if err != nil {
+		t.Fatalf("FindBrokenLinks: %v", err)
+	}
+	if report.Checked != 2 || report.Remaining != 1 {
+		t.Errorf("first run checked %d with %d remaining, want 2 and 1", report.Checked, report.Remaining)
+	}
+	if got := brokenIDs(report); len(got) != 2 || !got[never.ID] || !got[oldest.ID] {
+		t.Errorf("first run found %v broken, want the never and least recently checked links", got)
+	}
+
+	report, err = s.items.FindBrokenLinks(ctx, userID, false)
+	if err != nil {
+		t.Fatalf("second FindBrokenLinks: %v", err)
+	}
+	if report.Checked != 1 || report.Cached != 2 || report.Remaining != 0 {
+		t.Errorf("second run checked %d, cached %d, %d remaining; want 1, 2, 0", report.Checked, report.Cached, report.Remaining)
+	}
+	if got := brokenIDs(report); len(got) != 3 || !got[recent.ID] {
+		t.Errorf("second run found %v broken, want all three links", got)
+	}
+}
+
+// brokenIDs is the set of item IDs in report.Broken
+func brokenIDs(report *services.BrokenLinksReport) map[uuid.UUID]bool {
+	ids := map[uuid.UUID]bool{}
+	for _, item := range report.Broken {
+		ids[item.ID] = true
+	}
+	return ids
+}
+
+func TestCreateImageItemIsDescribedInBackground(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+	s.ai.Vision = true
+	s.ai.ImageDescription = " A red bicycle leaning on a brick wall. "
+
+	item, err := s.items.CreateItem(ctx, userID, &models.CreateItemRequest{
+		Title:    "My bike",
+		Content:  "Before the repaint.",
+		ImageURL: "https://example.com/bike.jpg",
+		Type:     "image",
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	if item.ImageDescription != "" {
+		t.Errorf("CreateItem waited for the image description %q", item.ImageDescription)
+	}
+
+	// The description is saved, then the item re-embedded with it
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		saved, err := s.repo.GetByID(ctx, userID, item.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if saved.ImageDescription != "" && saved.EmbeddingSourceHash != item.EmbeddingSourceHash {
+			if saved.ImageDescription != "A red bicycle leaning on a brick wall." {
+				t.Errorf("image description = %q", saved.ImageDescription)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("image wasn't described and re-embedded: description %q", saved.ImageDescription)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if n := s.ai.Called("GenerateEmbedding"); n != 2 {
+		t.Fatalf("GenerateEmbedding called %d times, want 2", n)
+	}
+	if embedded := s.ai.Embedded[1]; !strings.Contains(embedded, "A red bicycle leaning on a brick wall.") {
+		t.Errorf("re-embedded text %q doesn't include the description", embedded)
+	}
+}
+
+func TestCreateVideoFetchesTranscriptInBackground(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+	s.metadata.Transcript = "Today we look at how goroutines are scheduled."
+	s.ai.VideoSummary = "A talk on the Go scheduler."
+
+	item, err := s.items.CreateItem(ctx, userID, &models.CreateItemRequest{
+		Title:     "Go scheduler deep dive",
+		Content:   "Description: Conference talk.",
+		SourceURL: "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		Type:      "video",
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	if n := s.ai.Called("GenerateEmbedding"); n != 1 || strings.Contains(s.ai.Embedded[0], "Transcript:") {
+		t.Fatalf("save embedded %d texts, first %q; want one without the transcript", n, s.ai.Embedded[0])
+	}
+
+	// The video is re-embedded with its captions, then summarized from them
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		saved, err := s.repo.GetByID(ctx, userID, item.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if saved.Summary == "A talk on the Go scheduler." {
+			if saved.EmbeddingSourceHash == item.EmbeddingSourceHash {
+				t.Error("video wasn't re-embedded with its transcript")
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("video summary = %q, want the transcript-based one", saved.Summary)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if n := s.ai.Called("GenerateEmbedding"); n != 2 {
+		t.Fatalf("GenerateEmbedding called %d times, want 2", n)
+	}
+	if embedded := s.ai.Embedded[1]; !strings.Contains(embedded, "Transcript: Today we look at how goroutines are scheduled.") {
+		t.Errorf("re-embedded text %q doesn't include the transcript", embedded)
+	}
+}
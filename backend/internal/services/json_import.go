@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"synapse/internal/events"
+	"synapse/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ImportError records an exported item that couldn't be imported or indexed
+type ImportError struct {
+	ItemID uuid.UUID `json:"item_id"`
+	Error  string    `json:"error"`
+}
+
+// ImportReport summarizes an ImportJSON run
+type ImportReport struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	// Skipped items already existed and upsert was off
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+	// Unindexed items were saved but their embedding couldn't be regenerated; a reindex will retry them
+	Unindexed int           `json:"unindexed"`
+	Errors    []ImportError `json:"errors"`
+}
+
+func (r *ImportReport) addError(itemID uuid.UUID, err error) {
+	r.Errors = append(r.Errors, ImportError{ItemID: itemID, Error: err.Error()})
+}
+
+// ImportJSON recreates items from an ExportJSON array as userID's items,
+// keeping their IDs and created_at, and regenerates their embeddings. Items
+// whose ID already exists are skipped, or overwritten when upsert is set. The
+// input is decoded as a stream, a batch at a time.
+func (s *ItemService) ImportJSON(ctx context.Context, userID uuid.UUID, r io.Reader, upsert bool) (*ImportReport, error) {
+	report := &ImportReport{Errors: []ImportError{}}
+	decoder := json.NewDecoder(r)
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, fmt.Errorf("import must be a JSON array of items")
+	}
+
+	batch := make([]models.Item, 0, reindexBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		s.importBatch(ctx, userID, batch, upsert, report)
+		batch = batch[:0]
+	}
+	defer func() {
+		if report.Created+report.Updated > 0 {
+			s.itemsChanged(userID)
+		}
+	}()
+
+	for index := 0; decoder.More(); index++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		var item models.Item
+		if err := decoder.Decode(&item); err != nil {
+			// The rest of the stream can't be trusted after a syntax error, but
+			// the items before it are still imported
+			flush()
+			return report, fmt.Errorf("invalid item at index %d: %w", index, err)
+		}
+		batch = append(batch, item)
+		if len(batch) == reindexBatchSize {
+			flush()
+		}
+	}
+	flush()
+
+	return report, nil
+}
+
+// importBatch saves a batch of decoded items, then embeds the saved ones together
+func (s *ItemService) importBatch(ctx context.Context, userID uuid.UUID, items []models.Item, upsert bool, report *ImportReport) {
+	saved := make([]models.Item, 0, len(items))
+	for _, item := range items {
+		if item.ID == uuid.Nil {
+			item.ID = uuid.New()
+		}
+		item.UserID = userID
+		if item.CreatedAt.IsZero() {
+			item.CreatedAt = time.Now().UTC()
+		}
+		if item.SourceURL != "" {
+			item.NormalizedURL = normalizeURL(item.SourceURL)
+		}
+		// The vector is regenerated below, under this instance's provider
+		item.EmbeddingID = ""
+
+		existing, err := s.itemRepo.GetByID(ctx, userID, item.ID)
+		switch {
+		case err == nil && !upsert:
+			report.Skipped++
+			continue
+		case err == nil:
+			// Keep the existing vector's ID so the upsert replaces it
+			item.EmbeddingID = existing.EmbeddingID
+			err = s.itemRepo.Update(ctx, &item)
+		case errors.Is(err, pgx.ErrNoRows):
+			err = s.itemRepo.Create(ctx, &item)
+		}
+		if err != nil {
+			report.Failed++
+			report.addError(item.ID, fmt.Errorf("failed to save item: %w", err))
+			continue
+		}
+
+		if existing != nil {
+			report.Updated++
+			s.emit(ctx, events.ItemUpdated, &item)
+		} else {
+			report.Created++
+			s.emit(ctx, events.ItemCreated, &item)
+		}
+		saved = append(saved, item)
+	}
+
+	if len(saved) == 0 {
+		return
+	}
+	indexReport := &ReindexReport{Errors: []ReindexError{}}
+	s.reindexBatch(ctx, saved, indexReport)
+	report.Unindexed += indexReport.Failed
+	for _, indexErr := range indexReport.Errors {
+		report.Errors = append(report.Errors, ImportError{ItemID: indexErr.ItemID, Error: indexErr.Error})
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"synapse/internal/events"
+	"synapse/internal/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CheckLink reports whether url still works: status is its response status
+// and ok is false for 4xx/5xx. A HEAD request is tried first; servers that
+// reject or mishandle HEAD get a GET. A non-nil error means the link couldn't
+// be reached at all (DNS failure, refused, timed out), with status 0. Links
+// the URL guard won't request, such as intranet links, can't be judged from
+// here, so they come back with ok set alongside the ErrBlockedURL error.
+func (s *MetadataService) CheckLink(ctx context.Context, url string) (status int, ok bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	status, err = s.requestStatus(ctx, "HEAD", url)
+	if err != nil || status >= 400 {
+		// Many servers answer HEAD with 403, 404, or 405 while serving GET fine
+		status, err = s.requestStatus(ctx, "GET", url)
+	}
+	if err != nil {
+		return 0, errors.Is(err, ErrBlockedURL), err
+	}
+	return status, status < 400, nil
+}
+
+// requestStatus makes one request to url and returns its response status, without reading the body
+func (s *MetadataService) requestStatus(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// LinkPreview is what RefreshLink found at a saved link
+type LinkPreview struct {
+	// Status and OK are as CheckLink reports them
+	Status    int
+	OK        bool
+	EmbedHTML string
+	ImageURL  string
+	Title     string
+}
+
+// RefreshLink checks url and reads its preview from the same response, so a
+// refresh requests the link once: the GET's status says whether the link
+// works, and a working HTML page's Open Graph tags give its title and image.
+// Known media sites and PDFs get the preview GetURLMetadata would build.
+// Errors are reported the way CheckLink reports them.
+func (s *MetadataService) RefreshLink(ctx context.Context, url string) (*LinkPreview, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	resp, err := s.requestPage(ctx, url)
+	if err != nil {
+		return &LinkPreview{OK: errors.Is(err, ErrBlockedURL)}, err
+	}
+	defer resp.Body.Close()
+
+	preview := &LinkPreview{Status: resp.StatusCode, OK: resp.StatusCode < 400}
+	if !preview.OK {
+		return preview, nil
+	}
+	if embed, ok := s.matchEmbedProvider(ctx, url); ok {
+		preview.EmbedHTML, preview.ImageURL, preview.Title = embed.EmbedHTML, embed.ImageURL, embed.Title
+	} else if isPDFURL(url) {
+		preview.EmbedHTML = pdfEmbedHTML(url)
+	} else if _, ok := htmlMediaType(resp); ok {
+		og := parseOpenGraph(io.LimitReader(resp.Body, s.htmlMaxBytes))
+		preview.ImageURL, preview.Title = og.BestImage(), og.BestTitle()
+		preview.EmbedHTML = imageEmbedHTML(preview.ImageURL)
+	}
+	return preview, nil
+}
+
+// BrokenLinksReport is the result of FindBrokenLinks
+type BrokenLinksReport struct {
+	// Checked links were requested on this run; Cached ones reused a recent
+	// check. Remaining links were due a check but left for a later run.
+	Checked   int           `json:"checked"`
+	Cached    int           `json:"cached"`
+	Remaining int           `json:"remaining"`
+	Broken    []models.Item `json:"broken"`
+}
+
+// FindBrokenLinks checks the url, blog, and video links userID has saved and
+// returns the ones answering 4xx/5xx or unreachable, each with its
+// http_status. Links checked within LINK_CHECK_MAX_AGE_HOURS (default 24)
+// reuse their stored result unless recheck is set, so repeated runs are cheap.
+// A run checks at most LINK_CHECK_MAX_PER_RUN links (default 200), least
+// recently checked first, and reports how many are left for the next run. Up
+// to LINK_CHECK_CONCURRENCY links (default 8) are checked at once.
+func (s *ItemService) FindBrokenLinks(ctx context.Context, userID uuid.UUID, recheck bool) (*BrokenLinksReport, error) {
+	items, err := s.itemRepo.GetLinks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BrokenLinksReport{Broken: []models.Item{}}
+	freshAfter := time.Now().Add(-time.Duration(getEnvInt("LINK_CHECK_MAX_AGE_HOURS", 24)) * time.Hour)
+	workers := getEnvInt("LINK_CHECK_CONCURRENCY", 8)
+	if workers < 1 {
+		workers = 1
+	}
+
+	var stale []*models.Item
+	for i := range items {
+		if !recheck && items[i].LastCheckedAt != nil && items[i].LastCheckedAt.After(freshAfter) {
+			report.Cached++
+			continue
+		}
+		stale = append(stale, &items[i])
+	}
+	if maxPerRun := getEnvInt("LINK_CHECK_MAX_PER_RUN", 200); maxPerRun > 0 && len(stale) > maxPerRun {
+		sort.SliceStable(stale, func(i, j int) bool {
+			return checkedBefore(stale[i], stale[j])
+		})
+		report.Remaining = len(stale) - maxPerRun
+		stale = stale[:maxPerRun]
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(stale); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				s.checkItemLink(ctx, stale[i])
+			}
+		}()
+	}
+	for i := range stale {
+		if ctx.Err() != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	report.Checked = len(stale)
+
+	for _, item := range items {
+		if item.LinkBroken {
+			report.Broken = append(report.Broken, item)
+		}
+	}
+	return report, nil
+}
+
+// recordLinkStatus sets item's link status from a check made at checkedAt and
+// reports whether the link went from working to broken or back
+func recordLinkStatus(item *models.Item, status int, ok bool, checkedAt time.Time) bool {
+	wasBroken := item.LinkBroken
+	item.HTTPStatus = status
+	item.LinkBroken = !ok
+	item.LastCheckedAt = &checkedAt
+	return item.LinkBroken != wasBroken
+}
+
+// checkedBefore reports whether a's link was last checked before b's, counting never as earliest
+func checkedBefore(a, b *models.Item) bool {
+	if a.LastCheckedAt == nil || b.LastCheckedAt == nil {
+		return a.LastCheckedAt == nil && b.LastCheckedAt != nil
+	}
+	return a.LastCheckedAt.Before(*b.LastCheckedAt)
+}
+
+// checkItemLink checks item's link and saves the result, logging failures to save
+func (s *ItemService) checkItemLink(ctx context.Context, item *models.Item) {
+	status, ok, err := s.metadataService.CheckLink(ctx, item.SourceURL)
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	changed := recordLinkStatus(item, status, ok, time.Now())
+	if err := s.itemRepo.UpdateLinkStatus(ctx, item); err != nil {
+		s.logger.WarnContext(ctx, "failed to save link status", "operation", "check_links", "item_id", item.ID, "error", err)
+		return
+	}
+	if changed {
+		s.itemsChanged(item.UserID)
+		s.emit(ctx, events.ItemUpdated, item)
+	}
+}
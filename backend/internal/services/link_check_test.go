@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"synapse/internal/models"
+)
+
+func linkCheckService(server *httptest.Server) *MetadataService {
+	return &MetadataService{client: server.Client(), requestTimeout: 5 * time.Second, htmlMaxBytes: 1 << 20}
+}
+
+func TestCheckLink(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantOK     bool
+	}{
+		{"head ok", func(w http.ResponseWriter, r *http.Request) {}, http.StatusOK, true},
+		{"head rejected, get ok", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "HEAD" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		}, http.StatusOK, true},
+		{"gone", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}, http.StatusNotFound, false},
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}, http.StatusBadGateway, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			status, ok, err := linkCheckService(server).CheckLink(context.Background(), server.URL)
+			if err != nil || status != tt.wantStatus || ok != tt.wantOK {
+				t.Errorf("CheckLink = %d, %v, %v; want %d, %v, nil", status, ok, err, tt.wantStatus, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCheckLinkUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	s := linkCheckService(server)
+	server.Close()
+
+	status, ok, err := s.CheckLink(context.Background(), server.URL)
+	if err == nil || status != 0 || ok {
+		t.Errorf("CheckLink of a closed server = %d, %v, %v; want 0, false and an error", status, ok, err)
+	}
+}
+
+func TestRefreshLink(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		want        LinkPreview
+	}{
+		{
+			name:        "page with a preview",
+			status:      http.StatusOK,
+			contentType: "text/html; charset=utf-8",
+			body:        `<html><head><meta property="og:title" content="Fresh title"><meta property="og:image" content="https://cdn.example.com/a.png"></head></html>`,
+			want: LinkPreview{
+				Status:    http.StatusOK,
+				OK:        true,
+				Title:     "Fresh title",
+				ImageURL:  "https://cdn.example.com/a.png",
+				EmbedHTML: imageEmbedHTML("https://cdn.example.com/a.png"),
+			},
+		},
+		{
+			name:        "page without a preview",
+			status:      http.StatusOK,
+			contentType: "text/html",
+			body:        `<html><head><title>Plain</title></head></html>`,
+			want:        LinkPreview{Status: http.StatusOK, OK: true},
+		},
+		{
+			name:        "not a page",
+			status:      http.StatusOK,
+			contentType: "image/png",
+			body:        `<meta property="og:title" content="Not read">`,
+			want:        LinkPreview{Status: http.StatusOK, OK: true},
+		},
+		{
+			name:        "broken",
+			status:      http.StatusGone,
+			contentType: "text/html",
+			body:        `<html><head><meta property="og:title" content="Gone"></head></html>`,
+			want:        LinkPreview{Status: http.StatusGone},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			preview, err := linkCheckService(server).RefreshLink(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("RefreshLink: %v", err)
+			}
+			if *preview != tt.want {
+				t.Errorf("RefreshLink = %+v, want %+v", *preview, tt.want)
+			}
+			if n := requests.Load(); n != 1 {
+				t.Errorf("RefreshLink requested the link %d times, want once", n)
+			}
+		})
+	}
+}
+
+func TestRecordLinkStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		wasBroken   bool
+		status      int
+		ok          bool
+		wantChanged bool
+	}{
+		{"still working", false, http.StatusOK, true, false},
+		{"breaks", false, http.StatusNotFound, false, true},
+		{"still broken", true, 0, false, false},
+		{"recovers", true, http.StatusOK, true, true},
+	}
+
+	checkedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &models.Item{LinkBroken: tt.wasBroken, HTTPStatus: 500}
+			if changed := recordLinkStatus(item, tt.status, tt.ok, checkedAt); changed != tt.wantChanged {
+				t.Errorf("recordLinkStatus changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if item.HTTPStatus != tt.status || item.LinkBroken == tt.ok {
+				t.Errorf("item status = %d, broken %v; want %d, broken %v", item.HTTPStatus, item.LinkBroken, tt.status, !tt.ok)
+			}
+			if item.LastCheckedAt == nil || !item.LastCheckedAt.Equal(checkedAt) {
+				t.Errorf("LastCheckedAt = %v, want %v", item.LastCheckedAt, checkedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"synapse/internal/events"
+	"synapse/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNoLinkMetadata is returned by RefreshMetadata for items without a link preview to refresh
+var ErrNoLinkMetadata = errors.New("only url, blog, and video items with a source_url have link metadata")
+
+// hasLinkMetadata reports whether item's preview comes from its source URL
+func hasLinkMetadata(item *models.Item) bool {
+	return item.SourceURL != "" && (item.Type == "url" || item.Type == "blog" || item.Type == "video")
+}
+
+// RefreshMetadata re-fetches the link preview of a url, blog, or video item,
+// updating its title, image, and embed where the page now has different ones,
+// and records whether the link still works. Dead links (4xx/5xx responses or
+// unreachable hosts) are marked LinkBroken and keep their old preview.
+func (s *ItemService) RefreshMetadata(ctx context.Context, userID, id uuid.UUID) (*models.Item, error) {
+	item, err := s.itemRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !hasLinkMetadata(item) {
+		return nil, ErrNoLinkMetadata
+	}
+
+	if err := s.refreshLinkMetadata(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// refreshLinkMetadata re-fetches item's link preview and saves it along with the link's status
+func (s *ItemService) refreshLinkMetadata(ctx context.Context, item *models.Item) error {
+	preview, err := s.metadataService.RefreshLink(ctx, item.SourceURL)
+	if err != nil && ctx.Err() != nil {
+		// Shutting down or cancelled, which says nothing about the link
+		return ctx.Err()
+	}
+	metadata := embeddingMetadata(item)
+	changed := recordLinkStatus(item, preview.Status, preview.OK, time.Now())
+
+	if !item.LinkBroken {
+		// Only replace what the page still provides, so a thin response doesn't blank the preview
+		for _, field := range []struct {
+			current *string
+			fresh   string
+		}{{&item.Title, preview.Title}, {&item.ImageURL, preview.ImageURL}, {&item.EmbedHTML, preview.EmbedHTML}} {
+			if field.fresh != "" && field.fresh != *field.current {
+				*field.current = field.fresh
+				changed = true
+			}
+		}
+	} else {
+		s.logger.InfoContext(ctx, "link is broken", "operation", "refresh_metadata", "item_id", item.ID, "source_url", item.SourceURL, "http_status", preview.Status, "error", err)
+	}
+
+	if err := s.itemRepo.UpdateLinkMetadata(ctx, item); err != nil {
+		return err
+	}
+	// Search-as-you-type cards are drawn from the vector's copy of the title and image
+	s.updateVectorMetadata(ctx, "refresh_metadata", item, metadata)
+	if changed {
+		s.itemsChanged(item.UserID)
+		s.emit(ctx, events.ItemUpdated, item)
+	}
+	return nil
+}
+
+// RunMetadataRefresher refreshes stale link previews in the background until
+// ctx is done. Every METADATA_REFRESH_INTERVAL_MINUTES (default 60, 0 disables)
+// it refreshes up to METADATA_REFRESH_BATCH_SIZE items (default 50) across all
+// users whose link wasn't checked in the last METADATA_REFRESH_AGE_DAYS (default 30).
+func (s *ItemService) RunMetadataRefresher(ctx context.Context) {
+	interval := time.Duration(getEnvInt("METADATA_REFRESH_INTERVAL_MINUTES", 60)) * time.Minute
+	if interval <= 0 {
+		return
+	}
+	maxAge := time.Duration(getEnvInt("METADATA_REFRESH_AGE_DAYS", 30)) * 24 * time.Hour
+	batchSize := getEnvInt("METADATA_REFRESH_BATCH_SIZE", 50)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refreshStaleLinks(ctx, maxAge, batchSize)
+		}
+	}
+}
+
+// refreshStaleLinks runs one batch of RunMetadataRefresher
+func (s *ItemService) refreshStaleLinks(ctx context.Context, maxAge time.Duration, batchSize int) {
+	items, err := s.itemRepo.GetStaleLinks(ctx, time.Now().Add(-maxAge), batchSize)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to load stale links", "operation", "refresh_metadata", "error", err)
+		return
+	}
+
+	broken := 0
+	for i := range items {
+		if err := s.refreshLinkMetadata(ctx, &items[i]); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.logger.WarnContext(ctx, "failed to save refreshed link metadata", "operation", "refresh_metadata", "item_id", items[i].ID, "error", err)
+			continue
+		}
+		if items[i].LinkBroken {
+			broken++
+		}
+	}
+	if len(items) > 0 {
+		s.logger.InfoContext(ctx, "refreshed stale links", "operation", "refresh_metadata", "checked", len(items), "broken", broken)
+	}
+}
@@ -3,53 +3,159 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"os"
 	"regexp"
 	"strings"
+	"time"
+
+	"synapse/internal/logging"
 )
 
 type MetadataService struct {
 	client *http.Client
+	// requestTimeout bounds a single GetURLMetadata call, including page fetches
+	requestTimeout time.Duration
+	// unsplashAccessKey enables the official Unsplash API when source.unsplash.com fails
+	unsplashAccessKey string
+	// pdfMaxBytes and pdfMaxPages bound ExtractPDFText's download and parsing
+	pdfMaxBytes int64
+	pdfMaxPages int
+	// htmlMaxBytes caps how much of a page fetchHTML reads
+	htmlMaxBytes int64
+	// faviconGoogleFallback uses Google's favicon service for sites without an icon of their own
+	faviconGoogleFallback bool
+	// githubToken authenticates GitHub API calls for a higher rate limit; optional
+	githubToken string
+	// nitterURL is a Nitter instance X/Twitter post text is read from when oEmbed fails; optional
+	nitterURL string
+	// aiService reads images for ExtractImageText; nil disables it
+	aiService *AIService
+	logger    *slog.Logger
 }
 
-func NewMetadataService() *MetadataService {
+func NewMetadataService(logger *slog.Logger) *MetadataService {
+	client := newHTTPClient(getEnvSeconds("METADATA_HTTP_TIMEOUT_SECONDS", 15*time.Second), metadataUserAgent(), loadURLGuard())
+	client.CheckRedirect = limitRedirects(getEnvInt("METADATA_MAX_REDIRECTS", 5))
+
 	return &MetadataService{
-		client: &http.Client{},
+		client:                client,
+		requestTimeout:        getEnvSeconds("METADATA_REQUEST_TIMEOUT_SECONDS", 10*time.Second),
+		unsplashAccessKey:     os.Getenv("UNSPLASH_ACCESS_KEY"),
+		pdfMaxBytes:           int64(getEnvInt("PDF_MAX_BYTES", 20*1024*1024)),
+		pdfMaxPages:           getEnvInt("PDF_MAX_PAGES", 50),
+		htmlMaxBytes:          int64(getEnvInt("METADATA_MAX_HTML_BYTES", 2*1024*1024)),
+		faviconGoogleFallback: getEnvBool("FAVICON_GOOGLE_FALLBACK"),
+		githubToken:           os.Getenv("GITHUB_TOKEN"),
+		nitterURL:             strings.TrimRight(os.Getenv("NITTER_URL"), "/"),
+		logger:                logging.OrDefault(logger),
 	}
 }
 
-// GetURLMetadata extracts metadata from a URL including embed HTML and images
-func (s *MetadataService) GetURLMetadata(ctx context.Context, url string) (embedHTML string, imageURL string, err error) {
-	// For YouTube URLs, generate embed
-	if strings.Contains(url, "youtube.com") || strings.Contains(url, "youtu.be") {
-		videoID := s.extractYouTubeID(url)
-		if videoID != "" {
-			// Generate responsive embed HTML that works with the frontend wrapper
-			embedHTML = fmt.Sprintf(`<iframe width="100%%" height="100%%" src="https://www.youtube.com/embed/%s?rel=0" frameborder="0" allow="accelerometer; autoplay; clipboard-write; encrypted-media; gyroscope; picture-in-picture; web-share" allowfullscreen style="position: absolute; top: 0; left: 0; width: 100%%; height: 100%%;"></iframe>`, videoID)
-			imageURL = fmt.Sprintf("https://img.youtube.com/vi/%s/maxresdefault.jpg", videoID)
-			return embedHTML, imageURL, nil
-		}
+// GetURLMetadata extracts metadata from a URL including embed HTML and images.
+// title comes from oEmbed or Open Graph and may be empty.
+func (s *MetadataService) GetURLMetadata(ctx context.Context, url string) (embedHTML string, imageURL string, title string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	// For known media sites (YouTube, Vimeo, Spotify, SoundCloud), generate a player embed
+	if embed, ok := s.matchEmbedProvider(ctx, url); ok {
+		return embed.EmbedHTML, embed.ImageURL, embed.Title, nil
 	}
 
 	// For PDF URLs, generate PDF embed
-	if strings.HasSuffix(strings.ToLower(url), ".pdf") || strings.Contains(strings.ToLower(url), ".pdf?") {
-		// Generate responsive PDF embed using iframe
-		embedHTML = fmt.Sprintf(`<iframe width="100%%" height="100%%" src="%s" frameborder="0" style="position: absolute; top: 0; left: 0; width: 100%%; height: 100%%;" type="application/pdf"></iframe>`, url)
+	if isPDFURL(url) {
 		// PDFs don't have preview images, but we can use a generic PDF icon if needed
-		return embedHTML, "", nil
+		return pdfEmbedHTML(url), "", "", nil
 	}
 
 	// For other URLs, try to get Open Graph image
-	imageURL, _ = s.getOpenGraphImage(ctx, url)
-	
-	// Generate simple embed for other URLs
-	if imageURL != "" {
-		embedHTML = fmt.Sprintf(`<div class="url-preview"><img src="%s" alt="Preview" style="max-width: 100%%; border-radius: 8px;" /></div>`, imageURL)
+	og, err := s.GetOpenGraph(ctx, url)
+	if err != nil && errors.Is(err, context.DeadlineExceeded) {
+		// Surface timeouts so a dead server fails fast and clearly
+		return "", "", "", fmt.Errorf("timed out fetching metadata for %s: %w", url, err)
+	}
+	if og != nil {
+		imageURL = og.BestImage()
+		title = og.BestTitle()
+	}
+
+	return imageEmbedHTML(imageURL), imageURL, title, nil
+}
+
+// pdfEmbedHTML is a responsive iframe showing the PDF at url
+func pdfEmbedHTML(url string) string {
+	return fmt.Sprintf(`<iframe width="100%%" height="100%%" src="%s" frameborder="0" style="position: absolute; top: 0; left: 0; width: 100%%; height: 100%%;" type="application/pdf"></iframe>`, url)
+}
+
+// imageEmbedHTML is the simple preview of a page with an image, or "" without one
+func imageEmbedHTML(imageURL string) string {
+	if imageURL == "" {
+		return ""
 	}
+	return fmt.Sprintf(`<div class="url-preview"><img src="%s" alt="Preview" style="max-width: 100%%; border-radius: 8px;" /></div>`, imageURL)
+}
 
-	return embedHTML, imageURL, nil
+// PageMetadata is the descriptive metadata of a web page, with URLs made absolute
+type PageMetadata struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	SiteName    string `json:"site_name"`
+	ImageURL    string `json:"image_url"`
+	FaviconURL  string `json:"favicon_url"`
+}
+
+// GetPageMetadata fetches a page and extracts its title, description, author,
+// site name, preview image, and favicon. Open Graph values are preferred over
+// plain HTML tags, and relative URLs are resolved against the page.
+func (s *MetadataService) GetPageMetadata(ctx context.Context, pageURL string) (*PageMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	resp, err := s.fetchHTML(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	head := parseHTMLHead(resp.Body)
+	base := headBase(resp.Request.URL, head.baseHref)
+
+	metadata := &PageMetadata{
+		URL:         resp.Request.URL.String(),
+		Title:       head.og.BestTitle(),
+		Description: head.og.Description,
+		Author:      head.author,
+		SiteName:    head.siteName,
+		ImageURL:    resolveURL(base, head.og.BestImage()),
+	}
+	if metadata.Title == "" {
+		metadata.Title = strings.Join(strings.Fields(head.title), " ")
+	}
+	if metadata.Description == "" {
+		metadata.Description = head.description
+	}
+	metadata.FaviconURL = s.resolveFavicon(ctx, base, head.iconHrefs)
+
+	return metadata, nil
+}
+
+// resolveURL makes ref absolute relative to base, returning "" for empty or invalid refs
+func resolveURL(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	resolved, err := base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return resolved.String()
 }
 
 // DetectBookAndGetCover detects if content is about a book and fetches cover
@@ -72,7 +178,7 @@ func (s *MetadataService) DetectBookAndGetCover(ctx context.Context, title, cont
 	}
 
 	// Try to extract ISBN
-	isbn := s.extractISBN(content)
+	isbn := extractISBN(content)
 	if isbn != "" {
 		return s.getBookCoverByISBN(ctx, isbn)
 	}
@@ -105,15 +211,16 @@ func (s *MetadataService) DetectRecipeAndGetImage(ctx context.Context, title, co
 	return s.getRecipeImage(ctx, title)
 }
 
+// youTubeIDPatterns match every URL shape a YouTube video can be shared as.
+// The ID class excludes '&', '?', and '#', so trailing params like &t=30s are dropped.
+var youTubeIDPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`youtube\.com/watch\?(?:[^#]*&)?v=([a-zA-Z0-9_-]+)`),
+	regexp.MustCompile(`youtu\.be/([a-zA-Z0-9_-]+)`),
+	regexp.MustCompile(`youtube\.com/(?:embed|shorts|live|v)/([a-zA-Z0-9_-]+)`),
+}
+
 func (s *MetadataService) extractYouTubeID(url string) string {
-	patterns := []string{
-		`youtube\.com/watch\?v=([a-zA-Z0-9_-]+)`,
-		`youtu\.be/([a-zA-Z0-9_-]+)`,
-		`youtube\.com/embed/([a-zA-Z0-9_-]+)`,
-	}
-	
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
+	for _, re := range youTubeIDPatterns {
 		matches := re.FindStringSubmatch(url)
 		if len(matches) > 1 {
 			return matches[1]
@@ -122,59 +229,72 @@ func (s *MetadataService) extractYouTubeID(url string) string {
 	return ""
 }
 
-func (s *MetadataService) getOpenGraphImage(ctx context.Context, url string) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; SynapseBot/1.0)")
-	
-	resp, err := s.client.Do(req)
+// GetOpenGraph fetches a page and returns its Open Graph and Twitter Card metadata
+func (s *MetadataService) GetOpenGraph(ctx context.Context, url string) (*OpenGraph, error) {
+	resp, err := s.fetchHTML(ctx, url)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
-	
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-	
-	// Extract og:image
-	re := regexp.MustCompile(`<meta\s+property=["']og:image["']\s+content=["']([^"']+)["']`)
-	matches := re.FindStringSubmatch(string(body))
-	if len(matches) > 1 {
-		return matches[1], nil
-	}
-	
-	// Try twitter:image
-	re = regexp.MustCompile(`<meta\s+name=["']twitter:image["']\s+content=["']([^"']+)["']`)
-	matches = re.FindStringSubmatch(string(body))
-	if len(matches) > 1 {
-		return matches[1], nil
-	}
-	
-	return "", nil
+
+	return parseOpenGraph(resp.Body), nil
 }
 
-func (s *MetadataService) extractISBN(content string) string {
+// isbnPatterns are tried in order; a match is only accepted if its check digit
+// is valid. Unlabeled numbers must carry the 978/979 Bookland prefix, since bare
+// 10-digit runs are usually phone numbers or IDs.
+var isbnPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bISBN(?:-?1[03])?:?\s*(97[89](?:[- ]?[0-9]){10}|[0-9](?:[- ]?[0-9]){8}[- ]?[0-9Xx])\b`),
+	regexp.MustCompile(`\b(97[89](?:[- ]?[0-9]){10})\b`),
+}
+
+func extractISBN(content string) string {
 	// Extract ISBN-13 or ISBN-10
-	patterns := []string{
-		`ISBN[-\s]*(?:13)?[:\s]*([0-9]{13})`,
-		`ISBN[-\s]*(?:10)?[:\s]*([0-9X]{10})`,
-		`([0-9]{3}[- ]?[0-9]{10})`,
-	}
-	
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindStringSubmatch(content)
-		if len(matches) > 1 {
-			return strings.ReplaceAll(strings.ReplaceAll(matches[1], "-", ""), " ", "")
+	for _, re := range isbnPatterns {
+		for _, matches := range re.FindAllStringSubmatch(content, -1) {
+			isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(matches[1]))
+			if isValidISBN(isbn) {
+				return isbn
+			}
 		}
 	}
 	return ""
 }
 
+// isValidISBN checks the ISBN-10 or ISBN-13 check digit of a normalized ISBN
+func isValidISBN(isbn string) bool {
+	switch len(isbn) {
+	case 10:
+		sum := 0
+		for i, c := range isbn {
+			var digit int
+			if c == 'X' && i == 9 {
+				digit = 10
+			} else if c >= '0' && c <= '9' {
+				digit = int(c - '0')
+			} else {
+				return false
+			}
+			sum += (10 - i) * digit
+		}
+		return sum%11 == 0
+	case 13:
+		sum := 0
+		for i, c := range isbn {
+			if c < '0' || c > '9' {
+				return false
+			}
+			weight := 1
+			if i%2 == 1 {
+				weight = 3
+			}
+			sum += weight * int(c-'0')
+		}
+		return sum%10 == 0
+	}
+	return false
+}
+
 func (s *MetadataService) getBookCoverByISBN(ctx context.Context, isbn string) (string, error) {
 	// Use Open Library Covers API
 	url := fmt.Sprintf("https://covers.openlibrary.org/b/isbn/%s-L.jpg", isbn)
@@ -232,8 +352,7 @@ func (s *MetadataService) getRecipeImage(ctx context.Context, title string) (str
 		searchQuery = "recipe," + strings.Join(keywordParts, ",")
 	}
 	// Use Unsplash Source API with recipe-specific search
-	url := fmt.Sprintf("https://source.unsplash.com/400x300/?%s", strings.ReplaceAll(searchQuery, " ", "+"))
-	return url, nil
+	return s.resolveUnsplashImage(ctx, searchQuery), nil
 }
 
 // FetchRelevantImage attempts to fetch a relevant image for any content type
@@ -257,9 +376,8 @@ func (s *MetadataService) FetchRelevantImage(ctx context.Context, title, content
 			urlRe := regexp.MustCompile(`https?://[^\s]+`)
 			matches := urlRe.FindStringSubmatch(content)
 			if len(matches) > 0 {
-				imageURL, _ := s.getOpenGraphImage(ctx, matches[0])
-				if imageURL != "" {
-					return imageURL, nil
+				if og, err := s.GetOpenGraph(ctx, matches[0]); err == nil && og.BestImage() != "" {
+					return og.BestImage(), nil
 				}
 			}
 		}
@@ -317,8 +435,62 @@ func (s *MetadataService) getImageByCategory(ctx context.Context, title, categor
 	}
 	
 	// Use Unsplash Source API with search terms
-	url := fmt.Sprintf("https://source.unsplash.com/400x300/?%s", strings.ReplaceAll(searchQuery, " ", "+"))
-	return url, nil
+	return s.resolveUnsplashImage(ctx, searchQuery), nil
+}
+
+// resolveUnsplashImage returns a concrete photo URL for a comma-separated
+// query, or "" so the UI shows a placeholder instead of a broken <img>.
+// source.unsplash.com is deprecated, so its redirect is verified with a HEAD
+// request before falling back to the official API (needs UNSPLASH_ACCESS_KEY).
+func (s *MetadataService) resolveUnsplashImage(ctx context.Context, searchQuery string) string {
+	sourceURL := fmt.Sprintf("https://source.unsplash.com/400x300/?%s", strings.ReplaceAll(searchQuery, " ", "+"))
+
+	req, _ := http.NewRequestWithContext(ctx, "HEAD", sourceURL, nil)
+	resp, err := s.client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == 200 && strings.HasPrefix(resp.Header.Get("Content-Type"), "image/") {
+			// Store the redirect target so the image doesn't change on every load
+			return resp.Request.URL.String()
+		}
+	}
+
+	if s.unsplashAccessKey == "" {
+		return ""
+	}
+
+	apiURL := fmt.Sprintf("https://api.unsplash.com/search/photos?query=%s&per_page=1&orientation=landscape",
+		url.QueryEscape(strings.ReplaceAll(searchQuery, ",", " ")))
+	req, _ = http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	req.Header.Set("Authorization", "Client-ID "+s.unsplashAccessKey)
+	req.Header.Set("Accept-Version", "v1")
+
+	resp, err = s.client.Do(req)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Unsplash API request failed", "operation", "fetch_image", "error", err)
+		return ""
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		s.logger.WarnContext(ctx, "Unsplash API returned an error status", "operation", "fetch_image", "status", resp.StatusCode)
+		return ""
+	}
+
+	var result struct {
+		Results []struct {
+			URLs struct {
+				Small string `json:"small"`
+			} `json:"urls"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return ""
+	}
+	if len(result.Results) == 0 {
+		return ""
+	}
+	return result.Results[0].URLs.Small
 }
 
 // extractKeywordsFromTitle extracts meaningful keywords from title
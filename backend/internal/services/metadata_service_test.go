@@ -0,0 +1,81 @@
+package services
+
+import "testing"
+
+func TestExtractYouTubeID(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"watch", "https://www.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"watch with timestamp", "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=30s", "dQw4w9WgXcQ"},
+		{"v after other params", "https://www.youtube.com/watch?feature=share&list=PL123&v=dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"mobile", "https://m.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"short link", "https://youtu.be/dQw4w9WgXcQ?si=abc", "dQw4w9WgXcQ"},
+		{"shorts", "https://www.youtube.com/shorts/aBc_123-xyz", "aBc_123-xyz"},
+		{"live", "https://www.youtube.com/live/aBc_123-xyz?feature=share", "aBc_123-xyz"},
+		{"embed", "https://www.youtube.com/embed/dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"v in fragment", "https://www.youtube.com/watch?feature=share#v=dQw4w9WgXcQ", ""},
+		{"channel", "https://www.youtube.com/@somechannel", ""},
+		{"other site", "https://vimeo.com/123456", ""},
+	}
+
+	s := &MetadataService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.extractYouTubeID(tt.url); got != tt.want {
+				t.Errorf("extractYouTubeID(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractISBN(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"labeled ISBN-13 with hyphens", "ISBN: 978-0-306-40615-7", "9780306406157"},
+		{"labeled ISBN-13 suffix", "ISBN-13 9780306406157", "9780306406157"},
+		{"labeled ISBN-10", "isbn 0-306-40615-2", "0306406152"},
+		{"ISBN-10 with X check digit", "ISBN-10: 080442957x", "080442957X"},
+		{"unlabeled Bookland number", "Hardcover, 978 0 306 40615 7, 320 pages", "9780306406157"},
+		{"bad check digit", "ISBN: 978-0-306-40615-8", ""},
+		{"skips invalid for a later valid one", "ISBN 9780306406158 or ISBN 9780306406157", "9780306406157"},
+		{"unlabeled 10 digits", "Call 0306406152 for details", ""},
+		{"phone number", "Phone: 555-123-4567", ""},
+		{"none", "A book about Go", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractISBN(tt.content); got != tt.want {
+				t.Errorf("extractISBN(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidISBN(t *testing.T) {
+	tests := []struct {
+		isbn string
+		want bool
+	}{
+		{"9780306406157", true},
+		{"9780306406158", false},
+		{"0306406152", true},
+		{"0306406153", false},
+		{"080442957X", true},
+		{"08044295X7", false},
+		{"978030640615", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isValidISBN(tt.isbn); got != tt.want {
+			t.Errorf("isValidISBN(%q) = %v, want %v", tt.isbn, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// OpenGraph holds the Open Graph and Twitter Card tags found in a page's <head>
+type OpenGraph struct {
+	Title          string `json:"og_title"`
+	Description    string `json:"og_description"`
+	Image          string `json:"og_image"`
+	ImageSecureURL string `json:"og_image_secure_url"`
+	TwitterTitle   string `json:"twitter_title"`
+	TwitterImage   string `json:"twitter_image"`
+}
+
+// BestImage returns the most reliable preview image, preferring HTTPS
+func (og *OpenGraph) BestImage() string {
+	if og.ImageSecureURL != "" {
+		return og.ImageSecureURL
+	}
+	if og.Image != "" {
+		return og.Image
+	}
+	return og.TwitterImage
+}
+
+// BestTitle returns the Open Graph title, falling back to the Twitter Card title
+func (og *OpenGraph) BestTitle() string {
+	if og.Title != "" {
+		return og.Title
+	}
+	return og.TwitterTitle
+}
+
+// htmlHead is everything of interest found in a page's <head>
+type htmlHead struct {
+	og          OpenGraph
+	title       string
+	description string
+	author      string
+	siteName    string
+	baseHref    string
+	iconHrefs   []string
+}
+
+// parseOpenGraph tokenizes HTML and collects OG/Twitter meta tags. Attributes
+// may appear in any order and with any quoting; parsing stops at <body>.
+func parseOpenGraph(r io.Reader) *OpenGraph {
+	return &parseHTMLHead(r).og
+}
+
+// parseHTMLHead tokenizes a page's <head>, collecting meta tags, the <title>,
+// <base href>, and icon links. Parsing stops at </head> or <body>.
+func parseHTMLHead(r io.Reader) *htmlHead {
+	head := &htmlHead{}
+	og := &head.og
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			// io.EOF or malformed input - return whatever was found
+			return head
+		case html.TextToken:
+			if inTitle {
+				head.title += string(tokenizer.Text())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "body":
+				return head
+			case "title":
+				inTitle = head.title == ""
+			case "base":
+				setIfEmpty(&head.baseHref, tokenAttr(token, "href"))
+			case "link":
+				rel := strings.ToLower(tokenAttr(token, "rel"))
+				href := tokenAttr(token, "href")
+				if href != "" && strings.Contains(rel, "icon") && !strings.Contains(rel, "mask") {
+					head.iconHrefs = append(head.iconHrefs, href)
+				}
+			case "meta":
+				key, content := metaKeyAndContent(token)
+				if content == "" {
+					continue
+				}
+				switch key {
+				case "og:title":
+					setIfEmpty(&og.Title, content)
+				case "og:description":
+					setIfEmpty(&og.Description, content)
+				case "og:image", "og:image:url":
+					setIfEmpty(&og.Image, content)
+				case "og:image:secure_url":
+					setIfEmpty(&og.ImageSecureURL, content)
+				case "twitter:title":
+					setIfEmpty(&og.TwitterTitle, content)
+				case "twitter:image", "twitter:image:src":
+					setIfEmpty(&og.TwitterImage, content)
+				case "og:site_name":
+					setIfEmpty(&head.siteName, content)
+				case "description":
+					setIfEmpty(&head.description, content)
+				case "author", "article:author":
+					setIfEmpty(&head.author, content)
+				}
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return head
+			}
+		}
+	}
+}
+
+// tokenAttr returns the value of the named attribute, or ""
+func tokenAttr(token html.Token, name string) string {
+	for _, attr := range token.Attr {
+		if strings.EqualFold(attr.Key, name) {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return ""
+}
+
+// metaKeyAndContent returns the lowercased property/name and the content of a <meta> tag
+func metaKeyAndContent(token html.Token) (string, string) {
+	var key, content string
+	for _, attr := range token.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "property", "name":
+			if key == "" {
+				key = strings.ToLower(strings.TrimSpace(attr.Val))
+			}
+		case "content":
+			content = strings.TrimSpace(attr.Val)
+		}
+	}
+	return key, content
+}
+
+// setIfEmpty keeps the first value seen for a tag that may appear more than once
+func setIfEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
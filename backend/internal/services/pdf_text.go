@@ -0,0 +1,102 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// ErrNoExtractableText is returned by ExtractPDFText for PDFs without a text
+// layer, such as scanned documents
+var ErrNoExtractableText = errors.New("PDF has no extractable text")
+
+// isPDFURL reports whether a URL points at a PDF by its extension
+func isPDFURL(rawURL string) bool {
+	lower := strings.ToLower(rawURL)
+	return strings.HasSuffix(lower, ".pdf") || strings.Contains(lower, ".pdf?")
+}
+
+// ExtractPDFText downloads the PDF at url and returns its plain text. Downloads
+// over PDF_MAX_BYTES are rejected and only the first PDF_MAX_PAGES pages are read.
+func (s *MetadataService) ExtractPDFText(ctx context.Context, url string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("failed to fetch %s: status %d", url, resp.StatusCode)
+	}
+	if resp.ContentLength > s.pdfMaxBytes {
+		return "", fmt.Errorf("PDF %s is %d bytes, over the %d byte limit", url, resp.ContentLength, s.pdfMaxBytes)
+	}
+
+	// Read one byte past the limit so an oversized body without Content-Length is caught
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s.pdfMaxBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if int64(len(data)) > s.pdfMaxBytes {
+		return "", fmt.Errorf("PDF %s is over the %d byte limit", url, s.pdfMaxBytes)
+	}
+
+	return extractPDFText(data, s.pdfMaxPages)
+}
+
+// extractPDFText pulls the text layer out of the first maxPages pages of a PDF
+func extractPDFText(data []byte, maxPages int) (text string, err error) {
+	// The parser panics on some malformed files rather than returning an error
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to parse PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse PDF: %w", err)
+	}
+
+	pages := reader.NumPage()
+	if maxPages > 0 && pages > maxPages {
+		pages = maxPages
+	}
+
+	var sb strings.Builder
+	for i := 1; i <= pages; i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		pageText, err := page.GetPlainText(nil)
+		if err != nil {
+			// One unreadable page shouldn't lose the rest of the document
+			continue
+		}
+		if pageText = strings.TrimSpace(pageText); pageText != "" {
+			sb.WriteString(pageText)
+			sb.WriteString("\n\n")
+		}
+	}
+
+	text = strings.TrimSpace(sb.String())
+	if text == "" {
+		return "", ErrNoExtractableText
+	}
+	return text, nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildPDF returns a minimal PDF with one page per entry in pages, each
+// showing its text in Helvetica; an empty entry is a page without text
+func buildPDF(pages ...string) []byte {
+	var objects []string
+	kids := make([]string, len(pages))
+	// Objects 1-3 are the catalog, page tree, and font; pages follow in pairs
+	for i, text := range pages {
+		pageNum, contentNum := 4+2*i, 5+2*i
+		kids[i] = fmt.Sprintf("%d 0 R", pageNum)
+		stream := ""
+		if text != "" {
+			stream = fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		}
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", contentNum),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		)
+	}
+	objects = append([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}, objects...)
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestExtractPDFText(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     []byte
+		status   int
+		maxBytes int64
+		maxPages int
+		want     []string // substrings of the text
+		skipped  []string // text that must not be extracted
+		wantErr  error
+	}{
+		{
+			name: "text from every page",
+			body: buildPDF("Quarterly report", "Revenue grew"),
+			want: []string{"Quarterly report", "Revenue grew"},
+		},
+		{
+			name:     "pages past the limit are skipped",
+			body:     buildPDF("First page", "Second page"),
+			maxPages: 1,
+			want:     []string{"First page"},
+			skipped:  []string{"Second page"},
+		},
+		{
+			name:    "scanned document",
+			body:    buildPDF(""),
+			wantErr: ErrNoExtractableText,
+		},
+		{
+			name:     "over the size limit",
+			body:     buildPDF("Too big"),
+			maxBytes: 100,
+		},
+		{
+			name: "not a PDF",
+			body: []byte("<html>Not found</html>"),
+		},
+		{
+			name:   "error status",
+			body:   buildPDF("Gone"),
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				w.Write(tt.body)
+			}))
+			defer server.Close()
+
+			s := &MetadataService{client: server.Client(), requestTimeout: 5 * time.Second, pdfMaxBytes: 1 << 20, pdfMaxPages: tt.maxPages}
+			if tt.maxBytes != 0 {
+				s.pdfMaxBytes = tt.maxBytes
+			}
+
+			text, err := s.ExtractPDFText(context.Background(), server.URL+"/doc.pdf")
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("ExtractPDFText = %q, want an error", text)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("ExtractPDFText error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractPDFText: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("text %q is missing %q", text, want)
+				}
+			}
+			for _, skipped := range tt.skipped {
+				if strings.Contains(text, skipped) {
+					t.Errorf("text %q includes %q from past the page limit", text, skipped)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// promptData fills the placeholders of a prompt template. Each prompt uses only
+// some of the fields, e.g. {{.Title}}, {{.Content}} and {{.MaxTokens}}.
+type promptData struct {
+	Title      string
+	Content    string
+	Type       string
+	Query      string
+	Results    []promptResult
+	Categories []string
+	MaxTokens  int
+	MaxWords   int
+	// Transcript is a video's captions, empty when it has none
+	Transcript string
+}
+
+// promptResult is one search result listed in the rerank prompt; Number is 1-based
+type promptResult struct {
+	Number  int
+	Title   string
+	Summary string
+	Type    string
+}
+
+// defaultPrompts are the built-in templates, keyed by prompt name. Deployments can
+// replace any of them via PROMPTS_FILE or a PROMPT_<NAME> env var; see loadPrompts.
+var defaultPrompts = map[string]string{
+	"summary": "Summarize the following content in 2-3 concise sentences. Focus on the key points:\n\n{{.Content}}",
+
+	"tags": "Extract 3-5 relevant tags for this content. Return only comma-separated tags, no explanations, no numbering, just tags separated by commas:\n\n{{.Content}}",
+
+	"language": "Identify the language of the following text. Return ONLY its two-letter ISO 639-1 code in lowercase (for example: en, es, fr, de, hi), nothing else:\n\n{{.Content}}",
+
+	"translate": "Translate the following text into English. Preserve the meaning, names, and technical terms. Return ONLY the translation, no explanations:\n\n{{.Content}}",
+
+	"enhance_query": `You are a search query enhancement assistant. Your goal is to help users find content even when they use plain English that doesn't match exact words in the content.
+
+Analyze the following search query and return an improved search query that will find relevant content using semantic understanding.
+
+Examples:
+- "things about AI" → "artificial intelligence machine learning neural networks AI"
+- "cooking ideas" → "recipes cooking food preparation ingredients"
+- "workout tips" → "exercise fitness training health workout"
+- "money saving" → "budget savings finance frugal economical"
+
+Your task:
+1. Understand the user's intent and what they're really looking for
+2. Expand with relevant synonyms, related terms, and alternative phrasings
+3. Include both formal and informal terms
+4. Keep the original meaning but add searchable keywords
+5. Return ONLY the enhanced query with expanded terms, nothing else
+
+Original query: "{{.Query}}"
+
+Enhanced query:`,
+
+	"rerank": `You are a search result ranking assistant. Given a search query and a list of search results, rank them by relevance to the query.
+
+Search query: {{.Query}}
+
+Search results to rank:
+{{range .Results}}{{.Number}}. Title: {{.Title}}
+   Summary: {{.Summary}}
+   Type: {{.Type}}
+
+{{end}}
+
+Return ONLY a comma-separated list of numbers (1, 2, 3, etc.) representing the order of relevance, with the most relevant first. For example: "3,1,5,2,4"
+
+Ranked order:`,
+
+	"categorize": `Categorize this content into ONE of these specific sections:
+{{range .Categories}}- {{.}}
+{{end}}
+Title: {{.Title}}
+Type: {{.Type}}
+Content: {{.Content}}
+
+Return ONLY the category name, nothing else.`,
+
+	"title": `Write a short, descriptive title (at most {{.MaxWords}} words) for this saved content.
+
+Content: {{.Content}}
+
+Return ONLY the title, nothing else.`,
+
+	"semantic_summary": `Create a concise semantic summary (2-3 sentences) of this content that captures key concepts, topics, and ideas. This summary will be used for search, so include important keywords and concepts:
+    
+    Title: {{.Title}}
+    Content: {{.Content}}
+    
+    Summary:`,
+
+	"video_summary": `Create a SHORT, concise summary (2-3 sentences maximum) of this YouTube video. Focus only on the main topic and key points. Be brief and informative.
+
+Video Title: {{.Title}}
+Video Description: {{.Content}}
+{{if .Transcript}}
+Video Transcript: {{.Transcript}}
+{{end}}
+Provide a brief summary:`,
+
+	"image_text": `Transcribe all text visible in this image, such as a screenshot, slide, document, or recipe card, keeping its reading order and line breaks. If the image has little or no text, instead describe what it shows in 2-3 sentences, naming the key objects, people, and setting. Return ONLY the text or description, no explanations.`,
+
+	"suggest_query": `A search of a personal library of saved notes, articles, and videos found little or nothing for this query. If it has likely typos, return the corrected query. Otherwise return a broader query that would match more of the library. Prefer wording from the library's topics when it fits.
+
+Query: {{.Query}}
+{{if .Content}}
+Library topics: {{.Content}}
+{{end}}
+Return ONLY the suggested query, or NONE if the query is fine as it is.`,
+
+	"image_description": `Describe this image in one paragraph of 2-4 sentences, as alt text for someone who can't see it. Cover the main subject, setting, notable objects, colors, and any action or mood, using plain words a person might search for. Don't transcribe text in the image beyond naming what it is (e.g. "a restaurant menu"). Return ONLY the description.`,
+}
+
+// promptTemplates holds the parsed template for every prompt name
+type promptTemplates map[string]*template.Template
+
+// loadPrompts parses the built-in templates, then applies overrides: first from the
+// JSON object of name → template in PROMPTS_FILE, then from PROMPT_<NAME> env vars
+// (e.g. PROMPT_SUMMARY). An override that doesn't parse or render is skipped with a
+// warning, so a typo can't take a prompt down.
+func loadPrompts(logger *slog.Logger) promptTemplates {
+	prompts := make(promptTemplates, len(defaultPrompts))
+	for name, text := range defaultPrompts {
+		prompts[name] = template.Must(template.New(name).Parse(text))
+	}
+
+	overrides := map[string]string{}
+	if path := os.Getenv("PROMPTS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(data, &overrides)
+		}
+		if err != nil {
+			logger.Warn("failed to load PROMPTS_FILE, using built-in prompts", "path", path, "error", err)
+		}
+	}
+	for name := range defaultPrompts {
+		if text := os.Getenv("PROMPT_" + strings.ToUpper(name)); text != "" {
+			overrides[name] = text
+		}
+	}
+
+	for name, text := range overrides {
+		if _, ok := defaultPrompts[name]; !ok {
+			logger.Warn("ignoring unknown prompt", "prompt", name)
+			continue
+		}
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err == nil {
+			// Render once so a misspelled placeholder fails now rather than on every call
+			err = tmpl.Execute(&bytes.Buffer{}, promptData{Categories: categories})
+		}
+		if err != nil {
+			logger.Warn("invalid prompt template, using built-in", "prompt", name, "error", err)
+			continue
+		}
+		prompts[name] = tmpl
+	}
+	return prompts
+}
+
+// render fills the named prompt template with data
+func (p promptTemplates) render(name string, data promptData) (string, error) {
+	tmpl, ok := p[name]
+	if !ok {
+		return "", fmt.Errorf("unknown prompt %q", name)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s prompt: %w", name, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPromptsDefaults(t *testing.T) {
+	prompts := loadPrompts(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for name := range defaultPrompts {
+		got, err := prompts.render(name, promptData{Title: "Title", Content: "Body", Query: "query", Categories: categories})
+		if err != nil {
+			t.Errorf("render(%q): %v", name, err)
+			continue
+		}
+		if got == "" {
+			t.Errorf("render(%q) is empty", name)
+		}
+	}
+	if _, err := prompts.render("nope", promptData{}); err == nil {
+		t.Error("render of an unknown prompt succeeded")
+	}
+}
+
+func TestLoadPromptsOverrides(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "prompts.json")
+	err := os.WriteFile(file, []byte(`{
+		"summary": "File summary of {{.Content}}",
+		"tags": "File tags for {{.Content}}",
+		"title": "Broken {{.Nope}}",
+		"language": "Unclosed {{.Content",
+		"unknown": "Ignored"
+	}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PROMPTS_FILE", file)
+	// Env vars win over the file
+	t.Setenv("PROMPT_TAGS", "Env tags for {{.Content}}")
+	prompts := loadPrompts(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"summary", "File summary of Body"},
+		{"tags", "Env tags for Body"},
+		// Templates that don't parse or render keep the built-in
+		{"title", "Write a short, descriptive title"},
+		{"language", "Identify the language"},
+		// Prompts that weren't overridden are unchanged
+		{"translate", "Translate the following text"},
+	}
+
+	for _, tt := range tests {
+		got, err := prompts.render(tt.name, promptData{Content: "Body", MaxWords: 8})
+		if err != nil {
+			t.Errorf("render(%q): %v", tt.name, err)
+			continue
+		}
+		if !strings.HasPrefix(got, tt.want) {
+			t.Errorf("render(%q) = %q, want it to start with %q", tt.name, got, tt.want)
+		}
+	}
+	if _, ok := prompts["unknown"]; ok {
+		t.Error("unknown prompt name was loaded")
+	}
+}
+
+func TestLoadPromptsUnreadableFile(t *testing.T) {
+	t.Setenv("PROMPTS_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	prompts := loadPrompts(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	got, err := prompts.render("summary", promptData{Content: "Body"})
+	if err != nil || !strings.HasPrefix(got, "Summarize the following content") {
+		t.Errorf("render(summary) = %q, %v; want the built-in", got, err)
+	}
+}
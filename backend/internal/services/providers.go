@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+
+	"synapse/internal/models"
+)
+
+// AIProvider is the AI work ItemService and SearchService depend on.
+// *AIService implements it; servicestest has a fake for tests.
+type AIProvider interface {
+	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
+	GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error)
+	GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error)
+	EmbeddingModel() string
+	GenerateSemanticSummary(ctx context.Context, title, content string) (string, error)
+	SummarizeContentStream(ctx context.Context, content string) (<-chan string, <-chan error)
+	SummarizeYouTubeVideo(ctx context.Context, videoURL, title, description, transcript string) (string, error)
+	GenerateTags(ctx context.Context, content string) ([]string, error)
+	GenerateTitle(ctx context.Context, content string) (string, error)
+	CategorizeContent(ctx context.Context, title, content, itemType string, allowed []string) (string, error)
+	DetectLanguage(ctx context.Context, text string) (string, error)
+	TranslateToEnglish(ctx context.Context, text string) (string, error)
+	SupportsVision() bool
+	DescribeImage(ctx context.Context, imageURL string) (string, error)
+	EnhanceSearchQuery(ctx context.Context, query string) (string, error)
+	SuggestQuery(ctx context.Context, query string, topics []string) (string, error)
+	ReRankSearchResults(ctx context.Context, query string, results []models.SearchResult, topK int) ([]models.SearchResult, error)
+}
+
+// MetadataProvider is the page and media fetching ItemService depends on.
+// *MetadataService implements it; servicestest has a fake for tests.
+type MetadataProvider interface {
+	GetURLMetadata(ctx context.Context, url string) (embedHTML string, imageURL string, title string, err error)
+	GetPageMetadata(ctx context.Context, pageURL string) (*PageMetadata, error)
+	GetFavicon(ctx context.Context, pageURL string) (string, error)
+	CheckLink(ctx context.Context, url string) (status int, ok bool, err error)
+	RefreshLink(ctx context.Context, url string) (*LinkPreview, error)
+	ExtractReadableText(ctx context.Context, url string) (string, error)
+	ExtractPDFText(ctx context.Context, url string) (string, error)
+	ExtractImageText(ctx context.Context, imageURL string) (string, error)
+	GetAmazonProduct(ctx context.Context, url string) (*Product, error)
+	GetGitHubRepo(ctx context.Context, link string) (*GitHubRepo, error)
+	GetTweet(ctx context.Context, link string) (*Tweet, error)
+	GetYouTubeDuration(ctx context.Context, videoID string) (int, error)
+	GetYouTubeTranscript(ctx context.Context, videoID string) (string, error)
+	DetectBookAndGetCover(ctx context.Context, title, content string) (string, error)
+	DetectRecipeAndGetImage(ctx context.Context, title, content string) (string, error)
+	FetchRelevantImage(ctx context.Context, title, content, itemType, category string) (string, error)
+}
+
+var (
+	_ AIProvider       = (*AIService)(nil)
+	_ MetadataProvider = (*MetadataService)(nil)
+)
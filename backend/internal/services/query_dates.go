@@ -0,0 +1,168 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// datePattern is a date phrase ParseNaturalLanguageQuery understands. resolve
+// turns a match into the range it means relative to now; a nil to leaves the
+// range open-ended.
+type datePattern struct {
+	re      *regexp.Regexp
+	resolve func(match []string, now time.Time) (from, to *time.Time)
+}
+
+const monthNames = `january|february|march|april|may|june|july|august|september|october|november|december`
+
+// datePatterns are tried in order and the first match sets the range, so
+// "last 3 months" is read as a count before "last month" can match inside it.
+// Month names need a year or a preposition ("in may") since most double as words.
+var datePatterns = []datePattern{
+	// "past 3 days", "in the last 2 weeks": a rolling window up to now
+	{regexp.MustCompile(`\b(?:in the |within the )?(?:past|last) (\d+) (day|week|month|year)s?\b`), func(m []string, now time.Time) (*time.Time, *time.Time) {
+		n, _ := strconv.Atoi(m[1])
+		from := addUnits(now, m[2], -n)
+		return &from, &now
+	}},
+	// "3 days ago", "2 months ago": that whole day, week, month, or year
+	{regexp.MustCompile(`\b(\d+) (day|week|month|year)s? ago\b`), func(m []string, now time.Time) (*time.Time, *time.Time) {
+		n, _ := strconv.Atoi(m[1])
+		return periodRange(addUnits(periodStart(now, m[2]), m[2], -n), m[2])
+	}},
+	// "past week", "in the past month": a rolling window up to now
+	{regexp.MustCompile(`\b(?:in the )?past (day|week|month|year)\b`), func(m []string, now time.Time) (*time.Time, *time.Time) {
+		from := addUnits(now, m[1], -1)
+		return &from, &now
+	}},
+	// "this week", "last month": calendar periods, with weeks starting on Monday
+	{regexp.MustCompile(`\b(this|last) (week|month|year)\b`), func(m []string, now time.Time) (*time.Time, *time.Time) {
+		start := periodStart(now, m[2])
+		if m[1] == "last" {
+			start = addUnits(start, m[2], -1)
+		}
+		return periodRange(start, m[2])
+	}},
+	{regexp.MustCompile(`\btoday\b`), func(m []string, now time.Time) (*time.Time, *time.Time) {
+		return periodRange(periodStart(now, "day"), "day")
+	}},
+	{regexp.MustCompile(`\byesterday\b`), func(m []string, now time.Time) (*time.Time, *time.Time) {
+		return periodRange(periodStart(now, "day").AddDate(0, 0, -1), "day")
+	}},
+	// "in march 2023", "march 2023", "in march" (the latest March, this year or last)
+	{regexp.MustCompile(`\b(?:(?:in|from|during) )?(` + monthNames + `) ((?:19|20)\d{2})\b|\b(?:in|from|during) (` + monthNames + `)\b`), func(m []string, now time.Time) (*time.Time, *time.Time) {
+		if m[1] != "" {
+			year, _ := strconv.Atoi(m[2])
+			return periodRange(time.Date(year, monthNumber(m[1]), 1, 0, 0, 0, 0, now.Location()), "month")
+		}
+		month := monthNumber(m[3])
+		year := now.Year()
+		if month > now.Month() {
+			year--
+		}
+		return periodRange(time.Date(year, month, 1, 0, 0, 0, 0, now.Location()), "month")
+	}},
+	// "2020-2023", "from 2020 to 2023", "between 2020 and 2023": those whole
+	// years, so the second year isn't left behind as a search term
+	{regexp.MustCompile(`\bbetween ((?:19|20)\d{2}) and ((?:19|20)\d{2})\b|\b(?:(?:in|from|during) )?((?:19|20)\d{2})(?:-|–| [-–] | to | through )((?:19|20)\d{2})\b`), func(m []string, now time.Time) (*time.Time, *time.Time) {
+		first, last := m[1], m[2]
+		if first == "" {
+			first, last = m[3], m[4]
+		}
+		from, _ := strconv.Atoi(first)
+		to, _ := strconv.Atoi(last)
+		if from > to {
+			from, to = to, from
+		}
+		start, _ := periodRange(time.Date(from, 1, 1, 0, 0, 0, 0, now.Location()), "year")
+		_, end := periodRange(time.Date(to, 1, 1, 0, 0, 0, 0, now.Location()), "year")
+		return start, end
+	}},
+	// "since 2021": from the start of that year on
+	{regexp.MustCompile(`\bsince ((?:19|20)\d{2})\b`), func(m []string, now time.Time) (*time.Time, *time.Time) {
+		year, _ := strconv.Atoi(m[1])
+		from := time.Date(year, 1, 1, 0, 0, 0, 0, now.Location())
+		return &from, nil
+	}},
+	// "in 2023", "during 2022"
+	{regexp.MustCompile(`\b(?:in|from|during) ((?:19|20)\d{2})\b`), func(m []string, now time.Time) (*time.Time, *time.Time) {
+		year, _ := strconv.Atoi(m[1])
+		return periodRange(time.Date(year, 1, 1, 0, 0, 0, 0, now.Location()), "year")
+	}},
+}
+
+// extractDateRange returns the saved-date range a lowercased query asks for,
+// relative to now, or nils if it names none
+func extractDateRange(query string, now time.Time) (*time.Time, *time.Time) {
+	for _, pattern := range datePatterns {
+		if match := pattern.re.FindStringSubmatch(query); match != nil {
+			return pattern.resolve(match, now)
+		}
+	}
+	return nil, nil
+}
+
+// removeDatePhrases strips every date phrase from a lowercased query
+func removeDatePhrases(query string) string {
+	for _, pattern := range datePatterns {
+		query = pattern.re.ReplaceAllString(query, "")
+	}
+	return query
+}
+
+// periodStart is the start of the day, week (Monday), month, or year containing t
+func periodStart(t time.Time, unit string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch unit {
+	case "week":
+		// Weekday counts from Sunday; shift so Monday is 0
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case "year":
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+	}
+	return day
+}
+
+// periodRange is the day, week, month, or year starting at start. The end is
+// the last microsecond in it, since DateTo is inclusive and PostgreSQL
+// timestamps are stored to the microsecond.
+func periodRange(start time.Time, unit string) (*time.Time, *time.Time) {
+	end := addUnits(start, unit, 1).Add(-time.Microsecond)
+	return &start, &end
+}
+
+// addUnits adds n days, weeks, months, or years to t
+func addUnits(t time.Time, unit string, n int) time.Time {
+	switch unit {
+	case "week":
+		return t.AddDate(0, 0, 7*n)
+	case "month":
+		return addMonths(t, n)
+	case "year":
+		return addMonths(t, 12*n)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+// addMonths moves t by n calendar months, clamping the day to the end of a
+// shorter month: March 31 minus a month is February 28 (or 29), where
+// AddDate would overflow to March 3
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(t.Day(), lastDay), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// monthNumber converts a lowercase month name to its time.Month
+func monthNumber(name string) time.Month {
+	for i, month := range strings.Split(monthNames, "|") {
+		if month == name {
+			return time.Month(i + 1)
+		}
+	}
+	return 0
+}
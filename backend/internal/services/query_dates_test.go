@@ -0,0 +1,112 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseNaturalLanguageQueryDates(t *testing.T) {
+	// A Tuesday, on the last day of a month longer than the one before it
+	now := time.Date(2026, 3, 31, 15, 4, 5, 0, time.UTC)
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	// endOf is the inclusive end of the day
+	endOf := func(year int, month time.Month, day int) time.Time {
+		return date(year, month, day+1).Add(-time.Microsecond)
+	}
+	at := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		query    string
+		from, to *time.Time
+	}{
+		// Rolling windows end now
+		{"notes from the past 3 days", at(time.Date(2026, 3, 28, 15, 4, 5, 0, time.UTC)), at(now)},
+		{"articles in the last 2 weeks", at(time.Date(2026, 3, 17, 15, 4, 5, 0, time.UTC)), at(now)},
+		{"past 1 month", at(time.Date(2026, 2, 28, 15, 4, 5, 0, time.UTC)), at(now)},
+		{"videos from the past month", at(time.Date(2026, 2, 28, 15, 4, 5, 0, time.UTC)), at(now)},
+		{"last 3 months", at(time.Date(2025, 12, 31, 15, 4, 5, 0, time.UTC)), at(now)},
+		{"past year", at(time.Date(2025, 3, 31, 15, 4, 5, 0, time.UTC)), at(now)},
+		// "ago" means that whole period
+		{"saved 3 days ago", at(date(2026, 3, 28)), at(endOf(2026, 3, 28))},
+		{"2 months ago", at(date(2026, 1, 1)), at(endOf(2026, 1, 31))},
+		// Calendar periods; weeks start on Monday
+		{"today", at(date(2026, 3, 31)), at(endOf(2026, 3, 31))},
+		{"yesterday", at(date(2026, 3, 30)), at(endOf(2026, 3, 30))},
+		{"this week", at(date(2026, 3, 30)), at(endOf(2026, 4, 5))},
+		{"last week", at(date(2026, 3, 23)), at(endOf(2026, 3, 29))},
+		{"last month", at(date(2026, 2, 1)), at(endOf(2026, 2, 28))},
+		{"this year", at(date(2026, 1, 1)), at(endOf(2026, 12, 31))},
+		// Months and years
+		{"recipes from march 2023", at(date(2023, 3, 1)), at(endOf(2023, 3, 31))},
+		{"in march", at(date(2026, 3, 1)), at(endOf(2026, 3, 31))},
+		{"in may", at(date(2025, 5, 1)), at(endOf(2025, 5, 31))},
+		{"since 2021", at(date(2021, 1, 1)), nil},
+		{"during 2023", at(date(2023, 1, 1)), at(endOf(2023, 12, 31))},
+		// Year ranges cover both years whole
+		{"articles from 2020-2023", at(date(2020, 1, 1)), at(endOf(2023, 12, 31))},
+		{"between 2020 and 2023", at(date(2020, 1, 1)), at(endOf(2023, 12, 31))},
+		{"from 2019 to 2021", at(date(2019, 1, 1)), at(endOf(2021, 12, 31))},
+		{"2023–2020", at(date(2020, 1, 1)), at(endOf(2023, 12, 31))},
+		// No date
+		{"may the force be with you", nil, nil},
+		{"top 10 recipes", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			filters := ParseNaturalLanguageQueryAt(tt.query, now)
+			if !sameTime(filters.DateFrom, tt.from) || !sameTime(filters.DateTo, tt.to) {
+				t.Errorf("ParseNaturalLanguageQueryAt(%q) dates = %v to %v, want %v to %v",
+					tt.query, formatTime(filters.DateFrom), formatTime(filters.DateTo), formatTime(tt.from), formatTime(tt.to))
+			}
+		})
+	}
+}
+
+func TestParseNaturalLanguageQueryRemovesYearRanges(t *testing.T) {
+	now := time.Date(2026, 3, 31, 15, 4, 5, 0, time.UTC)
+	for _, query := range []string{"papers from 2020-2023", "papers between 2020 and 2023", "papers 2020 to 2023"} {
+		// Nothing of the range is left to become a search term, such as "-2023"
+		if got := ParseNaturalLanguageQueryAt(query, now).SearchTerms; got != "papers" {
+			t.Errorf("ParseNaturalLanguageQueryAt(%q).SearchTerms = %q, want %q", query, got, "papers")
+		}
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		t    time.Time
+		n    int
+		want time.Time
+	}{
+		{time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC), -1, time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC), -1, time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), 1, time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC), -13, time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), 12, time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC), 1, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), 0, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := addMonths(tt.t, tt.n); !got.Equal(tt.want) {
+			t.Errorf("addMonths(%v, %d) = %v, want %v", tt.t, tt.n, got, tt.want)
+		}
+	}
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "<nil>"
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+// operatorRe matches the search syntax ParseNaturalLanguageQuery understands,
+// in order of its alternatives:
+//   - a field filter: tag:golang, tag:"machine learning", type:video
+//   - an excluded phrase: -"helm chart"
+//   - an exact phrase: "machine learning" (straight or curly quotes)
+//   - an excluded term: a "-" starting a word and followed by a letter, as in
+//     "kubernetes -helm". Hyphens inside words ("to-do") and before numbers
+//     ("2020 -2023") aren't negations.
+//
+// Text inside quotes is never read as an operator.
+var operatorRe = regexp.MustCompile(`(^|\s)(?i:(tag|type)):(?:["“]([^"“”]*)["”]|([^\s"“”]+))|(^|\s)-["“]([^"“”]*)["”]|["“]([^"“”]*)["”]|(^|\s)-(\p{L}[\p{L}\p{N}_.+#'-]*)`)
+
+// Kinds of operatorMatch
+const (
+	operatorPhrase = iota
+	operatorExclude
+	operatorTag
+	operatorType
+)
+
+// searchOperators is the search syntax in a query, normalized to lowercase
+// with single spaces
+type searchOperators struct {
+	phrases  []string
+	exclude  []string
+	tags     []string
+	itemType string // The last type: given
+}
+
+// operatorMatch is one operator found by operatorRe; raw is its text in the
+// query, without the whitespace before it
+type operatorMatch struct {
+	kind int
+	text string
+	raw  string
+}
+
+// extractSearchOperators pulls the search syntax out of a query, returning it
+// and the rest of the query
+func extractSearchOperators(query string) (searchOperators, string) {
+	var ops searchOperators
+	seen := map[operatorMatch]bool{}
+	rest := replaceOperators(query, func(op operatorMatch) string {
+		text := strings.ToLower(strings.Join(strings.Fields(op.text), " "))
+		if op.kind == operatorTag {
+			// Match tags as they're stored
+			text = normalizeTag(op.text)
+		}
+		key := operatorMatch{kind: op.kind, text: text}
+		if text == "" || seen[key] {
+			return " "
+		}
+		seen[key] = true
+		switch op.kind {
+		case operatorPhrase:
+			ops.phrases = append(ops.phrases, text)
+		case operatorExclude:
+			ops.exclude = append(ops.exclude, text)
+		case operatorTag:
+			ops.tags = append(ops.tags, text)
+		case operatorType:
+			// Words for a type ("videos", "articles") work as well as its name
+			if itemType, ok := typeKeywords[text]; ok {
+				text = itemType
+			}
+			ops.itemType = text
+		}
+		return " "
+	})
+	return ops, rest
+}
+
+// queryForAI rewrites a query for the AI, which only needs to know what to look
+// for: excluded terms and type: filters are dropped, since "-helm" would steer
+// it toward helm, and tag: filters become their tag. Quoted phrases are kept.
+func queryForAI(query string) string {
+	return replaceOperators(query, func(op operatorMatch) string {
+		switch op.kind {
+		case operatorPhrase:
+			return op.raw
+		case operatorTag:
+			return " " + op.text + " "
+		}
+		return " "
+	})
+}
+
+// replaceOperators replaces each operator in query with replace's result and
+// collapses the whitespace left behind
+func replaceOperators(query string, replace func(operatorMatch) string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range operatorRe.FindAllStringSubmatchIndex(query, -1) {
+		b.WriteString(query[last:m[0]])
+		last = m[1]
+		var op operatorMatch
+		switch {
+		case m[4] >= 0:
+			b.WriteString(query[m[2]:m[3]])
+			op = operatorMatch{kind: operatorTag, raw: query[m[3]:m[1]]}
+			if strings.EqualFold(query[m[4]:m[5]], "type") {
+				op.kind = operatorType
+			}
+			if m[6] >= 0 {
+				op.text = query[m[6]:m[7]]
+			} else {
+				op.text = query[m[8]:m[9]]
+			}
+		case m[12] >= 0:
+			b.WriteString(query[m[10]:m[11]])
+			op = operatorMatch{kind: operatorExclude, text: query[m[12]:m[13]], raw: query[m[11]:m[1]]}
+		case m[14] >= 0:
+			op = operatorMatch{kind: operatorPhrase, text: query[m[14]:m[15]], raw: query[m[0]:m[1]]}
+		default:
+			b.WriteString(query[m[16]:m[17]])
+			// Sentence punctuation isn't part of the term ("-helm," or "-helm.")
+			op = operatorMatch{kind: operatorExclude, text: strings.TrimRight(query[m[18]:m[19]], ".,'-"), raw: query[m[17]:m[1]]}
+		}
+		b.WriteString(replace(op))
+	}
+	b.WriteString(query[last:])
+	return strings.Join(strings.Fields(b.String()), " ")
+}
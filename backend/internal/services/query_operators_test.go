@@ -0,0 +1,162 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseNaturalLanguageQueryExcludeTerms(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		query   string
+		exclude []string
+		terms   string
+	}{
+		{"kubernetes -helm", []string{"helm"}, "kubernetes"},
+		{"-helm kubernetes -Terraform", []string{"helm", "terraform"}, "kubernetes"},
+		{"rust -tokio.", []string{"tokio"}, "rust"},
+		{"golang -helm -HELM", []string{"helm"}, "golang"},
+		{"c++ -c#", []string{"c#"}, "c++"},
+		// Hyphens inside words and before numbers aren't negations
+		{"state-of-the-art parsers", nil, "state-of-the-art parsers"},
+		{"papers 2020 -2023", nil, "papers 2020 -2023"},
+		{"kubernetes - helm", nil, "kubernetes - helm"},
+		{`kubernetes -"Helm  chart"`, []string{"helm chart"}, "kubernetes"},
+		// Quoted text is searched for as written
+		{`"state -of the art" parsers`, nil, "state -of the art parsers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			filters := ParseNaturalLanguageQueryAt(tt.query, now)
+			if !reflect.DeepEqual(filters.ExcludeTerms, tt.exclude) {
+				t.Errorf("ExcludeTerms = %q, want %q", filters.ExcludeTerms, tt.exclude)
+			}
+			if filters.SearchTerms != tt.terms {
+				t.Errorf("SearchTerms = %q, want %q", filters.SearchTerms, tt.terms)
+			}
+		})
+	}
+}
+
+func TestParseNaturalLanguageQueryExcludeWithFilters(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	filters := ParseNaturalLanguageQueryAt("show me videos about kubernetes -helm from last week", now)
+	if !reflect.DeepEqual(filters.ExcludeTerms, []string{"helm"}) {
+		t.Errorf("ExcludeTerms = %q, want [helm]", filters.ExcludeTerms)
+	}
+	if filters.Type != "video" {
+		t.Errorf("Type = %q, want video", filters.Type)
+	}
+	if filters.DateFrom == nil {
+		t.Error("DateFrom = nil, want last week")
+	}
+}
+
+func TestParseNaturalLanguageQueryPhrases(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		query   string
+		phrases []string
+		terms   string
+	}{
+		// Without quotes the words match anywhere, in any order
+		{"machine learning papers", nil, "machine learning papers"},
+		{`"machine learning" papers`, []string{"machine learning"}, "machine learning papers"},
+		{`papers on "Machine   Learning"`, []string{"machine learning"}, "machine learning papers on"},
+		{`“deep learning” and "neural nets"`, []string{"deep learning", "neural nets"}, "deep learning neural nets and"},
+		{`"rust" "rust"`, []string{"rust"}, "rust"},
+		{`"" empty quotes`, nil, "empty quotes"},
+		// An unclosed quote is just text
+		{`"machine learning papers`, nil, `"machine learning papers`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			filters := ParseNaturalLanguageQueryAt(tt.query, now)
+			if !reflect.DeepEqual(filters.Phrases, tt.phrases) {
+				t.Errorf("Phrases = %q, want %q", filters.Phrases, tt.phrases)
+			}
+			if filters.SearchTerms != tt.terms {
+				t.Errorf("SearchTerms = %q, want %q", filters.SearchTerms, tt.terms)
+			}
+		})
+	}
+}
+
+func TestParseNaturalLanguageQueryPhraseIsNotAFilter(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	// Unquoted, "last week" is a date range
+	if filters := ParseNaturalLanguageQueryAt("last week tonight clips", now); filters.DateFrom == nil {
+		t.Error("unquoted DateFrom = nil, want last week")
+	}
+	filters := ParseNaturalLanguageQueryAt(`"last week tonight" clips`, now)
+	if filters.DateFrom != nil || filters.DateTo != nil {
+		t.Errorf("quoted dates = %v to %v, want none", filters.DateFrom, filters.DateTo)
+	}
+	if !reflect.DeepEqual(filters.Phrases, []string{"last week tonight"}) {
+		t.Errorf("Phrases = %q, want [last week tonight]", filters.Phrases)
+	}
+}
+
+func TestParseNaturalLanguageQueryFieldFilters(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		query    string
+		tags     []string
+		itemType string
+		terms    string
+	}{
+		{"tag:golang tag:database concurrency", []string{"golang", "database"}, "", "concurrency"},
+		{`Tag:"Machine  Learning" tag:golang tag:GoLang papers`, []string{"machine learning", "golang"}, "", "papers"},
+		{"#rust tag:wasm tag:rust", []string{"rust", "wasm"}, "", "#rust"},
+		{"type:video kubernetes", nil, "video", "kubernetes"},
+		// Type words work as well as type names, and the last type: wins
+		{"type:articles type:videos", nil, "video", ""},
+		// An explicit type's words in the query are still searched for
+		{"type:blog my video editing setup", nil, "blog", "my video editing setup"},
+		{"tag:golang", []string{"golang"}, "", ""},
+		// Only tokens starting a word, with a value, are filters
+		{"metatag:seo type:", nil, "", "metatag:seo type:"},
+		{`"tag:golang" syntax`, nil, "", "tag:golang syntax"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			filters := ParseNaturalLanguageQueryAt(tt.query, now)
+			if !reflect.DeepEqual(filters.Tags, tt.tags) {
+				t.Errorf("Tags = %q, want %q", filters.Tags, tt.tags)
+			}
+			if filters.Type != tt.itemType {
+				t.Errorf("Type = %q, want %q", filters.Type, tt.itemType)
+			}
+			if filters.SearchTerms != tt.terms {
+				t.Errorf("SearchTerms = %q, want %q", filters.SearchTerms, tt.terms)
+			}
+		})
+	}
+}
+
+func TestQueryForAI(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"kubernetes -helm", "kubernetes"},
+		{`"machine learning" -"deep learning" -pytorch papers`, `"machine learning" papers`},
+		{"my to-do list", "my to-do list"},
+		{`type:video tag:golang tag:"machine learning" concurrency`, "golang machine learning concurrency"},
+	}
+
+	for _, tt := range tests {
+		if got := queryForAI(tt.query); got != tt.want {
+			t.Errorf("queryForAI(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
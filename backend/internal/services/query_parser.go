@@ -1,17 +1,29 @@
 package services
 
 import (
-	"fmt"
 	"regexp"
 	"strings"
 	"synapse/internal/models"
 	"time"
+	"unicode"
 )
 
+// ParseNaturalLanguageQuery extracts filters from a search query, resolving
+// relative dates like "last month" against the current time
 func ParseNaturalLanguageQuery(query string) *models.QueryFilters {
-	filters := &models.QueryFilters{
-		SearchTerms: query,
-	}
+	return ParseNaturalLanguageQueryAt(query, time.Now())
+}
+
+// ParseNaturalLanguageQueryAt is ParseNaturalLanguageQuery with relative dates
+// resolved against now, so results don't depend on when it runs
+func ParseNaturalLanguageQueryAt(query string, now time.Time) *models.QueryFilters {
+	filters := &models.QueryFilters{}
+
+	// Pull out search syntax ("exact phrase", -helm, tag:golang) first so the
+	// filters below never see it; a quoted "last week" is text to find, not a date
+	ops, query := extractSearchOperators(query)
+	filters.Phrases, filters.ExcludeTerms = ops.phrases, ops.exclude
+	filters.SearchTerms = query
 
 	lowerQuery := strings.ToLower(query)
 
@@ -22,10 +34,13 @@ func ParseNaturalLanguageQuery(query string) *models.QueryFilters {
 	}
 
 	// Extract date filters
-	filters.DateFrom, filters.DateTo = extractDateRange(lowerQuery)
+	filters.DateFrom, filters.DateTo = extractDateRange(lowerQuery, now)
 
-	// Extract type filters
-	filters.Type = extractType(lowerQuery)
+	// Extract type filters. An explicit type: is set after cleaning, so words
+	// like "video" left in the query are still searched for.
+	if ops.itemType == "" {
+		filters.Type = extractType(lowerQuery)
+	}
 
 	// Extract price filters
 	filters.PriceMin, filters.PriceMax = extractPriceRange(lowerQuery)
@@ -33,17 +48,26 @@ func ParseNaturalLanguageQuery(query string) *models.QueryFilters {
 	// Extract author mentions
 	filters.Author = extractAuthor(lowerQuery)
 	
-	// Extract category filter (reusing Source field for category)
-	filters.Source = extractCategory(lowerQuery)
+	// Extract category filter
+	filters.Category = extractCategory(lowerQuery)
 
-	// Extract tags (common patterns)
-	filters.Tags = extractTags(lowerQuery)
+	// Extract tags (#golang and tag:golang)
+	filters.Tags = mergeTags(extractTags(lowerQuery), ops.tags)
 
 	// Clean search terms (remove filter phrases) - only if not a quote query
 	if quoteQuery == "" {
 		filters.SearchTerms = cleanSearchTerms(query, filters)
 	}
 
+	if ops.itemType != "" {
+		filters.Type = ops.itemType
+	}
+
+	// Phrases are also searched for, so they count toward ranking
+	if len(filters.Phrases) > 0 {
+		filters.SearchTerms = strings.TrimSpace(strings.Join(filters.Phrases, " ") + " " + filters.SearchTerms)
+	}
+
 	return filters
 }
 
@@ -69,58 +93,30 @@ func extractQuoteQuery(query, lowerQuery string) string {
 	return ""
 }
 
-func extractDateRange(query string) (*time.Time, *time.Time) {
-	now := time.Now()
-	var from, to *time.Time
-
-	// "last month"
-	if strings.Contains(query, "last month") {
-		lastMonth := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, now.Location())
-		from = &lastMonth
-		thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		to = &thisMonth
-	}
-
-	// "yesterday"
-	if strings.Contains(query, "yesterday") {
-		yesterday := time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, now.Location())
-		from = &yesterday
-		today := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
-		to = &today
-	}
-
-	// "last week"
-	if strings.Contains(query, "last week") {
-		lastWeek := now.AddDate(0, 0, -7)
-		from = &lastWeek
-		to = &now
-	}
-
-	// "this month"
-	if strings.Contains(query, "this month") {
-		thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		from = &thisMonth
-		to = &now
-	}
-
-	// "last year"
-	if strings.Contains(query, "last year") {
-		lastYear := time.Date(now.Year()-1, 1, 1, 0, 0, 0, 0, now.Location())
-		from = &lastYear
-		thisYear := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
-		to = &thisYear
-	}
-
-	// Relative days: "3 days ago", "5 days ago"
-	daysAgoRe := regexp.MustCompile(`(\d+)\s*days?\s*ago`)
-	if match := daysAgoRe.FindStringSubmatch(query); match != nil {
-		days, _ := time.ParseDuration(match[1] + "h")
-		daysAgo := now.Add(-days * 24)
-		from = &daysAgo
-		to = &now
-	}
-
-	return from, to
+// typeKeywords maps words for a kind of item to its type
+var typeKeywords = map[string]string{
+	"article":     "blog",
+	"articles":    "blog",
+	"blog":        "blog",
+	"blog post":   "blog",
+	"note":        "text",
+	"notes":       "text",
+	"handwritten": "text",
+	"video":       "video",
+	"videos":      "video",
+	"youtube":     "video",
+	"product":     "amazon",
+	"amazon":      "amazon",
+	"book":        "book",
+	"books":       "book",
+	"recipe":      "recipe",
+	"recipes":     "recipe",
+	"image":       "image",
+	"screenshot":  "image",
+	"todo":        "text",
+	"to-do":       "text",
+	"to do":       "text",
+	"list":        "text",
 }
 
 func extractType(query string) string {
@@ -140,32 +136,7 @@ func extractType(query string) string {
 		return ""
 	}
 
-	typeMap := map[string]string{
-		"article":     "blog",
-		"articles":    "blog",
-		"blog":        "blog",
-		"blog post":   "blog",
-		"note":        "text",
-		"notes":       "text",
-		"handwritten": "text",
-		"video":       "video",
-		"videos":      "video",
-		"youtube":     "video",
-		"product":     "amazon",
-		"amazon":      "amazon",
-		"book":        "book",
-		"books":       "book",
-		"recipe":      "recipe",
-		"recipes":     "recipe",
-		"image":       "image",
-		"screenshot":  "image",
-		"todo":        "text",
-		"to-do":       "text",
-		"to do":       "text",
-		"list":        "text",
-	}
-
-	for keyword, itemType := range typeMap {
+	for keyword, itemType := range typeKeywords {
 		if strings.Contains(query, keyword) {
 			return itemType
 		}
@@ -174,55 +145,6 @@ func extractType(query string) string {
 	return ""
 }
 
-func extractPriceRange(query string) (*float64, *float64) {
-	var min, max *float64
-
-	// "under $300", "below $300", "less than $300"
-	underRe := regexp.MustCompile(`(under|below|less than)\s*\$?(\d+(?:\.\d+)?)`)
-	if match := underRe.FindStringSubmatch(query); match != nil {
-		price := parsePrice(match[2])
-		if price > 0 {
-			max = &price
-		}
-	}
-
-	// "over $100", "above $100", "more than $100"
-	overRe := regexp.MustCompile(`(over|above|more than)\s*\$?(\d+(?:\.\d+)?)`)
-	if match := overRe.FindStringSubmatch(query); match != nil {
-		price := parsePrice(match[2])
-		if price > 0 {
-			min = &price
-		}
-	}
-
-	// "$100 to $300", "$100-$300"
-	rangeRe := regexp.MustCompile(`\$?(\d+(?:\.\d+)?)\s*(?:to|-)\s*\$?(\d+(?:\.\d+)?)`)
-	if match := rangeRe.FindStringSubmatch(query); match != nil {
-		price1 := parsePrice(match[1])
-		price2 := parsePrice(match[2])
-		if price1 > 0 && price2 > 0 {
-			if price1 < price2 {
-				min = &price1
-				max = &price2
-			} else {
-				min = &price2
-				max = &price1
-			}
-		}
-	}
-
-	return min, max
-}
-
-func parsePrice(s string) float64 {
-	var val float64
-	_, err := fmt.Sscanf(s, "%f", &val)
-	if err == nil {
-		return val
-	}
-	return 0
-}
-
 func extractAuthor(query string) string {
 	// "from Karpathy", "by Karpathy", "Karpathy said"
 	patterns := []string{
@@ -241,13 +163,47 @@ func extractAuthor(query string) string {
 	return ""
 }
 
+// categories is the canonical list CategorizeContent assigns items to
+var categories = []string{
+	"Technology",
+	"Food & Recipes",
+	"Books & Reading",
+	"Videos & Entertainment",
+	"Shopping & Products",
+	"Articles & News",
+	"Notes & Ideas",
+	"Design & Inspiration",
+	"Travel",
+	"Health & Fitness",
+	"Education & Learning",
+	"Other",
+}
+
+// canonicalCategory returns the canonical spelling of a category name, or "" if it isn't one
+func canonicalCategory(name string) string {
+	for _, category := range categories {
+		if strings.EqualFold(strings.TrimSpace(name), category) {
+			return category
+		}
+	}
+	return ""
+}
+
 func extractCategory(query string) string {
+	// An exact category name ("food & recipes") wins over keywords
+	for _, category := range categories {
+		if category != "Other" && strings.Contains(query, strings.ToLower(category)) {
+			return category
+		}
+	}
+
 	// Map common category mentions to actual category names
 	categoryMap := map[string]string{
 		"technology":     "Technology",
 		"tech":           "Technology",
 		"food":           "Food & Recipes",
 		"recipe":         "Food & Recipes",
+		"recipes":        "Food & Recipes",
 		"cooking":        "Food & Recipes",
 		"book":           "Books & Reading",
 		"books":          "Books & Reading",
@@ -274,9 +230,16 @@ func extractCategory(query string) string {
 		"learning":       "Education & Learning",
 	}
 
+	// Match whole words so "tech" doesn't fire on "technique"
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '-'
+	}) {
+		words[word] = true
+	}
 	for keyword, category := range categoryMap {
-		if strings.Contains(query, keyword) {
-			return category
+		if words[keyword] {
+			return canonicalCategory(category)
 		}
 	}
 
@@ -300,13 +263,7 @@ func cleanSearchTerms(originalQuery string, filters *models.QueryFilters) string
 	query := originalQuery
 
 	// Remove date phrases
-	datePhrases := []string{
-		"last month", "yesterday", "last week", "this month", "last year",
-		"days ago", "weeks ago", "months ago",
-	}
-	for _, phrase := range datePhrases {
-		query = strings.ReplaceAll(strings.ToLower(query), phrase, "")
-	}
+	query = removeDatePhrases(strings.ToLower(query))
 
 	// Only remove type phrases if a type filter was actually set
 	// This prevents removing search terms when type wasn't meant to be a filter
@@ -342,8 +299,7 @@ func cleanSearchTerms(originalQuery string, filters *models.QueryFilters) string
 	}
 
 	// Remove price phrases
-	priceRe := regexp.MustCompile(`(under|below|over|above|less than|more than)\s*\$?\d+`)
-	query = priceRe.ReplaceAllString(query, "")
+	query = removePricePhrases(query)
 
 	// Remove author phrases
 	authorRe := regexp.MustCompile(`(from|by)\s+[A-Z][a-z]+`)
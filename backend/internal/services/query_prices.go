@@ -0,0 +1,173 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// priceAmount matches a price as typed: "$300", "$ 19.99", "$1,200", "40 dollars",
+// "15 usd". Its groups are the "$", the number, and the currency word.
+const priceAmount = `(\$\s*)?(\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d+(?:\.\d+)?)(\s*(?:dollars?|usd|bucks))?`
+
+// Kinds of bound a pricePattern sets
+const (
+	priceBoundRange = iota
+	priceBoundMax
+	priceBoundMin
+)
+
+// pricePattern is a price phrase ParseNaturalLanguageQuery understands. Bare
+// numbers read as prices unless a unit follows ("more than 3 days"), but
+// currencyOnly patterns need a "$" or currency word so "2020-2023" isn't a price.
+type pricePattern struct {
+	re           *regexp.Regexp
+	bound        int
+	currencyOnly bool
+}
+
+// pricePatterns are tried in order, and a later match overlapping an earlier one
+// is ignored, so the "under" in "between $20 and under $40" doesn't count twice
+var pricePatterns = []pricePattern{
+	// "between $20 and $40", "between 20 and 40 dollars"
+	{regexp.MustCompile(`\bbetween\s+` + priceAmount + `\s+and\s+` + priceAmount), priceBoundRange, false},
+	// "$100 to $300", "$100-$300", "from 20 to 40 dollars"
+	{regexp.MustCompile(`(?:\bfrom\s+)?` + priceAmount + `\s*(?:to|-|–)\s*` + priceAmount), priceBoundRange, true},
+	// "under $300", "cheaper than 100 dollars", "up to $1,200"
+	{regexp.MustCompile(`\b(?:under|below|less than|cheaper than|no more than|at most|up to|max(?:imum)?(?: of)?)\s*` + priceAmount), priceBoundMax, false},
+	// "over $100", "more than 50 dollars", "at least $20"
+	{regexp.MustCompile(`\b(?:over|above|more than|at least|min(?:imum)?(?: of)?)\s*` + priceAmount), priceBoundMin, false},
+}
+
+// nonPriceUnits follow numbers that count something other than money
+var nonPriceUnits = map[string]bool{
+	"%": true, "percent": true,
+	"second": true, "seconds": true, "sec": true, "secs": true,
+	"minute": true, "minutes": true, "min": true, "mins": true,
+	"hour": true, "hours": true, "hr": true, "hrs": true,
+	"day": true, "days": true, "week": true, "weeks": true,
+	"month": true, "months": true, "year": true, "years": true,
+	"page": true, "pages": true, "word": true, "words": true,
+	"item": true, "items": true, "result": true, "results": true,
+	"star": true, "stars": true, "people": true, "times": true,
+	"kb": true, "mb": true, "gb": true, "tb": true,
+	"g": true, "kg": true, "lb": true, "lbs": true,
+	"mm": true, "cm": true, "inch": true, "inches": true, "km": true, "miles": true,
+}
+
+var priceNextWordRe = regexp.MustCompile(`^\s*(%|[a-z]+)`)
+
+// pricePhrase is a matched price phrase at query[start:end]
+type pricePhrase struct {
+	start, end int
+	bound      int
+	min, max   *float64
+}
+
+// extractPriceRange reads the price filter from a lowercased query. A range
+// sets both bounds; otherwise the first upper and lower limits are used.
+func extractPriceRange(query string) (*float64, *float64) {
+	var min, max *float64
+	for _, phrase := range findPricePhrases(query) {
+		switch phrase.bound {
+		case priceBoundRange:
+			return phrase.min, phrase.max
+		case priceBoundMax:
+			if max == nil {
+				max = phrase.max
+			}
+		case priceBoundMin:
+			if min == nil {
+				min = phrase.min
+			}
+		}
+	}
+	return min, max
+}
+
+// removePricePhrases strips the phrases extractPriceRange reads from query
+func removePricePhrases(query string) string {
+	phrases := findPricePhrases(query)
+	sort.Slice(phrases, func(i, j int) bool { return phrases[i].start > phrases[j].start })
+	for _, phrase := range phrases {
+		query = query[:phrase.start] + " " + query[phrase.end:]
+	}
+	return query
+}
+
+// findPricePhrases returns the price phrases in query, in pricePatterns order
+func findPricePhrases(query string) []pricePhrase {
+	var phrases []pricePhrase
+	for _, pattern := range pricePatterns {
+		for _, loc := range pattern.re.FindAllStringSubmatchIndex(query, -1) {
+			start, end := loc[0], loc[1]
+			if overlapsPricePhrase(phrases, start, end) {
+				continue
+			}
+
+			// Each amount is three groups: "$", the number, and the currency word
+			amounts := 1
+			if pattern.bound == priceBoundRange {
+				amounts = 2
+			}
+			currency := false
+			values := make([]float64, 0, amounts)
+			for i := 0; i < amounts; i++ {
+				group := 2 + i*6 // Submatch index pairs, skipping the whole match
+				if loc[group] >= 0 || loc[group+4] >= 0 {
+					currency = true
+				}
+				value, err := parsePrice(query[loc[group+2]:loc[group+3]])
+				if err != nil {
+					break
+				}
+				values = append(values, value)
+			}
+			if len(values) < amounts {
+				continue
+			}
+			if !currency {
+				if pattern.currencyOnly {
+					continue
+				}
+				if next := priceNextWordRe.FindStringSubmatch(query[end:]); next != nil && nonPriceUnits[next[1]] {
+					continue
+				}
+			}
+
+			phrase := pricePhrase{start: start, end: end, bound: pattern.bound}
+			switch pattern.bound {
+			case priceBoundRange:
+				low, high := values[0], values[1]
+				if low > high {
+					low, high = high, low
+				}
+				phrase.min, phrase.max = &low, &high
+			case priceBoundMax:
+				if values[0] <= 0 {
+					continue
+				}
+				phrase.max = &values[0]
+			case priceBoundMin:
+				phrase.min = &values[0]
+			}
+			phrases = append(phrases, phrase)
+		}
+	}
+	return phrases
+}
+
+func overlapsPricePhrase(phrases []pricePhrase, start, end int) bool {
+	for _, phrase := range phrases {
+		if start < phrase.end && phrase.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrice parses a typed amount, ignoring thousands separators ("1,200")
+func parsePrice(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseNaturalLanguageQueryPrices(t *testing.T) {
+	now := time.Date(2026, 3, 31, 15, 4, 5, 0, time.UTC)
+	price := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		query    string
+		min, max *float64
+	}{
+		// Upper and lower limits
+		{"headphones under $300", nil, price(300)},
+		{"keyboards below 100 dollars", nil, price(100)},
+		{"cheaper than 50 usd", nil, price(50)},
+		{"laptops up to $1,200", nil, price(1200)},
+		{"shoes under $ 19.99", nil, price(19.99)},
+		{"headphones under 300", nil, price(300)},
+		{"monitors over $100", price(100), nil},
+		{"chairs more than 50 bucks", price(50), nil},
+		{"at least $1,000.50", price(1000.50), nil},
+		// Ranges, in either order
+		{"between $20 and $40", price(20), price(40)},
+		{"between 20 and 40 dollars", price(20), price(40)},
+		{"desks $100 to $300", price(100), price(300)},
+		{"desks $100-$300", price(100), price(300)},
+		{"from 40 to 20 dollars", price(20), price(40)},
+		{"between $1,000 and $2,500", price(1000), price(2500)},
+		// Not prices
+		{"articles from 2020-2023", nil, nil},
+		{"more than 3 days", nil, nil},
+		{"videos under 5 minutes", nil, nil},
+		{"iphone 15", nil, nil},
+		{"top 10 recipes", nil, nil},
+		{"under 0 dollars", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			filters := ParseNaturalLanguageQueryAt(tt.query, now)
+			if !samePrice(filters.PriceMin, tt.min) || !samePrice(filters.PriceMax, tt.max) {
+				t.Errorf("ParseNaturalLanguageQueryAt(%q) prices = %v to %v, want %v to %v",
+					tt.query, formatPrice(filters.PriceMin), formatPrice(filters.PriceMax), formatPrice(tt.min), formatPrice(tt.max))
+			}
+		})
+	}
+}
+
+func TestParseNaturalLanguageQueryRemovesPrices(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"wireless headphones under $300", "wireless headphones"},
+		{"standing desks between $200 and $500", "standing desks"},
+		{"iphone 15", "iphone 15"},
+	}
+
+	for _, tt := range tests {
+		if got := ParseNaturalLanguageQueryAt(tt.query, time.Now()).SearchTerms; got != tt.want {
+			t.Errorf("ParseNaturalLanguageQueryAt(%q).SearchTerms = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func samePrice(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func formatPrice(p *float64) string {
+	if p == nil {
+		return "<nil>"
+	}
+	return strconv.FormatFloat(*p, 'f', -1, 64)
+}
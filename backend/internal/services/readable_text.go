@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ErrNoReadableText is returned by ExtractReadableText when a page has no
+// article-like body, e.g. a login wall or a JavaScript-only app
+var ErrNoReadableText = errors.New("page has no readable article text")
+
+// minReadableChars is the least text a container needs to be taken as the article body
+const minReadableChars = 200
+
+// chromeTags never hold article text
+var chromeTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+	"nav": true, "header": true, "footer": true, "aside": true,
+	"form": true, "button": true, "iframe": true, "svg": true, "select": true,
+}
+
+// chromeClassRe matches class/id values of navigation, ads, and other page chrome
+var chromeClassRe = regexp.MustCompile(`(?i)\b(nav|navbar|menu|footer|header|sidebar|comments?|ads?|advert\w*|sponsor\w*|promo\w*|banner|cookie\w*|share|social|related|newsletter|subscribe|breadcrumbs?|popup|modal)\b`)
+
+// blockTags end a line of text when extracting
+var blockTags = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"li": true, "ul": true, "ol": true, "blockquote": true, "pre": true,
+	"br": true, "tr": true, "table": true, "figure": true, "figcaption": true,
+}
+
+// looksLikeHTMLPage reports whether content is a full HTML document rather than text
+func looksLikeHTMLPage(content string) bool {
+	prefix := strings.ToLower(strings.TrimSpace(content))
+	if len(prefix) > 1024 {
+		prefix = prefix[:1024]
+	}
+	return strings.HasPrefix(prefix, "<!doctype html") || strings.Contains(prefix, "<html") ||
+		strings.Contains(prefix, "<head") || strings.Contains(prefix, "<body")
+}
+
+// ExtractReadableText fetches a page and returns its main article text with
+// navigation, ads, and other page chrome stripped
+func (s *MetadataService) ExtractReadableText(ctx context.Context, url string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	resp, err := s.fetchHTML(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	text := extractReadableText(resp.Body)
+	if text == "" {
+		return "", ErrNoReadableText
+	}
+	return text, nil
+}
+
+// extractReadableText returns the article body of an HTML page, or "" if none
+// is found. An <article> or <main> element wins; otherwise the container whose
+// direct paragraphs hold the most text is taken, readability-style.
+func extractReadableText(r io.Reader) string {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return ""
+	}
+	stripChrome(doc, articleHolders(doc))
+
+	var article, main *html.Node
+	// order lists the page's nodes in document order, so ties between
+	// equally scored containers go to the first one
+	var order []*html.Node
+	scores := make(map[*html.Node]int)
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		order = append(order, n)
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "article":
+				if article == nil {
+					article = n
+				}
+			case "main":
+				if main == nil {
+					main = n
+				}
+			case "p", "pre", "blockquote":
+				// Credit the paragraph's container fully and its grandparent by half
+				length := len(strings.Join(strings.Fields(nodeText(n)), " "))
+				if n.Parent != nil {
+					scores[n.Parent] += length
+					if n.Parent.Parent != nil {
+						scores[n.Parent.Parent] += length / 2
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	for _, candidate := range []*html.Node{article, main} {
+		if candidate == nil {
+			continue
+		}
+		if text := blockText(candidate); len(text) >= minReadableChars {
+			return text
+		}
+	}
+
+	var best *html.Node
+	for _, node := range order {
+		if score, ok := scores[node]; ok && (best == nil || score > scores[best]) {
+			best = node
+		}
+	}
+	if best == nil || scores[best] < minReadableChars {
+		return ""
+	}
+	return blockText(best)
+}
+
+// stripChrome removes elements that are never part of the article body.
+// holders are the elements articleHolders found wrapping the article.
+func stripChrome(n *html.Node, holders map[*html.Node]bool) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || (c.Type == html.ElementNode && isChrome(c, holders)) {
+			n.RemoveChild(c)
+		} else {
+			stripChrome(c, holders)
+		}
+		c = next
+	}
+}
+
+func isChrome(n *html.Node, holders map[*html.Node]bool) bool {
+	if chromeTags[n.Data] {
+		return true
+	}
+	// Never drop the page skeleton or a wrapper around the article, even when a
+	// theme gives it a class like "has-sidebar"
+	if n.Data == "html" || n.Data == "body" || n.Data == "article" || n.Data == "main" || holders[n] {
+		return false
+	}
+	for _, attr := range n.Attr {
+		switch attr.Key {
+		case "class", "id":
+			if chromeClassRe.MatchString(attr.Val) {
+				return true
+			}
+		case "role":
+			if attr.Val == "navigation" || attr.Val == "banner" || attr.Val == "contentinfo" {
+				return true
+			}
+		case "aria-hidden":
+			if attr.Val == "true" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// articleHolders returns the elements under root that wrap an <article> or
+// <main>, or enough paragraph text to be the article body itself. It works
+// bottom-up in one pass, so each element's text is only measured once.
+func articleHolders(root *html.Node) map[*html.Node]bool {
+	holders := make(map[*html.Node]bool)
+	var walk func(n *html.Node) (hasArticle bool, paragraphChars int)
+	walk = func(n *html.Node) (bool, int) {
+		hasArticle, paragraphChars := false, 0
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			childArticle, childChars := walk(c)
+			switch c.Data {
+			case "article", "main":
+				hasArticle = true
+			case "p":
+				// A paragraph counts as a whole; what's inside it doesn't count again
+				paragraphChars += len(strings.TrimSpace(nodeText(c)))
+			default:
+				hasArticle = hasArticle || childArticle
+				paragraphChars += childChars
+			}
+		}
+		if hasArticle || paragraphChars >= minReadableChars {
+			holders[n] = true
+		}
+		return hasArticle, paragraphChars
+	}
+	walk(root)
+	return holders
+}
+
+// nodeText concatenates all text under n
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// blockText renders the text under n with one line per block element and
+// whitespace collapsed within each line
+func blockText(n *html.Node) string {
+	var lines []string
+	var current strings.Builder
+	flush := func() {
+		if line := strings.Join(strings.Fields(current.String()), " "); line != "" {
+			lines = append(lines, line)
+		}
+		current.Reset()
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			current.WriteString(n.Data)
+			return
+		}
+		isBlock := n.Type == html.ElementNode && blockTags[n.Data]
+		if isBlock {
+			flush()
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if isBlock {
+			flush()
+		}
+	}
+	walk(n)
+	flush()
+
+	return strings.Join(lines, "\n")
+}
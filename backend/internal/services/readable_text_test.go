@@ -0,0 +1,96 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// paragraph returns a <p> holding letter n times
+func paragraph(letter string, n int) string {
+	return "<p>" + strings.Repeat(letter, n) + "</p>"
+}
+
+func TestExtractReadableText(t *testing.T) {
+	body := paragraph("b", 250)
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{
+			name: "article wins",
+			page: `<html><body><nav><p>Home</p></nav><article>` + body + `</article><footer>© 2025</footer></body></html>`,
+			want: strings.Repeat("b", 250),
+		},
+		{
+			name: "densest container",
+			page: `<html><body><div class="sidebar-links">` + paragraph("x", 220) + `</div><div>` + body + `</div></body></html>`,
+			want: strings.Repeat("b", 250),
+		},
+		{
+			// The wrapper's class looks like chrome, but it holds the article text
+			name: "chrome-looking wrapper kept",
+			page: `<html><body><div class="has-sidebar"><div>` + body + `</div></div></body></html>`,
+			want: strings.Repeat("b", 250),
+		},
+		{
+			name: "tie goes to the first container",
+			page: `<html><body><section><div>` + paragraph("f", 300) + `</div></section><section><div>` + paragraph("s", 300) + `</div></section></body></html>`,
+			want: strings.Repeat("f", 300),
+		},
+		{
+			name: "too little text",
+			page: `<html><body><div><p>Sign in to continue</p></div></body></html>`,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Run a few times: ties used to be broken by map order
+			for i := 0; i < 5; i++ {
+				if got := extractReadableText(strings.NewReader(tt.page)); got != tt.want {
+					t.Fatalf("extractReadableText = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestArticleHolders(t *testing.T) {
+	page := `<html><body><div id="wrap"><div id="inner"><main><p>Short</p></main></div></div><div id="chrome"><p>Short</p></div></body></html>`
+	doc := parseHTML(t, page)
+	holders := articleHolders(doc)
+
+	for id, want := range map[string]bool{"wrap": true, "inner": true, "chrome": false} {
+		if got := holders[findByID(doc, id)]; got != want {
+			t.Errorf("holds article #%s = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func parseHTML(t *testing.T, page string) *html.Node {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("html.Parse: %v", err)
+	}
+	return doc
+}
+
+// findByID returns the element under n with the given id attribute, or nil
+func findByID(n *html.Node, id string) *html.Node {
+	for _, attr := range n.Attr {
+		if attr.Key == "id" && attr.Val == id {
+			return n
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findByID(c, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
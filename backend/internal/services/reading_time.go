@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// readingWordsPerMinute is the average adult silent reading speed
+const readingWordsPerMinute = 200
+
+// estimateReadingTime returns the minutes needed to read content, rounded up, minimum 1
+func estimateReadingTime(content string) int {
+	words := len(strings.Fields(content))
+	if words == 0 {
+		return 0
+	}
+	minutes := (words + readingWordsPerMinute - 1) / readingWordsPerMinute
+	if minutes < 1 {
+		minutes = 1
+	}
+	return minutes
+}
+
+// durationToMinutes rounds a duration in seconds up to whole minutes, minimum 1
+func durationToMinutes(seconds int) int {
+	if seconds <= 0 {
+		return 0
+	}
+	return (seconds + 59) / 60
+}
+
+var isoDurationRe = regexp.MustCompile(`^P(?:(\d+)D)?T?(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?$`)
+
+// parseDurationSeconds parses a video duration given as plain seconds ("253")
+// or ISO 8601 ("PT4M13S"), returning 0 if it can't be parsed
+func parseDurationSeconds(value string) int {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return seconds
+	}
+
+	matches := isoDurationRe.FindStringSubmatch(strings.ToUpper(value))
+	if matches == nil {
+		return 0
+	}
+	seconds := 0
+	for i, unit := range []int{86400, 3600, 60, 1} {
+		if n, err := strconv.Atoi(matches[i+1]); err == nil {
+			seconds += n * unit
+		}
+	}
+	return seconds
+}
+
+var youTubeLengthRe = regexp.MustCompile(`"lengthSeconds"\s*:\s*"(\d+)"`)
+
+// GetYouTubeDuration fetches a YouTube watch page and returns the video length in seconds
+func (s *MetadataService) GetYouTubeDuration(ctx context.Context, videoID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	body, err := s.fetchYouTubeWatchPage(ctx, videoID)
+	if err != nil {
+		return 0, err
+	}
+	matches := youTubeLengthRe.FindSubmatch(body)
+	if matches == nil {
+		return 0, fmt.Errorf("video length not found on YouTube page")
+	}
+	return strconv.Atoi(string(matches[1]))
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateReadingTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{"empty", "", 0},
+		{"whitespace only", " \n\t ", 0},
+		{"one word", "hello", 1},
+		{"exactly one minute", strings.Repeat("word ", 200), 1},
+		{"rounds up", strings.Repeat("word ", 201), 2},
+		{"long article", strings.Repeat("word ", 1900), 10},
+		{"any whitespace separates words", strings.Repeat("word\n\tword ", 150), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateReadingTime(tt.content); got != tt.want {
+				t.Errorf("estimateReadingTime() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationToMinutes(t *testing.T) {
+	tests := []struct {
+		seconds int
+		want    int
+	}{
+		{-5, 0},
+		{0, 0},
+		{1, 1},
+		{60, 1},
+		{61, 2},
+		{253, 5},
+	}
+
+	for _, tt := range tests {
+		if got := durationToMinutes(tt.seconds); got != tt.want {
+			t.Errorf("durationToMinutes(%d) = %d, want %d", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestParseDurationSeconds(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  int
+	}{
+		{"plain seconds", "253", 253},
+		{"padded seconds", " 253 ", 253},
+		{"minutes and seconds", "PT4M13S", 253},
+		{"hours", "PT1H2M3S", 3723},
+		{"days", "P1DT1S", 86401},
+		{"minutes only", "PT10M", 600},
+		{"lower case", "pt4m13s", 253},
+		{"empty", "", 0},
+		{"not a duration", "four minutes", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseDurationSeconds(tt.value); got != tt.want {
+				t.Errorf("parseDurationSeconds(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
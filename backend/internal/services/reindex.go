@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"synapse/internal/db"
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// reindexBatchSize is how many items are embedded per provider request during a reindex
+const reindexBatchSize = 50
+
+// ReindexError records an item that couldn't be reindexed
+type ReindexError struct {
+	ItemID uuid.UUID `json:"item_id"`
+	Error  string    `json:"error"`
+}
+
+// ReindexReport summarizes a ReindexAll run
+type ReindexReport struct {
+	Total   int            `json:"total"`
+	Indexed int            `json:"indexed"`
+	Failed  int            `json:"failed"`
+	Errors  []ReindexError `json:"errors"`
+	// Cursor resumes an interrupted run from the last completed batch; empty when finished
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ReindexAll regenerates the embedding of every item userID owns and upserts it into ChromaDB,
+// backfilling items saved before ChromaDB or the embedding provider was
+// available. Upserting under the item's existing embedding ID makes it safe to
+// re-run. Pass the Cursor of an interrupted run to resume where it stopped.
+func (s *ItemService) ReindexAll(ctx context.Context, userID uuid.UUID, cursor string) (*ReindexReport, error) {
+	report := &ReindexReport{Errors: []ReindexError{}}
+
+	for {
+		page, err := s.GetItemsPage(ctx, userID, models.SortNewest, reindexBatchSize, 0, cursor)
+		if err != nil {
+			return nil, err
+		}
+		report.Total = page.Total
+
+		if len(page.Items) > 0 {
+			s.reindexBatch(ctx, page.Items, report)
+			s.logger.InfoContext(ctx, "reindex progress", "operation", "reindex", "processed", report.Indexed+report.Failed, "total", report.Total, "failed", report.Failed)
+		}
+
+		cursor = page.NextCursor
+		if cursor == "" {
+			report.Cursor = ""
+			s.itemsChanged(userID)
+			return report, nil
+		}
+		if ctx.Err() != nil {
+			// Hand back the cursor so the caller can resume later
+			report.Cursor = cursor
+			s.itemsChanged(userID)
+			return report, ctx.Err()
+		}
+	}
+}
+
+// reindexBatch embeds a batch of items in one request where possible and upserts the vectors
+func (s *ItemService) reindexBatch(ctx context.Context, items []models.Item, report *ReindexReport) {
+	texts := make([]string, len(items))
+	for i := range items {
+		texts[i] = s.embeddingText(&items[i])
+	}
+
+	embeddings, err := s.aiService.GenerateEmbeddings(ctx, texts)
+	if err != nil {
+		var batchErr *EmbeddingBatchError
+		if !errors.As(err, &batchErr) {
+			s.logger.WarnContext(ctx, "batch embedding failed, retrying items individually", "operation", "reindex", "error", err)
+		}
+		// One bad input fails the whole batch, so isolate it by embedding one at a time
+		embeddings = make([][]float32, len(items))
+		for i := range items {
+			embeddings[i], err = s.aiService.GenerateEmbedding(ctx, texts[i])
+			if err != nil {
+				embeddings[i] = nil
+				report.addError(items[i].ID, fmt.Errorf("failed to generate embedding: %w", err))
+			}
+		}
+	}
+
+	for i := range items {
+		if embeddings[i] == nil {
+			continue
+		}
+		if err := s.upsertItemEmbedding(ctx, &items[i], texts[i], embeddings[i]); err != nil {
+			report.addError(items[i].ID, err)
+			continue
+		}
+		report.Indexed++
+	}
+}
+
+// upsertItemEmbedding stores an item's vector, generated from text, assigning an
+// embedding ID to items saved without one
+func (s *ItemService) upsertItemEmbedding(ctx context.Context, item *models.Item, text string, embedding []float32) error {
+	if err := s.embeddingGuard.Check(ctx, embedding); err != nil {
+		return err
+	}
+
+	if item.EmbeddingID == "" {
+		item.EmbeddingID = item.ID.String()
+	}
+
+	metadata := embeddingMetadata(item)
+	if err := db.Chroma.UpsertEmbedding(s.collectionName, item.EmbeddingID, embedding, metadata); err != nil {
+		return fmt.Errorf("failed to store embedding in ChromaDB: %w", err)
+	}
+	item.EmbeddingSourceHash = embeddingSourceHash(text)
+	if err := s.itemRepo.UpdateEmbeddingID(ctx, item.ID, item.EmbeddingID, item.EmbeddingSourceHash); err != nil {
+		return fmt.Errorf("failed to save embedding ID: %w", err)
+	}
+	return nil
+}
+
+func (r *ReindexReport) addError(itemID uuid.UUID, err error) {
+	r.Failed++
+	r.Errors = append(r.Errors, ReindexError{ItemID: itemID, Error: err.Error()})
+}
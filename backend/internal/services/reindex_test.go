@@ -0,0 +1,73 @@
+package services_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"synapse/internal/db"
+	"synapse/internal/models"
+	"synapse/internal/services/servicestest"
+)
+
+func TestReindexAllBackfillsVectors(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+
+	indexed := s.save(t, userID, "Tomato soup", "Roast the tomatoes, then blend.", []float32{1, 0, 0})
+	// Saved while ChromaDB was down: no vector and no embedding ID
+	missing := s.save(t, userID, "Bike repair", "Adjust the limit screws.", []float32{0, 1, 0})
+	if err := db.Chroma.DeleteEmbedding(db.CollectionName(), missing.EmbeddingID); err != nil {
+		t.Fatalf("DeleteEmbedding: %v", err)
+	}
+	if err := s.repo.UpdateEmbeddingID(ctx, missing.ID, "", ""); err != nil {
+		t.Fatalf("UpdateEmbeddingID: %v", err)
+	}
+	other := s.save(t, servicestest.NewUser(t, s.pool), "Their note", "Someone else's.", []float32{1, 0, 0})
+
+	// e.g. after switching embedding models
+	s.ai.Embedding = []float32{0, 0, 1}
+	report, err := s.items.ReindexAll(ctx, userID, "")
+	if err != nil {
+		t.Fatalf("ReindexAll: %v", err)
+	}
+	if report.Total != 2 || report.Indexed != 2 || report.Failed != 0 || report.Cursor != "" {
+		t.Errorf("report = %+v, want 2 of 2 indexed and no cursor", report)
+	}
+
+	for _, item := range []*models.Item{indexed, missing} {
+		embedding, err := db.Chroma.GetEmbedding(db.CollectionName(), item.ID.String())
+		if err != nil {
+			t.Fatalf("GetEmbedding(%q): %v", item.Title, err)
+		}
+		if !reflect.DeepEqual(embedding, s.ai.Embedding) {
+			t.Errorf("%q vector = %v, want the new %v", item.Title, embedding, s.ai.Embedding)
+		}
+	}
+	// The backfilled item records the embedding ID it was given
+	stored, err := s.items.GetItem(ctx, userID, missing.ID)
+	if err != nil {
+		t.Fatalf("GetItem: %v", err)
+	}
+	if stored.EmbeddingID != missing.ID.String() || stored.EmbeddingSourceHash == "" {
+		t.Errorf("embedding ID, hash = %q, %q; want the item ID and a hash", stored.EmbeddingID, stored.EmbeddingSourceHash)
+	}
+	// Only the user's own items are reindexed
+	embedding, err := db.Chroma.GetEmbedding(db.CollectionName(), other.EmbeddingID)
+	if err != nil {
+		t.Fatalf("GetEmbedding of another user's item: %v", err)
+	}
+	if reflect.DeepEqual(embedding, s.ai.Embedding) {
+		t.Error("another user's item was reindexed")
+	}
+
+	// Upserting makes a second run safe
+	report, err = s.items.ReindexAll(ctx, userID, "")
+	if err != nil {
+		t.Fatalf("second ReindexAll: %v", err)
+	}
+	if report.Indexed != 2 || report.Failed != 0 {
+		t.Errorf("second report = %+v, want 2 indexed again", report)
+	}
+}
@@ -21,29 +21,27 @@ func NewRelationService(itemRepo *repository.ItemRepository, relationRepo *repos
 		itemRepo:       itemRepo,
 		relationRepo:   relationRepo,
 		aiService:      aiService,
-		collectionName: "synapse_items",
+		collectionName: db.CollectionName(),
 	}
 }
 
-func (s *RelationService) FindRelatedItems(ctx context.Context, itemID uuid.UUID, limit int) ([]models.RelatedItem, error) {
-	// Check cache first
+// FindRelatedItems returns userID's items most similar to one of their items
+func (s *RelationService) FindRelatedItems(ctx context.Context, userID, itemID uuid.UUID, limit int) ([]models.RelatedItem, error) {
+	// Get the item first so the cache can't reveal relations of someone else's item
+	item, err := s.itemRepo.GetByID(ctx, userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check cache
 	cached, err := s.relationRepo.GetRelated(ctx, itemID, limit)
 	if err == nil && len(cached) > 0 {
 		return cached, nil
 	}
 
-	// Get the item
-	item, err := s.itemRepo.GetByID(ctx, itemID)
-	if err != nil {
-		return nil, err
-	}
-
 	// Generate embedding for the item's content to use for similarity search
 	// (We could store this, but for MVP we'll regenerate)
-	searchText := item.Title + " " + item.Content
-	if len(searchText) > 1000 {
-		searchText = searchText[:1000]
-	}
+	searchText := truncateForModel(item.Title+" "+item.Content, 1000)
 	
 	embedding, err := s.aiService.GenerateEmbedding(ctx, searchText)
 	if err != nil {
@@ -51,7 +49,7 @@ func (s *RelationService) FindRelatedItems(ctx context.Context, itemID uuid.UUID
 	}
 
 	// Query for similar items (limit+1 to potentially exclude the item itself)
-	ids, distances, err := db.Chroma.Query(s.collectionName, embedding, limit+10)
+	ids, distances, _, err := db.Chroma.Query(s.collectionName, embedding, limit+10, userWhere(userID))
 	if err != nil {
 		return nil, err
 	}
@@ -79,7 +77,7 @@ func (s *RelationService) FindRelatedItems(ctx context.Context, itemID uuid.UUID
 	}
 
 	// Get items
-	items, err := s.itemRepo.GetByIDs(ctx, relatedIDs)
+	items, err := s.itemRepo.GetByIDs(ctx, userID, relatedIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -98,7 +96,7 @@ func (s *RelationService) FindRelatedItems(ctx context.Context, itemID uuid.UUID
 			continue
 		}
 
-		similarity := 1.0 - relatedDistances[i]
+		similarity := db.Similarity(relatedDistances[i])
 		if similarity < 0 {
 			similarity = 0
 		}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"synapse/internal/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// searchCache is a concurrency-safe LRU cache of search results whose entries
+// expire after ttl. Entries are per user so one user's saves only invalidate
+// their own searches.
+type searchCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List
+	// generations counts each user's invalidations. A search notes the
+	// generation before it starts and Put drops its results if an invalidation
+	// happened since, as they may predate the change.
+	generations map[uuid.UUID]uint64
+}
+
+type searchCacheEntry struct {
+	key     string
+	userID  uuid.UUID
+	results []models.SearchResult
+	// facets is nil for searches that didn't count them
+	facets  *models.Facets
+	expires time.Time
+}
+
+func newSearchCache(capacity int, ttl time.Duration) *searchCache {
+	return &searchCache{
+		capacity:    capacity,
+		ttl:         ttl,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+		generations: make(map[uuid.UUID]uint64),
+	}
+}
+
+// searchCacheKey identifies a search by user, normalized query, collection,
+// order, and page. Filters are parsed from the query, so the query stands in for them.
+func searchCacheKey(userID uuid.UUID, query string, collectionID *uuid.UUID, sortBy string, limit, offset int) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(query), " "))
+	collection := ""
+	if collectionID != nil {
+		collection = collectionID.String()
+	}
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%d\x00%d", userID, collection, normalized, sortBy, limit, offset)
+}
+
+func (c *searchCache) enabled() bool {
+	return c.capacity > 0 && c.ttl > 0
+}
+
+// Get returns a cached search's results and facets. The facets are shared, so
+// callers mustn't modify them.
+func (c *searchCache) Get(key string) ([]models.SearchResult, *models.Facets, bool) {
+	if !c.enabled() {
+		return nil, nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, nil, false
+	}
+	entry := elem.Value.(*searchCacheEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	// Copy so callers can't modify the cached slice
+	return append([]models.SearchResult(nil), entry.results...), entry.facets, true
+}
+
+// Generation returns userID's current generation, to pass to Put once the
+// search it's taken before has finished
+func (c *searchCache) Generation(userID uuid.UUID) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generations[userID]
+}
+
+// Put caches a search of userID's that started at generation; it's dropped if
+// their searches were invalidated since
+func (c *searchCache) Put(key string, userID uuid.UUID, generation uint64, results []models.SearchResult, facets *models.Facets) {
+	if !c.enabled() {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generations[userID] != generation {
+		return
+	}
+
+	entry := &searchCacheEntry{
+		key:     key,
+		userID:  userID,
+		results: append([]models.SearchResult(nil), results...),
+		facets:  facets,
+		expires: time.Now().Add(c.ttl),
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+
+	// Evict least recently used entries once over capacity
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*searchCacheEntry).key)
+	}
+}
+
+// InvalidateUser drops every cached search of userID, and any of their
+// searches still running when it's called
+func (c *searchCache) InvalidateUser(userID uuid.UUID) {
+	if !c.enabled() {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generations[userID]++
+
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if entry := elem.Value.(*searchCacheEntry); entry.userID == userID {
+			c.order.Remove(elem)
+			delete(c.entries, entry.key)
+		}
+		elem = next
+	}
+}
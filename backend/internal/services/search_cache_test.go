@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestSearchCacheDropsResultsFromBeforeInvalidation(t *testing.T) {
+	c := newSearchCache(10, time.Minute)
+	userID, other := uuid.New(), uuid.New()
+	results := []models.SearchResult{{SimilarityScore: 1}}
+
+	// A search starts, the user saves an item, then the search finishes
+	generation := c.Generation(userID)
+	otherGeneration := c.Generation(other)
+	c.InvalidateUser(userID)
+	c.Put("stale", userID, generation, results, nil)
+	if _, _, ok := c.Get("stale"); ok {
+		t.Error("results from before the invalidation were cached")
+	}
+
+	// Other users' searches are unaffected
+	c.Put("other", other, otherGeneration, results, nil)
+	if _, _, ok := c.Get("other"); !ok {
+		t.Error("another user's results weren't cached")
+	}
+
+	c.Put("fresh", userID, c.Generation(userID), results, nil)
+	if _, _, ok := c.Get("fresh"); !ok {
+		t.Error("results from after the invalidation weren't cached")
+	}
+}
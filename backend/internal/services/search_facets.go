@@ -0,0 +1,44 @@
+package services
+
+import (
+	"sort"
+
+	"synapse/internal/models"
+)
+
+// facetDepth is the fewest ranked candidates facet counts are taken over, so
+// the counts don't shrink to the size of the first page
+const facetDepth = 100
+
+// countFacets counts results by type and category
+func countFacets(results []models.SearchResult) *models.Facets {
+	types := make(map[string]int)
+	categories := make(map[string]int)
+	for _, result := range results {
+		if result.Item.Type != "" {
+			types[result.Item.Type]++
+		}
+		if result.Item.Category != "" {
+			categories[result.Item.Category]++
+		}
+	}
+	return &models.Facets{
+		Types:      facetCounts(types),
+		Categories: facetCounts(categories),
+	}
+}
+
+// facetCounts lists counts most common first, ties in alphabetical order
+func facetCounts(counts map[string]int) []models.FacetCount {
+	facets := make([]models.FacetCount, 0, len(counts))
+	for value, count := range counts {
+		facets = append(facets, models.FacetCount{Value: value, Count: count})
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Value < facets[j].Value
+	})
+	return facets
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"synapse/internal/models"
+)
+
+func TestCountFacets(t *testing.T) {
+	result := func(itemType, category string) models.SearchResult {
+		return models.SearchResult{Item: models.Item{Type: itemType, Category: category}}
+	}
+	results := []models.SearchResult{
+		result("video", "Technology"),
+		result("url", "Technology"),
+		result("video", "Videos & Entertainment"),
+		result("text", "Cooking"),
+		// Items without a category aren't counted under ""
+		result("url", ""),
+		result("video", ""),
+	}
+
+	got := countFacets(results)
+	want := &models.Facets{
+		// Most common first, ties in alphabetical order
+		Types: []models.FacetCount{{Value: "video", Count: 3}, {Value: "url", Count: 2}, {Value: "text", Count: 1}},
+		Categories: []models.FacetCount{
+			{Value: "Technology", Count: 2},
+			{Value: "Cooking", Count: 1},
+			{Value: "Videos & Entertainment", Count: 1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("countFacets = %+v, want %+v", got, want)
+	}
+
+	// No matches still give empty lists, not null
+	if empty := countFacets(nil); empty.Types == nil || empty.Categories == nil {
+		t.Errorf("countFacets(nil) = %+v, want empty lists", empty)
+	}
+}
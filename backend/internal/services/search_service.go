@@ -2,87 +2,390 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"regexp"
+	"sort"
 	"strings"
 	"synapse/internal/db"
+	"synapse/internal/logging"
+	"synapse/internal/metrics"
 	"synapse/internal/models"
 	"synapse/internal/repository"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type SearchService struct {
-	aiService      *AIService
+	aiService      AIProvider
 	itemRepo       *repository.ItemRepository
+	collectionRepo *repository.CollectionRepository
+	embeddingGuard *EmbeddingGuard
 	collectionName string
+	// minSimilarity drops semantic matches below this similarity before fusion
+	minSimilarity float64
+	// fuzzyThreshold is the trigram similarity for typo-tolerant title matches; 0 disables them
+	fuzzyThreshold float64
+	// trigram is whether PostgreSQL has pg_trgm, which fuzzy matching is built on
+	trigram bool
+	// semanticWeight and textWeight scale each list's share of the fused score
+	semanticWeight float64
+	textWeight     float64
+	// suggestMinResults is the result count at which Suggest considers a query good enough
+	suggestMinResults int
+	// recommendSeeds is how many of the newest items Recommend bases its feed on
+	recommendSeeds int
+	// cache holds recent results so repeated searches skip embedding and ranking
+	cache *searchCache
+	// now is the clock relative dates in queries ("last month") are resolved against
+	now     func() time.Time
+	metrics metrics.Metrics
+	logger  *slog.Logger
 }
 
-func NewSearchService(aiService *AIService, itemRepo *repository.ItemRepository) *SearchService {
+func NewSearchService(aiService AIProvider, itemRepo *repository.ItemRepository, collectionRepo *repository.CollectionRepository, embeddingGuard *EmbeddingGuard, m metrics.Metrics, logger *slog.Logger) *SearchService {
 	return &SearchService{
 		aiService:      aiService,
 		itemRepo:       itemRepo,
-		collectionName: "synapse_items",
+		collectionRepo: collectionRepo,
+		embeddingGuard: embeddingGuard,
+		collectionName: db.CollectionName(),
+		// ChromaDB always returns n results, however unrelated; set to 0 to keep them all
+		minSimilarity:  getEnvFloat("SEARCH_MIN_SIMILARITY", 0.2),
+		fuzzyThreshold: getEnvFloat("SEARCH_FUZZY_THRESHOLD", 0.5),
+		// Equal by default; raise one to lean toward meaning or keywords
+		semanticWeight:    getEnvFloat("SEARCH_SEMANTIC_WEIGHT", 1.0),
+		textWeight:        getEnvFloat("SEARCH_TEXT_WEIGHT", 1.0),
+		suggestMinResults: getEnvInt("SEARCH_SUGGEST_MIN_RESULTS", 3),
+		// At least one, or every feed would be empty
+		recommendSeeds: max(getEnvInt("RECOMMEND_SEED_ITEMS", 10), 1),
+		// SEARCH_CACHE_SIZE=0 disables the cache
+		cache:   newSearchCache(getEnvInt("SEARCH_CACHE_SIZE", 500), getEnvSeconds("SEARCH_CACHE_TTL_SECONDS", 60*time.Second)),
+		now:     time.Now,
+		metrics: metrics.OrNoop(m),
+		logger:  logging.OrDefault(logger),
 	}
 }
 
+// SetTrigramAvailable tells the service whether PostgreSQL has the pg_trgm
+// extension (see db.TrigramAvailable). Typo-tolerant title matching uses its
+// operators, so it stays off until this is set to true.
+func (s *SearchService) SetTrigramAvailable(available bool) {
+	s.trigram = available
+}
+
+// SetClock replaces the clock relative dates in queries are resolved against,
+// e.g. with a fixed time in tests. Set it before serving requests.
+func (s *SearchService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
 // Search performs hybrid search: semantic (ChromaDB) + text (PostgreSQL) with natural language parsing
-// Enhanced with Claude AI for query understanding and result re-ranking
-func (s *SearchService) Search(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
-	// Parse natural language query
-	filters := ParseNaturalLanguageQuery(query)
+// Enhanced with Claude AI for query understanding and result re-ranking.
+// Results are fused and re-ranked in memory, so offset pages over the top
+// offset+limit candidates rather than seeking in the database. Only userID's
+// items are searched, and only those in collectionID when it's non-nil.
+// sortBy is one of the models.Sort* orders; "" means relevance.
+func (s *SearchService) Search(ctx context.Context, userID uuid.UUID, query string, collectionID *uuid.UUID, sortBy string, limit, offset int) ([]models.SearchResult, error) {
+	results, _, err := s.search(ctx, userID, query, collectionID, sortBy, limit, offset, searchOptions{})
+	return results, err
+}
+
+// SearchExplain runs Search, bypassing the cache, and sets each result's
+// Explain to how it was scored: its semantic distance, its rank in the
+// semantic and text lists, and its fused score.
+func (s *SearchService) SearchExplain(ctx context.Context, userID uuid.UUID, query string, collectionID *uuid.UUID, sortBy string, limit, offset int) ([]models.SearchResult, error) {
+	results, _, err := s.search(ctx, userID, query, collectionID, sortBy, limit, offset, searchOptions{explain: true})
+	return results, err
+}
 
-	// Use Claude to enhance the search query - this converts plain English to searchable terms
-	// This is critical for finding content even when exact words don't match
-	enhancedQuery, err := s.aiService.EnhanceSearchQuery(ctx, query)
+// SearchWithFacets runs Search and also counts the matches by type and
+// category. The counts cover the ranked candidates, at least facetDepth of
+// them, rather than every item that matches at all.
+func (s *SearchService) SearchWithFacets(ctx context.Context, userID uuid.UUID, query string, collectionID *uuid.UUID, sortBy string, limit, offset int) (*models.FacetedSearch, error) {
+	results, facets, err := s.search(ctx, userID, query, collectionID, sortBy, limit, offset, searchOptions{facets: true})
 	if err != nil {
-		// If Claude enhancement fails, use original query
-		enhancedQuery = query
+		return nil, err
+	}
+	return &models.FacetedSearch{Results: results, Facets: *facets}, nil
+}
+
+// searchOptions are the extras a search can compute besides its results
+type searchOptions struct {
+	// explain sets each result's Explain, and bypasses the cache
+	explain bool
+	// facets counts the matches by type and category
+	facets bool
+	// countOnly is for callers that only need how many results there are: it
+	// skips the AI query enhancement and re-ranking, and the cache
+	countOnly bool
+}
+
+func (s *SearchService) search(ctx context.Context, userID uuid.UUID, query string, collectionID *uuid.UUID, sortBy string, limit, offset int, opts searchOptions) ([]models.SearchResult, *models.Facets, error) {
+	if sortBy == "" {
+		sortBy = models.SortRelevance
+	}
+	if !models.IsValidSortBy(sortBy) {
+		return nil, nil, ErrInvalidSortBy
+	}
+
+	cacheKey := searchCacheKey(userID, query, collectionID, sortBy, limit, offset)
+	if opts.facets {
+		// Counting facets ranks more candidates, which can change the results slightly
+		cacheKey += "\x00facets"
+	}
+	// Taken before searching, so a save made mid-search keeps these results out of the cache
+	generation := s.cache.Generation(userID)
+	cached := !opts.explain && !opts.countOnly
+	if cached {
+		if results, facets, ok := s.cache.Get(cacheKey); ok {
+			return results, facets, nil
+		}
+	}
+
+	// Parse natural language query
+	filters := ParseNaturalLanguageQueryAt(query, s.now())
+	filters.CollectionID = collectionID
+	if s.trigram {
+		filters.FuzzyThreshold = s.fuzzyThreshold
 	}
+	// filters.SortBy stays relevance: text candidates are picked by rank before
+	// the SQL LIMIT, and only the fused page is reordered by sortBy below
+
+	// The AI only sees what to look for, without the search syntax
+	aiQuery := queryForAI(query)
+
+	// Counting skips both AI calls and uses what enhanceQueryForPassageSearch
+	// keeps outside passage searches
+	enhancedQuery := filters.SearchTerms
+	if !opts.countOnly {
+		// Use Claude to enhance the search query - this converts plain English to searchable terms
+		// This is critical for finding content even when exact words don't match
+		aiEnhanced, err := s.aiService.EnhanceSearchQuery(ctx, aiQuery)
+		if err != nil {
+			// If Claude enhancement fails, use original query
+			aiEnhanced = aiQuery
+		}
 
-	// For quote/passage searches, enhance the query with context
-	enhancedQuery = s.enhanceQueryForPassageSearch(ctx, filters.SearchTerms, enhancedQuery)
+		// For quote/passage searches, enhance the query with context
+		enhancedQuery = s.enhanceQueryForPassageSearch(ctx, filters.SearchTerms, aiEnhanced)
+	}
 	
 	// Also enhance the search terms for text search to improve keyword matching
-	if enhancedQuery != query {
+	if enhancedQuery != aiQuery {
 		// Use enhanced query for better text search too
 		filters.SearchTerms = enhancedQuery
 	}
 
-	// Try semantic search first (if ChromaDB is available)
-	semanticResults, semanticErr := s.semanticSearch(ctx, enhancedQuery, limit*2)
+	// Rank enough candidates to cover every page up to the requested one
+	window := offset + limit
+	// Facet counts need more candidates than the page does
+	depth := window
+	if opts.facets {
+		depth = max(window, facetDepth)
+	}
+
+	// Try semantic search first (if ChromaDB is available). While it's down,
+	// the query embedding isn't generated either, since it could only be thrown away.
+	start := time.Now()
+	var semanticResults []models.SearchResult
+	semanticErr := db.ErrChromaUnavailable
+	if db.Chroma.Available(ctx) {
+		semanticResults, semanticErr = s.semanticSearch(ctx, userID, enhancedQuery, searchWhere(userID, filters), depth*2)
+	}
+	if semanticErr == nil && collectionID != nil {
+		// ChromaDB doesn't know about collections; text search filters in SQL
+		semanticResults, semanticErr = s.filterToCollection(ctx, semanticResults, *collectionID)
+	}
+	s.metrics.ObserveSearch("semantic", len(semanticResults), time.Since(start), semanticErr)
 	
 	// Always do text search as fallback/combination (includes OCR text)
-	textResults, textErr := s.itemRepo.SearchItems(ctx, filters, limit*2)
+	start = time.Now()
+	textResults, textErr := s.itemRepo.SearchItems(ctx, userID, filters, depth*2, 0)
+	s.metrics.ObserveSearch("text", len(textResults), time.Since(start), textErr)
 	
+	if errors.Is(semanticErr, ErrEmbeddingMismatch) {
+		// Don't quietly degrade to text-only results; the collection needs a reindex
+		return []models.SearchResult{}, nil, semanticErr
+	}
+
 	if semanticErr != nil && textErr != nil {
 		// Both failed, return empty
-		return []models.SearchResult{}, fmt.Errorf("search failed: semantic=%v, text=%v", semanticErr, textErr)
+		return []models.SearchResult{}, nil, fmt.Errorf("search failed: semantic=%v, text=%v", semanticErr, textErr)
 	}
 
 	// Combine results
-	results := s.combineResults(semanticResults, textResults, limit*2) // Get more results for re-ranking
+	results := s.combineResults(semanticResults, textResults, depth*2) // Get more results for re-ranking
 
 	// For quote searches, boost items that contain the exact phrase
-	results = s.boostExactMatches(results, filters.SearchTerms)
+	results = s.boostExactMatches(results, filters.SearchTerms, filters.Phrases)
 
 	// Apply post-filters (price, etc. that aren't in SQL)
 	results = s.applyPostFilters(results, filters)
 
-	// Use Claude to re-rank results by relevance (if we have results)
-	if len(results) > 1 {
-		reRanked, err := s.aiService.ReRankSearchResults(ctx, query, results, limit)
-		if err == nil && len(reRanked) > 0 {
-			results = reRanked
+	var facets *models.Facets
+	if opts.facets {
+		facets = countFacets(results)
+		// Only the page's candidates are re-ranked
+		if len(results) > window*2 {
+			results = results[:window*2]
+		}
+	}
+
+	for i := range results {
+		if opts.explain {
+			results[i].Explain.FusedRank = i + 1
+		} else {
+			results[i].Explain = nil
+		}
+	}
+
+	if sortBy == models.SortRelevance {
+		// Use Claude to re-rank results by relevance (if we have results)
+		if len(results) > 1 && !opts.countOnly {
+			reRanked, err := s.aiService.ReRankSearchResults(ctx, aiQuery, results, window)
+			if err == nil && len(reRanked) > 0 {
+				results = reRanked
+			}
 		}
+	} else {
+		// The fused candidates are still the most relevant ones, just reordered
+		sortResults(results, sortBy)
 	}
 
-	// Limit to requested number
+	// Slice out the requested page
+	if offset >= len(results) {
+		return []models.SearchResult{}, facets, nil
+	}
+	results = results[offset:]
 	if len(results) > limit {
 		results = results[:limit]
 	}
 
-	return results, nil
+	// Show why each result matched
+	addSnippets(results, filters.SearchTerms)
+
+	if cached {
+		s.cache.Put(cacheKey, userID, generation, results, facets)
+	}
+	return results, facets, nil
+}
+
+// suggestTopics is how many of the user's most used tags are given to the AI as
+// the library's vocabulary when suggesting a query
+const suggestTopics = 50
+
+// Suggest returns a "Did you mean" query for a search that finds fewer than
+// suggestMinResults results: the query with its typos corrected, or a broader
+// one, worded after the user's tags where possible. It returns "" when the
+// query already finds enough or nothing better is found. The results are only
+// counted, so they're neither enhanced nor re-ranked by the AI.
+func (s *SearchService) Suggest(ctx context.Context, userID uuid.UUID, query string) (string, error) {
+	results, _, err := s.search(ctx, userID, query, nil, models.SortRelevance, s.suggestMinResults, 0, searchOptions{countOnly: true})
+	if err == nil && len(results) >= s.suggestMinResults {
+		return "", nil
+	}
+
+	var topics []string
+	tags, err := s.itemRepo.ListTags(ctx, userID)
+	if err != nil {
+		// Optional - the suggestion just isn't steered toward the user's vocabulary
+		s.logger.WarnContext(ctx, "failed to list tags for query suggestion", "operation", "suggest_query", "error", err)
+	}
+	for _, tag := range tags {
+		if len(topics) == suggestTopics {
+			break
+		}
+		topics = append(topics, tag.Tag)
+	}
+
+	return s.aiService.SuggestQuery(ctx, query, topics)
+}
+
+// maxSearchDepth is how far into a search's ranking SearchPage can page
+const maxSearchDepth = 500
+
+// SearchPage is Search for infinite scroll: it returns one page of results and
+// a cursor for the next. Fusion and re-ranking happen in memory, so there's no
+// keyset to seek on; each page re-runs the search over every result up to its
+// end and slices out the page. The AI re-ranking can order a larger candidate
+// set slightly differently, so the cursor also holds the previous page's last
+// result, and the page resumes right after wherever that result now ranks.
+// Pages deeper in cost more, and paging stops at maxSearchDepth results.
+func (s *SearchService) SearchPage(ctx context.Context, userID uuid.UUID, query string, collectionID *uuid.UUID, sortBy string, limit int, cursor string) (*models.SearchPage, error) {
+	var after *models.SearchCursor
+	if cursor != "" {
+		var err error
+		after, err = models.DecodeSearchCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	start := 0
+	if after != nil {
+		start = after.Offset
+	}
+	if start >= maxSearchDepth {
+		return &models.SearchPage{Results: []models.SearchResult{}}, nil
+	}
+
+	// One extra result shows whether there's a next page
+	results, err := s.Search(ctx, userID, query, collectionID, sortBy, start+limit+1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if after != nil {
+		for i, result := range results {
+			if result.Item.ID == after.ID {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	page := &models.SearchPage{Results: []models.SearchResult{}}
+	if start >= len(results) {
+		return page, nil
+	}
+	end := start + limit
+	if end > len(results) {
+		end = len(results)
+	}
+	page.Results = results[start:end]
+	if end < len(results) && end < maxSearchDepth {
+		last := page.Results[len(page.Results)-1]
+		page.NextCursor = models.SearchCursor{Offset: end, ID: last.Item.ID}.Encode()
+	}
+	return page, nil
+}
+
+// ErrInvalidSortBy is returned by Search for a sort order that isn't one of models.Sort*
+var ErrInvalidSortBy = errors.New("invalid sort order")
+
+// sortResults orders results by a non-relevance sortBy, keeping ties in score order
+func sortResults(results []models.SearchResult, sortBy string) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].Item, results[j].Item
+		switch sortBy {
+		case models.SortNewest:
+			return a.CreatedAt.After(b.CreatedAt)
+		case models.SortOldest:
+			return a.CreatedAt.Before(b.CreatedAt)
+		case models.SortTitle:
+			return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+		}
+		return false
+	})
+}
+
+// InvalidateCache drops userID's cached search results, so their searches
+// reflect items they just saved, edited, or deleted
+func (s *SearchService) InvalidateCache(userID uuid.UUID) {
+	s.cache.InvalidateUser(userID)
 }
 
 // enhanceQueryForPassageSearch enhances queries to better find specific passages
@@ -107,13 +410,21 @@ func (s *SearchService) enhanceQueryForPassageSearch(ctx context.Context, search
 	return searchTerms
 }
 
-// boostExactMatches boosts items that contain exact phrase matches
-func (s *SearchService) boostExactMatches(results []models.SearchResult, searchTerms string) []models.SearchResult {
+// boostExactMatches boosts items that contain exact phrase matches. Quoted
+// phrases are required anyway, so they're only boosted when in the title.
+func (s *SearchService) boostExactMatches(results []models.SearchResult, searchTerms string, phrases []string) []models.SearchResult {
 	lowerSearch := strings.ToLower(searchTerms)
 	
 	for i := range results {
 		item := results[i].Item
 		searchableText := strings.ToLower(item.Title + " " + item.Content + " " + item.Summary + " " + item.OcrText)
+		before := results[i].SimilarityScore
+		lowerTitle := strings.ToLower(item.Title)
+		for _, phrase := range phrases {
+			if strings.Contains(lowerTitle, strings.ToLower(phrase)) {
+				results[i].SimilarityScore = math.Min(results[i].SimilarityScore+0.1, 1.0)
+			}
+		}
 		
 		// Boost if exact phrase found
 		if strings.Contains(searchableText, lowerSearch) {
@@ -130,6 +441,9 @@ func (s *SearchService) boostExactMatches(results []models.SearchResult, searchT
 				results[i].SimilarityScore = 1.0
 			}
 		}
+		if results[i].Explain != nil {
+			results[i].Explain.ExactMatchBoost = results[i].SimilarityScore - before
+		}
 	}
 	
 	// Re-sort by score
@@ -144,35 +458,53 @@ func (s *SearchService) boostExactMatches(results []models.SearchResult, searchT
 	return results
 }
 
-func (s *SearchService) semanticSearch(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
-	// Generate embedding for query
-	queryEmbedding, err := s.aiService.GenerateEmbedding(ctx, query)
+func (s *SearchService) semanticSearch(ctx context.Context, userID uuid.UUID, query string, where map[string]interface{}, limit int) ([]models.SearchResult, error) {
+	// Generate embedding for query; repeated queries hit the query embedding cache
+	queryEmbedding, err := s.aiService.GenerateQueryEmbedding(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
-	// Query ChromaDB
-	ids, distances, err := db.Chroma.Query(s.collectionName, queryEmbedding, limit)
-	if err != nil {
+	return s.vectorSearch(ctx, userID, queryEmbedding, where, limit)
+}
+
+// vectorSearch returns userID's items nearest to queryEmbedding, best first,
+// among the vectors matching the ChromaDB filter where (see searchWhere)
+func (s *SearchService) vectorSearch(ctx context.Context, userID uuid.UUID, queryEmbedding []float32, where map[string]interface{}, limit int) ([]models.SearchResult, error) {
+	if err := s.embeddingGuard.Check(ctx, queryEmbedding); err != nil {
 		return nil, err
 	}
 
-	if len(ids) == 0 {
-		return []models.SearchResult{}, nil
+	// Query ChromaDB
+	ids, distances, _, err := db.Chroma.Query(s.collectionName, queryEmbedding, limit, where)
+	if err != nil {
+		return nil, err
 	}
 
-	// Convert string IDs to UUIDs
+	// Convert string IDs to UUIDs, dropping matches too dissimilar to be relevant.
+	// Distances above 1 give negative similarity, so they're always dropped
+	// rather than clamped to a zero score that still competes in fusion.
 	var itemIDs []uuid.UUID
-	for _, id := range ids {
+	similarities := make(map[uuid.UUID]float64)
+	for i, id := range ids {
 		itemID, err := uuid.Parse(id)
 		if err != nil {
 			continue
 		}
+		similarity := db.Similarity(distances[i])
+		if similarity <= 0 || similarity < s.minSimilarity {
+			continue
+		}
 		itemIDs = append(itemIDs, itemID)
+		similarities[itemID] = similarity
+	}
+
+	if len(itemIDs) == 0 {
+		return []models.SearchResult{}, nil
 	}
 
 	// Get items from database
-	items, err := s.itemRepo.GetByIDs(ctx, itemIDs)
+	items, err := s.itemRepo.GetByIDs(ctx, userID, itemIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -183,72 +515,301 @@ func (s *SearchService) semanticSearch(ctx context.Context, query string, limit
 		itemMap[item.ID] = item
 	}
 
-	// Build results with similarity scores
+	// Build results with similarity scores, keeping ChromaDB's best-first order
 	var results []models.SearchResult
+	for _, itemID := range itemIDs {
+		item, exists := itemMap[itemID]
+		if !exists {
+			continue
+		}
+
+		results = append(results, models.SearchResult{
+			Item:            item,
+			SimilarityScore: similarities[itemID],
+		})
+	}
+
+	return results, nil
+}
+
+// PreviewSearch is a semantic-only search for result cards: results carry the
+// title, type, category, image, and date stored with each vector rather than
+// the full item, so PostgreSQL is only read for vectors stored without them.
+// There's no query enhancement, text matching, or re-ranking.
+func (s *SearchService) PreviewSearch(ctx context.Context, userID uuid.UUID, query string, limit int) ([]models.SearchResult, error) {
+	if !db.Chroma.Available(ctx) {
+		return nil, db.ErrChromaUnavailable
+	}
+	queryEmbedding, err := s.aiService.GenerateQueryEmbedding(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.embeddingGuard.Check(ctx, queryEmbedding); err != nil {
+		return nil, err
+	}
+
+	ids, distances, metadatas, err := db.Chroma.Query(s.collectionName, queryEmbedding, limit, userWhere(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.SearchResult{}
+	// Indexes into results of the matches that still need their item from PostgreSQL
+	missing := make(map[uuid.UUID]int)
 	for i, id := range ids {
 		itemID, err := uuid.Parse(id)
 		if err != nil {
 			continue
 		}
-
-		item, exists := itemMap[itemID]
-		if !exists {
+		similarity := db.Similarity(distances[i])
+		if similarity <= 0 || similarity < s.minSimilarity {
+			continue
+		}
+		item, ok := itemFromMetadata(itemID, metadatas[i])
+		if ok && item.UserID != userID {
+			// Only the default user's query is unfiltered, and it mustn't see other users' items
 			continue
 		}
+		if !ok {
+			missing[itemID] = len(results)
+		}
+		results = append(results, models.SearchResult{Item: item, SimilarityScore: similarity})
+	}
 
-		// Convert distance to similarity score (1 - distance)
-		similarity := 1.0 - distances[i]
-		if similarity < 0 {
-			similarity = 0
+	if len(missing) > 0 {
+		missingIDs := make([]uuid.UUID, 0, len(missing))
+		for itemID := range missing {
+			missingIDs = append(missingIDs, itemID)
+		}
+		items, err := s.itemRepo.GetByIDs(ctx, userID, missingIDs)
+		if err != nil {
+			return nil, err
 		}
+		for _, item := range items {
+			results[missing[item.ID]].Item = item
+		}
+	}
 
-		results = append(results, models.SearchResult{
-			Item:            item,
-			SimilarityScore: similarity,
-		})
+	// Drop matches whose item no longer exists (or isn't the user's)
+	found := results[:0]
+	for _, result := range results {
+		if result.Item.ID != uuid.Nil {
+			found = append(found, result)
+		}
+	}
+	return found, nil
+}
+
+// RelatedItems finds userID's items most similar to one of their items
+// ("more like this"), best first, excluding the item itself. It starts from
+// the item's stored vector, regenerating one from its content if ChromaDB
+// doesn't have it.
+func (s *SearchService) RelatedItems(ctx context.Context, userID, itemID uuid.UUID, limit int) ([]models.SearchResult, error) {
+	item, err := s.itemRepo.GetByID(ctx, userID, itemID)
+	if err != nil {
+		return nil, err
 	}
 
-	return results, nil
+	var embedding []float32
+	if item.EmbeddingID != "" {
+		embedding, err = db.Chroma.GetEmbedding(s.collectionName, item.EmbeddingID)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to fetch stored embedding, regenerating", "operation", "related_items", "item_id", itemID, "error", err)
+		}
+	}
+	if embedding == nil {
+		content := item.Content
+		if content == "" {
+			content = item.Title
+		}
+		embedding, err = s.aiService.GenerateEmbedding(ctx, content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// One extra so the item itself can be dropped
+	results, err := s.vectorSearch(ctx, userID, embedding, userWhere(userID), limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	related := []models.SearchResult{}
+	for _, result := range results {
+		if result.Item.ID == itemID {
+			continue
+		}
+		related = append(related, result)
+	}
+	if len(related) > limit {
+		related = related[:limit]
+	}
+	return related, nil
 }
 
-func (s *SearchService) combineResults(semanticResults []models.SearchResult, textResults []models.Item, limit int) []models.SearchResult {
-	// Create a map to deduplicate and combine scores
-	resultMap := make(map[uuid.UUID]models.SearchResult)
+// Recommend is a "for you" feed resurfacing userID's older saves: the items
+// nearest the centroid (average) of the stored vectors of their recommendSeeds
+// newest items, best first, excluding those newest items themselves. Items
+// whose vector can't be fetched don't count toward the centroid.
+func (s *SearchService) Recommend(ctx context.Context, userID uuid.UUID, limit int) ([]models.SearchResult, error) {
+	if !db.Chroma.Available(ctx) {
+		return nil, db.ErrChromaUnavailable
+	}
 
-	// Add semantic results with their scores
-	for _, result := range semanticResults {
-		resultMap[result.Item.ID] = result
+	recent, _, err := s.itemRepo.GetAllPaginated(ctx, userID, models.SortNewest, s.recommendSeeds, 0)
+	if err != nil {
+		return nil, err
 	}
 
-	// Add text results, combining scores if they exist
-	for _, item := range textResults {
-		if existing, exists := resultMap[item.ID]; exists {
-			// Item found in both - boost the score
-			existing.SimilarityScore = existing.SimilarityScore*0.7 + 0.3
-			resultMap[item.ID] = existing
-		} else {
-			// New item from text search - give it a base score
-			resultMap[item.ID] = models.SearchResult{
-				Item:            item,
-				SimilarityScore: 0.5, // Base score for text matches
+	seeds := make(map[uuid.UUID]bool, len(recent))
+	var embeddingIDs []string
+	for _, item := range recent {
+		seeds[item.ID] = true
+		if item.EmbeddingID != "" {
+			embeddingIDs = append(embeddingIDs, item.EmbeddingID)
+		}
+	}
+
+	// The seeds' vectors are fetched in one request
+	var embeddings [][]float32
+	if len(embeddingIDs) > 0 {
+		stored, err := db.Chroma.GetEmbeddings(s.collectionName, embeddingIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range embeddingIDs {
+			if embedding := stored[id]; embedding != nil {
+				embeddings = append(embeddings, embedding)
 			}
 		}
 	}
 
-	// Convert map to slice and sort by score
-	results := make([]models.SearchResult, 0, len(resultMap))
-	for _, result := range resultMap {
-		results = append(results, result)
+	centroid := meanEmbedding(embeddings)
+	if centroid == nil {
+		// Nothing recent to go on
+		return []models.SearchResult{}, nil
 	}
 
-	// Simple sort by similarity score (descending)
-	for i := 0; i < len(results)-1; i++ {
-		for j := i + 1; j < len(results); j++ {
-			if results[i].SimilarityScore < results[j].SimilarityScore {
-				results[i], results[j] = results[j], results[i]
-			}
+	// Extra results so the seeds themselves can be dropped
+	results, err := s.vectorSearch(ctx, userID, centroid, userWhere(userID), limit+len(seeds))
+	if err != nil {
+		return nil, err
+	}
+
+	recommended := []models.SearchResult{}
+	for _, result := range results {
+		if seeds[result.Item.ID] {
+			continue
+		}
+		recommended = append(recommended, result)
+	}
+	if len(recommended) > limit {
+		recommended = recommended[:limit]
+	}
+	return recommended, nil
+}
+
+// meanEmbedding averages embeddings element-wise, skipping any whose dimension
+// differs from the first's. It returns nil if there are none.
+func meanEmbedding(embeddings [][]float32) []float32 {
+	if len(embeddings) == 0 {
+		return nil
+	}
+	sum := make([]float64, len(embeddings[0]))
+	n := 0
+	for _, embedding := range embeddings {
+		if len(embedding) != len(sum) {
+			continue
+		}
+		for i, v := range embedding {
+			sum[i] += float64(v)
 		}
+		n++
 	}
+	mean := make([]float32, len(sum))
+	for i := range sum {
+		mean[i] = float32(sum[i] / float64(n))
+	}
+	return mean
+}
+
+// filterToCollection keeps only the results whose item is in the collection
+func (s *SearchService) filterToCollection(ctx context.Context, results []models.SearchResult, collectionID uuid.UUID) ([]models.SearchResult, error) {
+	ids, err := s.collectionRepo.ItemIDs(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		members[id] = true
+	}
+
+	filtered := []models.SearchResult{}
+	for _, result := range results {
+		if members[result.Item.ID] {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered, nil
+}
+
+// rrfK dampens the advantage of top ranks in Reciprocal Rank Fusion; 60 is the
+// value from the original RRF paper and works well without tuning
+const rrfK = 60
+
+// combineResults fuses semantic and text results with Reciprocal Rank Fusion:
+// each list is ranked independently and an item scores sum(1/(k+rank)) over
+// the lists it appears in. Only ranks matter, so the two lists' incomparable
+// score scales (cosine similarity vs ts_rank) can't skew the result. Each
+// list's terms are scaled by semanticWeight and textWeight, and the fused
+// score is normalized so an item ranked first in both lists scores 1.0.
+// Each result's Explain records its ranks and fused score.
+func (s *SearchService) combineResults(semanticResults []models.SearchResult, textResults []models.SearchResult, limit int) []models.SearchResult {
+	semanticWeight, textWeight := fusionWeights(s.semanticWeight, s.textWeight)
+
+	// Create a map to deduplicate and combine scores
+	resultMap := make(map[uuid.UUID]*models.SearchResult)
+	order := []uuid.UUID{}
+	fused := func(result models.SearchResult) *models.SearchResult {
+		existing, ok := resultMap[result.Item.ID]
+		if !ok {
+			existing = &models.SearchResult{Item: result.Item, Explain: &models.SearchExplanation{}}
+			resultMap[result.Item.ID] = existing
+			order = append(order, result.Item.ID)
+		}
+		return existing
+	}
+
+	// Both lists arrive sorted best-first, so index is rank
+	for rank, result := range semanticResults {
+		existing := fused(result)
+		existing.SemanticScore = result.SimilarityScore
+		existing.SimilarityScore += semanticWeight / float64(rrfK+rank+1)
+		distance := db.Distance(result.SimilarityScore)
+		existing.Explain.SemanticDistance = &distance
+		existing.Explain.SemanticRank = rank + 1
+	}
+	for rank, result := range textResults {
+		existing := fused(result)
+		existing.TextScore = result.SimilarityScore
+		existing.SimilarityScore += textWeight / float64(rrfK+rank+1)
+		existing.Explain.TextRank = rank + 1
+		existing.Explain.InBoth = existing.Explain.SemanticRank > 0
+	}
+
+	maxScore := (semanticWeight + textWeight) / float64(rrfK+1)
+	results := make([]models.SearchResult, 0, len(order))
+	for _, id := range order {
+		result := resultMap[id]
+		result.SimilarityScore /= maxScore
+		result.Explain.FusedScore = result.SimilarityScore
+		results = append(results, *result)
+	}
+
+	// Stable so ties keep semantic-first order
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].SimilarityScore > results[j].SimilarityScore
+	})
 
 	// Limit results
 	if len(results) > limit {
@@ -258,15 +819,59 @@ func (s *SearchService) combineResults(semanticResults []models.SearchResult, te
 	return results
 }
 
+// fusionWeights sanitizes the configured fusion weights: negatives count as 0,
+// and if both are 0 the lists are weighted equally
+func fusionWeights(semantic, text float64) (float64, float64) {
+	semantic, text = math.Max(semantic, 0), math.Max(text, 0)
+	if semantic == 0 && text == 0 {
+		return 1, 1
+	}
+	return semantic, text
+}
+
 func (s *SearchService) applyPostFilters(results []models.SearchResult, filters *models.QueryFilters) []models.SearchResult {
-	if filters.PriceMax == nil && filters.PriceMin == nil {
+	if filters.PriceMax == nil && filters.PriceMin == nil && filters.Type == "" && filters.Category == "" &&
+		filters.DateFrom == nil && filters.DateTo == nil && len(filters.Tags) == 0 &&
+		len(filters.ExcludeTerms) == 0 && len(filters.Phrases) == 0 {
 		return results
 	}
 
 	filtered := []models.SearchResult{}
 	for _, result := range results {
-		// Extract price from content (for Amazon products)
-		price := extractPriceFromContent(result.Item.Content)
+		// Semantic hits are about the query's meaning rather than its words, so
+		// check they have every phrase and none of the excluded terms
+		text := searchableText(result.Item)
+		if !containsAll(text, filters.Phrases) || containsAny(text, filters.ExcludeTerms) {
+			continue
+		}
+		// Semantic results aren't filtered in SQL, so enforce the type filter here too
+		if filters.Type != "" && result.Item.Type != filters.Type {
+			continue
+		}
+		// Semantic results aren't filtered by tag either; like tags && in SQL, any tag matches
+		if len(filters.Tags) > 0 && !hasAnyTag(result.Item, filters.Tags) {
+			continue
+		}
+		// Matched ignoring case, like LOWER(category) in SQL
+		if filters.Category != "" && !strings.EqualFold(result.Item.Category, filters.Category) {
+			continue
+		}
+		// Semantic matches aren't date-filtered in ChromaDB (see searchWhere)
+		if filters.DateFrom != nil && result.Item.CreatedAt.Before(*filters.DateFrom) {
+			continue
+		}
+		if filters.DateTo != nil && result.Item.CreatedAt.After(*filters.DateTo) {
+			continue
+		}
+
+		// Text results were already price-filtered in SQL, but semantic results
+		// weren't. Items saved before prices were stored only have one in content.
+		var price float64
+		if result.Item.Price != nil {
+			price = *result.Item.Price
+		} else {
+			price = extractPriceFromContent(result.Item.Content)
+		}
 		if price == 0 {
 			// No price found, include it anyway
 			filtered = append(filtered, result)
@@ -287,13 +892,58 @@ func (s *SearchService) applyPostFilters(results []models.SearchResult, filters
 	return filtered
 }
 
-func extractPriceFromContent(content string) float64 {
-	// Try to extract price from content (e.g., "Price: $299.99")
-	priceRe := regexp.MustCompile(`(?i)price[:\s]+\$?(\d+(?:\.\d+)?)`)
-	if match := priceRe.FindStringSubmatch(content); match != nil {
-		var price float64
-		fmt.Sscanf(match[1], "%f", &price)
-		return price
+// hasAnyTag reports whether item has any of tags
+func hasAnyTag(item models.Item, tags []string) bool {
+	for _, tag := range item.Tags {
+		for _, want := range tags {
+			if tag == want {
+				return true
+			}
+		}
 	}
-	return 0
+	return false
+}
+
+// searchableText is the lowercased text the ILIKE checks in SearchItems look
+// through: an item's title, summary, content, and OCR text
+func searchableText(item models.Item) string {
+	return strings.ToLower(item.Title + "\n" + item.Summary + "\n" + item.Content + "\n" + item.OcrText)
+}
+
+// containsAll reports whether lowercased text contains every one of terms, ignoring case
+func containsAll(text string, terms []string) bool {
+	for _, term := range terms {
+		if !strings.Contains(text, strings.ToLower(term)) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAny reports whether lowercased text contains any of terms, ignoring case
+func containsAny(text string, terms []string) bool {
+	for _, term := range terms {
+		if strings.Contains(text, strings.ToLower(term)) {
+			return true
+		}
+	}
+	return false
+}
+
+// contentPriceRe matches a labeled price such as "Price: $1,299.99" or "price ₹ 2,499"
+var contentPriceRe = regexp.MustCompile(`(?i)price[:\s]+((?:US\$|CA\$|A\$|R\$|Rs\.|[$€£¥₹])?\s?\d[\d.,]*(?:\s?€)?)`)
+
+// priceFromContent scrapes a labeled price out of free text. It's a last
+// resort for items without a stored price.
+func priceFromContent(content string) (float64, string, bool) {
+	if match := contentPriceRe.FindStringSubmatch(content); match != nil {
+		return parseProductPrice(match[1])
+	}
+	return 0, "", false
+}
+
+// extractPriceFromContent returns the labeled price in content, or 0 if there is none
+func extractPriceFromContent(content string) float64 {
+	price, _, _ := priceFromContent(content)
+	return price
 }
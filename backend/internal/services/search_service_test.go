@@ -0,0 +1,415 @@
+package services
+
+import (
+	"math"
+	"synapse/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ranked returns search results for ids, best first, with descending scores
+func ranked(ids ...uuid.UUID) []models.SearchResult {
+	results := []models.SearchResult{}
+	for i, id := range ids {
+		results = append(results, models.SearchResult{
+			Item:            models.Item{ID: id},
+			SimilarityScore: 0.9 - float64(i)*0.1,
+		})
+	}
+	return results
+}
+
+// fusedIDs returns the IDs of results' items, in order
+func fusedIDs(results []models.SearchResult) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, result := range results {
+		ids = append(ids, result.Item.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []uuid.UUID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCombineResultsReciprocalRankFusion(t *testing.T) {
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	s := &SearchService{semanticWeight: 1, textWeight: 1}
+
+	tests := []struct {
+		name     string
+		semantic []models.SearchResult
+		text     []models.SearchResult
+		limit    int
+		want     []uuid.UUID
+	}{
+		// b is second in both lists, which beats first in only one;
+		// a and c tie and keep semantic-first order
+		{"found by both wins", ranked(a, b), ranked(c, b), 10, []uuid.UUID{b, a, c}},
+		{"semantic only", ranked(a, b, c), nil, 10, []uuid.UUID{a, b, c}},
+		{"text only", nil, ranked(c, a), 10, []uuid.UUID{c, a}},
+		{"limited", ranked(a, b), ranked(c, d), 3, []uuid.UUID{a, c, b}},
+		{"none", nil, nil, 10, []uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fusedIDs(s.combineResults(tt.semantic, tt.text, tt.limit))
+			if !equalIDs(got, tt.want) {
+				t.Errorf("combineResults order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombineResultsScoresAndExplain(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	s := &SearchService{semanticWeight: 1, textWeight: 1}
+
+	semantic := []models.SearchResult{
+		{Item: models.Item{ID: a}, SimilarityScore: 0.8},
+		{Item: models.Item{ID: b}, SimilarityScore: 0.6},
+	}
+	text := []models.SearchResult{
+		{Item: models.Item{ID: a}, SimilarityScore: 0.05},
+	}
+	results := s.combineResults(semantic, text, 10)
+	if len(results) != 2 {
+		t.Fatalf("combineResults returned %d results, want 2", len(results))
+	}
+
+	// First in both lists is normalized to exactly 1
+	top := results[0]
+	if top.Item.ID != a || math.Abs(top.SimilarityScore-1) > 1e-9 {
+		t.Errorf("top result = %v scoring %v, want %v scoring 1", top.Item.ID, top.SimilarityScore, a)
+	}
+	// The original scores are kept apart from the fused one
+	if top.SemanticScore != 0.8 || top.TextScore != 0.05 {
+		t.Errorf("SemanticScore, TextScore = %v, %v, want 0.8, 0.05", top.SemanticScore, top.TextScore)
+	}
+	if e := top.Explain; e.SemanticRank != 1 || e.TextRank != 1 || !e.InBoth || e.FusedScore != top.SimilarityScore {
+		t.Errorf("top Explain = %+v", *e)
+	}
+
+	// Second in the semantic list only: 1/(k+2) out of the 2/(k+1) maximum
+	second := results[1]
+	want := (1.0 / 62) / (2.0 / 61)
+	if math.Abs(second.SimilarityScore-want) > 1e-9 {
+		t.Errorf("second SimilarityScore = %v, want %v", second.SimilarityScore, want)
+	}
+	if e := second.Explain; e.SemanticRank != 2 || e.TextRank != 0 || e.InBoth {
+		t.Errorf("second Explain = %+v", *e)
+	}
+}
+
+func TestPriceFromContent(t *testing.T) {
+	tests := []struct {
+		content      string
+		wantPrice    float64
+		wantCurrency string
+		wantOK       bool
+	}{
+		{"Price: $1,299.99", 1299.99, "USD", true},
+		{"Great blender. price ₹ 2,499 with free delivery", 2499, "INR", true},
+		{"PRICE: 1.299,99 €", 1299.99, "EUR", true},
+		{"price 19.99", 19.99, "", true},
+		{"Costs about $40", 0, "", false},
+		{"No price here", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		price, currency, ok := priceFromContent(tt.content)
+		if price != tt.wantPrice || currency != tt.wantCurrency || ok != tt.wantOK {
+			t.Errorf("priceFromContent(%q) = %v, %q, %v, want %v, %q, %v", tt.content, price, currency, ok, tt.wantPrice, tt.wantCurrency, tt.wantOK)
+		}
+	}
+}
+
+func TestApplyPostFiltersPrice(t *testing.T) {
+	price := func(p float64) *float64 { return &p }
+	cheap := models.SearchResult{Item: models.Item{ID: uuid.New(), Price: price(15)}}
+	pricey := models.SearchResult{Item: models.Item{ID: uuid.New(), Price: price(250)}}
+	// Saved before prices were stored, so its price is read from the content
+	legacy := models.SearchResult{Item: models.Item{ID: uuid.New(), Content: "Price: $99.00"}}
+	// Most items aren't products, and are kept whatever the range
+	note := models.SearchResult{Item: models.Item{ID: uuid.New(), Content: "Meeting notes"}}
+	results := []models.SearchResult{cheap, pricey, legacy, note}
+
+	tests := []struct {
+		name    string
+		filters models.QueryFilters
+		want    []uuid.UUID
+	}{
+		{"no filter", models.QueryFilters{}, []uuid.UUID{cheap.Item.ID, pricey.Item.ID, legacy.Item.ID, note.Item.ID}},
+		{"max", models.QueryFilters{PriceMax: price(100)}, []uuid.UUID{cheap.Item.ID, legacy.Item.ID, note.Item.ID}},
+		{"min", models.QueryFilters{PriceMin: price(100)}, []uuid.UUID{pricey.Item.ID, note.Item.ID}},
+		{"range", models.QueryFilters{PriceMin: price(50), PriceMax: price(100)}, []uuid.UUID{legacy.Item.ID, note.Item.ID}},
+		{"bounds inclusive", models.QueryFilters{PriceMin: price(15), PriceMax: price(15)}, []uuid.UUID{cheap.Item.ID, note.Item.ID}},
+	}
+
+	s := &SearchService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fusedIDs(s.applyPostFilters(results, &tt.filters))
+			if !equalIDs(got, tt.want) {
+				t.Errorf("applyPostFilters = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPostFiltersExcludeTerms(t *testing.T) {
+	k8s := models.SearchResult{Item: models.Item{ID: uuid.New(), Title: "Kubernetes the hard way"}}
+	// Semantic hits about helm needn't have matched "kubernetes" as a keyword
+	helmChart := models.SearchResult{Item: models.Item{ID: uuid.New(), Title: "Writing charts", Content: "A Helm chart packages..."}}
+	helmSummary := models.SearchResult{Item: models.Item{ID: uuid.New(), Title: "Deploying apps", Summary: "Uses HELM to deploy"}}
+	screenshot := models.SearchResult{Item: models.Item{ID: uuid.New(), Title: "Screenshot", OcrText: "helm install nginx"}}
+	results := []models.SearchResult{k8s, helmChart, helmSummary, screenshot}
+
+	tests := []struct {
+		name    string
+		exclude []string
+		want    []uuid.UUID
+	}{
+		{"none", nil, []uuid.UUID{k8s.Item.ID, helmChart.Item.ID, helmSummary.Item.ID, screenshot.Item.ID}},
+		{"any field, any case", []string{"helm"}, []uuid.UUID{k8s.Item.ID}},
+		{"any term", []string{"hard", "charts"}, []uuid.UUID{helmSummary.Item.ID, screenshot.Item.ID}},
+	}
+
+	s := &SearchService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fusedIDs(s.applyPostFilters(results, &models.QueryFilters{ExcludeTerms: tt.exclude}))
+			if !equalIDs(got, tt.want) {
+				t.Errorf("applyPostFilters = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPostFiltersPhrases(t *testing.T) {
+	exact := models.SearchResult{Item: models.Item{ID: uuid.New(), Title: "Open Source licensing"}}
+	scattered := models.SearchResult{Item: models.Item{ID: uuid.New(), Title: "Source code open to all"}}
+	inOCR := models.SearchResult{Item: models.Item{ID: uuid.New(), Title: "Whiteboard", OcrText: "an open source tool"}}
+	results := []models.SearchResult{exact, scattered, inOCR}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []uuid.UUID
+	}{
+		{"without quotes", "open source", []uuid.UUID{exact.Item.ID, scattered.Item.ID, inOCR.Item.ID}},
+		{"with quotes", `"open source"`, []uuid.UUID{exact.Item.ID, inOCR.Item.ID}},
+		{"every phrase", `"open source" "licensing"`, []uuid.UUID{exact.Item.ID}},
+	}
+
+	s := &SearchService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters := ParseNaturalLanguageQuery(tt.query)
+			got := fusedIDs(s.applyPostFilters(results, filters))
+			if !equalIDs(got, tt.want) {
+				t.Errorf("applyPostFilters(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPostFiltersTags(t *testing.T) {
+	golang := models.SearchResult{Item: models.Item{ID: uuid.New(), Tags: []string{"golang", "concurrency"}}}
+	postgres := models.SearchResult{Item: models.Item{ID: uuid.New(), Tags: []string{"database"}}}
+	untagged := models.SearchResult{Item: models.Item{ID: uuid.New(), Tags: []string{}}}
+	results := []models.SearchResult{golang, postgres, untagged}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []uuid.UUID
+	}{
+		{"no tags", "concurrency", []uuid.UUID{golang.Item.ID, postgres.Item.ID, untagged.Item.ID}},
+		{"one tag", "tag:golang concurrency", []uuid.UUID{golang.Item.ID}},
+		// Like tags && in SQL, any of the tags matches
+		{"any tag", "tag:golang tag:database concurrency", []uuid.UUID{golang.Item.ID, postgres.Item.ID}},
+		{"hashtag", "#database", []uuid.UUID{postgres.Item.ID}},
+	}
+
+	s := &SearchService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fusedIDs(s.applyPostFilters(results, ParseNaturalLanguageQuery(tt.query)))
+			if !equalIDs(got, tt.want) {
+				t.Errorf("applyPostFilters(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPostFiltersCategory(t *testing.T) {
+	recipe := models.SearchResult{Item: models.Item{ID: uuid.New(), Category: "Food & Recipes"}}
+	legacy := models.SearchResult{Item: models.Item{ID: uuid.New(), Category: "food & recipes"}}
+	article := models.SearchResult{Item: models.Item{ID: uuid.New(), Category: "Articles & News"}}
+	uncategorized := models.SearchResult{Item: models.Item{ID: uuid.New()}}
+	results := []models.SearchResult{recipe, legacy, article, uncategorized}
+
+	tests := []struct {
+		name     string
+		category string
+		want     []uuid.UUID
+	}{
+		{"no category", "", []uuid.UUID{recipe.Item.ID, legacy.Item.ID, article.Item.ID, uncategorized.Item.ID}},
+		// Semantic hits aren't filtered in ChromaDB, and match ignoring case like the SQL filter
+		{"category", "Food & Recipes", []uuid.UUID{recipe.Item.ID, legacy.Item.ID}},
+		{"any case", "ARTICLES & NEWS", []uuid.UUID{article.Item.ID}},
+	}
+
+	s := &SearchService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fusedIDs(s.applyPostFilters(results, &models.QueryFilters{Category: tt.category}))
+			if !equalIDs(got, tt.want) {
+				t.Errorf("applyPostFilters(%q) = %v, want %v", tt.category, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoostExactMatchesPhraseInTitle(t *testing.T) {
+	inBody := models.SearchResult{Item: models.Item{ID: uuid.New(), Title: "Course notes", Content: "machine learning basics"}, SimilarityScore: 0.5}
+	inTitle := models.SearchResult{Item: models.Item{ID: uuid.New(), Title: "Machine learning basics"}, SimilarityScore: 0.45}
+
+	s := &SearchService{}
+	got := s.boostExactMatches([]models.SearchResult{inBody, inTitle}, "unrelated", []string{"machine learning"})
+	if want := []uuid.UUID{inTitle.Item.ID, inBody.Item.ID}; !equalIDs(fusedIDs(got), want) {
+		t.Errorf("boostExactMatches order = %v, want %v", fusedIDs(got), want)
+	}
+	if math.Abs(got[0].SimilarityScore-0.55) > 1e-9 || math.Abs(got[1].SimilarityScore-0.5) > 1e-9 {
+		t.Errorf("boostExactMatches scores = %v, %v, want 0.55, 0.5", got[0].SimilarityScore, got[1].SimilarityScore)
+	}
+}
+
+func TestCombineResultsWeights(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	// The lists disagree: semantic ranks a first, text ranks b first
+	semantic, text := ranked(a, b), ranked(b, a)
+
+	tests := []struct {
+		name                       string
+		semanticWeight, textWeight float64
+		want                       []uuid.UUID
+	}{
+		// Equal weights tie, and ties keep semantic-first order
+		{"equal", 1, 1, []uuid.UUID{a, b}},
+		{"lean semantic", 2, 1, []uuid.UUID{a, b}},
+		{"lean text", 1, 2, []uuid.UUID{b, a}},
+		{"text only", 0, 1, []uuid.UUID{b, a}},
+		{"negative counts as zero", -1, 1, []uuid.UUID{b, a}},
+		{"both zero weigh equally", 0, 0, []uuid.UUID{a, b}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SearchService{semanticWeight: tt.semanticWeight, textWeight: tt.textWeight}
+			results := s.combineResults(semantic, text, 10)
+			if got := fusedIDs(results); !equalIDs(got, tt.want) {
+				t.Errorf("combineResults order = %v, want %v", got, tt.want)
+			}
+			// Whatever the weights, scores stay in (0, 1]
+			for _, result := range results {
+				if result.SimilarityScore <= 0 || result.SimilarityScore > 1+1e-9 {
+					t.Errorf("fused score %v outside (0, 1]", result.SimilarityScore)
+				}
+			}
+		})
+	}
+}
+
+func TestFusionWeights(t *testing.T) {
+	tests := []struct {
+		semantic, text         float64
+		wantSemantic, wantText float64
+	}{
+		{1, 1, 1, 1},
+		{0.5, 2, 0.5, 2},
+		{-1, 1, 0, 1},
+		{1, -3, 1, 0},
+		{0, 0, 1, 1},
+		{-1, -1, 1, 1},
+	}
+
+	for _, tt := range tests {
+		semantic, text := fusionWeights(tt.semantic, tt.text)
+		if semantic != tt.wantSemantic || text != tt.wantText {
+			t.Errorf("fusionWeights(%v, %v) = %v, %v, want %v, %v", tt.semantic, tt.text, semantic, text, tt.wantSemantic, tt.wantText)
+		}
+	}
+}
+
+func TestMeanEmbedding(t *testing.T) {
+	tests := []struct {
+		name       string
+		embeddings [][]float32
+		want       []float32
+	}{
+		{"none", nil, nil},
+		{"one", [][]float32{{1, 2, 3}}, []float32{1, 2, 3}},
+		{"average", [][]float32{{1, 0, 0}, {0, 1, 0}, {0, 0, 4}, {3, 2, 0}}, []float32{1, 0.75, 1}},
+		// A vector from another model can't be averaged in
+		{"other dimension skipped", [][]float32{{2, 4}, {1, 2, 3}, {0, 0}}, []float32{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := meanEmbedding(tt.embeddings)
+			if len(got) != len(tt.want) || (got == nil) != (tt.want == nil) {
+				t.Fatalf("meanEmbedding = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if math.Abs(float64(got[i]-tt.want[i])) > 1e-6 {
+					t.Errorf("meanEmbedding = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSortResults(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	// In score order: the best match is neither first by date nor by title
+	banana := models.Item{ID: uuid.New(), Title: "banana bread", CreatedAt: day(2)}
+	apple := models.Item{ID: uuid.New(), Title: "Apple pie", CreatedAt: day(1)}
+	cherry := models.Item{ID: uuid.New(), Title: "Cherry tart", CreatedAt: day(3)}
+	// Same title and date as banana, so it stays after it
+	banana2 := models.Item{ID: uuid.New(), Title: "Banana bread", CreatedAt: day(2)}
+
+	tests := []struct {
+		sortBy string
+		want   []uuid.UUID
+	}{
+		{models.SortNewest, []uuid.UUID{cherry.ID, banana.ID, banana2.ID, apple.ID}},
+		{models.SortOldest, []uuid.UUID{apple.ID, banana.ID, banana2.ID, cherry.ID}},
+		// Case-insensitive
+		{models.SortTitle, []uuid.UUID{apple.ID, banana.ID, banana2.ID, cherry.ID}},
+		{models.SortRelevance, []uuid.UUID{banana.ID, apple.ID, cherry.ID, banana2.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			results := []models.SearchResult{{Item: banana}, {Item: apple}, {Item: cherry}, {Item: banana2}}
+			sortResults(results, tt.sortBy)
+			if got := fusedIDs(results); !equalIDs(got, tt.want) {
+				t.Errorf("sortResults(%q) = %v, want %v", tt.sortBy, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"synapse/internal/models"
+	"unicode/utf8"
+)
+
+// snippetLength is the approximate length of a search result snippet, in bytes
+const snippetLength = 200
+
+// addSnippets sets each result's Snippet from the search terms
+func addSnippets(results []models.SearchResult, searchTerms string) {
+	termsRe := snippetTermsRegexp(searchTerms)
+	for i := range results {
+		results[i].Snippet = buildSnippet(&results[i].Item, termsRe)
+	}
+}
+
+// snippetTermsRegexp builds a case-insensitive regexp matching any search term, or nil if there are none
+func snippetTermsRegexp(searchTerms string) *regexp.Regexp {
+	var terms []string
+	for _, term := range strings.Fields(searchTerms) {
+		term = strings.Trim(term, ".,!?;:()[]{}\"'")
+		if len(term) < 2 {
+			continue
+		}
+		terms = append(terms, regexp.QuoteMeta(term))
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)` + strings.Join(terms, "|"))
+}
+
+// buildSnippet returns an HTML excerpt centered on the first term match in the
+// item's content or OCR text, with matches wrapped in <mark>. Pure semantic
+// hits with no textual match fall back to the start of the summary.
+func buildSnippet(item *models.Item, termsRe *regexp.Regexp) string {
+	if termsRe != nil {
+		for _, text := range []string{item.Content, item.OcrText, item.Summary} {
+			text = strings.Join(strings.Fields(text), " ")
+			if loc := termsRe.FindStringIndex(text); loc != nil {
+				start, end := snippetWindow(text, loc[0], loc[1])
+				return markSnippet(text, start, end, termsRe)
+			}
+		}
+	}
+
+	summary := strings.Join(strings.Fields(item.Summary), " ")
+	if summary == "" {
+		return ""
+	}
+	_, end := snippetWindow(summary, 0, 0)
+	return markSnippet(summary, 0, end, nil)
+}
+
+// snippetWindow picks a ~snippetLength window around [matchStart, matchEnd),
+// widened to word boundaries
+func snippetWindow(text string, matchStart, matchEnd int) (int, int) {
+	start := matchStart - (snippetLength-(matchEnd-matchStart))/2
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetLength
+	if end > len(text) {
+		end = len(text)
+		start = end - snippetLength
+		if start < 0 {
+			start = 0
+		}
+	}
+
+	// Don't cut words in half, but never cut into the match itself
+	if start > 0 {
+		if i := strings.IndexByte(text[start:matchStart], ' '); i >= 0 {
+			start += i + 1
+		}
+	}
+	if end < len(text) {
+		if i := strings.LastIndexByte(text[matchEnd:end], ' '); i >= 0 {
+			end = matchEnd + i
+		}
+	}
+
+	// Byte offsets may land mid-rune if no space was found
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+	return start, end
+}
+
+// markSnippet HTML-escapes text[start:end], wrapping term matches in <mark> and
+// adding ellipses where the excerpt was cut
+func markSnippet(text string, start, end int, termsRe *regexp.Regexp) string {
+	excerpt := text[start:end]
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	last := 0
+	if termsRe != nil {
+		for _, loc := range termsRe.FindAllStringIndex(excerpt, -1) {
+			b.WriteString(html.EscapeString(excerpt[last:loc[0]]))
+			b.WriteString("<mark>")
+			b.WriteString(html.EscapeString(excerpt[loc[0]:loc[1]]))
+			b.WriteString("</mark>")
+			last = loc[1]
+		}
+	}
+	b.WriteString(html.EscapeString(excerpt[last:]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
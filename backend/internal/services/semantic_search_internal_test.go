@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"synapse/internal/db"
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeSemanticBackend serves the query embedding and a ChromaDB query that
+// returns one match per distance
+func fakeSemanticBackend(t *testing.T, distances []float64) {
+	t.Helper()
+	ids := make([]string, len(distances))
+	for i := range ids {
+		ids[i] = uuid.NewString()
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/embeddings":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": []map[string]interface{}{{"embedding": []float32{1, 0, 0}}},
+			})
+		case "/api/v1/collections/" + db.CollectionName() + "/query":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ids":       [][]string{ids},
+				"distances": [][]float64{distances},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("AI_PROVIDER", "claude")
+	t.Setenv("ANTHROPIC_AUTH_TOKEN", "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", server.URL)
+	previous := db.Chroma
+	t.Cleanup(func() { db.Chroma = previous })
+	db.Chroma = &db.ChromaClient{BaseURL: server.URL, Client: server.Client()}
+}
+
+func TestSemanticSearchDropsDissimilarMatches(t *testing.T) {
+	tests := []struct {
+		name          string
+		minSimilarity string // SEARCH_MIN_SIMILARITY, "" for the default
+		distances     []float64
+	}{
+		// Similarities 0.1 and -0.5 are both under the default 0.2
+		{"default", "", []float64{0.9, 1.5}},
+		{"strict", "0.8", []float64{0.3}},
+		// Opposite vectors are dropped even with the threshold off
+		{"off", "0", []float64{1.5, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.minSimilarity != "" {
+				t.Setenv("SEARCH_MIN_SIMILARITY", tt.minSimilarity)
+			}
+			fakeSemanticBackend(t, tt.distances)
+			// No match survives, so the items are never loaded from PostgreSQL
+			ai := NewAIService(nil, nil)
+			guard := &EmbeddingGuard{aiService: ai, config: &models.EmbeddingConfig{Model: ai.EmbeddingModel(), Dimension: 3}}
+			s := NewSearchService(ai, nil, nil, guard, nil, nil)
+
+			results, err := s.semanticSearch(context.Background(), uuid.New(), "xylophone", nil, 10)
+			if err != nil {
+				t.Fatalf("semanticSearch: %v", err)
+			}
+			if len(results) != 0 {
+				t.Errorf("semanticSearch returned %d results, want none", len(results))
+			}
+		})
+	}
+}
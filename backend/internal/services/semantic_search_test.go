@@ -0,0 +1,331 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"synapse/internal/db"
+	"synapse/internal/models"
+	"synapse/internal/services"
+	"synapse/internal/services/servicestest"
+
+	"github.com/google/uuid"
+)
+
+func TestSemanticSearchMinSimilarity(t *testing.T) {
+	tests := []struct {
+		name          string
+		minSimilarity string // SEARCH_MIN_SIMILARITY, "" for the default
+		wantMiddling  bool
+	}{
+		{"default", "", true},
+		{"strict", "0.8", false},
+		{"off", "0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.minSimilarity != "" {
+				t.Setenv("SEARCH_MIN_SIMILARITY", tt.minSimilarity)
+			}
+			s := newTestStack(t)
+			userID := servicestest.NewUser(t, s.pool)
+
+			// Similarities to the query: 1, about 0.71, 0, and -1
+			nearest := s.save(t, userID, "Alpha", "First note.", []float32{1, 0, 0})
+			middling := s.save(t, userID, "Beta", "Second note.", []float32{1, 1, 0})
+			s.save(t, userID, "Gamma", "Third note.", []float32{0, 0, 1})
+			s.save(t, userID, "Delta", "Fourth note.", []float32{-1, 0, 0})
+
+			// No item's text matches, so results come from semantic search alone
+			s.ai.Embedding = []float32{1, 0, 0}
+			results, err := s.search.Search(context.Background(), userID, "xylophone", nil, "", 10, 0)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+
+			want := []uuid.UUID{nearest.ID}
+			if tt.wantMiddling {
+				want = append(want, middling.ID)
+			}
+			got := []uuid.UUID{}
+			for _, result := range results {
+				got = append(got, result.Item.ID)
+			}
+			// Unrelated and opposite items are never returned, whatever the threshold
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Search = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRelatedItems(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+
+	item := s.save(t, userID, "Channels", "Note.", []float32{1, 0, 0})
+	nearest := s.save(t, userID, "Select", "Note.", []float32{1, 0.1, 0})
+	middling := s.save(t, userID, "Mutexes", "Note.", []float32{1, 1, 0})
+	s.save(t, userID, "Sourdough", "Recipe.", []float32{-1, 0, 0})
+	s.save(t, servicestest.NewUser(t, s.pool), "Goroutines", "Their note.", []float32{1, 0, 0})
+
+	// relatedIDs returns the IDs of the items related to item
+	relatedIDs := func(limit int) []uuid.UUID {
+		t.Helper()
+		results, err := s.search.RelatedItems(ctx, userID, item.ID, limit)
+		if err != nil {
+			t.Fatalf("RelatedItems: %v", err)
+		}
+		got := []uuid.UUID{}
+		for _, result := range results {
+			got = append(got, result.Item.ID)
+		}
+		return got
+	}
+
+	// The item itself, another user's items, and opposite items are left out
+	s.ai.Embedding = []float32{0, 0, 1}
+	embeddings := s.ai.Called("GenerateEmbedding")
+	if got, want := relatedIDs(10), []uuid.UUID{nearest.ID, middling.ID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RelatedItems = %v, want %v", got, want)
+	}
+	if got, want := relatedIDs(1), []uuid.UUID{nearest.ID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RelatedItems limited to 1 = %v, want %v", got, want)
+	}
+	if n := s.ai.Called("GenerateEmbedding"); n != embeddings {
+		t.Errorf("GenerateEmbedding called %d more times, want the stored vector used", n-embeddings)
+	}
+
+	// Without a stored vector, one is generated from the item's content
+	if err := db.Chroma.DeleteEmbedding(db.CollectionName(), item.EmbeddingID); err != nil {
+		t.Fatalf("DeleteEmbedding: %v", err)
+	}
+	s.ai.Embedding = []float32{1, 0, 0}
+	if got, want := relatedIDs(10), []uuid.UUID{nearest.ID, middling.ID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RelatedItems without a stored vector = %v, want %v", got, want)
+	}
+}
+
+func TestRecommendResurfacesOlderItems(t *testing.T) {
+	// The two newest items are the feed's seeds
+	t.Setenv("RECOMMEND_SEED_ITEMS", "2")
+	s := newTestStack(t)
+	userID := servicestest.NewUser(t, s.pool)
+
+	related := s.save(t, userID, "Goroutines", "Old note.", []float32{1, 0.2, 0})
+	s.save(t, userID, "Sourdough", "Old recipe.", []float32{0, 0, 1})
+	s.save(t, userID, "Channels", "New note.", []float32{1, 0, 0})
+	s.save(t, userID, "Select", "New note.", []float32{1, 0.1, 0})
+	// Another user's items are never recommended
+	s.save(t, servicestest.NewUser(t, s.pool), "Mutexes", "Their note.", []float32{1, 0.1, 0})
+
+	results, err := s.search.Recommend(context.Background(), userID, 10)
+	if err != nil {
+		t.Fatalf("Recommend: %v", err)
+	}
+
+	got := []uuid.UUID{}
+	for _, result := range results {
+		got = append(got, result.Item.ID)
+	}
+	// The seeds themselves and the unrelated recipe are left out
+	if want := []uuid.UUID{related.ID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Recommend = %v, want %v", got, want)
+	}
+}
+
+func TestRecommendUsesAtLeastOneSeed(t *testing.T) {
+	t.Setenv("RECOMMEND_SEED_ITEMS", "0")
+	s := newTestStack(t)
+	userID := servicestest.NewUser(t, s.pool)
+
+	related := s.save(t, userID, "Goroutines", "Old note.", []float32{1, 0.2, 0})
+	s.save(t, userID, "Channels", "New note.", []float32{1, 0, 0})
+
+	results, err := s.search.Recommend(context.Background(), userID, 10)
+	if err != nil {
+		t.Fatalf("Recommend: %v", err)
+	}
+	// The newest item is still the seed
+	if len(results) != 1 || results[0].Item.ID != related.ID {
+		t.Errorf("Recommend returned %d results, want just %q", len(results), related.Title)
+	}
+}
+
+func TestPreviewSearchCards(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+
+	s.ai.Embedding = []float32{1, 0, 0}
+	link, err := s.items.CreateItem(ctx, userID, &models.CreateItemRequest{
+		Title:          "Old title",
+		Content:        "Notes on the page.",
+		SourceURL:      "https://example.com/page",
+		Type:           "url",
+		AllowDuplicate: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	note := s.save(t, userID, "Bread", "Knead for ten minutes.", []float32{0.9, 0.1, 0})
+	// A vector stored before card fields existed is drawn from PostgreSQL
+	legacy := s.save(t, userID, "Legacy", "Saved long ago.", []float32{0.8, 0.2, 0})
+	s.chroma.Add(legacy.EmbeddingID, []float32{0.8, 0.2, 0}, map[string]interface{}{"user_id": userID.String()})
+	// Another user's match never shows
+	s.save(t, servicestest.NewUser(t, s.pool), "Not yours", "Someone else's note.", []float32{1, 0, 0})
+
+	// Edits that keep the vector still reach the cards
+	title := "Sourdough bread"
+	if _, err := s.items.UpdateItem(ctx, userID, note.ID, &models.UpdateItemRequest{Title: &title}); err != nil {
+		t.Fatalf("UpdateItem: %v", err)
+	}
+	s.metadata.LinkStatus, s.metadata.LinkOK = 200, true
+	s.metadata.Title, s.metadata.ImageURL = "Fresh title", "https://example.com/fresh.png"
+	if _, err := s.items.RefreshMetadata(ctx, userID, link.ID); err != nil {
+		t.Fatalf("RefreshMetadata: %v", err)
+	}
+
+	s.ai.Embedding = []float32{1, 0, 0}
+	results, err := s.search.PreviewSearch(ctx, userID, "bread", 10)
+	if err != nil {
+		t.Fatalf("PreviewSearch: %v", err)
+	}
+	cards := make(map[uuid.UUID]models.Item)
+	for _, result := range results {
+		cards[result.Item.ID] = result.Item
+	}
+	if len(results) != 3 || len(cards) != 3 {
+		t.Fatalf("PreviewSearch returned %d results, want the user's 3", len(results))
+	}
+
+	if card := cards[link.ID]; card.Title != "Fresh title" || card.ImageURL != "https://example.com/fresh.png" || card.Type != "url" {
+		t.Errorf("refreshed link card = %+v, want the refreshed title and image", card)
+	}
+	// Cards come from the vector's metadata, so content isn't loaded
+	if card := cards[note.ID]; card.Title != title || card.Content != "" || card.CreatedAt.IsZero() {
+		t.Errorf("edited note card = %+v, want the new title from metadata", card)
+	}
+	if card := cards[legacy.ID]; card.Title != "Legacy" || card.Content != "Saved long ago." {
+		t.Errorf("legacy card = %+v, want the full item from PostgreSQL", card)
+	}
+}
+
+func TestSearchPageResumesAfterPreviousPage(t *testing.T) {
+	s := newTestStack(t)
+	ctx := context.Background()
+	userID := servicestest.NewUser(t, s.pool)
+
+	// Ranked by similarity to the query, most similar first
+	var ranked []uuid.UUID
+	for i, y := range []float32{0, 0.2, 0.4, 0.6, 0.8} {
+		item := s.save(t, userID, "Note "+string(rune('A'+i)), "Nothing to match.", []float32{1, y, 0})
+		ranked = append(ranked, item.ID)
+	}
+	s.ai.Embedding = []float32{1, 0, 0}
+
+	page := func(cursor string) *models.SearchPage {
+		t.Helper()
+		p, err := s.search.SearchPage(ctx, userID, "xylophone", nil, "", 2, cursor)
+		if err != nil {
+			t.Fatalf("SearchPage(%q): %v", cursor, err)
+		}
+		return p
+	}
+	ids := func(p *models.SearchPage) []uuid.UUID {
+		got := []uuid.UUID{}
+		for _, result := range p.Results {
+			got = append(got, result.Item.ID)
+		}
+		return got
+	}
+
+	first := page("")
+	if want := ranked[:2]; !reflect.DeepEqual(ids(first), want) || first.NextCursor == "" {
+		t.Fatalf("first page = %v (next %q), want %v and a cursor", ids(first), first.NextCursor, want)
+	}
+
+	// A new best match shifts everything down one place; the next page still
+	// starts right after the last result shown rather than at its old offset
+	s.save(t, userID, "Newcomer", "Nothing to match either.", []float32{1, 0, 0})
+	s.ai.Embedding = []float32{1, 0, 0}
+	second := page(first.NextCursor)
+	if want := ranked[2:4]; !reflect.DeepEqual(ids(second), want) {
+		t.Errorf("second page = %v, want %v", ids(second), want)
+	}
+
+	third := page(second.NextCursor)
+	if want := ranked[4:]; !reflect.DeepEqual(ids(third), want) || third.NextCursor != "" {
+		t.Errorf("last page = %v (next %q), want %v and no cursor", ids(third), third.NextCursor, want)
+	}
+}
+
+func TestSearchPageCursorLimits(t *testing.T) {
+	ai := &servicestest.FakeAI{Embedding: []float32{1, 0, 0}}
+	// Neither case gets as far as searching, so nothing else is needed
+	s := services.NewSearchService(ai, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	if _, err := s.SearchPage(ctx, uuid.New(), "bread", nil, "", 20, "not a cursor!"); !errors.Is(err, models.ErrInvalidCursor) {
+		t.Errorf("malformed cursor: err = %v, want ErrInvalidCursor", err)
+	}
+
+	deep := models.SearchCursor{Offset: 500, ID: uuid.New()}.Encode()
+	page, err := s.SearchPage(ctx, uuid.New(), "bread", nil, "", 20, deep)
+	if err != nil {
+		t.Fatalf("SearchPage at maxSearchDepth: %v", err)
+	}
+	if len(page.Results) != 0 || page.NextCursor != "" {
+		t.Errorf("page at maxSearchDepth = %d results (next %q), want an empty last page", len(page.Results), page.NextCursor)
+	}
+	if n := len(ai.Calls); n != 0 {
+		t.Errorf("paging past maxSearchDepth made %d AI calls, want none", n)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	t.Setenv("SEARCH_SUGGEST_MIN_RESULTS", "2")
+	s := newTestStack(t)
+	userID := servicestest.NewUser(t, s.pool)
+	s.save(t, userID, "Goroutines", "Notes on goroutines.", []float32{1, 0, 0})
+	s.save(t, userID, "Channels", "Goroutines talk over channels.", []float32{1, 0.1, 0})
+	s.ai.Suggestion = "sourdough bread"
+
+	tests := []struct {
+		name      string
+		query     string
+		embedding []float32
+		want      string
+	}{
+		{"enough results", "goroutines", []float32{1, 0, 0}, ""},
+		{"too few results", "sourdogh", []float32{0, 0, 1}, "sourdough bread"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.ai.Embedding = tt.embedding
+			s.ai.Calls = nil
+
+			got, err := s.search.Suggest(context.Background(), userID, tt.query)
+			if err != nil {
+				t.Fatalf("Suggest: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Suggest(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+			if n := s.ai.Called("SuggestQuery"); (n == 1) != (tt.want != "") {
+				t.Errorf("SuggestQuery called %d times", n)
+			}
+			// The results are only counted
+			for _, method := range []string{"EnhanceSearchQuery", "ReRankSearchResults"} {
+				if n := s.ai.Called(method); n != 0 {
+					t.Errorf("%s called %d times, want none", method, n)
+				}
+			}
+		})
+	}
+}
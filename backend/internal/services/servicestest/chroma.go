@@ -0,0 +1,350 @@
+package servicestest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"synapse/internal/db"
+
+	"github.com/google/uuid"
+)
+
+// FakeChroma is an in-memory ChromaDB serving the parts of the v1 HTTP API
+// db.ChromaClient uses: collection lookup, add, upsert, update, delete, get,
+// and query with where filters. Distances are cosine distances, like collections created by
+// db.CreateCollection.
+type FakeChroma struct {
+	mu sync.Mutex
+	// collections maps collection name to vector ID to vector
+	collections map[string]map[string]*fakeVector
+}
+
+type fakeVector struct {
+	embedding []float32
+	metadata  map[string]interface{}
+}
+
+// NewChroma starts a FakeChroma and points db.Chroma at it until the test ends.
+// CHROMA_COLLECTION is set to a collection of the test's own, so services
+// created afterwards don't share its recorded embedding config with others.
+func NewChroma(t testing.TB) *FakeChroma {
+	t.Helper()
+	t.Setenv("CHROMA_COLLECTION", "servicestest_"+strings.ReplaceAll(uuid.NewString(), "-", ""))
+	f := &FakeChroma{collections: make(map[string]map[string]*fakeVector)}
+	srv := httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(srv.Close)
+
+	previous := db.Chroma
+	t.Cleanup(func() { db.Chroma = previous })
+	t.Setenv("CHROMA_URL", srv.URL)
+	if err := db.InitChroma(nil); err != nil {
+		t.Fatalf("servicestest: InitChroma: %v", err)
+	}
+	return f
+}
+
+// Add stores a vector in the items collection, as if it had been saved earlier
+func (f *FakeChroma) Add(id string, embedding []float32, metadata map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.collection(db.CollectionName())[id] = &fakeVector{embedding: embedding, metadata: normalizeMetadata(metadata)}
+}
+
+// Has reports whether the items collection holds a vector under id
+func (f *FakeChroma) Has(id string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.collection(db.CollectionName())[id]
+	return ok
+}
+
+// Metadata returns the metadata stored with id in the items collection, nil if there's no such vector
+func (f *FakeChroma) Metadata(id string) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.collection(db.CollectionName())[id]; ok {
+		return v.metadata
+	}
+	return nil
+}
+
+// collection returns the named collection, creating it; f.mu must be held
+func (f *FakeChroma) collection(name string) map[string]*fakeVector {
+	c, ok := f.collections[name]
+	if !ok {
+		c = make(map[string]*fakeVector)
+		f.collections[name] = c
+	}
+	return c
+}
+
+// chromaRequest is the union of the request bodies the fake accepts
+type chromaRequest struct {
+	IDs             []string                 `json:"ids"`
+	Embeddings      [][]float32              `json:"embeddings"`
+	Metadatas       []map[string]interface{} `json:"metadatas"`
+	QueryEmbeddings [][]float32              `json:"query_embeddings"`
+	NResults        int                      `json:"n_results"`
+	Where           map[string]interface{}   `json:"where"`
+}
+
+func (f *FakeChroma) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+	if strings.HasSuffix(r.URL.Path, "/heartbeat") {
+		writeJSON(w, map[string]interface{}{"nanosecond heartbeat": 1})
+		return
+	}
+	if path == "/collections" {
+		writeJSON(w, map[string]interface{}{})
+		return
+	}
+
+	// /collections/{name}/{operation}
+	parts := strings.Split(strings.TrimPrefix(path, "/collections/"), "/")
+	if len(parts) == 1 && r.Method == http.MethodGet {
+		writeJSON(w, map[string]interface{}{"name": parts[0], "metadata": map[string]interface{}{"hnsw:space": "cosine"}})
+		return
+	}
+	if len(parts) != 2 || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	var req chromaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	vectors := f.collection(parts[0])
+
+	switch parts[1] {
+	case "add", "upsert":
+		if len(req.Embeddings) != len(req.IDs) {
+			http.Error(w, "ids and embeddings differ in length", http.StatusBadRequest)
+			return
+		}
+		for i, id := range req.IDs {
+			if _, exists := vectors[id]; exists && parts[1] == "add" {
+				continue
+			}
+			vectors[id] = &fakeVector{embedding: req.Embeddings[i], metadata: metadataAt(req.Metadatas, i)}
+		}
+		writeJSON(w, true)
+	case "update":
+		for i, id := range req.IDs {
+			if v, ok := vectors[id]; ok {
+				v.metadata = metadataAt(req.Metadatas, i)
+			}
+		}
+		writeJSON(w, true)
+	case "delete":
+		for _, id := range req.IDs {
+			delete(vectors, id)
+		}
+		writeJSON(w, req.IDs)
+	case "get":
+		ids := []string{}
+		embeddings := [][]float32{}
+		metadatas := []map[string]interface{}{}
+		for _, id := range req.IDs {
+			if v, ok := vectors[id]; ok {
+				ids = append(ids, id)
+				embeddings = append(embeddings, v.embedding)
+				metadatas = append(metadatas, v.metadata)
+			}
+		}
+		writeJSON(w, map[string]interface{}{"ids": ids, "embeddings": embeddings, "metadatas": metadatas})
+	case "query":
+		if len(req.QueryEmbeddings) != 1 {
+			http.Error(w, "expected one query embedding", http.StatusBadRequest)
+			return
+		}
+		ids, distances, metadatas, err := query(vectors, req.QueryEmbeddings[0], req.NResults, req.Where)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"ids":       [][]string{ids},
+			"distances": [][]float64{distances},
+			"metadatas": [][]map[string]interface{}{metadatas},
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// query returns the n vectors nearest to embedding among those matching where
+func query(vectors map[string]*fakeVector, embedding []float32, n int, where map[string]interface{}) ([]string, []float64, []map[string]interface{}, error) {
+	type match struct {
+		id       string
+		distance float64
+		metadata map[string]interface{}
+	}
+	matches := []match{}
+	for id, v := range vectors {
+		if len(v.embedding) != len(embedding) {
+			return nil, nil, nil, fmt.Errorf("embedding dimension %d does not match collection dimensionality %d", len(embedding), len(v.embedding))
+		}
+		ok, err := matchesWhere(v.metadata, where)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if ok {
+			matches = append(matches, match{id: id, distance: cosineDistance(embedding, v.embedding), metadata: v.metadata})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].id < matches[j].id
+	})
+	if n > 0 && len(matches) > n {
+		matches = matches[:n]
+	}
+
+	ids := make([]string, len(matches))
+	distances := make([]float64, len(matches))
+	metadatas := make([]map[string]interface{}, len(matches))
+	for i, m := range matches {
+		ids[i], distances[i], metadatas[i] = m.id, m.distance, m.metadata
+	}
+	return ids, distances, metadatas, nil
+}
+
+// matchesWhere evaluates a ChromaDB where filter: {"field": value},
+// {"field": {"$op": value}}, {"$and": [...]}, or {"$or": [...]}
+func matchesWhere(metadata map[string]interface{}, where map[string]interface{}) (bool, error) {
+	for key, cond := range where {
+		switch key {
+		case "$and", "$or":
+			clauses, ok := cond.([]interface{})
+			if !ok {
+				return false, fmt.Errorf("%s expects a list", key)
+			}
+			matchedAny := false
+			for _, clause := range clauses {
+				clauseMap, ok := clause.(map[string]interface{})
+				if !ok {
+					return false, fmt.Errorf("%s expects a list of filters", key)
+				}
+				matched, err := matchesWhere(metadata, clauseMap)
+				if err != nil {
+					return false, err
+				}
+				if key == "$and" && !matched {
+					return false, nil
+				}
+				matchedAny = matchedAny || matched
+			}
+			if key == "$or" && !matchedAny {
+				return false, nil
+			}
+		default:
+			ops, ok := cond.(map[string]interface{})
+			if !ok {
+				ops = map[string]interface{}{"$eq": cond}
+			}
+			value, present := metadata[key]
+			for op, operand := range ops {
+				matched, err := compare(value, present, op, operand)
+				if err != nil {
+					return false, err
+				}
+				if !matched {
+					return false, nil
+				}
+			}
+		}
+	}
+	return true, nil
+}
+
+// compare applies one where operator to a metadata value; absent fields match nothing
+func compare(value interface{}, present bool, op string, operand interface{}) (bool, error) {
+	if !present {
+		return false, nil
+	}
+	switch op {
+	case "$eq":
+		return value == operand, nil
+	case "$ne":
+		return value != operand, nil
+	case "$in", "$nin":
+		list, ok := operand.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s expects a list", op)
+		}
+		found := false
+		for _, item := range list {
+			found = found || value == item
+		}
+		return found == (op == "$in"), nil
+	case "$gt", "$gte", "$lt", "$lte":
+		a, aok := value.(float64)
+		b, bok := operand.(float64)
+		if !aok || !bok {
+			return false, fmt.Errorf("%s expects numbers", op)
+		}
+		switch op {
+		case "$gt":
+			return a > b, nil
+		case "$gte":
+			return a >= b, nil
+		case "$lt":
+			return a < b, nil
+		default:
+			return a <= b, nil
+		}
+	}
+	return false, fmt.Errorf("unsupported where operator %s", op)
+}
+
+// cosineDistance is 1 - cosine similarity, ChromaDB's "cosine" space
+func cosineDistance(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 1
+	}
+	return 1 - dot/(math.Sqrt(normA)*math.Sqrt(normB))
+}
+
+// metadataAt returns the i'th metadata, or nil when there isn't one
+func metadataAt(metadatas []map[string]interface{}, i int) map[string]interface{} {
+	if i < len(metadatas) {
+		return metadatas[i]
+	}
+	return nil
+}
+
+// normalizeMetadata round-trips metadata through JSON, so values stored with
+// Add compare the way values sent over HTTP do (numbers as float64)
+func normalizeMetadata(metadata map[string]interface{}) map[string]interface{} {
+	if metadata == nil {
+		return nil
+	}
+	data, _ := json.Marshal(metadata)
+	var normalized map[string]interface{}
+	json.Unmarshal(data, &normalized)
+	return normalized
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,100 @@
+package servicestest
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"synapse/internal/db"
+)
+
+func TestFakeChromaThroughClient(t *testing.T) {
+	f := NewChroma(t)
+	collection := db.CollectionName()
+
+	add := func(id string, embedding []float32, metadata map[string]interface{}) {
+		t.Helper()
+		if err := db.Chroma.AddEmbedding(collection, id, embedding, metadata); err != nil {
+			t.Fatalf("AddEmbedding(%s): %v", id, err)
+		}
+	}
+	add("a", []float32{1, 0}, map[string]interface{}{"user_id": "u1", "type": "text", "created_at": 100})
+	add("b", []float32{1, 1}, map[string]interface{}{"user_id": "u1", "type": "video", "created_at": 200})
+	add("c", []float32{0, 1}, map[string]interface{}{"user_id": "u2", "type": "text", "created_at": 300})
+	f.Add("d", []float32{-1, 0}, map[string]interface{}{"user_id": "u1", "type": "text", "created_at": 400})
+
+	tests := []struct {
+		name  string
+		where map[string]interface{}
+		want  []string
+	}{
+		{"everything", nil, []string{"a", "b", "c", "d"}},
+		{"one user", map[string]interface{}{"user_id": "u1"}, []string{"a", "b", "d"}},
+		{"and", map[string]interface{}{"$and": []interface{}{
+			map[string]interface{}{"user_id": "u1"},
+			map[string]interface{}{"type": "text"},
+		}}, []string{"a", "d"}},
+		{"or", map[string]interface{}{"$or": []interface{}{
+			map[string]interface{}{"type": "video"},
+			map[string]interface{}{"user_id": "u2"},
+		}}, []string{"b", "c"}},
+		{"range", map[string]interface{}{"$and": []interface{}{
+			map[string]interface{}{"created_at": map[string]interface{}{"$gte": 200}},
+			map[string]interface{}{"created_at": map[string]interface{}{"$lte": 300}},
+		}}, []string{"b", "c"}},
+		{"in", map[string]interface{}{"type": map[string]interface{}{"$in": []interface{}{"video"}}}, []string{"b"}},
+		{"missing field", map[string]interface{}{"category": "Books"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, distances, metadatas, err := db.Chroma.Query(collection, []float32{1, 0}, 10, tt.where)
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("Query ids = %v, want %v", ids, tt.want)
+			}
+			if len(distances) != len(ids) || len(metadatas) != len(ids) {
+				t.Errorf("Query returned %d distances and %d metadatas for %d ids", len(distances), len(metadatas), len(ids))
+			}
+		})
+	}
+
+	// Cosine distances: identical, 45 degrees, orthogonal, opposite
+	_, distances, _, err := db.Chroma.Query(collection, []float32{2, 0}, 4, nil)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	for i, want := range []float64{0, 1 - math.Sqrt2/2, 1, 2} {
+		if math.Abs(distances[i]-want) > 1e-6 {
+			t.Errorf("distance %d = %v, want %v", i, distances[i], want)
+		}
+	}
+
+	// Stored vectors come back, and unknown ones are nil
+	if got, err := db.Chroma.GetEmbedding(collection, "b"); err != nil || !reflect.DeepEqual(got, []float32{1, 1}) {
+		t.Errorf("GetEmbedding(b) = %v, %v", got, err)
+	}
+	if got, err := db.Chroma.GetEmbedding(collection, "missing"); err != nil || got != nil {
+		t.Errorf("GetEmbedding(missing) = %v, %v, want nil", got, err)
+	}
+	got, err := db.Chroma.GetEmbeddings(collection, []string{"b", "missing", "a"})
+	if want := map[string][]float32{"a": {1, 0}, "b": {1, 1}}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("GetEmbeddings(b, missing, a) = %v, %v; want %v", got, err, want)
+	}
+
+	if err := db.Chroma.UpdateMetadata(collection, []string{"a"}, []map[string]interface{}{{"user_id": "u2"}}); err != nil {
+		t.Fatalf("UpdateMetadata: %v", err)
+	}
+	if got := f.Metadata("a")["user_id"]; got != "u2" {
+		t.Errorf("updated user_id = %v, want u2", got)
+	}
+
+	if err := db.Chroma.DeleteEmbedding(collection, "a"); err != nil {
+		t.Fatalf("DeleteEmbedding: %v", err)
+	}
+	if f.Has("a") {
+		t.Error("deleted vector is still stored")
+	}
+}
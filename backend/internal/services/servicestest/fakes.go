@@ -0,0 +1,334 @@
+// Package servicestest provides fakes of the services' external dependencies,
+// so ItemService and SearchService can be exercised without live AI providers,
+// network fetches, or a ChromaDB server. Postgres connects tests that need the
+// database to TEST_DATABASE_URL.
+package servicestest
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"synapse/internal/models"
+	"synapse/internal/services"
+)
+
+// ErrNotStubbed is returned by fake calls whose result wasn't set
+var ErrNotStubbed = errors.New("servicestest: result not stubbed")
+
+// FakeAI is a services.AIProvider that returns canned results. When Err is
+// set every call fails with it. Calls records the method names called, and
+// Embedded the texts passed to GenerateEmbedding.
+type FakeAI struct {
+	Embedding []float32
+	// Model is what EmbeddingModel reports, "fake/embedding" when unset
+	Model            string
+	Summary          string
+	VideoSummary     string
+	Tags             []string
+	Title            string
+	Category         string
+	Language         string
+	Translation      string
+	Vision           bool
+	ImageDescription string
+	EnhancedQuery    string
+	Suggestion       string
+	Err              error
+
+	mu       sync.Mutex
+	Calls    []string
+	Embedded []string
+}
+
+var _ services.AIProvider = (*FakeAI)(nil)
+
+func (f *FakeAI) record(method string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls = append(f.Calls, method)
+	return f.Err
+}
+
+// Called reports how many times method was called
+func (f *FakeAI) Called(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, call := range f.Calls {
+		if call == method {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *FakeAI) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if err := f.record("GenerateEmbedding"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.Embedded = append(f.Embedded, text)
+	f.mu.Unlock()
+	return f.Embedding, nil
+}
+
+func (f *FakeAI) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := f.record("GenerateEmbeddings"); err != nil {
+		return nil, err
+	}
+	embeddings := make([][]float32, len(texts))
+	for i := range texts {
+		embeddings[i] = f.Embedding
+	}
+	return embeddings, nil
+}
+
+func (f *FakeAI) GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error) {
+	if err := f.record("GenerateQueryEmbedding"); err != nil {
+		return nil, err
+	}
+	return f.Embedding, nil
+}
+
+func (f *FakeAI) EmbeddingModel() string {
+	f.record("EmbeddingModel")
+	if f.Model == "" {
+		return "fake/embedding"
+	}
+	return f.Model
+}
+
+func (f *FakeAI) GenerateSemanticSummary(ctx context.Context, title, content string) (string, error) {
+	if err := f.record("GenerateSemanticSummary"); err != nil {
+		return "", err
+	}
+	return f.Summary, nil
+}
+
+// SummarizeContentStream sends Summary as a single chunk
+func (f *FakeAI) SummarizeContentStream(ctx context.Context, content string) (<-chan string, <-chan error) {
+	chunks := make(chan string, 1)
+	errs := make(chan error, 1)
+	if err := f.record("SummarizeContentStream"); err != nil {
+		errs <- err
+	} else {
+		chunks <- f.Summary
+	}
+	close(chunks)
+	close(errs)
+	return chunks, errs
+}
+
+func (f *FakeAI) SummarizeYouTubeVideo(ctx context.Context, videoURL, title, description, transcript string) (string, error) {
+	if err := f.record("SummarizeYouTubeVideo"); err != nil {
+		return "", err
+	}
+	return f.VideoSummary, nil
+}
+
+func (f *FakeAI) GenerateTags(ctx context.Context, content string) ([]string, error) {
+	if err := f.record("GenerateTags"); err != nil {
+		return nil, err
+	}
+	return f.Tags, nil
+}
+
+func (f *FakeAI) GenerateTitle(ctx context.Context, content string) (string, error) {
+	if err := f.record("GenerateTitle"); err != nil {
+		return "", err
+	}
+	return f.Title, nil
+}
+
+func (f *FakeAI) CategorizeContent(ctx context.Context, title, content, itemType string, allowed []string) (string, error) {
+	if err := f.record("CategorizeContent"); err != nil {
+		return "", err
+	}
+	return f.Category, nil
+}
+
+func (f *FakeAI) DetectLanguage(ctx context.Context, text string) (string, error) {
+	if err := f.record("DetectLanguage"); err != nil {
+		return "", err
+	}
+	return f.Language, nil
+}
+
+func (f *FakeAI) TranslateToEnglish(ctx context.Context, text string) (string, error) {
+	if err := f.record("TranslateToEnglish"); err != nil {
+		return "", err
+	}
+	return f.Translation, nil
+}
+
+func (f *FakeAI) SupportsVision() bool {
+	f.record("SupportsVision")
+	return f.Vision
+}
+
+func (f *FakeAI) DescribeImage(ctx context.Context, imageURL string) (string, error) {
+	if err := f.record("DescribeImage"); err != nil {
+		return "", err
+	}
+	return f.ImageDescription, nil
+}
+
+// EnhanceSearchQuery returns EnhancedQuery, or the query unchanged when it's unset
+func (f *FakeAI) EnhanceSearchQuery(ctx context.Context, query string) (string, error) {
+	if err := f.record("EnhanceSearchQuery"); err != nil {
+		return "", err
+	}
+	if f.EnhancedQuery == "" {
+		return query, nil
+	}
+	return f.EnhancedQuery, nil
+}
+
+func (f *FakeAI) SuggestQuery(ctx context.Context, query string, topics []string) (string, error) {
+	if err := f.record("SuggestQuery"); err != nil {
+		return "", err
+	}
+	return f.Suggestion, nil
+}
+
+// ReRankSearchResults keeps the results' order, cut to topK
+func (f *FakeAI) ReRankSearchResults(ctx context.Context, query string, results []models.SearchResult, topK int) ([]models.SearchResult, error) {
+	if err := f.record("ReRankSearchResults"); err != nil {
+		return nil, err
+	}
+	if topK > 0 && len(results) > topK {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+// FakeMetadata is a services.MetadataProvider that returns canned results.
+// Calls whose result is unset fail with ErrNotStubbed, the way a fetch of an
+// unreachable page would, so only the fields a test cares about need setting.
+type FakeMetadata struct {
+	EmbedHTML    string
+	ImageURL     string
+	Title        string
+	Page         *services.PageMetadata
+	FaviconURL   string
+	LinkStatus   int
+	LinkOK       bool
+	ReadableText string
+	PDFText      string
+	ImageText    string
+	Product      *services.Product
+	Repo         *services.GitHubRepo
+	Tweet        *services.Tweet
+	// Duration is a video's length in seconds
+	Duration   int
+	Transcript string
+	BookCover  string
+	// RecipeImage and RelevantImage are returned by the recipe and stock image lookups
+	RecipeImage   string
+	RelevantImage string
+}
+
+var _ services.MetadataProvider = (*FakeMetadata)(nil)
+
+func stub(value string) (string, error) {
+	if value == "" {
+		return "", ErrNotStubbed
+	}
+	return value, nil
+}
+
+func (f *FakeMetadata) GetURLMetadata(ctx context.Context, url string) (string, string, string, error) {
+	if f.EmbedHTML == "" && f.ImageURL == "" && f.Title == "" {
+		return "", "", "", ErrNotStubbed
+	}
+	return f.EmbedHTML, f.ImageURL, f.Title, nil
+}
+
+func (f *FakeMetadata) GetPageMetadata(ctx context.Context, pageURL string) (*services.PageMetadata, error) {
+	if f.Page == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.Page, nil
+}
+
+func (f *FakeMetadata) GetFavicon(ctx context.Context, pageURL string) (string, error) {
+	return stub(f.FaviconURL)
+}
+
+func (f *FakeMetadata) CheckLink(ctx context.Context, url string) (int, bool, error) {
+	return f.LinkStatus, f.LinkOK, nil
+}
+
+// RefreshLink reports LinkStatus and LinkOK along with the stubbed preview fields
+func (f *FakeMetadata) RefreshLink(ctx context.Context, url string) (*services.LinkPreview, error) {
+	return &services.LinkPreview{Status: f.LinkStatus, OK: f.LinkOK, EmbedHTML: f.EmbedHTML, ImageURL: f.ImageURL, Title: f.Title}, nil
+}
+
+func (f *FakeMetadata) ExtractReadableText(ctx context.Context, url string) (string, error) {
+	if f.ReadableText == "" {
+		return "", services.ErrNoReadableText
+	}
+	return f.ReadableText, nil
+}
+
+func (f *FakeMetadata) ExtractPDFText(ctx context.Context, url string) (string, error) {
+	if f.PDFText == "" {
+		return "", services.ErrNoExtractableText
+	}
+	return f.PDFText, nil
+}
+
+func (f *FakeMetadata) ExtractImageText(ctx context.Context, imageURL string) (string, error) {
+	if f.ImageText == "" {
+		return "", services.ErrNoImageText
+	}
+	return f.ImageText, nil
+}
+
+func (f *FakeMetadata) GetAmazonProduct(ctx context.Context, url string) (*services.Product, error) {
+	if f.Product == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.Product, nil
+}
+
+func (f *FakeMetadata) GetGitHubRepo(ctx context.Context, link string) (*services.GitHubRepo, error) {
+	if f.Repo == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.Repo, nil
+}
+
+func (f *FakeMetadata) GetTweet(ctx context.Context, link string) (*services.Tweet, error) {
+	if f.Tweet == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.Tweet, nil
+}
+
+func (f *FakeMetadata) GetYouTubeDuration(ctx context.Context, videoID string) (int, error) {
+	if f.Duration == 0 {
+		return 0, ErrNotStubbed
+	}
+	return f.Duration, nil
+}
+
+func (f *FakeMetadata) GetYouTubeTranscript(ctx context.Context, videoID string) (string, error) {
+	if f.Transcript == "" {
+		return "", services.ErrNoTranscript
+	}
+	return f.Transcript, nil
+}
+
+func (f *FakeMetadata) DetectBookAndGetCover(ctx context.Context, title, content string) (string, error) {
+	return stub(f.BookCover)
+}
+
+func (f *FakeMetadata) DetectRecipeAndGetImage(ctx context.Context, title, content string) (string, error) {
+	return stub(f.RecipeImage)
+}
+
+func (f *FakeMetadata) FetchRelevantImage(ctx context.Context, title, content, itemType, category string) (string, error) {
+	return stub(f.RelevantImage)
+}
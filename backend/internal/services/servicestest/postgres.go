@@ -0,0 +1,43 @@
+package servicestest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"synapse/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres points db.Pool at the PostgreSQL database in TEST_DATABASE_URL and
+// creates the schema, skipping the test when it isn't set. The database is
+// shared, so tests should save their items under a NewUser.
+func Postgres(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	t.Setenv("DATABASE_URL", url)
+	if err := db.InitPostgres(); err != nil {
+		t.Fatalf("servicestest: InitPostgres: %v", err)
+	}
+	t.Cleanup(db.Pool.Close)
+	if err := db.CreateSchema(); err != nil {
+		t.Fatalf("servicestest: CreateSchema: %v", err)
+	}
+	return db.Pool
+}
+
+// NewUser returns a fresh user ID whose items are deleted when the test ends
+func NewUser(t testing.TB, pool *pgxpool.Pool) uuid.UUID {
+	t.Helper()
+	userID := uuid.New()
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM items WHERE user_id = $1`, userID)
+		pool.Exec(context.Background(), `DELETE FROM collections WHERE user_id = $1`, userID)
+	})
+	return userID
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"synapse/internal/models"
+)
+
+// embeddingMetadata is the ChromaDB metadata stored alongside an item's vector.
+// user_id, type, and category_key let searches filter in ChromaDB (see
+// searchWhere), and with the rest a result card can be drawn from a query alone
+// (see itemFromMetadata).
+func embeddingMetadata(item *models.Item) map[string]interface{} {
+	return map[string]interface{}{
+		"title":    item.Title,
+		"type":     item.Type,
+		"category": item.Category,
+		// ChromaDB only matches exactly, and categories are compared ignoring case
+		"category_key": strings.ToLower(item.Category),
+		"user_id":      item.UserID.String(),
+		// Unix seconds, since ChromaDB metadata can't hold times
+		"created_at": item.CreatedAt.Unix(),
+		"image_url":  item.ImageURL,
+	}
+}
+
+// itemFromMetadata rebuilds the card fields of item id from its vector's
+// metadata. ok is false for vectors stored before created_at was added, whose
+// metadata is too sparse to show.
+func itemFromMetadata(id uuid.UUID, metadata map[string]interface{}) (item models.Item, ok bool) {
+	createdAt, ok := metadata["created_at"].(float64)
+	if !ok {
+		return models.Item{}, false
+	}
+	item = models.Item{
+		ID:        id,
+		Tags:      []string{},
+		CreatedAt: time.Unix(int64(createdAt), 0).UTC(),
+	}
+	item.Title, _ = metadata["title"].(string)
+	item.Type, _ = metadata["type"].(string)
+	item.Category, _ = metadata["category"].(string)
+	item.ImageURL, _ = metadata["image_url"].(string)
+	userID, _ := metadata["user_id"].(string)
+	if parsed, err := uuid.Parse(userID); err == nil {
+		item.UserID = parsed
+	}
+	return item, true
+}
+
+// userWhere is the ChromaDB filter restricting a query to userID's vectors, so
+// other users' items are never returned and a query's top results aren't spent
+// on them. Vectors indexed before multi-tenancy get their user_id from
+// BackfillVectorMetadata.
+func userWhere(userID uuid.UUID) map[string]interface{} {
+	return map[string]interface{}{"user_id": userID.String()}
+}
+
+// searchWhere is userWhere narrowed to the search's type and category filters,
+// so ChromaDB returns only neighbors that can appear in the results. Category is
+// matched on the lowercased category_key, ignoring case like the SQL filter.
+// Dates aren't pushed down: a vector stored without created_at would never
+// match a range, so they're only checked in applyPostFilters.
+func searchWhere(userID uuid.UUID, filters *models.QueryFilters) map[string]interface{} {
+	clauses := []map[string]interface{}{userWhere(userID)}
+	if filters == nil {
+		return chromaWhere(clauses)
+	}
+	if filters.Type != "" {
+		clauses = append(clauses, map[string]interface{}{"type": filters.Type})
+	}
+	if filters.Category != "" {
+		clauses = append(clauses, map[string]interface{}{"category_key": strings.ToLower(filters.Category)})
+	}
+	return chromaWhere(clauses)
+}
+
+// chromaWhere combines clauses, each a ChromaDB where filter on one field, into
+// one filter. ChromaDB takes one field per filter, so several are wrapped in $and.
+func chromaWhere(clauses []map[string]interface{}) map[string]interface{} {
+	switch len(clauses) {
+	case 0:
+		return nil
+	case 1:
+		return clauses[0]
+	}
+	return map[string]interface{}{"$and": clauses}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"synapse/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestSearchWhere(t *testing.T) {
+	userID := uuid.New()
+	user := map[string]interface{}{"user_id": userID.String()}
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		filters *models.QueryFilters
+		want    map[string]interface{}
+	}{
+		{"no filters", nil, user},
+		{"empty filters", &models.QueryFilters{}, user},
+		// Matched on the lowercased key, ignoring case
+		{"category", &models.QueryFilters{Category: "Food & Recipes"}, map[string]interface{}{"$and": []map[string]interface{}{
+			user,
+			{"category_key": "food & recipes"},
+		}}},
+		{"type", &models.QueryFilters{Type: "video"}, map[string]interface{}{"$and": []map[string]interface{}{
+			user,
+			{"type": "video"},
+		}}},
+		// Vectors without created_at would never match, so dates are post-filtered
+		{"dates", &models.QueryFilters{DateFrom: &from, DateTo: &to}, user},
+		{"type and dates", &models.QueryFilters{Type: "video", DateFrom: &from}, map[string]interface{}{"$and": []map[string]interface{}{
+			user,
+			{"type": "video"},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchWhere(userID, tt.filters); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("searchWhere = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserWhereScopesEveryUser(t *testing.T) {
+	// The default user is a user like any other: it never sees other users' vectors
+	for _, userID := range []uuid.UUID{uuid.Nil, uuid.New()} {
+		want := map[string]interface{}{"user_id": userID.String()}
+		if got := userWhere(userID); !reflect.DeepEqual(got, want) {
+			t.Errorf("userWhere(%v) = %v, want %v", userID, got, want)
+		}
+	}
+}
+
+func TestEmbeddingMetadataRoundTrip(t *testing.T) {
+	item := &models.Item{
+		ID:        uuid.New(),
+		Title:     "Knife skills",
+		Type:      "video",
+		Category:  "Food & Recipes",
+		ImageURL:  "https://example.com/thumb.jpg",
+		UserID:    uuid.New(),
+		CreatedAt: time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC),
+	}
+
+	// Metadata comes back from ChromaDB as JSON, numbers as float64
+	data, err := json.Marshal(embeddingMetadata(item))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := itemFromMetadata(item.ID, metadata)
+	if !ok {
+		t.Fatal("itemFromMetadata rejected complete metadata")
+	}
+	want := models.Item{
+		ID:        item.ID,
+		Title:     item.Title,
+		Type:      item.Type,
+		Category:  item.Category,
+		ImageURL:  item.ImageURL,
+		UserID:    item.UserID,
+		CreatedAt: item.CreatedAt,
+		Tags:      []string{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("itemFromMetadata = %+v, want %+v", got, want)
+	}
+
+	// Vectors stored before created_at was added can't be shown
+	if _, ok := itemFromMetadata(item.ID, map[string]interface{}{"user_id": item.UserID.String()}); ok {
+		t.Error("itemFromMetadata accepted metadata without created_at")
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// inputLimits caps, in characters (runes), how much text each AI prompt is
+// given. Each is configurable as AI_MAX_CHARS_<PROMPT>, e.g. AI_MAX_CHARS_TAGS;
+// 0 or less sends the text whole.
+type inputLimits struct {
+	tags            int
+	language        int
+	translate       int
+	categorize      int
+	title           int
+	semanticSummary int
+	videoSummary    int
+	transcript      int
+}
+
+func loadInputLimits() inputLimits {
+	return inputLimits{
+		tags:            getEnvInt("AI_MAX_CHARS_TAGS", 2000),
+		language:        getEnvInt("AI_MAX_CHARS_LANGUAGE", 500),
+		translate:       getEnvInt("AI_MAX_CHARS_TRANSLATE", 4000),
+		categorize:      getEnvInt("AI_MAX_CHARS_CATEGORIZE", 1500),
+		title:           getEnvInt("AI_MAX_CHARS_TITLE", 1500),
+		semanticSummary: getEnvInt("AI_MAX_CHARS_SEMANTIC_SUMMARY", 3000),
+		videoSummary:    getEnvInt("AI_MAX_CHARS_VIDEO_SUMMARY", 5000),
+		transcript:      getEnvInt("AI_MAX_CHARS_TRANSCRIPT", 8000),
+	}
+}
+
+// truncateForModel shortens text to at most maxRunes runes without splitting
+// a multibyte character. When a word boundary falls within the last tenth of
+// the limit, the cut is made there so the final word isn't split either.
+// maxRunes <= 0 means no limit.
+func truncateForModel(text string, maxRunes int) string {
+	// Every rune is at least one byte, so short strings need no counting
+	if maxRunes <= 0 || len(text) <= maxRunes {
+		return text
+	}
+
+	runes := 0
+	for i := range text {
+		if runes < maxRunes {
+			runes++
+			continue
+		}
+		cut := text[:i]
+		if next, _ := utf8.DecodeRuneInString(text[i:]); unicode.IsSpace(next) {
+			return strings.TrimRightFunc(cut, unicode.IsSpace)
+		}
+		if space := strings.LastIndexFunc(cut, unicode.IsSpace); space > 0 && len([]rune(cut[space:])) <= maxRunes/10 {
+			cut = cut[:space]
+		}
+		return strings.TrimRightFunc(cut, unicode.IsSpace)
+	}
+	return text
+}
+
+// previewText is text cut to maxRunes with "..." appended when anything was cut
+func previewText(text string, maxRunes int) string {
+	if truncated := truncateForModel(text, maxRunes); truncated != text {
+		return truncated + "..."
+	}
+	return text
+}
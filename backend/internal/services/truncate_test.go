@@ -0,0 +1,49 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateForModel(t *testing.T) {
+	long := strings.Repeat("a", 18)
+
+	tests := []struct {
+		name     string
+		text     string
+		maxRunes int
+		want     string
+	}{
+		{"no limit", "hello world", 0, "hello world"},
+		{"negative limit", "hello world", -1, "hello world"},
+		{"short enough", "hello", 5, "hello"},
+		// 9 bytes but only 3 runes
+		{"multibyte within the limit", "日本語", 3, "日本語"},
+		{"multibyte", "日本語のテキスト", 3, "日本語"},
+		{"accented", "héllo wörld", 9, "héllo wör"},
+		{"cut at a space", "hello world", 5, "hello"},
+		// The last word starts within the last tenth of the limit, so it's dropped whole
+		{"word boundary in the last tenth", long + " bbbb", 20, long},
+		{"word boundary further back", "hello wonderful world", 12, "hello wonder"},
+		// A multibyte space right after the cut is a word boundary too
+		{"no-break space after the cut", long + " b\u00a0cc", 20, long + " b"},
+		{"ideographic space after the cut", "日本語\u3000テキスト", 3, "日本語"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateForModel(tt.text, tt.maxRunes); got != tt.want {
+				t.Errorf("truncateForModel(%q, %d) = %q, want %q", tt.text, tt.maxRunes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewText(t *testing.T) {
+	if got := previewText("hello world", 5); got != "hello..." {
+		t.Errorf("previewText = %q, want %q", got, "hello...")
+	}
+	if got := previewText("hello", 5); got != "hello" {
+		t.Errorf("previewText of short text = %q, want it unchanged", got)
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// tweetURLRe matches a post on X/Twitter, capturing the author's handle and the post ID
+var tweetURLRe = regexp.MustCompile(`(?i)^https?://(?:www\.|mobile\.)?(?:twitter|x)\.com/([A-Za-z0-9_]{1,15})/status(?:es)?/(\d+)`)
+
+// Tweet is a post on X/Twitter. Text is empty when neither oEmbed nor the
+// NITTER_URL fallback could provide it.
+type Tweet struct {
+	ID           string `json:"id"`
+	AuthorName   string `json:"author_name"`
+	AuthorHandle string `json:"author_handle"`
+	Text         string `json:"text"`
+	ImageURL     string `json:"image_url"`
+	// EmbedHTML is the post's player embed, the one GetURLMetadata builds
+	EmbedHTML string `json:"embed_html"`
+}
+
+// parseTweetURL returns the author handle and post ID of an X/Twitter post link
+func parseTweetURL(link string) (handle, id string, ok bool) {
+	matches := tweetURLRe.FindStringSubmatch(link)
+	if matches == nil {
+		return "", "", false
+	}
+	return matches[1], matches[2], true
+}
+
+// canonicalTweetURL is a post's twitter.com link, the form Twitter's oEmbed endpoint expects
+func canonicalTweetURL(handle, id string) string {
+	return fmt.Sprintf("https://twitter.com/%s/status/%s", handle, id)
+}
+
+// tweetEmbedURL is the page Twitter's own widget renders a post in, usable as a plain iframe
+func tweetEmbedURL(id string) string {
+	return "https://platform.twitter.com/embed/Tweet.html?dnt=true&id=" + id
+}
+
+// GetTweet returns the author, text, and embed of an X/Twitter post. The text comes
+// from Twitter's public oEmbed endpoint, which needs no API key; when that
+// fails and NITTER_URL names a Nitter instance, it's read from the post's
+// page there. A post whose text can't be found still returns its author handle.
+func (s *MetadataService) GetTweet(ctx context.Context, link string) (*Tweet, error) {
+	handle, id, ok := parseTweetURL(link)
+	if !ok {
+		return nil, fmt.Errorf("not an X/Twitter post link: %s", link)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	tweet := &Tweet{ID: id, AuthorHandle: handle, EmbedHTML: responsiveIframe(tweetEmbedURL(id))}
+	oembed, err := s.fetchOEmbed(ctx, "https://publish.twitter.com/oembed", canonicalTweetURL(handle, id))
+	if err == nil {
+		tweet.AuthorName = oembed.AuthorName
+		tweet.Text = tweetTextFromEmbed(oembed.HTML)
+	} else {
+		s.logger.DebugContext(ctx, "failed to fetch tweet oEmbed", "tweet_id", id, "error", err)
+	}
+
+	if tweet.Text == "" && s.nitterURL != "" {
+		og, err := s.GetOpenGraph(ctx, fmt.Sprintf("%s/%s/status/%s", s.nitterURL, url.PathEscape(handle), id))
+		if err != nil {
+			s.logger.DebugContext(ctx, "failed to fetch tweet from Nitter", "tweet_id", id, "error", err)
+		} else {
+			tweet.Text = strings.TrimSpace(og.Description)
+			setIfNotEmpty(&tweet.AuthorName, strings.TrimSpace(strings.Split(og.BestTitle(), "(")[0]))
+			tweet.ImageURL = og.BestImage()
+		}
+	}
+	return tweet, nil
+}
+
+// Title names a post by its author, e.g. "Jane Doe (@jane) on X"
+func (t *Tweet) Title() string {
+	if t.AuthorName != "" {
+		return fmt.Sprintf("%s (@%s) on X", t.AuthorName, t.AuthorHandle)
+	}
+	return fmt.Sprintf("@%s on X", t.AuthorHandle)
+}
+
+// tweetTextFromEmbed extracts the post text from oEmbed's blockquote HTML,
+// whose first <p> is the post, keeping its line breaks
+func tweetTextFromEmbed(embedHTML string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(embedHTML))
+	var text strings.Builder
+	inText := false
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(text.String())
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "p":
+				inText = true
+			case "br":
+				if inText {
+					text.WriteString("\n")
+				}
+			}
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "p" && inText {
+				return strings.TrimSpace(text.String())
+			}
+		case html.TextToken:
+			if inText {
+				text.Write(tokenizer.Text())
+			}
+		}
+	}
+}
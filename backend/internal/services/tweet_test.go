@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseTweetURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		link       string
+		wantHandle string
+		wantID     string
+		wantOK     bool
+	}{
+		{"x.com", "https://x.com/golang/status/1234567890", "golang", "1234567890", true},
+		{"twitter.com with query", "https://twitter.com/golang/status/1234567890?s=20&t=abc", "golang", "1234567890", true},
+		{"www and mobile", "https://mobile.twitter.com/go_lang/statuses/42", "go_lang", "42", true},
+		{"photo suffix", "http://www.x.com/golang/status/42/photo/1", "golang", "42", true},
+		{"case", "HTTPS://X.COM/GoLang/status/42", "GoLang", "42", true},
+		{"profile", "https://x.com/golang", "", "", false},
+		{"handle too long", "https://x.com/abcdefghijklmnop/status/42", "", "", false},
+		{"other site", "https://example.com/golang/status/42", "", "", false},
+		{"lookalike host", "https://notx.com/golang/status/42", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handle, id, ok := parseTweetURL(tt.link)
+			if handle != tt.wantHandle || id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("parseTweetURL(%q) = %q, %q, %v; want %q, %q, %v", tt.link, handle, id, ok, tt.wantHandle, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTweetTextFromEmbed(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "post with link and byline",
+			html: `<blockquote class="twitter-tweet"><p lang="en" dir="ltr">Go 1.22 is out! <a href="https://t.co/x">https://t.co/x</a></p>&mdash; Go (@golang) <a href="https://twitter.com/golang/status/1">February 6, 2024</a></blockquote>` + "\n<script async src=\"https://platform.twitter.com/widgets.js\"></script>",
+			want: "Go 1.22 is out! https://t.co/x",
+		},
+		{
+			name: "line breaks and entities",
+			html: `<blockquote><p>First line<br>second &amp; last<br/></p></blockquote>`,
+			want: "First line\nsecond & last",
+		},
+		{name: "no paragraph", html: `<blockquote>&mdash; Go (@golang)</blockquote>`, want: ""},
+		{name: "empty", html: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tweetTextFromEmbed(tt.html); got != tt.want {
+				t.Errorf("tweetTextFromEmbed = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// rerouteTransport sends every request to target, whatever its host, so
+// fixed endpoints such as Twitter's oEmbed can be served by a test server
+type rerouteTransport struct {
+	target *url.URL
+}
+
+func (t rerouteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = t.target.Scheme, t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestGetTweet(t *testing.T) {
+	const nitterPage = `<html><head>
+		<meta property="og:title" content="Jane Doe (@jane)">
+		<meta property="og:description" content="  Shipping the new release today.  ">
+		<meta property="og:image" content="https://nitter.example.com/pic/1.jpg">
+	</head></html>`
+
+	tests := []struct {
+		name       string
+		oembedOK   bool
+		nitter     bool
+		want       Tweet
+		wantNitter int
+	}{
+		{
+			name:     "from oEmbed",
+			oembedOK: true,
+			nitter:   true,
+			want:     Tweet{AuthorName: "Jane Doe", Text: "Hello from oEmbed"},
+		},
+		{
+			name:       "Nitter fallback",
+			nitter:     true,
+			want:       Tweet{AuthorName: "Jane Doe", Text: "Shipping the new release today.", ImageURL: "https://nitter.example.com/pic/1.jpg"},
+			wantNitter: 1,
+		},
+		{name: "neither", want: Tweet{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nitterRequests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/oembed":
+					if r.URL.Query().Get("url") != "https://twitter.com/jane/status/42" {
+						t.Errorf("oEmbed asked for %q", r.URL.Query().Get("url"))
+					}
+					if !tt.oembedOK {
+						http.NotFound(w, r)
+						return
+					}
+					w.Write([]byte(`{"author_name":"Jane Doe","html":"<blockquote><p>Hello from oEmbed</p>&mdash; Jane</blockquote>"}`))
+				case "/jane/status/42":
+					nitterRequests++
+					w.Header().Set("Content-Type", "text/html")
+					w.Write([]byte(nitterPage))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			target, _ := url.Parse(server.URL)
+			s := &MetadataService{
+				client:         &http.Client{Transport: rerouteTransport{target: target}},
+				requestTimeout: 5 * time.Second,
+				htmlMaxBytes:   1 << 20,
+				logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			if tt.nitter {
+				s.nitterURL = "https://nitter.example.com"
+			}
+
+			tweet, err := s.GetTweet(context.Background(), "https://x.com/jane/status/42?s=20")
+			if err != nil {
+				t.Fatalf("GetTweet: %v", err)
+			}
+			want := tt.want
+			want.ID, want.AuthorHandle, want.EmbedHTML = "42", "jane", responsiveIframe(tweetEmbedURL("42"))
+			if *tweet != want {
+				t.Errorf("GetTweet = %+v, want %+v", *tweet, want)
+			}
+			if nitterRequests != tt.wantNitter {
+				t.Errorf("Nitter requested %d times, want %d", nitterRequests, tt.wantNitter)
+			}
+		})
+	}
+}
+
+func TestGetTweetRejectsOtherLinks(t *testing.T) {
+	if _, err := (&MetadataService{}).GetTweet(context.Background(), "https://example.com/jane/status/42"); err == nil {
+		t.Error("GetTweet of a non-post link succeeded")
+	}
+}
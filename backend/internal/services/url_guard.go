@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrBlockedURL is returned for requests the metadata fetcher refuses to make:
+// non-http(s) URLs, denylisted hosts, and hosts on private networks
+var ErrBlockedURL = errors.New("URL not allowed")
+
+// nonPublicNets are ranges net.IP's predicates don't cover that still aren't the public internet
+var nonPublicNets = mustParseCIDRs("0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "240.0.0.0/4")
+
+// urlGuard keeps server-side fetches of user-supplied URLs (page metadata,
+// images) from reaching the server's own network, e.g. a cloud metadata
+// endpoint at 169.254.169.254 or a database on localhost. Hosts are resolved
+// and every address checked before connecting, and the connection is made to
+// a checked address, so a DNS answer can't change between check and use.
+type urlGuard struct {
+	// allowPrivate turns off the private-network check, for single-user installs saving intranet links
+	allowPrivate bool
+	// allowHosts and allowNets are always reachable, even on private networks
+	allowHosts []string
+	allowNets  []*net.IPNet
+	// denyHosts and denyNets are never reachable
+	denyHosts []string
+	denyNets  []*net.IPNet
+	dialer    *net.Dialer
+}
+
+// loadURLGuard configures a urlGuard from the environment.
+// METADATA_URL_ALLOWLIST and METADATA_URL_DENYLIST are comma-separated
+// hostnames (matching their subdomains too), IPs, or CIDRs.
+func loadURLGuard() *urlGuard {
+	g := &urlGuard{
+		allowPrivate: getEnvBool("METADATA_ALLOW_PRIVATE_NETWORKS"),
+		dialer:       &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+	}
+	g.allowHosts, g.allowNets = parseHostList(os.Getenv("METADATA_URL_ALLOWLIST"))
+	g.denyHosts, g.denyNets = parseHostList(os.Getenv("METADATA_URL_DENYLIST"))
+	return g
+}
+
+// parseHostList splits a comma-separated list into hostnames and IP ranges;
+// a bare IP is a single-address range
+func parseHostList(list string) (hosts []string, nets []*net.IPNet) {
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			nets = append(nets, ipNet)
+		} else if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		} else {
+			hosts = append(hosts, strings.TrimPrefix(entry, "."))
+		}
+	}
+	return hosts, nets
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = ipNet
+	}
+	return nets
+}
+
+// matchHost reports whether host is one of hosts or a subdomain of one
+func matchHost(host string, hosts []string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkURL rejects URLs that aren't http(s) or whose host is denylisted. The
+// addresses a hostname resolves to are checked when connecting (see
+// dialContext), or by guardTransport for requests sent through a proxy.
+func (g *urlGuard) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrBlockedURL, u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: no host in %s", ErrBlockedURL, u)
+	}
+	if matchHost(host, g.denyHosts) {
+		return fmt.Errorf("%w: %s is denylisted", ErrBlockedURL, host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return g.checkIP(ip)
+	}
+	return nil
+}
+
+// checkIP rejects denylisted addresses and, unless allowPrivate is set or the
+// address is allowlisted, loopback, private, link-local, and other non-public ones
+func (g *urlGuard) checkIP(ip net.IP) error {
+	if containsIP(g.denyNets, ip) {
+		return fmt.Errorf("%w: %s is denylisted", ErrBlockedURL, ip)
+	}
+	if g.allowPrivate || containsIP(g.allowNets, ip) {
+		return nil
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || containsIP(nonPublicNets, ip) {
+		return fmt.Errorf("%w: %s is not a public address", ErrBlockedURL, ip)
+	}
+	return nil
+}
+
+// proxyAddrKey is the context key guardTransport stores the address of a
+// request's proxy under, for dialContext
+type proxyAddrKey struct{}
+
+// proxyAddr is the host:port a transport dials to reach proxy
+func proxyAddr(proxy *url.URL) string {
+	port := proxy.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}[proxy.Scheme]
+	}
+	return net.JoinHostPort(strings.ToLower(proxy.Hostname()), port)
+}
+
+// dialContext is a Transport.DialContext that resolves the host itself,
+// checks every address, and connects only to checked ones. Allowlisted hosts
+// are dialed as is, as is the proxy guardTransport routed the request
+// through; any other connection to the proxy's host is checked like the rest.
+func (g *urlGuard) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if proxy, ok := ctx.Value(proxyAddrKey{}).(string); (ok && strings.EqualFold(addr, proxy)) || matchHost(host, g.allowHosts) {
+		return g.dialer.DialContext(ctx, network, addr)
+	}
+
+	addrs, err := g.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, a := range addrs {
+		conn, err := g.dialer.DialContext(ctx, network, net.JoinHostPort(a.IP.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// resolve looks host up and checks every address it resolves to, not just
+// the first, since the one dialed isn't up to us
+func (g *urlGuard) resolve(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if matchHost(host, g.denyHosts) {
+		return nil, fmt.Errorf("%w: %s is denylisted", ErrBlockedURL, host)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addrs {
+		if err := g.checkIP(a.IP); err != nil {
+			return nil, fmt.Errorf("%s: %w", host, err)
+		}
+	}
+	return addrs, nil
+}
+
+// guardTransport checks each request's URL, including every redirect, before
+// sending it. proxy is the base transport's proxy selector: a request sent
+// through a proxy is connected by the proxy, out of dialContext's reach, so its
+// host is resolved and checked here instead. The proxy does its own lookup, so
+// unlike a direct connection this can't rule out the answer changing in between.
+// The proxy's address is passed on to dialContext, which lets the transport
+// connect to it even on a private network.
+type guardTransport struct {
+	base  http.RoundTripper
+	guard *urlGuard
+	proxy func(*http.Request) (*url.URL, error)
+}
+
+func (t *guardTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.guard.checkURL(req.URL); err != nil {
+		return nil, err
+	}
+	if t.proxy == nil {
+		return t.base.RoundTrip(req)
+	}
+	proxyURL, err := t.proxy(req)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL != nil {
+		if !matchHost(req.URL.Hostname(), t.guard.allowHosts) {
+			if _, err := t.guard.resolve(req.Context(), req.URL.Hostname()); err != nil {
+				return nil, err
+			}
+		}
+		req = req.WithContext(context.WithValue(req.Context(), proxyAddrKey{}, proxyAddr(proxyURL)))
+	}
+	return t.base.RoundTrip(req)
+}
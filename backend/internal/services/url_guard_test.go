@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testGuard(allowlist, denylist string) *urlGuard {
+	g := &urlGuard{dialer: &net.Dialer{Timeout: 5 * time.Second}}
+	g.allowHosts, g.allowNets = parseHostList(allowlist)
+	g.denyHosts, g.denyNets = parseHostList(denylist)
+	return g
+}
+
+// guardedClient builds a client the way newHTTPClient does, but with proxy
+// as the proxy selector, since ProxyFromEnvironment only reads the
+// environment once per process
+func guardedClient(guard *urlGuard, proxy func(*http.Request) (*url.URL, error)) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	transport.DialContext = guard.dialContext
+	return &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &guardTransport{base: transport, guard: guard, proxy: proxy},
+	}
+}
+
+// withHost replaces the host of a test server's URL, keeping its port
+func withHost(t *testing.T, rawURL, host string) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Host = net.JoinHostPort(host, u.Port())
+	return u.String()
+}
+
+func TestParseHostList(t *testing.T) {
+	hosts, nets := parseHostList(" Example.com, .internal.dev,10.0.0.0/8, 192.168.1.5 ,::1,, fd00::/8 ")
+
+	wantHosts := []string{"example.com", "internal.dev"}
+	if strings.Join(hosts, ",") != strings.Join(wantHosts, ",") {
+		t.Errorf("hosts = %v, want %v", hosts, wantHosts)
+	}
+
+	wantNets := []string{"10.0.0.0/8", "192.168.1.5/32", "::1/128", "fd00::/8"}
+	if len(nets) != len(wantNets) {
+		t.Fatalf("nets = %v, want %v", nets, wantNets)
+	}
+	for i, n := range nets {
+		if n.String() != wantNets[i] {
+			t.Errorf("nets[%d] = %s, want %s", i, n, wantNets[i])
+		}
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	hosts := []string{"example.com", "internal.dev"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"example.com", true},
+		{"EXAMPLE.com", true},
+		{"example.com.", true},
+		{"api.example.com", true},
+		{"a.b.internal.dev", true},
+		{"notexample.com", false},
+		{"example.com.evil.net", false},
+		{"dev", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := matchHost(tt.host, hosts); got != tt.want {
+				t.Errorf("matchHost(%q) = %v, want %v", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		guard   *urlGuard
+		ip      string
+		blocked bool
+	}{
+		{"public IPv4", testGuard("", ""), "93.184.216.34", false},
+		{"public IPv6", testGuard("", ""), "2606:4700::1111", false},
+		{"loopback", testGuard("", ""), "127.0.0.1", true},
+		{"IPv6 loopback", testGuard("", ""), "::1", true},
+		{"cloud metadata", testGuard("", ""), "169.254.169.254", true},
+		{"IPv6 link-local", testGuard("", ""), "fe80::1", true},
+		{"10/8", testGuard("", ""), "10.1.2.3", true},
+		{"172.16/12", testGuard("", ""), "172.20.0.1", true},
+		{"192.168/16", testGuard("", ""), "192.168.0.10", true},
+		{"carrier-grade NAT", testGuard("", ""), "100.64.0.1", true},
+		{"unique local IPv6", testGuard("", ""), "fd12::1", true},
+		{"unspecified", testGuard("", ""), "0.0.0.0", true},
+		{"IPv4-mapped loopback", testGuard("", ""), "::ffff:127.0.0.1", true},
+		{"allowlisted range", testGuard("10.0.0.0/8", ""), "10.1.2.3", false},
+		{"allowlist doesn't cover others", testGuard("10.0.0.0/8", ""), "192.168.0.10", true},
+		{"allowPrivate", &urlGuard{allowPrivate: true}, "192.168.0.10", false},
+		{"denylisted public", testGuard("", "93.184.216.0/24"), "93.184.216.34", true},
+		{"deny beats allow", testGuard("10.0.0.0/8", "10.1.2.3"), "10.1.2.3", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.guard.checkIP(net.ParseIP(tt.ip))
+			if blocked := errors.Is(err, ErrBlockedURL); blocked != tt.blocked {
+				t.Errorf("checkIP(%s) = %v, want blocked %v", tt.ip, err, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	guard := testGuard("", "tracker.example")
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"https://example.com/page", false},
+		{"http://93.184.216.34/", false},
+		{"ftp://example.com/file", true},
+		{"file:///etc/passwd", true},
+		{"http:///path", true},
+		{"http://127.0.0.1:8080/", true},
+		{"http://[::1]/", true},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"https://ads.tracker.example/", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+			err := guard.checkURL(u)
+			if blocked := errors.Is(err, ErrBlockedURL); blocked != tt.blocked {
+				t.Errorf("checkURL(%s) = %v, want blocked %v", tt.url, err, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestGuardedClientBlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "secret")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		guard   *urlGuard
+		url     string
+		blocked bool
+	}{
+		{"loopback IP", testGuard("", ""), server.URL, true},
+		{"hostname resolving to loopback", testGuard("", ""), withHost(t, server.URL, "localhost"), true},
+		{"allowlisted IP", testGuard("127.0.0.1", ""), server.URL, false},
+		{"allowlisted hostname", testGuard("localhost", ""), withHost(t, server.URL, "localhost"), false},
+		{"denylisted hostname", testGuard("", "localhost"), withHost(t, server.URL, "localhost"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := guardedClient(tt.guard, nil).Get(tt.url)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if blocked := errors.Is(err, ErrBlockedURL); blocked != tt.blocked {
+				t.Errorf("Get(%s) = %v, want blocked %v", tt.url, err, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestGuardedClientBlocksRedirects(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "secret")
+	}))
+	defer internal.Close()
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"cloud metadata", "http://169.254.169.254/latest/meta-data/"},
+		{"loopback", internal.URL},
+		{"hostname resolving to loopback", withHost(t, internal.URL, "localhost")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The redirecting page is allowlisted by name; its target isn't
+			public := httptest.NewServer(http.RedirectHandler(tt.target, http.StatusFound))
+			defer public.Close()
+			guard := testGuard("redirector.test", "")
+
+			client := guardedClient(guard, nil)
+			client.Transport.(*guardTransport).base.(*http.Transport).DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				if host, _, _ := net.SplitHostPort(addr); host == "redirector.test" {
+					return guard.dialer.DialContext(ctx, network, public.Listener.Addr().String())
+				}
+				return guard.dialContext(ctx, network, addr)
+			}
+
+			resp, err := client.Get("http://redirector.test/")
+			if err == nil {
+				resp.Body.Close()
+			}
+			if !errors.Is(err, ErrBlockedURL) {
+				t.Errorf("redirect to %s: err = %v, want ErrBlockedURL", tt.target, err)
+			}
+		})
+	}
+}
+
+func TestDialContextExemptsOnlyTheProxy(t *testing.T) {
+	var proxied []string
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		io.WriteString(w, "via proxy")
+	}))
+	defer proxyServer.Close()
+	proxyURL, _ := url.Parse(withHost(t, proxyServer.URL, "localhost"))
+
+	// Only the public site goes through the proxy; the proxy's own address is
+	// reachable directly but isn't an allowed destination
+	proxy := func(r *http.Request) (*url.URL, error) {
+		if r.URL.Hostname() == "93.184.216.34" {
+			return proxyURL, nil
+		}
+		return nil, nil
+	}
+	guard := testGuard("", "")
+	client := guardedClient(guard, proxy)
+
+	resp, err := client.Get("http://93.184.216.34/page")
+	if err != nil {
+		t.Fatalf("proxied request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "via proxy" || len(proxied) != 1 || proxied[0] != "http://93.184.216.34/page" {
+		t.Errorf("proxied request: body %q, proxy saw %v", body, proxied)
+	}
+
+	resp, err = client.Get(proxyURL.String() + "/admin")
+	if err == nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, ErrBlockedURL) {
+		t.Errorf("direct request to the proxy's host: err = %v, want ErrBlockedURL", err)
+	}
+
+	// Dials are exempt only for the exact proxy address guardTransport set
+	ctx := context.WithValue(context.Background(), proxyAddrKey{}, proxyAddr(proxyURL))
+	conn, err := guard.dialContext(ctx, "tcp", proxyURL.Host)
+	if err != nil {
+		t.Errorf("dial to the proxy: %v", err)
+	} else {
+		conn.Close()
+	}
+	if _, err := guard.dialContext(ctx, "tcp", "localhost:1"); !errors.Is(err, ErrBlockedURL) {
+		t.Errorf("dial to another port on the proxy's host: err = %v, want ErrBlockedURL", err)
+	}
+}
+
+func TestProxyAddr(t *testing.T) {
+	tests := []struct {
+		proxy string
+		want  string
+	}{
+		{"http://Proxy.corp:3128", "proxy.corp:3128"},
+		{"http://proxy.corp", "proxy.corp:80"},
+		{"https://proxy.corp", "proxy.corp:443"},
+		{"socks5://10.0.0.1", "10.0.0.1:1080"},
+		{"http://[fd00::1]:8080", "[fd00::1]:8080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proxy, func(t *testing.T) {
+			u, _ := url.Parse(tt.proxy)
+			if got := proxyAddr(u); got != tt.want {
+				t.Errorf("proxyAddr(%s) = %q, want %q", tt.proxy, got, tt.want)
+			}
+		})
+	}
+}
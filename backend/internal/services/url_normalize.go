@@ -0,0 +1,64 @@
+package services
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters that identify the referrer, not the page
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"igshid":  true,
+	"_ga":     true,
+}
+
+// normalizeURL returns the canonical form of a link used to detect duplicates:
+// lowercase scheme and host, no default port, no tracking parameters (utm_*,
+// fbclid, gclid, ...), sorted query, no fragment, and no trailing slash.
+// Input that isn't an absolute URL is returned trimmed but otherwise unchanged.
+func normalizeURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return rawURL
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+	if (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	switch {
+	case port != "":
+		// JoinHostPort brackets IPv6 addresses: [::1]:8080
+		host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		host = "[" + host + "]"
+	}
+	parsed.Host = host
+
+	query := parsed.Query()
+	for key := range query {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, "utm_") || trackingParams[lower] {
+			query.Del(key)
+		}
+	}
+	// Encode sorts by key, so parameter order doesn't matter
+	parsed.RawQuery = query.Encode()
+
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	parsed.User = nil
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	parsed.RawPath = strings.TrimRight(parsed.RawPath, "/")
+
+	return parsed.String()
+}
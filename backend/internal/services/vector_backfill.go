@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"synapse/internal/db"
+	"synapse/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// vectorMetadataMigration names the embeddingMetadata layout the stored vectors
+// were last rewritten to. Change it when searches start filtering on a field
+// older vectors lack, so BackfillVectorMetadata runs again.
+const vectorMetadataMigration = "embedding_metadata_category_key"
+
+// vectorBackfillBatchSize is how many vectors' metadata is rewritten per ChromaDB request
+const vectorBackfillBatchSize = 100
+
+// BackfillVectorMetadata rewrites the ChromaDB metadata of every indexed item
+// from PostgreSQL, once per vectorMetadataMigration. Vectors stored before
+// multi-tenancy have no user_id, and semantic queries always filter on it, so
+// without this they'd never be found; nor would older vectors without a
+// category_key by a category search. Only metadata changes, so no embeddings are regenerated. An
+// interrupted run starts over on the next call; rewriting is idempotent.
+func (s *ItemService) BackfillVectorMetadata(ctx context.Context, migrations *repository.VectorMigrationRepository) error {
+	done, err := migrations.Done(ctx, vectorMetadataMigration)
+	if err != nil {
+		return fmt.Errorf("failed to check vector migrations: %w", err)
+	}
+	if done {
+		return nil
+	}
+
+	updated := 0
+	after := uuid.Nil
+	for {
+		items, err := s.itemRepo.GetEmbeddedAfter(ctx, after, vectorBackfillBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		ids := make([]string, len(items))
+		metadatas := make([]map[string]interface{}, len(items))
+		for i := range items {
+			ids[i] = items[i].EmbeddingID
+			metadatas[i] = embeddingMetadata(&items[i])
+		}
+		if err := db.Chroma.UpdateMetadata(s.collectionName, ids, metadatas); err != nil {
+			return fmt.Errorf("failed to backfill vector metadata: %w", err)
+		}
+		updated += len(items)
+		after = items[len(items)-1].ID
+	}
+
+	s.logger.InfoContext(ctx, "backfilled vector metadata", "operation", "backfill_vector_metadata", "migration", vectorMetadataMigration, "vectors", updated)
+	return migrations.MarkDone(ctx, vectorMetadataMigration)
+}
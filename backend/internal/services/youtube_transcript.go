@@ -0,0 +1,156 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrNoTranscript is returned by GetYouTubeTranscript for videos without captions
+var ErrNoTranscript = errors.New("video has no captions")
+
+// captionTrack is one entry of a watch page's captionTracks list
+type captionTrack struct {
+	BaseURL      string `json:"baseUrl"`
+	LanguageCode string `json:"languageCode"`
+	// Kind is "asr" for auto-generated captions, empty for uploaded ones
+	Kind string `json:"kind"`
+}
+
+// fetchYouTubeWatchPage returns the HTML of a video's watch page, whose inline
+// player response holds its length and caption tracks
+func (s *MetadataService) fetchYouTubeWatchPage(ctx context.Context, videoID string) ([]byte, error) {
+	pageURL := fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept-Language", "en")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch YouTube page: status %d", resp.StatusCode)
+	}
+
+	// The player response is embedded in an inline script near the top of the page
+	return io.ReadAll(io.LimitReader(resp.Body, 4*1024*1024))
+}
+
+// GetYouTubeTranscript returns the captions of a YouTube video as plain text,
+// preferring uploaded English captions, then auto-generated English ones, then
+// any language. It returns ErrNoTranscript when the video has no captions.
+func (s *MetadataService) GetYouTubeTranscript(ctx context.Context, videoID string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	page, err := s.fetchYouTubeWatchPage(ctx, videoID)
+	if err != nil {
+		return "", err
+	}
+
+	track, ok := pickCaptionTrack(parseCaptionTracks(page))
+	if !ok {
+		return "", ErrNoTranscript
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", track.BaseURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch captions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch captions: status %d", resp.StatusCode)
+	}
+
+	transcript, err := parseCaptionXML(io.LimitReader(resp.Body, 2*1024*1024))
+	if err != nil {
+		return "", err
+	}
+	if transcript == "" {
+		return "", ErrNoTranscript
+	}
+	return transcript, nil
+}
+
+// parseCaptionTracks reads the captionTracks array out of a watch page's
+// player response; nil if the video has none
+func parseCaptionTracks(page []byte) []captionTrack {
+	const marker = `"captionTracks":`
+	start := bytes.Index(page, []byte(marker))
+	if start == -1 {
+		return nil
+	}
+
+	// Decode just the array; the decoder stops at its closing bracket
+	var tracks []captionTrack
+	if err := json.NewDecoder(bytes.NewReader(page[start+len(marker):])).Decode(&tracks); err != nil {
+		return nil
+	}
+	return tracks
+}
+
+// pickCaptionTrack chooses the track that best represents what's said, in
+// order: uploaded English, auto-generated English, uploaded, anything
+func pickCaptionTrack(tracks []captionTrack) (captionTrack, bool) {
+	best, bestRank := captionTrack{}, -1
+	for _, track := range tracks {
+		if track.BaseURL == "" {
+			continue
+		}
+		rank := 0
+		if strings.HasPrefix(track.LanguageCode, "en") {
+			rank += 2
+		}
+		if track.Kind != "asr" {
+			rank++
+		}
+		if rank > bestRank {
+			best, bestRank = track, rank
+		}
+	}
+	return best, bestRank >= 0
+}
+
+// parseCaptionXML flattens a timedtext caption document, either the
+// <transcript><text> format or the newer <timedtext><body><p> one, into text
+func parseCaptionXML(r io.Reader) (string, error) {
+	decoder := xml.NewDecoder(r)
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse captions: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			// Caption text is HTML-escaped inside the XML, e.g. &amp;#39; for '
+			text.WriteString(html.UnescapeString(string(t)))
+		case xml.EndElement:
+			if t.Name.Local == "text" || t.Name.Local == "p" {
+				text.WriteString(" ")
+			}
+		}
+	}
+	return strings.Join(strings.Fields(text.String()), " "), nil
+}
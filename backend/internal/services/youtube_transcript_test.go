@@ -0,0 +1,108 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCaptionTracks(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want []captionTrack
+	}{
+		{
+			name: "tracks in the player response",
+			page: `<script>var ytInitialPlayerResponse = {"captions":{"playerCaptionsTracklistRenderer":{"captionTracks":[{"baseUrl":"https://www.youtube.com/api/timedtext?v=abc&lang=en","languageCode":"en","kind":"asr"},{"baseUrl":"https://www.youtube.com/api/timedtext?v=abc&lang=de","languageCode":"de"}],"audioTracks":[]}}};</script>`,
+			want: []captionTrack{
+				{BaseURL: "https://www.youtube.com/api/timedtext?v=abc&lang=en", LanguageCode: "en", Kind: "asr"},
+				{BaseURL: "https://www.youtube.com/api/timedtext?v=abc&lang=de", LanguageCode: "de"},
+			},
+		},
+		{
+			name: "escaped URL",
+			page: `"captionTracks":[{"baseUrl":"https://www.youtube.com/api/timedtext?v=abc\u0026lang=en","languageCode":"en"}]`,
+			want: []captionTrack{{BaseURL: "https://www.youtube.com/api/timedtext?v=abc&lang=en", LanguageCode: "en"}},
+		},
+		{name: "no captions", page: `<script>var ytInitialPlayerResponse = {"videoDetails":{}};</script>`},
+		{name: "cut off", page: `"captionTracks":[{"baseUrl":"https://www.youtube.com/api/timed`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseCaptionTracks([]byte(tt.page)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCaptionTracks = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickCaptionTrack(t *testing.T) {
+	uploadedEnglish := captionTrack{BaseURL: "u-en", LanguageCode: "en-GB"}
+	autoEnglish := captionTrack{BaseURL: "a-en", LanguageCode: "en", Kind: "asr"}
+	uploadedGerman := captionTrack{BaseURL: "u-de", LanguageCode: "de"}
+	autoGerman := captionTrack{BaseURL: "a-de", LanguageCode: "de", Kind: "asr"}
+
+	tests := []struct {
+		name   string
+		tracks []captionTrack
+		want   captionTrack
+		wantOK bool
+	}{
+		{"uploaded English first", []captionTrack{autoGerman, autoEnglish, uploadedGerman, uploadedEnglish}, uploadedEnglish, true},
+		{"auto-generated English over other languages", []captionTrack{uploadedGerman, autoEnglish}, autoEnglish, true},
+		{"uploaded over auto-generated", []captionTrack{autoGerman, uploadedGerman}, uploadedGerman, true},
+		{"anything", []captionTrack{autoGerman}, autoGerman, true},
+		{"tracks without a URL are skipped", []captionTrack{{LanguageCode: "en"}, autoGerman}, autoGerman, true},
+		{"none", nil, captionTrack{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := pickCaptionTrack(tt.tracks)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("pickCaptionTrack = %+v, %v; want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseCaptionXML(t *testing.T) {
+	tests := []struct {
+		name    string
+		xml     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "transcript format",
+			xml:  `<?xml version="1.0" encoding="utf-8" ?><transcript><text start="0" dur="2.1">Hello and</text><text start="2.1" dur="3">welcome   back</text></transcript>`,
+			want: "Hello and welcome back",
+		},
+		{
+			name: "escaped entities",
+			xml:  `<transcript><text start="0">it&amp;#39;s R&amp;amp;D</text></transcript>`,
+			want: "it's R&D",
+		},
+		{
+			name: "timedtext format",
+			xml:  "<timedtext format=\"3\"><body><p t=\"0\" d=\"1500\">first\nline</p><p t=\"1500\" d=\"900\">second</p></body></timedtext>",
+			want: "first line second",
+		},
+		{name: "empty", xml: `<transcript></transcript>`, want: ""},
+		{name: "malformed", xml: `<transcript><text>unclosed</transcript>`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCaptionXML(strings.NewReader(tt.xml))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCaptionXML error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseCaptionXML = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
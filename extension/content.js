@@ -238,6 +238,7 @@ async function extractVideoInfo() {
     platform: '',
     thumbnail: '',
     description: '',
+    duration: '',
   };
 
   const url = window.location.href;
@@ -519,6 +520,12 @@ async function extractVideoInfo() {
     }
   }
 
+  // Length in seconds from the page's player, used as the item's reading time
+  const player = document.querySelector('video');
+  if (player && Number.isFinite(player.duration)) {
+    video.duration = String(Math.round(player.duration));
+  }
+
   return video;
 }
 
@@ -655,6 +662,7 @@ chrome.runtime.onMessage.addListener((request, sender, sendResponse) => {
             channel: video.channel,
             thumbnail: video.thumbnail,
             description: video.description,
+            duration: video.duration,
           };
         } else {
           const page = extractPageContent();